@@ -0,0 +1,10 @@
+-- Add media_type column to downloads table (audiobook or ebook)
+ALTER TABLE downloads ADD COLUMN media_type TEXT DEFAULT 'audiobook';
+
+-- Ebook organization settings
+INSERT OR IGNORE INTO configs (key, value, description) VALUES
+    ('paths.ebook_destination', '/ebooks', 'Base directory for organized ebooks'),
+    ('paths.ebook_template', '{author}/{series}/{title}', 'Ebook path template with series'),
+    ('paths.ebook_no_series_template', '{author}/{title}', 'Ebook path template without series'),
+    ('ebooks.file_types', 'epub,mobi,azw3,pdf', 'Comma-separated ebook file extensions to organize'),
+    ('ebooks.write_opf', 'true', 'Write a metadata.opf sidecar next to organized ebooks');
@@ -0,0 +1,7 @@
+-- Ownership and permission settings applied to organized files and directories
+INSERT OR IGNORE INTO configs (key, value, description) VALUES
+    ('permissions.puid', '', 'User ID to own organized files (empty keeps the process user)'),
+    ('permissions.pgid', '', 'Group ID to own organized files (empty keeps the process group)'),
+    ('permissions.dir_mode', '0755', 'Octal mode for created directories'),
+    ('permissions.file_mode', '0644', 'Octal mode for organized files'),
+    ('permissions.inherit_group', 'false', 'Set setgid on created directories and inherit the parent directory group');
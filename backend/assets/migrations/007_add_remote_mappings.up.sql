@@ -0,0 +1,3 @@
+-- Multiple qBittorrent→local path prefix mappings (supersedes paths.local_mount)
+INSERT OR IGNORE INTO configs (key, value, description) VALUES
+    ('paths.remote_mappings', '', 'JSON list of {"remote", "local"} mappings from qBittorrent save paths to local paths; longest remote prefix wins');
@@ -0,0 +1,12 @@
+-- Per-file journal of in-progress organizations, used to recover after a restart
+CREATE TABLE IF NOT EXISTS organization_journal (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    download_id TEXT NOT NULL,
+    source_path TEXT NOT NULL,
+    dest_path TEXT NOT NULL,
+    operation TEXT NOT NULL,
+    done INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_journal_download_id ON organization_journal(download_id);
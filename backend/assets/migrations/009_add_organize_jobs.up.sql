@@ -0,0 +1,19 @@
+-- Persistent queue of organization jobs processed by the monitor's worker pool
+CREATE TABLE IF NOT EXISTS organize_jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    download_id TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    source TEXT NOT NULL DEFAULT 'monitor',
+    error_message TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_organize_jobs_status ON organize_jobs(status);
+
+-- At most one pending or running job per download
+CREATE UNIQUE INDEX IF NOT EXISTS idx_organize_jobs_active ON organize_jobs(download_id) WHERE status IN ('pending', 'running');
+
+INSERT OR IGNORE INTO configs (key, value, description) VALUES
+    ('monitor.max_concurrent', '3', 'Maximum number of downloads organized at the same time');
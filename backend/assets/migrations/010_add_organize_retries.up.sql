@@ -0,0 +1,10 @@
+-- Each organize job is one attempt; transient failures schedule a follow-up job
+ALTER TABLE organize_jobs ADD COLUMN attempt INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE organize_jobs ADD COLUMN run_after TIMESTAMP;
+ALTER TABLE organize_jobs ADD COLUMN transient INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_organize_jobs_download ON organize_jobs(download_id);
+
+INSERT OR IGNORE INTO configs (key, value, description) VALUES
+    ('monitor.retry_max_attempts', '5', 'Maximum organization attempts for a download before transient failures are final'),
+    ('monitor.retry_delay_seconds', '60', 'Delay before the first organization retry; doubles with every further attempt');
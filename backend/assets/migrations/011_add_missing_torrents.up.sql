@@ -0,0 +1,8 @@
+-- When the monitor first saw the torrent missing from qBittorrent; cleared once it is seen again
+ALTER TABLE downloads ADD COLUMN missing_since TIMESTAMP;
+
+-- The .torrent file the download was added from, so a vanished torrent can be re-added
+ALTER TABLE downloads ADD COLUMN torrent_data BLOB;
+
+INSERT OR IGNORE INTO configs (key, value, description) VALUES
+    ('monitor.missing_grace_seconds', '300', 'How long a torrent may be missing from qBittorrent before its download is marked missing');
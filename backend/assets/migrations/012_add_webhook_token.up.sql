@@ -0,0 +1,3 @@
+-- Shared secret for qBittorrent's "run external program on torrent finished" webhook; empty disables it
+INSERT OR IGNORE INTO configs (key, value, description) VALUES
+    ('webhook.token', '', 'Token qBittorrent must send to the torrent finished webhook; empty disables the webhook');
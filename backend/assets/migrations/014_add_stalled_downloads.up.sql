@@ -0,0 +1,24 @@
+-- When download progress last advanced, to flag downloads that stopped making progress
+ALTER TABLE downloads ADD COLUMN progress_at TIMESTAMP;
+
+-- Where the torrent came from, so a stalled download's search can be re-run for alternatives
+ALTER TABLE downloads ADD COLUMN torrent_id TEXT;
+ALTER TABLE downloads ADD COLUMN search_query TEXT;
+
+-- Torrents of stalled downloads swapped for an alternative release
+CREATE TABLE IF NOT EXISTS torrent_replacements (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    download_id TEXT NOT NULL,
+    old_hash TEXT NOT NULL,
+    old_torrent_id TEXT NOT NULL DEFAULT '',
+    new_hash TEXT NOT NULL,
+    new_torrent_id TEXT NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_torrent_replacements_download ON torrent_replacements(download_id);
+
+INSERT OR IGNORE INTO configs (key, value, description) VALUES
+    ('monitor.stalled_after_minutes', '60', 'Minutes without download progress before a download is marked stalled; 0 disables'),
+    ('monitor.replace_stalled', 'false', 'Search for an alternative release of stalled downloads and swap it in');
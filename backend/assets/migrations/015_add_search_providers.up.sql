@@ -0,0 +1,9 @@
+-- Search provider a download's torrent came from, to fetch it again from the right one
+ALTER TABLE downloads ADD COLUMN provider TEXT;
+
+ALTER TABLE torrent_replacements ADD COLUMN old_provider TEXT NOT NULL DEFAULT '';
+ALTER TABLE torrent_replacements ADD COLUMN new_provider TEXT NOT NULL DEFAULT '';
+
+INSERT OR IGNORE INTO configs (key, value, description) VALUES
+    ('mam.enabled', 'true', 'Search MyAnonamouse once its secret is set'),
+    ('search.provider_timeout_seconds', '30', 'Seconds each search provider may take before a search goes on without it');
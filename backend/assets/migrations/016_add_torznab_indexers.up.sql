@@ -0,0 +1,2 @@
+INSERT OR IGNORE INTO configs (key, value, description) VALUES
+    ('torznab.indexers', '', 'JSON list of Torznab indexers (Prowlarr, Jackett) to search: name, url, api_key, optional audiobook_categories, ebook_categories and enabled');
@@ -0,0 +1,19 @@
+-- Preferences for ranking search results and picking releases automatically. List columns
+-- hold JSON arrays.
+CREATE TABLE IF NOT EXISTS quality_profiles (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    preferred_formats TEXT NOT NULL DEFAULT '[]',
+    min_size_mb INTEGER NOT NULL DEFAULT 0,
+    max_size_mb INTEGER NOT NULL DEFAULT 0,
+    preferred_languages TEXT NOT NULL DEFAULT '[]',
+    freeleech_bonus INTEGER NOT NULL DEFAULT 0,
+    min_seeders INTEGER NOT NULL DEFAULT 0,
+    preferred_uploaders TEXT NOT NULL DEFAULT '[]',
+    blocked_uploaders TEXT NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT OR IGNORE INTO configs (key, value, description) VALUES
+    ('search.quality_profile', '', 'ID of the quality profile that ranks search results and picks releases automatically; empty keeps provider order');
@@ -0,0 +1,26 @@
+-- Cached provider responses, so repeated searches and torrent downloads don't hit the
+-- trackers again. Search results are stored as JSON under a key built from the normalized
+-- query.
+CREATE TABLE IF NOT EXISTS search_cache (
+    cache_key TEXT PRIMARY KEY,
+    results TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_search_cache_expires_at ON search_cache(expires_at);
+
+CREATE TABLE IF NOT EXISTS torrent_cache (
+    provider TEXT NOT NULL,
+    torrent_id TEXT NOT NULL,
+    data BLOB NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (provider, torrent_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_torrent_cache_expires_at ON torrent_cache(expires_at);
+
+INSERT OR IGNORE INTO configs (key, value, description) VALUES
+    ('search.cache_ttl_minutes', '15', 'Minutes search results are cached; 0 disables the cache'),
+    ('search.torrent_cache_ttl_hours', '24', 'Hours downloaded .torrent files are cached; 0 disables the cache');
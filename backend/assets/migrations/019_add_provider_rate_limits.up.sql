@@ -0,0 +1,3 @@
+-- Requests a minute sent to MyAnonamouse; Torznab indexers set theirs in torznab.indexers
+INSERT OR IGNORE INTO configs (key, value, description) VALUES
+    ('mam.requests_per_minute', '60', 'Most requests a minute sent to MyAnonamouse; 0 only backs off when MAM asks to');
@@ -0,0 +1,4 @@
+-- MAM's dynamic seedbox IP registration endpoint. mam.secret is kept up to date as MAM
+-- rotates the session cookie.
+INSERT OR IGNORE INTO configs (key, value, description) VALUES
+    ('mam.seedbox_url', 'https://t.myanonamouse.net/json/dynamicSeedbox.php', 'MAM dynamic seedbox IP registration endpoint');
@@ -0,0 +1,4 @@
+-- Guard MAM grabs against the account's unsatisfied torrent limit and a ratio floor
+INSERT OR IGNORE INTO configs (key, value, description) VALUES
+    ('mam.account_guard', 'warn', 'What to do with MAM grabs that would exceed the unsatisfied limit or ratio floor: off, warn or refuse'),
+    ('mam.min_ratio', '1.0', 'Lowest ratio a non-freeleech MAM grab may leave the account with');
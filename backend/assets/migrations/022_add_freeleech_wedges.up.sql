@@ -0,0 +1,7 @@
+-- Record whether a MAM freeleech wedge was spent on a download's torrent
+ALTER TABLE downloads ADD COLUMN wedge_used INTEGER NOT NULL DEFAULT 0;
+
+-- When to spend freeleech wedges on MAM grabs that aren't freeleech already
+INSERT OR IGNORE INTO configs (key, value, description) VALUES
+    ('mam.wedge_policy', 'never', 'When to spend a freeleech wedge on a MAM grab: never, always or over_size'),
+    ('mam.wedge_min_size_mb', '1024', 'Size above which the over_size wedge policy spends a wedge, in MB');
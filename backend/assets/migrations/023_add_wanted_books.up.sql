@@ -0,0 +1,23 @@
+-- Books searched for on a schedule and downloaded once a release shows up
+CREATE TABLE IF NOT EXISTS wanted_books (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    author TEXT NOT NULL,
+    series TEXT NOT NULL DEFAULT '',
+    series_number TEXT NOT NULL DEFAULT '',
+    media_type TEXT NOT NULL DEFAULT 'audiobook',
+    category TEXT NOT NULL DEFAULT '',
+    quality_profile_id INTEGER,
+    status TEXT NOT NULL DEFAULT 'wanted',
+    download_id TEXT NOT NULL DEFAULT '',
+    last_searched_at TIMESTAMP,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (title COLLATE NOCASE, author COLLATE NOCASE, media_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_wanted_books_status ON wanted_books(status);
+
+INSERT OR IGNORE INTO configs (key, value, description) VALUES
+    ('wanted.search_interval_minutes', '360', 'How often wanted books are searched for, in minutes; 0 stops scheduled searches');
@@ -0,0 +1,24 @@
+-- Series whose missing and newly released entries are added to the wanted list
+CREATE TABLE IF NOT EXISTS followed_series (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    provider TEXT NOT NULL,
+    series_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    media_type TEXT NOT NULL DEFAULT 'audiobook',
+    category TEXT NOT NULL DEFAULT '',
+    quality_profile_id INTEGER,
+    start_after TEXT NOT NULL DEFAULT '',
+    last_checked_at TIMESTAMP,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (provider, series_id, media_type)
+);
+
+-- The followed series a wanted book was queued for
+ALTER TABLE wanted_books ADD COLUMN followed_series_id INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_wanted_books_followed_series ON wanted_books(followed_series_id);
+
+INSERT OR IGNORE INTO configs (key, value, description) VALUES
+    ('series.check_interval_minutes', '720', 'How often followed series are checked for new entries, in minutes; 0 stops scheduled checks');
@@ -0,0 +1,4 @@
+-- The mam_id MAM last rotated the session to, kept apart from mam.secret so MAM_SECRET
+-- doesn't bring back a session MAM rotated away from
+INSERT OR IGNORE INTO configs (key, value, description) VALUES
+    ('mam.rotated_secret', '', 'MAM session cookie rotated from mam.secret, managed automatically');
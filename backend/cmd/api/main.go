@@ -15,8 +15,10 @@ import (
 	"github.com/nathanael/organizr/internal/downloads"
 	"github.com/nathanael/organizr/internal/persistence/sqlite"
 	"github.com/nathanael/organizr/internal/qbittorrent"
+	"github.com/nathanael/organizr/internal/quality"
 	"github.com/nathanael/organizr/internal/search"
 	"github.com/nathanael/organizr/internal/server"
+	"github.com/nathanael/organizr/internal/wanted"
 
 	_ "github.com/nathanael/organizr/docs" // Required for swag
 )
@@ -62,23 +64,31 @@ func run() error {
 	// 3. Initialize repositories
 	downloadRepo := sqlite.NewDownloadRepository(db)
 	configRepo := sqlite.NewConfigRepository(db)
+	journalRepo := sqlite.NewOrganizationJournalRepository(db)
+	jobRepo := sqlite.NewOrganizeJobRepository(db)
+	qualityProfileRepo := sqlite.NewQualityProfileRepository(db)
+	searchCacheRepo := sqlite.NewSearchCacheRepository(db)
+	wantedRepo := sqlite.NewWantedBookRepository(db)
+	followedSeriesRepo := sqlite.NewFollowedSeriesRepository(db)
 
 	// 4. Initialize config service
 	configService := config.NewService(configRepo)
 
-	// 5. Initialize MAM service
-	mamService := search.NewMAMService(configRepo)
+	// 5. Initialize search service over the providers configured in the database, and the
+	// quality profiles that rank its results
+	searchService := search.NewService(configService, searchCacheRepo)
+	qualityService := quality.NewService(qualityProfileRepo, configService)
 
-	// 6. Initialize qBittorrent client
-	qbURL, err := configRepo.Get(context.Background(), "qbittorrent.url")
+	// 6. Initialize qBittorrent client; the monitor reconfigures it when these settings change
+	qbURL, err := configService.Get(context.Background(), "qbittorrent.url")
 	if err != nil {
 		qbURL = "http://localhost:8080"
 	}
-	qbUser, err := configRepo.Get(context.Background(), "qbittorrent.username")
+	qbUser, err := configService.Get(context.Background(), "qbittorrent.username")
 	if err != nil {
 		qbUser = "admin"
 	}
-	qbPass, err := configRepo.Get(context.Background(), "qbittorrent.password")
+	qbPass, err := configService.Get(context.Background(), "qbittorrent.password")
 	if err != nil {
 		qbPass = "adminpass"
 	}
@@ -89,26 +99,45 @@ func run() error {
 	}
 
 	// 7. Initialize download services
-	downloadService := downloads.NewService(db, qbClient, downloadRepo, configService, mamService)
-	monitor := downloads.NewMonitor(db, qbClient, downloadRepo, configService)
-
-	// 8. Start background monitor
+	// Shared so manual and automatic organization of the same download can't overlap
+	organizeLocks := downloads.NewDownloadLocks()
+	downloadService := downloads.NewService(db, qbClient, downloadRepo, journalRepo, jobRepo, organizeLocks, configService, searchService)
+	monitor := downloads.NewMonitor(db, qbClient, downloadRepo, journalRepo, jobRepo, organizeLocks, configService, searchService, qualityService)
+	// The wanted list grabs releases through the download service on its own schedule, and
+	// queues the missing entries of followed series
+	wantedService := wanted.NewService(wantedRepo, followedSeriesRepo, searchService, qualityService, downloadService, configService)
+
+	// 8. Recover organizations interrupted by the last shutdown, then start background monitor
 	monitorCtx, cancelMonitor := context.WithCancel(context.Background())
 	monitorDone := make(chan error, 1)
+	monitorStopped := make(chan struct{})
 
 	go func() {
+		defer close(monitorStopped)
+		if err := monitor.RecoverInterrupted(monitorCtx); err != nil {
+			log.Printf("Failed to recover interrupted organizations: %v", err)
+		}
 		if err := monitor.Run(monitorCtx); err != nil && err != context.Canceled {
 			monitorDone <- err
 		}
 	}()
 
+	go func() {
+		if err := wantedService.Run(monitorCtx); err != nil && err != context.Canceled {
+			log.Printf("Wanted list scheduler stopped: %v", err)
+		}
+	}()
+
 	// 9. Create and start HTTP server
 	srv := server.New(server.Config{
 		Port:            "8080",
 		AllowedOrigins:  []string{"*"},
 		DownloadService: downloadService,
-		SearchService:   mamService,
+		Monitor:         monitor,
+		SearchService:   searchService,
 		ConfigService:   configService,
+		QualityService:  qualityService,
+		WantedService:   wantedService,
 	})
 
 	go func() {
@@ -133,10 +162,35 @@ func run() error {
 		return fmt.Errorf("server shutdown failed: %w", err)
 	}
 
+	// Let in-flight organizations finish; any still running at the deadline are cancelled
+	// and resumed from their journal on the next start
+	orgCtx, cancelOrgWait := context.WithTimeout(context.Background(), organizeShutdownTimeout())
+	defer cancelOrgWait()
+
+	select {
+	case <-monitorStopped:
+	case <-orgCtx.Done():
+	}
+	if err := monitor.Shutdown(orgCtx); err != nil {
+		log.Printf("Organizations did not finish before shutdown, they will resume on next start: %v", err)
+	}
+
 	log.Println("Shutdown complete")
 	return nil
 }
 
+// organizeShutdownTimeout is how long shutdown waits for in-flight organizations,
+// configurable with ORGANIZR_SHUTDOWN_TIMEOUT (e.g. "2m")
+func organizeShutdownTimeout() time.Duration {
+	if value := os.Getenv("ORGANIZR_SHUTDOWN_TIMEOUT"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+		log.Printf("Invalid ORGANIZR_SHUTDOWN_TIMEOUT %q, using default", value)
+	}
+	return 2 * time.Minute
+}
+
 func runMigrations(db *sql.DB) error {
 	// Create migrations tracking table
 	createMigrationsTable := `
@@ -158,6 +212,27 @@ func runMigrations(db *sql.DB) error {
 		{2, "./assets/migrations/002_add_category.up.sql"},
 		{3, "./assets/migrations/003_add_path_prefix.up.sql"},
 		{4, "./assets/migrations/004_add_series_number.up.sql"},
+		{5, "./assets/migrations/005_add_media_type.up.sql"},
+		{6, "./assets/migrations/006_add_permissions.up.sql"},
+		{7, "./assets/migrations/007_add_remote_mappings.up.sql"},
+		{8, "./assets/migrations/008_add_organization_journal.up.sql"},
+		{9, "./assets/migrations/009_add_organize_jobs.up.sql"},
+		{10, "./assets/migrations/010_add_organize_retries.up.sql"},
+		{11, "./assets/migrations/011_add_missing_torrents.up.sql"},
+		{12, "./assets/migrations/012_add_webhook_token.up.sql"},
+		{13, "./assets/migrations/013_add_download_events.up.sql"},
+		{14, "./assets/migrations/014_add_stalled_downloads.up.sql"},
+		{15, "./assets/migrations/015_add_search_providers.up.sql"},
+		{16, "./assets/migrations/016_add_torznab_indexers.up.sql"},
+		{17, "./assets/migrations/017_add_quality_profiles.up.sql"},
+		{18, "./assets/migrations/018_add_search_cache.up.sql"},
+		{19, "./assets/migrations/019_add_provider_rate_limits.up.sql"},
+		{20, "./assets/migrations/020_add_mam_seedbox.up.sql"},
+		{21, "./assets/migrations/021_add_mam_account_guard.up.sql"},
+		{22, "./assets/migrations/022_add_freeleech_wedges.up.sql"},
+		{23, "./assets/migrations/023_add_wanted_books.up.sql"},
+		{24, "./assets/migrations/024_add_followed_series.up.sql"},
+		{25, "./assets/migrations/025_add_mam_rotated_secret.up.sql"},
 	}
 
 	for _, migration := range migrations {
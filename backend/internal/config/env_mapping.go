@@ -1,18 +1,50 @@
 package config
 
 var envKeyMap = map[string]string{
-	"qbittorrent.url":          "QBITTORRENT_URL",
-	"qbittorrent.username":     "QBITTORRENT_USERNAME",
-	"qbittorrent.password":     "QBITTORRENT_PASSWORD",
-	"paths.destination":        "PATHS_DESTINATION",
-	"paths.template":           "PATHS_TEMPLATE",
-	"paths.no_series_template": "PATHS_NO_SERIES_TEMPLATE",
-	"paths.operation":          "PATHS_OPERATION",
-	"paths.local_mount":        "PATHS_LOCAL_MOUNT",
-	"monitor.interval_seconds": "MONITOR_INTERVAL_SECONDS",
-	"monitor.auto_organize":    "MONITOR_AUTO_ORGANIZE",
-	"mam.baseurl":              "MAM_BASEURL",
-	"mam.secret":               "MAM_SECRET",
+	"qbittorrent.url":                 "QBITTORRENT_URL",
+	"qbittorrent.username":            "QBITTORRENT_USERNAME",
+	"qbittorrent.password":            "QBITTORRENT_PASSWORD",
+	"paths.destination":               "PATHS_DESTINATION",
+	"paths.template":                  "PATHS_TEMPLATE",
+	"paths.no_series_template":        "PATHS_NO_SERIES_TEMPLATE",
+	"paths.operation":                 "PATHS_OPERATION",
+	"paths.local_mount":               "PATHS_LOCAL_MOUNT",
+	"paths.remote_mappings":           "PATHS_REMOTE_MAPPINGS",
+	"paths.ebook_destination":         "PATHS_EBOOK_DESTINATION",
+	"paths.ebook_template":            "PATHS_EBOOK_TEMPLATE",
+	"paths.ebook_no_series_template":  "PATHS_EBOOK_NO_SERIES_TEMPLATE",
+	"ebooks.file_types":               "EBOOKS_FILE_TYPES",
+	"ebooks.write_opf":                "EBOOKS_WRITE_OPF",
+	"permissions.puid":                "PUID",
+	"permissions.pgid":                "PGID",
+	"permissions.dir_mode":            "PERMISSIONS_DIR_MODE",
+	"permissions.file_mode":           "PERMISSIONS_FILE_MODE",
+	"permissions.inherit_group":       "PERMISSIONS_INHERIT_GROUP",
+	"monitor.interval_seconds":        "MONITOR_INTERVAL_SECONDS",
+	"monitor.auto_organize":           "MONITOR_AUTO_ORGANIZE",
+	"monitor.max_concurrent":          "MONITOR_MAX_CONCURRENT",
+	"monitor.retry_max_attempts":      "MONITOR_RETRY_MAX_ATTEMPTS",
+	"monitor.retry_delay_seconds":     "MONITOR_RETRY_DELAY_SECONDS",
+	"monitor.missing_grace_seconds":   "MONITOR_MISSING_GRACE_SECONDS",
+	"monitor.stalled_after_minutes":   "MONITOR_STALLED_AFTER_MINUTES",
+	"monitor.replace_stalled":         "MONITOR_REPLACE_STALLED",
+	"mam.baseurl":                     "MAM_BASEURL",
+	"mam.secret":                      "MAM_SECRET",
+	"mam.enabled":                     "MAM_ENABLED",
+	"mam.seedbox_url":                 "MAM_SEEDBOX_URL",
+	"mam.requests_per_minute":         "MAM_REQUESTS_PER_MINUTE",
+	"mam.account_guard":               "MAM_ACCOUNT_GUARD",
+	"mam.min_ratio":                   "MAM_MIN_RATIO",
+	"mam.wedge_policy":                "MAM_WEDGE_POLICY",
+	"mam.wedge_min_size_mb":           "MAM_WEDGE_MIN_SIZE_MB",
+	"wanted.search_interval_minutes":  "WANTED_SEARCH_INTERVAL_MINUTES",
+	"series.check_interval_minutes":   "SERIES_CHECK_INTERVAL_MINUTES",
+	"search.provider_timeout_seconds": "SEARCH_PROVIDER_TIMEOUT_SECONDS",
+	"torznab.indexers":                "TORZNAB_INDEXERS",
+	"search.cache_ttl_minutes":        "SEARCH_CACHE_TTL_MINUTES",
+	"search.torrent_cache_ttl_hours":  "SEARCH_TORRENT_CACHE_TTL_HOURS",
+	"search.quality_profile":          "SEARCH_QUALITY_PROFILE",
+	"webhook.token":                   "WEBHOOK_TOKEN",
 }
 
 func getEnvKey(dbKey string) string {
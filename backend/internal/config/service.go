@@ -4,14 +4,23 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sync"
 
 	"github.com/nathanael/organizr/internal/persistence"
 )
 
 type Service struct {
 	repo persistence.ConfigRepository
+
+	mu        sync.Mutex
+	listeners map[int]ChangeListener
+	nextID    int
 }
 
+// ChangeListener is notified after a config value is set. It runs on the goroutine that
+// called Set, so it must not block.
+type ChangeListener func(key, value string)
+
 func NewService(repo persistence.ConfigRepository) *Service {
 	return &Service{
 		repo: repo,
@@ -55,5 +64,42 @@ func (s *Service) Set(ctx context.Context, key, value string) error {
 	if err := s.repo.Set(ctx, key, value); err != nil {
 		return fmt.Errorf("failed to set config %s: %w", key, err)
 	}
+
+	s.notify(key, value)
 	return nil
 }
+
+// Subscribe registers a listener for config changes made through Set. Changes to keys
+// overridden by an environment variable are still reported, although Get keeps returning
+// the environment value. Call the returned function to unsubscribe.
+func (s *Service) Subscribe(listener ChangeListener) (unsubscribe func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listeners == nil {
+		s.listeners = make(map[int]ChangeListener)
+	}
+	id := s.nextID
+	s.nextID++
+	s.listeners[id] = listener
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.listeners, id)
+	}
+}
+
+func (s *Service) notify(key, value string) {
+	// Copy so listeners can subscribe or unsubscribe without deadlocking
+	s.mu.Lock()
+	listeners := make([]ChangeListener, 0, len(s.listeners))
+	for _, listener := range s.listeners {
+		listeners = append(listeners, listener)
+	}
+	s.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener(key, value)
+	}
+}
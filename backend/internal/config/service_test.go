@@ -251,3 +251,41 @@ func TestService_Set_WritesToDatabaseOnly(t *testing.T) {
 	testutil.AssertNoError(t, err)
 	testutil.AssertEqual(t, value, "http://env-qbit:8080")
 }
+
+func TestService_Subscribe(t *testing.T) {
+	failing := false
+	repo := &mockConfigRepository{
+		setFunc: func(ctx context.Context, key, value string) error {
+			if failing {
+				return os.ErrPermission
+			}
+			return nil
+		},
+	}
+	service := NewService(repo)
+
+	var changes []string
+	unsubscribe := service.Subscribe(func(key, value string) {
+		changes = append(changes, key+"="+value)
+	})
+
+	if err := service.Set(context.Background(), "monitor.interval_seconds", "60"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	// Failed writes aren't reported
+	failing = true
+	if err := service.Set(context.Background(), "monitor.interval_seconds", "90"); err == nil {
+		t.Fatal("expected Set() to fail")
+	}
+	failing = false
+
+	unsubscribe()
+	if err := service.Set(context.Background(), "qbittorrent.url", "http://qbit:8080"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	if len(changes) != 1 || changes[0] != "monitor.interval_seconds=60" {
+		t.Errorf("changes = %v, want only the first successful Set", changes)
+	}
+}
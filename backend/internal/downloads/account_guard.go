@@ -0,0 +1,180 @@
+package downloads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/search/providers"
+)
+
+// ErrAccountGuard is returned when the MAM account guard refuses a grab that would exceed the
+// account's unsatisfied torrent limit or drop its ratio below the configured floor
+var ErrAccountGuard = errors.New("grab refused by MAM account guard")
+
+// Account guard modes, set with mam.account_guard
+const (
+	AccountGuardOff    = "off"
+	AccountGuardWarn   = "warn"
+	AccountGuardRefuse = "refuse"
+)
+
+// accountStatsTTL is how long fetched account stats are reused. Grabs made meanwhile are
+// added to them, so a batch doesn't fetch the stats once per torrent.
+const accountStatsTTL = time.Minute
+
+// accountStatter fetches the MAM account's stats
+type accountStatter interface {
+	AccountStats(ctx context.Context) (*providers.AccountStats, error)
+}
+
+// accountGuard checks MAM grabs against the account's unsatisfied limit and ratio
+type accountGuard struct {
+	account accountStatter
+
+	mu           sync.Mutex
+	stats        *providers.AccountStats
+	fetchedAt    time.Time
+	grabs        int   // MAM grabs since the stats were fetched
+	grabbedBytes int64 // Bytes of non-freeleech MAM grabs since the stats were fetched
+}
+
+// current returns the account's stats with the grabs made since they were fetched added
+func (g *accountGuard) current(ctx context.Context) (providers.AccountStats, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.stats == nil || time.Since(g.fetchedAt) > accountStatsTTL {
+		stats, err := g.account.AccountStats(ctx)
+		if err != nil {
+			return providers.AccountStats{}, err
+		}
+		g.stats = stats
+		g.fetchedAt = time.Now()
+		g.grabs = 0
+		g.grabbedBytes = 0
+	}
+
+	stats := *g.stats
+	stats.Unsatisfied += g.grabs
+	stats.DownloadedBytes += g.grabbedBytes
+	return stats, nil
+}
+
+// record adds a grab to the cached stats
+func (g *accountGuard) record(size int64, freeleech bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.grabs++
+	if !freeleech {
+		g.grabbedBytes += size
+	}
+}
+
+// unsatisfiedViolations lists how grabbing one more torrent would break the account's
+// unsatisfied limit
+func unsatisfiedViolations(stats providers.AccountStats) []string {
+	if stats.UnsatisfiedLimit > 0 && stats.Unsatisfied+1 > stats.UnsatisfiedLimit {
+		return []string{fmt.Sprintf("unsatisfied torrents would exceed the limit (%d of %d in use)", stats.Unsatisfied, stats.UnsatisfiedLimit)}
+	}
+	return nil
+}
+
+// ratioViolations lists how grabbing a torrent of size bytes would break the account's
+// ratio floor. Freeleech torrents don't count against the ratio.
+func ratioViolations(stats providers.AccountStats, size int64, freeleech bool, minRatio float64) []string {
+	if freeleech || minRatio <= 0 {
+		return nil
+	}
+	downloaded := stats.DownloadedBytes + size
+	ratio := math.Inf(1)
+	if downloaded > 0 {
+		ratio = float64(stats.UploadedBytes) / float64(downloaded)
+	}
+	if ratio < minRatio {
+		return []string{fmt.Sprintf("ratio would drop to %.2f, below the minimum of %.2f", ratio, minRatio)}
+	}
+	return nil
+}
+
+// isMAMGrab reports whether d's torrent comes from MyAnonamouse
+func isMAMGrab(d *models.Download) bool {
+	if d.TorrentID != "" {
+		return d.Provider == "" || d.Provider == providers.MyAnonamouseName
+	}
+	return strings.Contains(d.TorrentURL, "myanonamouse.net")
+}
+
+// accountCheck is the account guard's view of a MAM grab between its two checks
+type accountCheck struct {
+	mode     string
+	minRatio float64
+	stats    providers.AccountStats
+}
+
+// checkAccount runs the account guard's unsatisfied limit check for a MAM grab, which holds
+// whether or not a wedge is spent on it. It returns the check to finish with checkRatio once
+// that's known (nil when the guard doesn't apply), the warnings to record with the download,
+// or ErrAccountGuard when the guard refuses the grab. Account stats that can't be fetched
+// only produce a warning.
+func (s *Service) checkAccount(ctx context.Context, d *models.Download) (*accountCheck, []string, error) {
+	if s.accountGuard == nil || !isMAMGrab(d) {
+		return nil, nil, nil
+	}
+
+	mode, err := s.configService.Get(ctx, "mam.account_guard")
+	if err != nil || mode == "" {
+		mode = AccountGuardWarn
+	}
+	if mode == AccountGuardOff {
+		return nil, nil, nil
+	}
+
+	minRatio := 0.0
+	if value, err := s.configService.Get(ctx, "mam.min_ratio"); err == nil && value != "" {
+		if minRatio, err = strconv.ParseFloat(value, 64); err != nil {
+			log.Printf("Invalid mam.min_ratio %q, ignoring the ratio floor: %v", value, err)
+			minRatio = 0
+		}
+	}
+
+	stats, err := s.accountGuard.current(ctx)
+	if err != nil {
+		log.Printf("Could not check MAM account before adding %s: %v", d.Title, err)
+		return nil, []string{fmt.Sprintf("could not check MAM account: %v", err)}, nil
+	}
+
+	check := &accountCheck{mode: mode, minRatio: minRatio, stats: stats}
+	warnings, err := check.judge(d, unsatisfiedViolations(stats))
+	if err != nil {
+		return nil, nil, err
+	}
+	return check, warnings, nil
+}
+
+// checkRatio finishes an account check with the ratio floor, which depends on whether the
+// grab ended up freeleech
+func (c *accountCheck) checkRatio(d *models.Download) ([]string, error) {
+	if c == nil {
+		return nil, nil
+	}
+	return c.judge(d, ratioViolations(c.stats, d.Size, d.Freeleech, c.minRatio))
+}
+
+// judge refuses a grab with violations in refuse mode, or returns them as warnings
+func (c *accountCheck) judge(d *models.Download, violations []string) ([]string, error) {
+	if len(violations) > 0 && c.mode == AccountGuardRefuse {
+		return nil, fmt.Errorf("%w: %s", ErrAccountGuard, strings.Join(violations, "; "))
+	}
+	for _, violation := range violations {
+		log.Printf("MAM account guard: adding %s: %s", d.Title, violation)
+	}
+	return violations, nil
+}
@@ -0,0 +1,140 @@
+package downloads
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nathanael/organizr/internal/config"
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/search/providers"
+)
+
+// fakeAccount returns fixed account stats and counts how often they were fetched
+type fakeAccount struct {
+	stats   providers.AccountStats
+	err     error
+	fetches int
+}
+
+func (f *fakeAccount) AccountStats(ctx context.Context) (*providers.AccountStats, error) {
+	f.fetches++
+	if f.err != nil {
+		return nil, f.err
+	}
+	stats := f.stats
+	return &stats, nil
+}
+
+const gib = int64(1) << 30
+
+func TestAccountViolations(t *testing.T) {
+	stats := providers.AccountStats{UploadedBytes: 10 * gib, DownloadedBytes: 8 * gib, Unsatisfied: 49, UnsatisfiedLimit: 50}
+
+	tests := []struct {
+		name      string
+		stats     providers.AccountStats
+		size      int64
+		freeleech bool
+		minRatio  float64
+		want      int
+	}{
+		{"Within limits", stats, gib, false, 1.0, 0},
+		{"Ratio drops below floor", stats, 3 * gib, false, 1.0, 1},
+		{"Freeleech ignores ratio", stats, 3 * gib, true, 1.0, 0},
+		{"No ratio floor", stats, 3 * gib, false, 0, 0},
+		{"Unsatisfied limit reached", providers.AccountStats{UploadedBytes: 10 * gib, Unsatisfied: 50, UnsatisfiedLimit: 50}, gib, false, 1.0, 1},
+		{"Both", providers.AccountStats{UploadedBytes: gib, DownloadedBytes: gib, Unsatisfied: 50, UnsatisfiedLimit: 50}, gib, false, 1.0, 2},
+		{"Unknown limit", providers.AccountStats{UploadedBytes: 10 * gib, Unsatisfied: 50}, gib, false, 1.0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := append(unsatisfiedViolations(tt.stats), ratioViolations(tt.stats, tt.size, tt.freeleech, tt.minRatio)...)
+			if len(got) != tt.want {
+				t.Errorf("violations = %v, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckAccount(t *testing.T) {
+	stats := providers.AccountStats{UploadedBytes: 10 * gib, DownloadedBytes: 8 * gib, Unsatisfied: 10, UnsatisfiedLimit: 50}
+	full := providers.AccountStats{UploadedBytes: 10 * gib, Unsatisfied: 50, UnsatisfiedLimit: 50}
+	mamGrab := func(size int64) *models.Download {
+		return &models.Download{Title: "Book", TorrentID: "123", Size: size}
+	}
+
+	tests := []struct {
+		name         string
+		mode         string
+		stats        providers.AccountStats
+		download     *models.Download
+		err          error
+		wantWarnings int
+		wantErr      error
+	}{
+		{"Warns", AccountGuardWarn, stats, mamGrab(3 * gib), nil, 1, nil},
+		{"Refuses", AccountGuardRefuse, stats, mamGrab(3 * gib), nil, 0, ErrAccountGuard},
+		{"Refuses at unsatisfied limit", AccountGuardRefuse, full, mamGrab(gib), nil, 0, ErrAccountGuard},
+		{"Off", AccountGuardOff, stats, mamGrab(3 * gib), nil, 0, nil},
+		{"Allowed", AccountGuardRefuse, stats, mamGrab(gib), nil, 0, nil},
+		{"Other provider", AccountGuardRefuse, stats, &models.Download{TorrentID: "1", Provider: "Prowlarr", Size: 3 * gib}, nil, 0, nil},
+		{"Stats unavailable", AccountGuardRefuse, stats, mamGrab(3 * gib), errors.New("connection refused"), 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Service{
+				configService: config.NewService(newMockConfigService(map[string]string{
+					"mam.account_guard": tt.mode,
+					"mam.min_ratio":     "1.0",
+				})),
+				accountGuard: &accountGuard{account: &fakeAccount{stats: tt.stats, err: tt.err}},
+			}
+
+			check, warnings, err := s.checkAccount(context.Background(), tt.download)
+			if err == nil {
+				var violations []string
+				violations, err = check.checkRatio(tt.download)
+				warnings = append(warnings, violations...)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("checkAccount() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(warnings) != tt.wantWarnings {
+				t.Errorf("checkAccount() warnings = %v, want %d", warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestAccountGuard_CountsGrabsBetweenFetches(t *testing.T) {
+	account := &fakeAccount{stats: providers.AccountStats{UploadedBytes: 10 * gib, Unsatisfied: 48, UnsatisfiedLimit: 50}}
+	guard := &accountGuard{account: account}
+	ctx := context.Background()
+
+	for range 2 {
+		stats, err := guard.current(ctx)
+		if err != nil {
+			t.Fatalf("current() error = %v", err)
+		}
+		if violations := unsatisfiedViolations(stats); len(violations) != 0 {
+			t.Fatalf("grab %d refused: %v", stats.Unsatisfied, violations)
+		}
+		guard.record(gib, false)
+	}
+
+	// The third grab would be the 51st unsatisfied torrent
+	stats, err := guard.current(ctx)
+	if err != nil {
+		t.Fatalf("current() error = %v", err)
+	}
+	if stats.Unsatisfied != 50 || stats.DownloadedBytes != 2*gib {
+		t.Errorf("stats = %d unsatisfied, %d bytes downloaded, want 50 and %d", stats.Unsatisfied, stats.DownloadedBytes, 2*gib)
+	}
+	if violations := unsatisfiedViolations(stats); len(violations) != 1 {
+		t.Errorf("unsatisfiedViolations() = %v, want the unsatisfied limit", violations)
+	}
+	if account.fetches != 1 {
+		t.Errorf("stats fetched %d times, want once", account.fetches)
+	}
+}
@@ -0,0 +1,49 @@
+package downloads
+
+import (
+	"errors"
+	"time"
+
+	"github.com/nathanael/organizr/internal/qbittorrent"
+)
+
+// maxRetryDelay caps the exponential backoff between organization attempts
+const maxRetryDelay = 6 * time.Hour
+
+// PermanentError marks an organization failure that retrying cannot fix, such as invalid
+// settings or a torrent qBittorrent no longer has. It needs the user to act first.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+func permanent(err error) error {
+	return &PermanentError{Err: err}
+}
+
+// IsTransient reports whether a failed organization may succeed when retried, e.g. once a
+// NAS is back online or disk space is freed. Failures are transient unless known otherwise.
+func IsTransient(err error) bool {
+	var permanentErr *PermanentError
+	if errors.As(err, &permanentErr) {
+		return false
+	}
+	// Retrying won't bring the torrent back; POST /downloads/{id}/retry can re-add it
+	return !errors.Is(err, qbittorrent.ErrTorrentNotFound)
+}
+
+// retryDelay returns the backoff after the given failed attempt: base, then doubling for
+// every further attempt, up to maxRetryDelay
+func retryDelay(base time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
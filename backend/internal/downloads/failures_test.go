@@ -0,0 +1,52 @@
+package downloads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nathanael/organizr/internal/qbittorrent"
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"disk full", errors.New("insufficient disk space: need 2 GB, only 1 GB available"), true},
+		{"source offline", fmt.Errorf("source file is not accessible: %w", errors.New("input/output error")), true},
+		{"timeout", fmt.Errorf("failed to copy file: %w", context.DeadlineExceeded), true},
+		{"invalid settings", permanent(errors.New("invalid permission settings")), false},
+		{"wrapped permanent", fmt.Errorf("failed to organize: %w", permanent(errors.New("bad mapping"))), false},
+		{"torrent gone", fmt.Errorf("failed to get torrent files: %w", qbittorrent.ErrTorrentNotFound), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTransient(tt.err); got != tt.want {
+				t.Errorf("IsTransient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{20, maxRetryDelay},
+	}
+
+	for _, tt := range tests {
+		if got := retryDelay(time.Minute, tt.attempt); got != tt.want {
+			t.Errorf("retryDelay(1m, %d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package downloads
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/nathanael/organizr/internal/qbittorrent"
+)
+
+// fakeQBittorrent holds what a fake qBittorrent serves besides its torrents
+type fakeQBittorrent struct {
+	files           map[string][]string // File names by torrent hash
+	defaultSavePath string
+	addedState      string // State of uploaded torrents
+}
+
+type fakeQBittorrentOption func(*fakeQBittorrent)
+
+// withAddedState gives uploaded torrents a state, e.g. metaDL while fetching metadata
+func withAddedState(state string) fakeQBittorrentOption {
+	return func(f *fakeQBittorrent) { f.addedState = state }
+}
+
+// withTorrentFiles serves the file names of each torrent, by hash
+func withTorrentFiles(files map[string][]string) fakeQBittorrentOption {
+	return func(f *fakeQBittorrent) { f.files = files }
+}
+
+// withDefaultSavePath serves path as the default save path for new torrents
+func withDefaultSavePath(path string) fakeQBittorrentOption {
+	return func(f *fakeQBittorrent) { f.defaultSavePath = path }
+}
+
+// newFakeQBittorrent starts an httptest server implementing the qBittorrent endpoints the
+// downloads package uses and returns a client pointed at it. Torrents are filtered by hash
+// and category like the real API. What is added and deleted is recorded, in order: the URL
+// for links, "file:<contents>" for uploaded torrent files, and "delete:<hash>" for deletions,
+// or "delete-files:<hash>" when the files go too. An uploaded file adds a torrent with hash
+// "uploaded" saving to the default save path.
+func newFakeQBittorrent(t *testing.T, torrents []qbittorrent.TorrentInfo, opts ...fakeQBittorrentOption) (*qbittorrent.Client, *[]string) {
+	t.Helper()
+
+	fake := &fakeQBittorrent{}
+	for _, opt := range opts {
+		opt(fake)
+	}
+
+	var mu sync.Mutex
+	var calls []string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("Ok."))
+	})
+	mux.HandleFunc("/api/v2/app/version", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("v4.6.0"))
+	})
+	mux.HandleFunc("/api/v2/app/defaultSavePath", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(fake.defaultSavePath))
+	})
+	mux.HandleFunc("/api/v2/torrents/info", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		query := r.URL.Query()
+		matching := []qbittorrent.TorrentInfo{}
+		for _, torrent := range torrents {
+			if category := query.Get("category"); category != "" && category != torrent.Category {
+				continue
+			}
+			if hash := query.Get("hashes"); hash != "" && hash != torrent.Hash {
+				continue
+			}
+			matching = append(matching, torrent)
+		}
+		_ = json.NewEncoder(w).Encode(matching)
+	})
+	mux.HandleFunc("/api/v2/torrents/files", func(w http.ResponseWriter, r *http.Request) {
+		var entries []map[string]interface{}
+		for _, name := range fake.files[r.URL.Query().Get("hash")] {
+			entries = append(entries, map[string]interface{}{"name": name, "size": 1})
+		}
+		_ = json.NewEncoder(w).Encode(entries)
+	})
+	mux.HandleFunc("/api/v2/torrents/add", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if file, _, err := r.FormFile("torrents"); err == nil {
+			data, _ := io.ReadAll(file)
+			calls = append(calls, "file:"+string(data))
+			torrents = append(torrents, qbittorrent.TorrentInfo{Hash: "uploaded", State: fake.addedState, SavePath: fake.defaultSavePath, AddedOn: 1})
+		} else {
+			calls = append(calls, r.FormValue("urls"))
+		}
+		_, _ = w.Write([]byte("Ok."))
+	})
+	mux.HandleFunc("/api/v2/torrents/delete", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		hash := r.FormValue("hashes")
+		if r.FormValue("deleteFiles") == "true" {
+			calls = append(calls, "delete-files:"+hash)
+		} else {
+			calls = append(calls, "delete:"+hash)
+		}
+		remaining := torrents[:0]
+		for _, torrent := range torrents {
+			if torrent.Hash != hash {
+				remaining = append(remaining, torrent)
+			}
+		}
+		torrents = remaining
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	qb, err := qbittorrent.NewClient(server.URL, "admin", "adminpass")
+	if err != nil {
+		t.Fatalf("failed to create qBittorrent client: %v", err)
+	}
+	return qb, &calls
+}
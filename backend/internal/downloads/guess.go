@@ -0,0 +1,150 @@
+package downloads
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nathanael/organizr/internal/fileutil"
+	"github.com/nathanael/organizr/internal/models"
+)
+
+// BookGuess is the book a torrent most likely holds, for the user to confirm or correct
+// before it is imported
+type BookGuess struct {
+	Title        string
+	Author       string
+	Series       string
+	SeriesNumber string
+	MediaType    models.MediaType
+	FromTags     bool // Whether embedded audio tags informed the guess
+}
+
+var (
+	// Bracketed release info such as "[2006]", "(Unabridged)" or "{MP3 64kbps}"
+	bracketedPattern = regexp.MustCompile(`[\[({][^\])}]*[\])}]`)
+	// "Mistborn #1", "Mistborn, Book 1", "The Expanse Vol. 3", "Discworld 01"
+	seriesPattern         = regexp.MustCompile(`(?i)^(.*?)[\s,:]*(?:#|\bbook\b|\bbk\.?|\bvol(?:ume)?\.?|\bpart\b)\s*(\d+(?:\.\d+)?)$`)
+	trailingNumberPattern = regexp.MustCompile(`^(.*\D)\s+(\d{1,3}(?:\.\d+)?)$`)
+	byPattern             = regexp.MustCompile(`(?i)^(.+?)\s+by\s+(.+)$`)
+	whitespacePattern     = regexp.MustCompile(`\s+`)
+)
+
+// GuessBookFromName guesses a book's title, author and series from a torrent name such as
+// "Brandon Sanderson - Mistborn 01 - The Final Empire [2006] (MP3)" or
+// "The Final Empire by Brandon Sanderson (Mistborn, Book 1)"
+func GuessBookFromName(name string) BookGuess {
+	guess := BookGuess{MediaType: models.MediaTypeAudiobook}
+
+	// Scene-style names use dots or underscores instead of spaces
+	if !strings.Contains(name, " ") {
+		name = strings.NewReplacer(".", " ", "_", " ").Replace(name)
+	}
+
+	// A bracketed series such as "(Mistborn, Book 1)" is kept; other brackets are noise
+	for _, group := range bracketedPattern.FindAllString(name, -1) {
+		if series, number, ok := parseSeries(group[1 : len(group)-1]); ok && guess.Series == "" {
+			guess.Series, guess.SeriesNumber = series, number
+		}
+	}
+	name = cleanGuessPart(bracketedPattern.ReplaceAllString(name, " "))
+
+	parts := splitNameParts(name)
+	switch {
+	case len(parts) >= 3:
+		guess.Author = parts[0]
+		guess.Title = parts[len(parts)-1]
+		middle := strings.Join(parts[1:len(parts)-1], " - ")
+		if series, number, ok := parseSeries(middle); ok {
+			guess.Series, guess.SeriesNumber = series, number
+		} else if _, err := strconv.ParseFloat(middle, 64); err == nil {
+			guess.SeriesNumber = normalizeSeriesNumber(middle)
+		} else {
+			guess.Series = middle
+		}
+	case len(parts) == 2:
+		guess.Author, guess.Title = parts[0], parts[1]
+	case len(parts) == 1:
+		if match := byPattern.FindStringSubmatch(parts[0]); match != nil {
+			guess.Title, guess.Author = cleanGuessPart(match[1]), cleanGuessPart(match[2])
+		} else {
+			guess.Title = parts[0]
+		}
+	}
+
+	return guess
+}
+
+// applyAudioTags overrides the guess with what the file's tags say. Audiobook rips
+// usually carry the book title in the album and the author in the album artist.
+func applyAudioTags(guess *BookGuess, tags fileutil.AudioTags) {
+	if title := cleanGuessPart(tags.Album); title != "" {
+		guess.Title = title
+		guess.FromTags = true
+	}
+
+	for _, author := range []string{tags.AlbumArtist, tags.Artist} {
+		// Multiple artists usually list the narrator after the author
+		if i := strings.IndexAny(author, ";/"); i >= 0 {
+			author = author[:i]
+		}
+		if author = cleanGuessPart(author); author != "" {
+			guess.Author = author
+			guess.FromTags = true
+			break
+		}
+	}
+
+	if grouping := cleanGuessPart(tags.Grouping); grouping != "" {
+		if series, number, ok := parseSeries(grouping); ok {
+			guess.Series, guess.SeriesNumber = series, number
+		} else {
+			guess.Series = grouping
+		}
+		guess.FromTags = true
+	}
+}
+
+// parseSeries splits "Mistborn, Book 1" or "Discworld 01" into series name and number
+func parseSeries(value string) (series, number string, ok bool) {
+	value = cleanGuessPart(value)
+	match := seriesPattern.FindStringSubmatch(value)
+	if match == nil {
+		match = trailingNumberPattern.FindStringSubmatch(value)
+	}
+	if match == nil {
+		return "", "", false
+	}
+
+	series = cleanGuessPart(match[1])
+	if series == "" {
+		return "", "", false
+	}
+	return series, normalizeSeriesNumber(match[2]), true
+}
+
+// normalizeSeriesNumber drops zero padding, so "01" becomes "1" and "02.5" becomes "2.5"
+func normalizeSeriesNumber(number string) string {
+	trimmed := strings.TrimLeft(number, "0")
+	if trimmed == "" || strings.HasPrefix(trimmed, ".") {
+		trimmed = "0" + trimmed
+	}
+	return trimmed
+}
+
+// splitNameParts splits a name on " - " separators, dropping empty parts
+func splitNameParts(name string) []string {
+	var parts []string
+	for _, part := range strings.Split(name, " - ") {
+		if part = cleanGuessPart(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// cleanGuessPart collapses whitespace and trims separators left over from stripped brackets
+func cleanGuessPart(value string) string {
+	value = whitespacePattern.ReplaceAllString(value, " ")
+	return strings.Trim(value, " -_,:;")
+}
@@ -0,0 +1,87 @@
+package downloads
+
+import (
+	"testing"
+
+	"github.com/nathanael/organizr/internal/fileutil"
+	"github.com/nathanael/organizr/internal/models"
+)
+
+func TestGuessBookFromName(t *testing.T) {
+	tests := []struct {
+		name string
+		want BookGuess
+	}{
+		{
+			name: "Brandon Sanderson - Mistborn 01 - The Final Empire [2006] (MP3)",
+			want: BookGuess{Title: "The Final Empire", Author: "Brandon Sanderson", Series: "Mistborn", SeriesNumber: "1"},
+		},
+		{
+			name: "James S. A. Corey - The Expanse Vol. 3 - Abaddon's Gate",
+			want: BookGuess{Title: "Abaddon's Gate", Author: "James S. A. Corey", Series: "The Expanse", SeriesNumber: "3"},
+		},
+		{
+			name: "Terry Pratchett - Discworld - Guards! Guards!",
+			want: BookGuess{Title: "Guards! Guards!", Author: "Terry Pratchett", Series: "Discworld"},
+		},
+		{
+			name: "Andy Weir - Project Hail Mary {Unabridged} [M4B]",
+			want: BookGuess{Title: "Project Hail Mary", Author: "Andy Weir"},
+		},
+		{
+			name: "The Final Empire by Brandon Sanderson (Mistborn, Book 1)",
+			want: BookGuess{Title: "The Final Empire", Author: "Brandon Sanderson", Series: "Mistborn", SeriesNumber: "1"},
+		},
+		{
+			name: "Frank_Herbert_-_Dune",
+			want: BookGuess{Title: "Dune", Author: "Frank Herbert"},
+		},
+		{
+			name: "Some Unlabelled Rip",
+			want: BookGuess{Title: "Some Unlabelled Rip"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.MediaType = models.MediaTypeAudiobook
+			if got := GuessBookFromName(tt.name); got != tt.want {
+				t.Errorf("GuessBookFromName() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyAudioTags(t *testing.T) {
+	guess := GuessBookFromName("Stephen King - Gunslinger (2003 Revised)")
+
+	applyAudioTags(&guess, fileutil.AudioTags{
+		Title:    "Chapter 01",
+		Album:    "The Gunslinger",
+		Artist:   "Stephen King; George Guidall",
+		Grouping: "The Dark Tower #1",
+	})
+
+	want := BookGuess{
+		Title:        "The Gunslinger",
+		Author:       "Stephen King",
+		Series:       "The Dark Tower",
+		SeriesNumber: "1",
+		MediaType:    models.MediaTypeAudiobook,
+		FromTags:     true,
+	}
+	if guess != want {
+		t.Errorf("guess = %+v, want %+v", guess, want)
+	}
+}
+
+func TestApplyAudioTags_EmptyTagsKeepNameGuess(t *testing.T) {
+	guess := GuessBookFromName("Andy Weir - The Martian")
+	want := guess
+
+	applyAudioTags(&guess, fileutil.AudioTags{Title: "Track 1"})
+
+	if guess != want {
+		t.Errorf("guess = %+v, want name guess %+v kept", guess, want)
+	}
+}
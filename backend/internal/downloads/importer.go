@@ -0,0 +1,237 @@
+package downloads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nathanael/organizr/internal/fileutil"
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/qbittorrent"
+)
+
+// ErrAlreadyTracked is returned when importing a torrent that already has a download
+var ErrAlreadyTracked = errors.New("torrent is already tracked by a download")
+
+// audioExtensions are the audio files ReadAudioTags understands
+var audioExtensions = []string{"mp3", "m4a", "m4b", "mp4"}
+
+// ImportCandidate is a torrent in qBittorrent that no download tracks yet, with a guess
+// at the book it holds
+type ImportCandidate struct {
+	Hash     string
+	Name     string
+	Category string
+	Tags     string
+	State    string
+	Progress float64 // Percentage, 0-100
+	Size     int64
+	SavePath string
+	AddedOn  time.Time
+	Guess    BookGuess
+	TagError string // Why embedded tags couldn't be read, if they were requested
+}
+
+// ImportRequest adopts an untracked torrent as a download with user-confirmed metadata
+type ImportRequest struct {
+	Hash         string
+	Title        string
+	Author       string
+	Series       string
+	SeriesNumber string
+	MediaType    models.MediaType
+}
+
+// ImportResult is the outcome of one ImportRequest: the created download or why it failed
+type ImportResult struct {
+	Download *models.Download
+	Err      error
+}
+
+// ListImportCandidates returns the torrents in the given category and tag that no download
+// tracks, oldest first. Empty filters match every torrent. When readTags is set, title,
+// author and series are taken from the first audio file's tags where present, which
+// requires the torrent's files to be reachable locally.
+func (s *Service) ListImportCandidates(ctx context.Context, category, tag string, readTags bool) ([]*ImportCandidate, error) {
+	torrents, err := s.qbClient.ListTorrents(ctx, category, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list qBittorrent torrents: %w", err)
+	}
+
+	tracked, err := s.trackedHashes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var mappings []fileutil.PathMapping
+	var mappingsErr error
+	var mountPoint string
+	if readTags {
+		mappingsValue, _ := s.configService.Get(ctx, "paths.remote_mappings")
+		mappings, mappingsErr = fileutil.ParsePathMappings(mappingsValue)
+		mountPoint, _ = s.configService.Get(ctx, "paths.local_mount")
+	}
+
+	candidates := []*ImportCandidate{}
+	for _, torrent := range torrents {
+		if tracked[strings.ToLower(torrent.Hash)] {
+			continue
+		}
+
+		candidate := &ImportCandidate{
+			Hash:     torrent.Hash,
+			Name:     torrent.Name,
+			Category: torrent.Category,
+			Tags:     torrent.Tags,
+			State:    torrent.State,
+			Progress: torrent.Progress * 100,
+			Size:     torrent.Size,
+			SavePath: torrent.SavePath,
+			AddedOn:  time.Unix(torrent.AddedOn, 0),
+			Guess:    GuessBookFromName(torrent.Name),
+		}
+
+		if readTags {
+			if mappingsErr != nil {
+				candidate.TagError = fmt.Sprintf("invalid paths.remote_mappings: %v", mappingsErr)
+			} else if err := s.inspectTorrentFiles(ctx, candidate, mappings, mountPoint); err != nil {
+				candidate.TagError = err.Error()
+			}
+		}
+
+		candidates = append(candidates, candidate)
+	}
+
+	// qBittorrent lists torrents in no particular order
+	sortCandidates(candidates)
+
+	return candidates, nil
+}
+
+// inspectTorrentFiles refines the candidate's guess from its files: torrents holding only
+// ebooks are guessed as ebooks, and audio tags override the name-based guess
+func (s *Service) inspectTorrentFiles(ctx context.Context, candidate *ImportCandidate, mappings []fileutil.PathMapping, mountPoint string) error {
+	files, err := s.qbClient.GetTorrentFiles(ctx, candidate.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to get torrent files: %w", err)
+	}
+
+	audioFiles := filterFilesByExtension(files, audioExtensions)
+	if len(audioFiles) == 0 {
+		if len(filterFilesByExtension(files, ebookExtensions(ctx, s.configService))) > 0 {
+			candidate.Guess.MediaType = models.MediaTypeEbook
+		}
+		return nil
+	}
+
+	file := audioFiles[0]
+	path := resolveLocalPath(file, mappings, mountPoint)
+	tags, err := fileutil.ReadAudioTags(path)
+	if err != nil {
+		return fmt.Errorf("failed to read tags from %s: %w", filepath.Base(path), err)
+	}
+
+	applyAudioTags(&candidate.Guess, tags)
+	return nil
+}
+
+// ImportTorrents creates a download for each request's torrent so the monitor tracks and
+// organizes it like any other. Results line up with requests; a request fails on its own
+// if its torrent is missing from qBittorrent, already tracked, or listed twice.
+func (s *Service) ImportTorrents(ctx context.Context, requests []ImportRequest) ([]ImportResult, error) {
+	torrents, err := s.qbClient.ListTorrents(ctx, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list qBittorrent torrents: %w", err)
+	}
+
+	byHash := make(map[string]qbittorrent.TorrentInfo, len(torrents))
+	for _, torrent := range torrents {
+		byHash[strings.ToLower(torrent.Hash)] = torrent
+	}
+
+	tracked, err := s.trackedHashes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ImportResult, len(requests))
+	for i, req := range requests {
+		hash := strings.ToLower(strings.TrimSpace(req.Hash))
+
+		torrent, ok := byHash[hash]
+		if !ok {
+			results[i].Err = fmt.Errorf("torrent %s not found in qBittorrent", req.Hash)
+			continue
+		}
+		if tracked[hash] {
+			results[i].Err = ErrAlreadyTracked
+			continue
+		}
+
+		mediaType := req.MediaType
+		if mediaType == "" {
+			mediaType = models.MediaTypeAudiobook
+		}
+
+		download := &models.Download{
+			ID:           uuid.New().String(),
+			Title:        strings.TrimSpace(req.Title),
+			Author:       strings.TrimSpace(req.Author),
+			Series:       strings.TrimSpace(req.Series),
+			SeriesNumber: strings.TrimSpace(req.SeriesNumber),
+			MediaType:    mediaType,
+			Category:     torrent.Category,
+			QBitHash:     torrent.Hash,
+			Status:       models.StatusQueued,
+			CreatedAt:    time.Now(),
+		}
+		if download.Title == "" || download.Author == "" {
+			results[i].Err = fmt.Errorf("title and author are required")
+			continue
+		}
+
+		if err := s.downloadRepo.Create(ctx, download); err != nil {
+			results[i].Err = fmt.Errorf("failed to save download to database: %w", err)
+			continue
+		}
+
+		recordCreated(ctx, s.downloadRepo, download, models.ActorUser, "imported from qBittorrent")
+
+		// Later duplicates of this hash in the same batch fail as already tracked
+		tracked[hash] = true
+		results[i].Download = download
+		log.Printf("Imported torrent %s as download %s (%s by %s)", torrent.Hash, download.ID, download.Title, download.Author)
+	}
+
+	return results, nil
+}
+
+// trackedHashes returns the lowercased qBittorrent hashes of all downloads
+func (s *Service) trackedHashes(ctx context.Context) (map[string]bool, error) {
+	downloads, err := s.downloadRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list downloads: %w", err)
+	}
+
+	tracked := make(map[string]bool, len(downloads))
+	for _, d := range downloads {
+		tracked[strings.ToLower(d.QBitHash)] = true
+	}
+	return tracked, nil
+}
+
+// sortCandidates orders candidates by when they were added to qBittorrent, then by name
+func sortCandidates(candidates []*ImportCandidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if !candidates[i].AddedOn.Equal(candidates[j].AddedOn) {
+			return candidates[i].AddedOn.Before(candidates[j].AddedOn)
+		}
+		return candidates[i].Name < candidates[j].Name
+	})
+}
@@ -0,0 +1,178 @@
+package downloads
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nathanael/organizr/internal/config"
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/qbittorrent"
+)
+
+// writeID3File writes an MP3 holding an ID3v2.3 tag with Latin-1 text frames
+func writeID3File(t *testing.T, path string, frames map[string]string) {
+	t.Helper()
+
+	var body []byte
+	for id, text := range frames {
+		size := make([]byte, 4)
+		binary.BigEndian.PutUint32(size, uint32(len(text)+1))
+		body = append(body, id...)
+		body = append(body, size...)
+		body = append(body, 0, 0, 0) // Flags and Latin-1 encoding
+		body = append(body, text...)
+	}
+	n := len(body)
+	data := append([]byte{'I', 'D', '3', 3, 0, 0, byte(n >> 21 & 0x7f), byte(n >> 14 & 0x7f), byte(n >> 7 & 0x7f), byte(n & 0x7f)}, body...)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write MP3: %v", err)
+	}
+}
+
+func TestListImportCandidates(t *testing.T) {
+	savePath := t.TempDir()
+	writeID3File(t, filepath.Join(savePath, "Mistborn", "01.mp3"), map[string]string{
+		"TIT2": "Chapter 1",
+		"TALB": "The Final Empire",
+		"TPE2": "Brandon Sanderson",
+		"TIT1": "Mistborn, Book 1",
+	})
+
+	torrents := []qbittorrent.TorrentInfo{
+		{Hash: "tagged", Name: "Mistborn", Category: "audiobooks", SavePath: savePath, Progress: 1, AddedOn: 300},
+		{Hash: "ebook", Name: "Frank Herbert - Dune", Category: "audiobooks", SavePath: savePath, Progress: 0.5, AddedOn: 100},
+		{Hash: "unreadable", Name: "Andy Weir - The Martian", Category: "audiobooks", SavePath: savePath, AddedOn: 200},
+		{Hash: "TRACKED", Name: "Already Imported", Category: "audiobooks", SavePath: savePath},
+		{Hash: "movie", Name: "Some Movie", Category: "movies", SavePath: savePath},
+	}
+	qb, _ := newFakeQBittorrent(t, torrents, withTorrentFiles(map[string][]string{
+		"tagged":     {"Mistborn/cover.jpg", "Mistborn/01.mp3"},
+		"ebook":      {"Dune.epub", "Dune.nfo"},
+		"unreadable": {"The Martian/01.mp3"},
+	}))
+
+	repo := newMockDownloadRepo()
+	repo.created = []*models.Download{{ID: "dl-1", QBitHash: "tracked"}}
+
+	svc := &Service{
+		qbClient:      qb,
+		downloadRepo:  repo,
+		configService: config.NewService(newMockConfigService(map[string]string{})),
+	}
+
+	candidates, err := svc.ListImportCandidates(context.Background(), "audiobooks", "", true)
+	if err != nil {
+		t.Fatalf("ListImportCandidates() error = %v", err)
+	}
+
+	var hashes []string
+	for _, c := range candidates {
+		hashes = append(hashes, c.Hash)
+	}
+	if len(hashes) != 3 || hashes[0] != "ebook" || hashes[1] != "unreadable" || hashes[2] != "tagged" {
+		t.Fatalf("candidates = %v, want untracked audiobooks oldest first", hashes)
+	}
+
+	ebook := candidates[0]
+	if ebook.Guess.MediaType != models.MediaTypeEbook || ebook.Guess.Title != "Dune" || ebook.Progress != 50 {
+		t.Errorf("ebook candidate = %+v, want a half-done ebook guess of Dune", ebook)
+	}
+
+	unreadable := candidates[1]
+	if unreadable.TagError == "" {
+		t.Error("expected a tag error for a torrent whose files are missing locally")
+	}
+	if unreadable.Guess.Title != "The Martian" || unreadable.Guess.Author != "Andy Weir" || unreadable.Guess.FromTags {
+		t.Errorf("unreadable guess = %+v, want the name-based guess", unreadable.Guess)
+	}
+
+	tagged := candidates[2].Guess
+	want := BookGuess{
+		Title:        "The Final Empire",
+		Author:       "Brandon Sanderson",
+		Series:       "Mistborn",
+		SeriesNumber: "1",
+		MediaType:    models.MediaTypeAudiobook,
+		FromTags:     true,
+	}
+	if tagged != want {
+		t.Errorf("tagged guess = %+v, want %+v", tagged, want)
+	}
+
+	// Skipping tags leaves the name-based guess alone
+	candidates, err = svc.ListImportCandidates(context.Background(), "audiobooks", "", false)
+	if err != nil {
+		t.Fatalf("ListImportCandidates() error = %v", err)
+	}
+	if guess := candidates[2].Guess; guess.FromTags || guess.Title != "Mistborn" || candidates[2].TagError != "" {
+		t.Errorf("guess without tags = %+v, want the torrent name as title", guess)
+	}
+}
+
+func TestImportTorrents(t *testing.T) {
+	qb, added := newFakeQBittorrent(t, []qbittorrent.TorrentInfo{
+		{Hash: "abc", Name: "Mistborn", Category: "audiobooks"},
+		{Hash: "def", Name: "Dune", Category: "ebooks"},
+		{Hash: "tracked", Name: "Already Imported"},
+	})
+
+	repo := newMockDownloadRepo()
+	repo.created = []*models.Download{{ID: "dl-1", QBitHash: "tracked"}}
+	svc := &Service{qbClient: qb, downloadRepo: repo}
+
+	results, err := svc.ImportTorrents(context.Background(), []ImportRequest{
+		{Hash: "ABC", Title: " The Final Empire ", Author: "Brandon Sanderson", Series: "Mistborn", SeriesNumber: "1"},
+		{Hash: "def", Title: "Dune", Author: "Frank Herbert", MediaType: models.MediaTypeEbook},
+		{Hash: "abc", Title: "The Final Empire", Author: "Brandon Sanderson"},
+		{Hash: "tracked", Title: "Already Imported", Author: "Someone"},
+		{Hash: "nope", Title: "Unknown", Author: "Someone"},
+		{Hash: "def", Title: "", Author: "Frank Herbert"},
+	})
+	if err != nil {
+		t.Fatalf("ImportTorrents() error = %v", err)
+	}
+	if len(results) != 6 {
+		t.Fatalf("expected a result per request, got %d", len(results))
+	}
+
+	audiobook := results[0].Download
+	if results[0].Err != nil || audiobook == nil {
+		t.Fatalf("first import failed: %v", results[0].Err)
+	}
+	if audiobook.QBitHash != "abc" || audiobook.Title != "The Final Empire" || audiobook.Category != "audiobooks" ||
+		audiobook.Status != models.StatusQueued || audiobook.MediaType != models.MediaTypeAudiobook || audiobook.ID == "" {
+		t.Errorf("imported download = %+v, want a queued audiobook tracking torrent abc", audiobook)
+	}
+
+	if results[1].Err != nil || results[1].Download.MediaType != models.MediaTypeEbook {
+		t.Errorf("ebook import = %+v, want an ebook download", results[1])
+	}
+
+	if !errors.Is(results[2].Err, ErrAlreadyTracked) {
+		t.Errorf("duplicate in batch error = %v, want ErrAlreadyTracked", results[2].Err)
+	}
+	if !errors.Is(results[3].Err, ErrAlreadyTracked) {
+		t.Errorf("tracked torrent error = %v, want ErrAlreadyTracked", results[3].Err)
+	}
+	if results[4].Err == nil {
+		t.Error("expected an error for a torrent missing from qBittorrent")
+	}
+	if results[5].Err == nil {
+		t.Error("expected an error for a missing title")
+	}
+
+	if len(repo.created) != 3 {
+		t.Errorf("expected 2 new downloads alongside the tracked one, got %d in total", len(repo.created))
+	}
+	if len(*added) != 0 {
+		t.Errorf("qBittorrent calls = %v, want imported torrents left as they are", *added)
+	}
+}
@@ -0,0 +1,64 @@
+package downloads
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nathanael/organizr/internal/models"
+)
+
+// HasSeriesEntry reports whether an entry of a series is already downloaded or in the library:
+// a download of the same series number that hasn't failed, or a directory where the path
+// templates would organize book. book needs its series, series number and media type; its
+// title and author only place it in the library.
+func (s *Service) HasSeriesEntry(ctx context.Context, book *models.Download) (bool, error) {
+	if book.MediaType == "" {
+		book.MediaType = models.MediaTypeAudiobook
+	}
+
+	downloads, err := s.downloadRepo.List(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to list downloads: %w", err)
+	}
+	for _, d := range downloads {
+		if d.Status == models.StatusFailed || d.MediaType != book.MediaType {
+			continue
+		}
+		if strings.EqualFold(strings.TrimSpace(d.Series), strings.TrimSpace(book.Series)) && SameSeriesNumber(d.SeriesNumber, book.SeriesNumber) {
+			return true, nil
+		}
+	}
+
+	// Books organized before they were tracked, or whose downloads were deleted
+	orgService := NewOrganizationService(s.qbClient, s.configService, s.journalRepo)
+	_, path, err := orgService.destination(ctx, book)
+	if err != nil {
+		return false, err
+	}
+	info, err := os.Stat(path)
+	if err == nil {
+		return info.IsDir(), nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to check library path %s: %w", path, err)
+}
+
+// SameSeriesNumber reports whether two series numbers are the same, reading "01" and "1.0" as
+// 1. Empty numbers match nothing.
+func SameSeriesNumber(a, b string) bool {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	if a == "" || b == "" {
+		return false
+	}
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		return x == y
+	}
+	return strings.EqualFold(a, b)
+}
@@ -0,0 +1,64 @@
+package downloads
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nathanael/organizr/internal/config"
+	"github.com/nathanael/organizr/internal/models"
+)
+
+func TestService_HasSeriesEntry(t *testing.T) {
+	library := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(library, "Frank Herbert", "Dune", "Children of Dune"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := newMockDownloadRepo()
+	repo.created = []*models.Download{
+		{ID: "1", Title: "Dune", Series: "Dune", SeriesNumber: "1", MediaType: models.MediaTypeAudiobook, Status: models.StatusOrganized},
+		{ID: "2", Title: "Dune Messiah", Series: "dune", SeriesNumber: "02", MediaType: models.MediaTypeAudiobook, Status: models.StatusDownloading},
+		{ID: "3", Title: "God Emperor of Dune", Series: "Dune", SeriesNumber: "4", MediaType: models.MediaTypeAudiobook, Status: models.StatusFailed},
+		{ID: "4", Title: "Heretics of Dune", Series: "Dune", SeriesNumber: "5", MediaType: models.MediaTypeEbook, Status: models.StatusOrganized},
+	}
+	s := &Service{
+		downloadRepo: repo,
+		configService: config.NewService(newMockConfigService(map[string]string{
+			"paths.destination": library,
+			"paths.template":    "{author}/{series}/{title}",
+		})),
+	}
+
+	tests := []struct {
+		name  string
+		title string
+		entry string
+		want  bool
+	}{
+		{"Organized download", "Dune", "1", true},
+		{"Download in progress", "Dune Messiah", "2", true},
+		{"In the library", "Children of Dune", "3", true},
+		{"Failed download", "God Emperor of Dune", "4", false},
+		{"Downloaded as another media type", "Heretics of Dune", "5", false},
+		{"Not downloaded", "Chapterhouse: Dune", "6", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.HasSeriesEntry(context.Background(), &models.Download{
+				Title:        tt.title,
+				Author:       "Frank Herbert",
+				Series:       "Dune",
+				SeriesNumber: tt.entry,
+				MediaType:    models.MediaTypeAudiobook,
+			})
+			if err != nil {
+				t.Fatalf("HasSeriesEntry() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("HasSeriesEntry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package downloads
+
+import "sync"
+
+// DownloadLocks guards against organizing the same download twice at once, e.g. a manual
+// organize request racing the monitor's workers. Share one instance between Service and Monitor.
+type DownloadLocks struct {
+	mu     sync.Mutex
+	locked map[string]bool
+}
+
+func NewDownloadLocks() *DownloadLocks {
+	return &DownloadLocks{locked: make(map[string]bool)}
+}
+
+// TryLock locks the download and reports whether it was not already locked
+func (l *DownloadLocks) TryLock(downloadID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.locked[downloadID] {
+		return false
+	}
+	l.locked[downloadID] = true
+	return true
+}
+
+func (l *DownloadLocks) Unlock(downloadID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.locked, downloadID)
+}
@@ -3,51 +3,158 @@ package downloads
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/nathanael/organizr/internal/config"
 	"github.com/nathanael/organizr/internal/models"
 	"github.com/nathanael/organizr/internal/persistence"
 	"github.com/nathanael/organizr/internal/qbittorrent"
+	"github.com/nathanael/organizr/internal/quality"
+	"github.com/nathanael/organizr/internal/search"
 )
 
+// organizeTimeout bounds a single organization run
+const organizeTimeout = 5 * time.Minute
+
+// organizeCancelGrace is how long Shutdown waits for cancelled organizations to stop
+const organizeCancelGrace = 5 * time.Second
+
 type Monitor struct {
 	db            *sql.DB
 	qbClient      *qbittorrent.Client
 	downloadRepo  persistence.DownloadRepository
+	journalRepo   persistence.OrganizationJournalRepository
+	jobRepo       persistence.OrganizeJobRepository
 	orgService    *OrganizationService
 	configService *config.Service
+	locks         *DownloadLocks
 	interval      time.Duration
 	maxConcurrent int
+
+	// checkMu serializes polling passes with webhook-triggered checks
+	checkMu sync.Mutex
+
+	// Transient organization failures are retried with exponential backoff
+	retryMaxAttempts int
+	retryDelay       time.Duration
+
+	// How long a torrent may be gone from qBittorrent before its download is marked missing
+	missingGrace time.Duration
+
+	// How long a download may go without progress before it is marked stalled; 0 disables.
+	// Stalled downloads' searches are re-run with searcher to find alternative releases.
+	stalledAfter time.Duration
+	searcher     torrentSearcher
+	// Picks among alternative releases by the default quality profile, if one is set
+	profiles qualityProfiles
+
+	// Organizations outlive the monitor loop so shutdown can let them finish
+	orgCtx     context.Context
+	cancelOrgs context.CancelFunc
+	inFlight   sync.WaitGroup
+
+	// wake nudges idle workers when a job is enqueued
+	wake chan struct{}
+
+	// configChanged tells Run to re-read live settings; resetTicker restarts the poll interval
+	configChanged chan struct{}
+	resetTicker   chan struct{}
+
+	// statusMu guards the activity snapshot reported by Status
+	statusMu     sync.Mutex
+	status       MonitorStatus
+	inFlightOrgs map[int64]InFlightOrganization
 }
 
-func NewMonitor(db *sql.DB, qbClient *qbittorrent.Client, downloadRepo persistence.DownloadRepository, configService *config.Service) *Monitor {
-	return &Monitor{
+func NewMonitor(db *sql.DB, qbClient *qbittorrent.Client, downloadRepo persistence.DownloadRepository, journalRepo persistence.OrganizationJournalRepository, jobRepo persistence.OrganizeJobRepository, locks *DownloadLocks, configService *config.Service, searchService *search.Service, qualityService *quality.Service) *Monitor {
+	orgCtx, cancelOrgs := context.WithCancel(context.Background())
+	m := &Monitor{
 		db:            db,
 		qbClient:      qbClient,
 		downloadRepo:  downloadRepo,
-		orgService:    NewOrganizationService(qbClient, configService),
+		journalRepo:   journalRepo,
+		jobRepo:       jobRepo,
+		orgService:    NewOrganizationService(qbClient, configService, journalRepo),
 		configService: configService,
+		locks:         locks,
 		interval:      30 * time.Second,
 		maxConcurrent: 3,
+		orgCtx:        orgCtx,
+		cancelOrgs:    cancelOrgs,
+		wake:          make(chan struct{}, 1),
+		configChanged: make(chan struct{}, 1),
+		resetTicker:   make(chan struct{}, 1),
+
+		retryMaxAttempts: 5,
+		retryDelay:       time.Minute,
+		missingGrace:     5 * time.Minute,
+		stalledAfter:     time.Hour,
 	}
+	if searchService != nil {
+		m.searcher = searchService
+	}
+	if qualityService != nil {
+		m.profiles = qualityService
+	}
+	return m
 }
 
 func (m *Monitor) Run(ctx context.Context) error {
-	// Get interval from config
-	intervalStr, err := m.configService.Get(ctx, "monitor.interval_seconds")
-	if err == nil {
-		if seconds, err := strconv.Atoi(intervalStr); err == nil {
-			m.interval = time.Duration(seconds) * time.Second
+	m.interval = m.configuredInterval(ctx)
+
+	// Get worker pool size from config
+	if maxStr, err := m.configService.Get(ctx, "monitor.max_concurrent"); err == nil {
+		if n, err := strconv.Atoi(maxStr); err == nil && n > 0 {
+			m.maxConcurrent = n
+		}
+	}
+
+	// Get retry policy from config
+	if attemptsStr, err := m.configService.Get(ctx, "monitor.retry_max_attempts"); err == nil {
+		if n, err := strconv.Atoi(attemptsStr); err == nil && n > 0 {
+			m.retryMaxAttempts = n
 		}
 	}
+	if delayStr, err := m.configService.Get(ctx, "monitor.retry_delay_seconds"); err == nil {
+		if seconds, err := strconv.Atoi(delayStr); err == nil && seconds > 0 {
+			m.retryDelay = time.Duration(seconds) * time.Second
+		}
+	}
+
+	if graceStr, err := m.configService.Get(ctx, "monitor.missing_grace_seconds"); err == nil {
+		if seconds, err := strconv.Atoi(graceStr); err == nil && seconds >= 0 {
+			m.missingGrace = time.Duration(seconds) * time.Second
+		}
+	}
+
+	if stalledStr, err := m.configService.Get(ctx, "monitor.stalled_after_minutes"); err == nil {
+		if minutes, err := strconv.Atoi(stalledStr); err == nil && minutes >= 0 {
+			m.stalledAfter = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	m.startWorkers(ctx)
+
+	// Poll interval and qBittorrent connection changes apply without a restart
+	unsubscribe := m.configService.Subscribe(func(key, value string) {
+		if key == "monitor.interval_seconds" || strings.HasPrefix(key, "qbittorrent.") {
+			notify(m.configChanged)
+		}
+	})
+	defer unsubscribe()
 
 	ticker := time.NewTicker(m.interval)
 	defer ticker.Stop()
 
+	m.setRunning(true, m.interval)
+	defer func() { m.setRunning(false, m.interval) }()
+
 	log.Println("Download monitor started")
 
 	for {
@@ -56,6 +163,10 @@ func (m *Monitor) Run(ctx context.Context) error {
 			if err := m.checkDownloads(ctx); err != nil {
 				log.Printf("Monitor error: %v", err)
 			}
+		case <-m.configChanged:
+			m.applyConfigChanges(ctx, ticker)
+		case <-m.resetTicker:
+			ticker.Reset(m.interval)
 		case <-ctx.Done():
 			log.Println("Monitor stopped")
 			return ctx.Err()
@@ -63,127 +174,286 @@ func (m *Monitor) Run(ctx context.Context) error {
 	}
 }
 
+// configuredInterval returns the configured poll interval, or the current one if unset or invalid
+func (m *Monitor) configuredInterval(ctx context.Context) time.Duration {
+	intervalStr, err := m.configService.Get(ctx, "monitor.interval_seconds")
+	if err != nil {
+		return m.interval
+	}
+	seconds, err := strconv.Atoi(intervalStr)
+	if err != nil || seconds <= 0 {
+		log.Printf("Ignoring invalid monitor.interval_seconds %q", intervalStr)
+		return m.interval
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// applyConfigChanges re-reads the poll interval and qBittorrent connection settings,
+// restarting the ticker and reconnecting the shared qBittorrent client when they changed
+func (m *Monitor) applyConfigChanges(ctx context.Context, ticker *time.Ticker) {
+	if interval := m.configuredInterval(ctx); interval != m.interval {
+		log.Printf("Monitor interval changed: %s → %s", m.interval, interval)
+		m.interval = interval
+		ticker.Reset(interval)
+		m.setRunning(true, interval)
+	}
+
+	if err := m.reconnectQBittorrent(ctx); err != nil {
+		log.Printf("Failed to apply qBittorrent settings: %v", err)
+	}
+}
+
+// reconnectQBittorrent points the shared qBittorrent client at the configured server and
+// credentials. Services holding the client use the new settings from their next request.
+func (m *Monitor) reconnectQBittorrent(ctx context.Context) error {
+	url, err := m.configService.Get(ctx, "qbittorrent.url")
+	if err != nil || url == "" {
+		return fmt.Errorf("qbittorrent.url is not set")
+	}
+	username, _ := m.configService.Get(ctx, "qbittorrent.username")
+	password, _ := m.configService.Get(ctx, "qbittorrent.password")
+
+	changed, err := m.qbClient.Reconfigure(url, username, password)
+	if err != nil {
+		return err
+	}
+	if changed {
+		log.Printf("qBittorrent client reconfigured for %s", url)
+	}
+	return nil
+}
+
+// Refresh checks all active downloads right away instead of waiting for the next tick, and
+// restarts the poll interval from now
+func (m *Monitor) Refresh(ctx context.Context) error {
+	if err := m.checkDownloads(ctx); err != nil {
+		return err
+	}
+	notify(m.resetTicker)
+	return nil
+}
+
+// notify signals ch without blocking; a signal already pending covers this one
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
 func (m *Monitor) checkDownloads(ctx context.Context) error {
+	// Webhook-triggered checks of single torrents must not interleave with a full pass
+	m.checkMu.Lock()
+	defer m.checkMu.Unlock()
+
+	started := time.Now()
+
 	// Get active downloads
 	downloads, err := m.downloadRepo.GetActive(ctx)
 	if err != nil {
+		m.recordTick(started, 0, 0, 0, nil)
 		return fmt.Errorf("failed to get active downloads: %w", err)
 	}
 
 	// Track if all downloads failed (suggests qBittorrent is down)
 	allFailed := true
 	var lastErr error
+	changed, errored := 0, 0
 
 	for _, dl := range downloads {
-		// Check status in qBittorrent
-		status, progress, err := m.qbClient.GetTorrentStatus(ctx, dl.QBitHash)
+		dlChanged, err := m.checkDownload(ctx, dl, "monitor", models.ActorMonitor)
 		if err != nil {
 			log.Printf("Warning: Failed to get status for download %s (%s): %v", dl.ID, dl.Title, err)
 			lastErr = err
+			errored++
 			continue
 		}
 
 		// At least one download succeeded
 		allFailed = false
-
-		// Update progress
-		if err := m.downloadRepo.UpdateProgress(ctx, dl.ID, progress); err != nil {
-			log.Printf("Failed to update progress for download %s: %v", dl.ID, err)
+		if dlChanged {
+			changed++
 		}
+	}
 
-		// Map qBittorrent state to our status
-		newStatus := mapQBitStatusToModel(status)
+	m.recordTick(started, len(downloads), changed, errored, lastErr)
 
-		// Log state transitions (only when state actually changes)
-		if newStatus != dl.Status && newStatus != "" {
-			log.Printf("Download %s (%s) state changed: %s → %s", dl.ID, dl.Title, dl.Status, newStatus)
-		}
+	// If all downloads failed, qBittorrent may be unavailable
+	if len(downloads) > 0 && allFailed {
+		log.Printf("Warning: qBittorrent may be unavailable - all %d download status checks failed (last error: %v)", len(downloads), lastErr)
+		// Don't return error - continue monitoring, qBittorrent may recover
+	}
 
-		// Check if completed
-		if (status == "uploading" || status == "stalledUP" || status == "pausedUP") && dl.Status != models.StatusOrganized {
-			log.Printf("Download %s (%s) completed, marking as complete", dl.ID, dl.Title)
+	return nil
+}
 
-			// Mark completed
-			if err := m.downloadRepo.UpdateCompleted(ctx, dl.ID); err != nil {
-				log.Printf("Failed to mark download as completed: %v", err)
-				continue
-			}
+// CheckTorrent checks the download tracking hash right away instead of on the next tick,
+// so a finished torrent is queued for organization immediately. Downloads the monitor no
+// longer polls are returned unchanged. Returns the download as it is after the check.
+func (m *Monitor) CheckTorrent(ctx context.Context, hash string) (*models.Download, error) {
+	m.checkMu.Lock()
+	defer m.checkMu.Unlock()
 
-			// Check if auto-organization is enabled (default: true for backward compatibility)
-			autoOrganize := true
-			if autoOrganizeStr, err := m.configService.Get(ctx, "organization.auto_organize"); err == nil {
-				if autoOrganizeStr == "false" {
-					autoOrganize = false
-					log.Printf("Auto-organization disabled for download %s (%s), skipping organization", dl.ID, dl.Title)
+	// Read under the lock so a concurrent poll's changes are seen
+	dl, err := m.downloadRepo.GetByQBitHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	switch dl.Status {
+	case models.StatusQueued, models.StatusDownloading, models.StatusStalled, models.StatusCompleted:
+	default:
+		return dl, nil
+	}
+
+	if _, err := m.checkDownload(ctx, dl, "webhook", models.ActorAPI); err != nil {
+		return nil, fmt.Errorf("failed to get torrent status: %w", err)
+	}
+
+	return m.downloadRepo.GetByID(ctx, dl.ID)
+}
+
+// checkDownload syncs one active download with qBittorrent and queues it for organization
+// once complete, recording source as what queued the job and actor as what changed its status.
+// Reports whether the download's state changed. Returns an error only if qBittorrent couldn't be asked about the torrent;
+// a torrent qBittorrent no longer has is reconciled as missing.
+func (m *Monitor) checkDownload(ctx context.Context, dl *models.Download, source string, actor models.EventActor) (bool, error) {
+	// Check status in qBittorrent
+	status, progress, err := m.qbClient.GetTorrentStatus(ctx, dl.QBitHash)
+	if errors.Is(err, qbittorrent.ErrTorrentNotFound) {
+		// qBittorrent answered, the torrent itself is gone
+		return m.reconcileMissing(ctx, dl), nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	m.clearMissing(ctx, dl)
+
+	// Update progress
+	if err := m.downloadRepo.UpdateProgress(ctx, dl.ID, progress); err != nil {
+		log.Printf("Failed to update progress for download %s: %v", dl.ID, err)
+	}
+
+	// Map qBittorrent state to our status; no progress for too long overrides it
+	newStatus := mapQBitStatusToModel(status)
+	if m.isStalled(dl, status, progress) {
+		newStatus = models.StatusStalled
+	}
+
+	// Record state transitions (only when state actually changes)
+	changed := false
+	if newStatus != dl.Status && newStatus != "" {
+		log.Printf("Download %s (%s) state changed: %s → %s", dl.ID, dl.Title, dl.Status, newStatus)
+		wasStalled := dl.Status == models.StatusStalled
+		if err := transition(ctx, m.downloadRepo, dl, newStatus, actor, "qBittorrent reports "+status); err != nil {
+			log.Printf("Failed to update status for download %s: %v", dl.ID, err)
+		} else {
+			changed = true
+			switch {
+			case newStatus == models.StatusStalled:
+				m.handleStalled(ctx, dl)
+			case wasStalled:
+				if err := m.downloadRepo.UpdateError(ctx, dl.ID, ""); err != nil {
+					log.Printf("Failed to clear download error for %s: %v", dl.ID, err)
 				}
 			}
+		}
+	}
 
-			// Only auto-organize if enabled
-			if autoOrganize {
-				log.Printf("Auto-organizing download %s (%s)", dl.ID, dl.Title)
-
-				// Create context with timeout for organization (respects parent cancellation but prevents indefinite hanging)
-				orgCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
-
-				// Trigger organization in goroutine
-				// IMPORTANT: Pass parent-derived context (orgCtx) to ensure organization goroutines
-				// are cancelled when monitor stops. This prevents delayed shutdowns and goroutine leaks.
-				// The 5-minute timeout is applied on top of parent cancellation.
-				go func(ctx context.Context, download *models.Download) {
-					defer cancel()
-					m.organizeDownload(ctx, download)
-				}(orgCtx, dl)
+	// Check if completed
+	if (status == "uploading" || status == "stalledUP" || status == "pausedUP" || status == "queuedUP") && dl.Status == models.StatusCompleted {
+		// Check if auto-organization is enabled (default: true for backward compatibility)
+		autoOrganize := true
+		if autoOrganizeStr, err := m.configService.Get(ctx, "organization.auto_organize"); err == nil {
+			if autoOrganizeStr == "false" {
+				autoOrganize = false
+				log.Printf("Auto-organization disabled for download %s (%s), skipping organization", dl.ID, dl.Title)
 			}
 		}
-	}
 
-	// If all downloads failed, qBittorrent may be unavailable
-	if len(downloads) > 0 && allFailed {
-		log.Printf("Warning: qBittorrent may be unavailable - all %d download status checks failed (last error: %v)", len(downloads), lastErr)
-		// Don't return error - continue monitoring, qBittorrent may recover
+		// Only auto-organize if enabled
+		if autoOrganize {
+			log.Printf("Auto-organizing download %s (%s)", dl.ID, dl.Title)
+
+			m.enqueueOrganize(ctx, dl, source)
+		}
 	}
 
-	return nil
+	return changed, nil
 }
 
 // mapQBitStatusToModel maps qBittorrent state to our download status
 func mapQBitStatusToModel(qbitStatus string) models.DownloadStatus {
 	switch qbitStatus {
-	case "queuedDL", "queuedUP":
+	case "queuedDL":
 		return models.StatusQueued
 	case "downloading", "metaDL", "allocating", "checkingDL", "forcedDL":
 		return models.StatusDownloading
-	case "uploading", "stalledUP", "pausedUP", "forcedUP", "checkingUP":
+	// queuedUP torrents are finished, waiting for an upload slot to seed
+	case "uploading", "stalledUP", "pausedUP", "forcedUP", "checkingUP", "queuedUP":
 		return models.StatusCompleted
 	default:
 		return "" // Unknown state, don't update
 	}
 }
 
-func (m *Monitor) organizeDownload(ctx context.Context, dl *models.Download) {
+// Shutdown waits for in-flight organizations to finish; workers stop taking new jobs once
+// Run's context is cancelled. If ctx expires first they are cancelled; interrupted downloads
+// stay organizing with their journal and are resumed by RecoverInterrupted on the next start.
+// Call after Run has returned.
+func (m *Monitor) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		m.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		log.Println("Timed out waiting for organizations, cancelling them")
+		m.cancelOrgs()
+		select {
+		case <-done:
+		case <-time.After(organizeCancelGrace):
+		}
+		return ctx.Err()
+	}
+}
+
+// organizeDownload organizes a download and records the outcome on it. The caller must hold
+// the download's lock. Returns the organization error, if any.
+func (m *Monitor) organizeDownload(ctx context.Context, dl *models.Download) error {
 	// Mark as organizing
-	if err := m.downloadRepo.UpdateStatus(ctx, dl.ID, models.StatusOrganizing); err != nil {
+	if err := transition(ctx, m.downloadRepo, dl, models.StatusOrganizing, models.ActorMonitor, "organization started"); err != nil {
 		log.Printf("Failed to update status for download %s: %v", dl.ID, err)
-		return
+		return fmt.Errorf("failed to mark download organizing: %w", err)
 	}
 
 	// Perform organization
 	if err := m.orgService.Organize(ctx, dl); err != nil {
+		if errors.Is(err, context.Canceled) {
+			// Shutting down: leave the download organizing so recovery resumes it
+			log.Printf("Organization of download %s interrupted, will resume on next start: %v", dl.ID, err)
+			return err
+		}
 		log.Printf("Failed to organize download %s: %v", dl.ID, err)
 		// Update error status in database - log if this also fails
 		if updateErr := m.downloadRepo.UpdateError(ctx, dl.ID, err.Error()); updateErr != nil {
 			log.Printf("Failed to update download error for %s: %v", dl.ID, updateErr)
 		}
-		if updateErr := m.downloadRepo.UpdateStatus(ctx, dl.ID, models.StatusFailed); updateErr != nil {
+		if updateErr := transition(ctx, m.downloadRepo, dl, models.StatusFailed, models.ActorMonitor, err.Error()); updateErr != nil {
 			log.Printf("Failed to update download status for %s: %v", dl.ID, updateErr)
 		}
-		return
+		return err
 	}
 
 	// Mark as organized
-	if err := m.downloadRepo.UpdateStatus(ctx, dl.ID, models.StatusOrganized); err != nil {
+	if err := transition(ctx, m.downloadRepo, dl, models.StatusOrganized, models.ActorMonitor, "organized to "+dl.OrganizedPath); err != nil {
 		log.Printf("Failed to update status for download %s: %v", dl.ID, err)
-		return
+		return fmt.Errorf("failed to mark download organized: %w", err)
 	}
 
 	if err := m.downloadRepo.UpdateOrganizedPath(ctx, dl.ID, dl.OrganizedPath); err != nil {
@@ -191,4 +461,5 @@ func (m *Monitor) organizeDownload(ctx context.Context, dl *models.Download) {
 	}
 
 	log.Printf("Download %s organized successfully to %s", dl.ID, dl.OrganizedPath)
+	return nil
 }
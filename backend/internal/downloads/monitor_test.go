@@ -2,12 +2,16 @@ package downloads
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/nathanael/organizr/internal/config"
 	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/persistence"
 	"github.com/nathanael/organizr/internal/qbittorrent"
 )
 
@@ -15,6 +19,8 @@ import (
 type mockDownloadRepo struct {
 	mu                  sync.Mutex
 	getActiveFunc       func(ctx context.Context) ([]*models.Download, error)
+	getByStatusFunc     func(ctx context.Context, status models.DownloadStatus) ([]*models.Download, error)
+	getByIDFunc         func(ctx context.Context, id string) (*models.Download, error)
 	updateProgressFunc  func(ctx context.Context, id string, progress float64) error
 	updateCompletedFunc func(ctx context.Context, id string) error
 	updateStatusFunc    func(ctx context.Context, id string, status models.DownloadStatus) error
@@ -26,6 +32,13 @@ type mockDownloadRepo struct {
 	completedCalls  []string
 	errorUpdates    map[string]string
 	pathUpdates     map[string]string
+	hashUpdates     map[string]string
+	missingUpdates  map[string]*time.Time
+	torrentData     map[string][]byte
+	deleted         []string
+	created         []*models.Download
+	events          []*models.DownloadEvent
+	replacements    []*models.TorrentReplacement
 }
 
 func newMockDownloadRepo() *mockDownloadRepo {
@@ -35,6 +48,9 @@ func newMockDownloadRepo() *mockDownloadRepo {
 		completedCalls:  []string{},
 		errorUpdates:    make(map[string]string),
 		pathUpdates:     make(map[string]string),
+		hashUpdates:     make(map[string]string),
+		missingUpdates:  make(map[string]*time.Time),
+		torrentData:     make(map[string][]byte),
 	}
 }
 
@@ -45,6 +61,13 @@ func (m *mockDownloadRepo) GetActive(ctx context.Context) ([]*models.Download, e
 	return []*models.Download{}, nil
 }
 
+func (m *mockDownloadRepo) GetByStatus(ctx context.Context, status models.DownloadStatus) ([]*models.Download, error) {
+	if m.getByStatusFunc != nil {
+		return m.getByStatusFunc(ctx, status)
+	}
+	return []*models.Download{}, nil
+}
+
 func (m *mockDownloadRepo) UpdateProgress(ctx context.Context, id string, progress float64) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -75,6 +98,72 @@ func (m *mockDownloadRepo) UpdateStatus(ctx context.Context, id string, status m
 	return nil
 }
 
+func (m *mockDownloadRepo) TransitionStatus(ctx context.Context, event *models.DownloadEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.updateStatusFunc != nil {
+		if err := m.updateStatusFunc(ctx, event.DownloadID, event.ToStatus); err != nil {
+			return err
+		}
+	}
+	if event.ToStatus == models.StatusCompleted {
+		m.completedCalls = append(m.completedCalls, event.DownloadID)
+	}
+	m.statusUpdates[event.DownloadID] = event.ToStatus
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *mockDownloadRepo) AddEvent(ctx context.Context, event *models.DownloadEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *mockDownloadRepo) ListEvents(ctx context.Context, downloadID string) ([]*models.DownloadEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var events []*models.DownloadEvent
+	for _, e := range m.events {
+		if e.DownloadID == downloadID {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (m *mockDownloadRepo) ReplaceTorrent(ctx context.Context, replacement *models.TorrentReplacement, torrentData []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.replacements = append(m.replacements, replacement)
+	m.hashUpdates[replacement.DownloadID] = replacement.NewHash
+	m.torrentData[replacement.DownloadID] = torrentData
+	if replacement.CreatedAt.IsZero() {
+		replacement.CreatedAt = time.Now()
+	}
+	for _, d := range m.created {
+		if d.ID == replacement.DownloadID {
+			replacedAt := replacement.CreatedAt
+			d.QBitHash, d.TorrentID, d.Provider = replacement.NewHash, replacement.NewTorrentID, replacement.NewProvider
+			d.Progress, d.ProgressAt = 0, &replacedAt
+		}
+	}
+	return nil
+}
+
+func (m *mockDownloadRepo) ListReplacements(ctx context.Context, downloadID string) ([]*models.TorrentReplacement, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var replacements []*models.TorrentReplacement
+	for _, r := range m.replacements {
+		if r.DownloadID == downloadID {
+			replacements = append(replacements, r)
+		}
+	}
+	return replacements, nil
+}
+
 func (m *mockDownloadRepo) UpdateError(ctx context.Context, id string, errorMsg string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -95,23 +184,66 @@ func (m *mockDownloadRepo) UpdateOrganizedPath(ctx context.Context, id string, p
 	return nil
 }
 
-// Unused methods required by interface
-func (m *mockDownloadRepo) Create(ctx context.Context, d *models.Download) error {
+func (m *mockDownloadRepo) UpdateQBitHash(ctx context.Context, id string, hash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hashUpdates[id] = hash
 	return nil
 }
 
+func (m *mockDownloadRepo) UpdateMissingSince(ctx context.Context, id string, since *time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.missingUpdates[id] = since
+	return nil
+}
+
+func (m *mockDownloadRepo) GetTorrentData(ctx context.Context, id string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.torrentData[id], nil
+}
+
 func (m *mockDownloadRepo) GetByID(ctx context.Context, id string) (*models.Download, error) {
+	if m.getByIDFunc != nil {
+		return m.getByIDFunc(ctx, id)
+	}
 	return nil, fmt.Errorf("not implemented")
 }
 
-func (m *mockDownloadRepo) List(ctx context.Context) ([]*models.Download, error) {
-	return nil, nil
+func (m *mockDownloadRepo) GetByQBitHash(ctx context.Context, hash string) (*models.Download, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.created {
+		if strings.EqualFold(d.QBitHash, hash) {
+			copied := *d
+			return &copied, nil
+		}
+	}
+	return nil, persistence.ErrDownloadNotFound
 }
 
 func (m *mockDownloadRepo) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleted = append(m.deleted, id)
 	return nil
 }
 
+// Unused methods required by interface
+func (m *mockDownloadRepo) Create(ctx context.Context, d *models.Download) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.created = append(m.created, d)
+	return nil
+}
+
+func (m *mockDownloadRepo) List(ctx context.Context) ([]*models.Download, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*models.Download(nil), m.created...), nil
+}
+
 // mockQBClientForMonitor for testing
 type mockQBClientForMonitor struct {
 	mu                   sync.Mutex
@@ -773,7 +905,7 @@ func TestMapQBitStatusToModel(t *testing.T) {
 		wantStatus models.DownloadStatus
 	}{
 		{"queuedDL", models.StatusQueued},
-		{"queuedUP", models.StatusQueued},
+		{"queuedUP", models.StatusCompleted},
 		{"downloading", models.StatusDownloading},
 		{"metaDL", models.StatusDownloading},
 		{"allocating", models.StatusDownloading},
@@ -796,3 +928,176 @@ func TestMapQBitStatusToModel(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckTorrent(t *testing.T) {
+	qb, _ := newFakeQBittorrent(t, []qbittorrent.TorrentInfo{
+		{Hash: "finished", State: "stalledUP", Progress: 1},
+		{Hash: "running", State: "downloading", Progress: 0.4},
+	})
+
+	repo := newMockDownloadRepo()
+	repo.created = []*models.Download{
+		{ID: "dl-1", Title: "Finished", QBitHash: "finished", Status: models.StatusDownloading},
+		{ID: "dl-2", Title: "Running", QBitHash: "running", Status: models.StatusDownloading},
+		{ID: "dl-3", Title: "Done", QBitHash: "organized", Status: models.StatusOrganized},
+	}
+	repo.getByIDFunc = func(ctx context.Context, id string) (*models.Download, error) {
+		for _, d := range repo.created {
+			if d.ID == id {
+				return d, nil
+			}
+		}
+		return nil, persistence.ErrDownloadNotFound
+	}
+	jobs := &mockJobRepo{}
+
+	m := &Monitor{
+		qbClient:      qb,
+		downloadRepo:  repo,
+		jobRepo:       jobs,
+		configService: config.NewService(newMockConfigService(map[string]string{})),
+		wake:          make(chan struct{}, 1),
+	}
+
+	// Hashes match case-insensitively
+	if _, err := m.CheckTorrent(context.Background(), "FINISHED"); err != nil {
+		t.Fatalf("CheckTorrent() error = %v", err)
+	}
+	if len(repo.completedCalls) != 1 || repo.completedCalls[0] != "dl-1" {
+		t.Errorf("completed = %v, want dl-1 marked complete", repo.completedCalls)
+	}
+	if len(jobs.jobs) != 1 || jobs.jobs[0].DownloadID != "dl-1" || jobs.jobs[0].Source != "webhook" {
+		t.Fatalf("jobs = %+v, want one webhook job for dl-1", jobs.jobs)
+	}
+
+	// An unfinished torrent only has its progress updated
+	if _, err := m.CheckTorrent(context.Background(), "running"); err != nil {
+		t.Fatalf("CheckTorrent() error = %v", err)
+	}
+	if repo.progressUpdates["dl-2"] != 40 || len(jobs.jobs) != 1 {
+		t.Errorf("progress = %v, jobs = %d, want progress updated and nothing queued", repo.progressUpdates["dl-2"], len(jobs.jobs))
+	}
+
+	// Downloads the monitor no longer polls are left alone
+	dl, err := m.CheckTorrent(context.Background(), "organized")
+	if err != nil {
+		t.Fatalf("CheckTorrent() error = %v", err)
+	}
+	if dl.ID != "dl-3" || len(jobs.jobs) != 1 {
+		t.Errorf("organized download must not be checked again, got %+v", dl)
+	}
+
+	if _, err := m.CheckTorrent(context.Background(), "unknown"); !errors.Is(err, persistence.ErrDownloadNotFound) {
+		t.Errorf("error = %v, want download not found", err)
+	}
+}
+
+func TestCheckTorrent_OrganizesQueuedSeeder(t *testing.T) {
+	qb, _ := newFakeQBittorrent(t, []qbittorrent.TorrentInfo{
+		{Hash: "seeding", State: "queuedUP", Progress: 1},
+	})
+
+	dl := &models.Download{ID: "dl-1", Title: "Seeding", QBitHash: "seeding", Status: models.StatusCompleted}
+	repo := newMockDownloadRepo()
+	repo.created = []*models.Download{dl}
+	repo.getByIDFunc = func(ctx context.Context, id string) (*models.Download, error) {
+		return dl, nil
+	}
+	jobs := &mockJobRepo{}
+
+	m := &Monitor{
+		qbClient:      qb,
+		downloadRepo:  repo,
+		jobRepo:       jobs,
+		configService: config.NewService(newMockConfigService(map[string]string{})),
+		wake:          make(chan struct{}, 1),
+	}
+
+	// Waiting in qBittorrent's upload queue, the torrent is still finished
+	if _, err := m.CheckTorrent(context.Background(), "seeding"); err != nil {
+		t.Fatalf("CheckTorrent() error = %v", err)
+	}
+	if status, ok := repo.statusUpdates["dl-1"]; ok {
+		t.Errorf("status moved to %s, want it left completed", status)
+	}
+	if len(jobs.jobs) != 1 || jobs.jobs[0].DownloadID != "dl-1" {
+		t.Fatalf("jobs = %+v, want dl-1 queued for organization", jobs.jobs)
+	}
+}
+
+func TestMonitorRun_AppliesConfigChanges(t *testing.T) {
+	oldQB, _ := newFakeQBittorrent(t, nil)
+	newQB, _ := newFakeQBittorrent(t, nil)
+	newURL := newQB.BaseURL()
+
+	checked := make(chan struct{}, 10)
+	repo := newMockDownloadRepo()
+	repo.getActiveFunc = func(ctx context.Context) ([]*models.Download, error) {
+		checked <- struct{}{}
+		return nil, nil
+	}
+
+	configs := newMockConfigService(map[string]string{
+		"qbittorrent.url":          "http://old-qbit:8080",
+		"qbittorrent.username":     "admin",
+		"qbittorrent.password":     "adminpass",
+		"monitor.interval_seconds": "3600",
+	})
+	configSvc := config.NewService(configs)
+
+	m := NewMonitor(nil, oldQB, repo, nil, &mockJobRepo{}, NewDownloadLocks(), configSvc, nil, nil)
+	m.maxConcurrent = 1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- m.Run(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Changes made before Run subscribes are missed, so keep setting until one lands
+	deadline := time.Now().Add(2 * time.Second)
+	for oldQB.BaseURL() != newURL {
+		if time.Now().After(deadline) {
+			t.Fatalf("qBittorrent client still points at %s, want %s", oldQB.BaseURL(), newURL)
+		}
+		if err := configSvc.Set(ctx, "qbittorrent.url", newURL); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A shorter interval takes effect without waiting out the old one
+	if err := configSvc.Set(ctx, "monitor.interval_seconds", "1"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	select {
+	case <-checked:
+	case <-time.After(3 * time.Second):
+		t.Fatal("expected a poll within the new interval")
+	}
+}
+
+func TestMonitorRefresh(t *testing.T) {
+	qb, _ := newFakeQBittorrent(t, []qbittorrent.TorrentInfo{{Hash: "abc", State: "downloading", Progress: 0.25}})
+
+	repo := newMockDownloadRepo()
+	repo.getActiveFunc = func(ctx context.Context) ([]*models.Download, error) {
+		return []*models.Download{{ID: "dl-1", QBitHash: "abc", Status: models.StatusQueued}}, nil
+	}
+
+	m := &Monitor{qbClient: qb, downloadRepo: repo, resetTicker: make(chan struct{}, 1)}
+	if err := m.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	if repo.progressUpdates["dl-1"] != 25 {
+		t.Errorf("progress = %v, want 25", repo.progressUpdates["dl-1"])
+	}
+	select {
+	case <-m.resetTicker:
+	default:
+		t.Error("expected the poll interval to restart after a refresh")
+	}
+}
@@ -7,11 +7,14 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 
 	"github.com/nathanael/organizr/internal/config"
 	"github.com/nathanael/organizr/internal/fileutil"
 	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/persistence"
 	"github.com/nathanael/organizr/internal/qbittorrent"
 )
 
@@ -25,38 +28,45 @@ type configService interface {
 	Get(ctx context.Context, key string) (string, error)
 }
 
+// organizationJournal interface defines the methods we need from the journal repository
+type organizationJournal interface {
+	Replace(ctx context.Context, downloadID string, entries []*models.JournalEntry) error
+	MarkDone(ctx context.Context, id int64) error
+	ListByDownload(ctx context.Context, downloadID string) ([]*models.JournalEntry, error)
+	DeleteByDownload(ctx context.Context, downloadID string) error
+}
+
+// defaultEbookFileTypes is used when ebooks.file_types is not configured
+const defaultEbookFileTypes = "epub,mobi,azw3,pdf"
+
 type OrganizationService struct {
 	qbClient      qbittorrentClient
 	configService configService
+	journal       organizationJournal
 }
 
-func NewOrganizationService(qbClient *qbittorrent.Client, configService *config.Service) *OrganizationService {
+func NewOrganizationService(qbClient *qbittorrent.Client, configService *config.Service, journalRepo persistence.OrganizationJournalRepository) *OrganizationService {
 	return &OrganizationService{
 		qbClient:      qbClient,
 		configService: configService,
+		journal:       journalRepo,
 	}
 }
 
 func (o *OrganizationService) Organize(ctx context.Context, dl *models.Download) error {
-	// Get config
-	destBase, err := o.configService.Get(ctx, "paths.destination")
+	destBase, fullPath, err := o.destination(ctx, dl)
 	if err != nil {
-		return fmt.Errorf("failed to get destination path: %w", err)
+		return permanent(err)
 	}
 
-	// Ensure base destination directory exists
-	if err := os.MkdirAll(destBase, 0755); err != nil {
-		return fmt.Errorf("failed to create base destination directory %s: %w", destBase, err)
-	}
-
-	template, err := o.configService.Get(ctx, "paths.template")
+	perms, err := o.loadPermissions(ctx)
 	if err != nil {
-		template = "{author}/{series}/{title}"
+		return permanent(fmt.Errorf("invalid permission settings: %w", err))
 	}
 
-	noSeriesTemplate, err := o.configService.Get(ctx, "paths.no_series_template")
-	if err != nil {
-		noSeriesTemplate = "{author}/{title}"
+	// Ensure base destination directory exists
+	if err := perms.MkdirAll(destBase); err != nil {
+		return fmt.Errorf("failed to create base destination directory %s: %w", destBase, err)
 	}
 
 	operation, err := o.configService.Get(ctx, "paths.operation")
@@ -64,53 +74,59 @@ func (o *OrganizationService) Organize(ctx context.Context, dl *models.Download)
 		operation = "copy"
 	}
 
-	// Choose template based on whether series exists
-	pathTemplate := template
-	if dl.Series == "" {
-		pathTemplate = noSeriesTemplate
-	}
-
-	// Sanitize variables BEFORE template parsing (preserves directory structure)
-	sanitizedVars := map[string]string{
-		"author":        fileutil.SanitizePath(dl.Author),
-		"series":        fileutil.SanitizePath(dl.Series),
-		"series_number": fileutil.SanitizePath(dl.SeriesNumber),
-		"title":         fileutil.SanitizePath(dl.Title),
-	}
-
-	// Parse template with sanitized variables
-	path := fileutil.ParseTemplate(pathTemplate, sanitizedVars)
-
-	// Build full destination
-	fullPath := filepath.Join(destBase, path)
-
 	// Get torrent files from qBittorrent
 	files, err := o.qbClient.GetTorrentFiles(ctx, dl.QBitHash)
 	if err != nil {
 		return fmt.Errorf("failed to get torrent files: %w", err)
 	}
 
-	// Get mount point configuration for remote qBittorrent setups
+	// Ebook torrents often bundle covers, NFOs and duplicate formats; keep only configured ebook files
+	if dl.MediaType == models.MediaTypeEbook {
+		extensions := ebookExtensions(ctx, o.configService)
+		files = filterFilesByExtension(files, extensions)
+		if len(files) == 0 {
+			return permanent(fmt.Errorf("no ebook files found in torrent (allowed types: %s)", strings.Join(extensions, ", ")))
+		}
+	}
+
+	// Translate qBittorrent's paths to local ones for remote setups (network shares, Docker volumes)
+	mappingsValue, _ := o.configService.Get(ctx, "paths.remote_mappings")
+	mappings, err := fileutil.ParsePathMappings(mappingsValue)
+	if err != nil {
+		return permanent(fmt.Errorf("invalid paths.remote_mappings: %w", err))
+	}
 	mountPoint, _ := o.configService.Get(ctx, "paths.local_mount")
 
-	// Prepend mount point if configured (for network shares or Docker volumes)
-	if mountPoint != "" {
-		for _, file := range files {
-			// qBittorrent reports paths relative to its filesystem
-			// Prepend the local mount point to access them
-			file.Path = filepath.Join(mountPoint, file.Path)
+	for _, file := range files {
+		file.Path = resolveLocalPath(file, mappings, mountPoint)
+	}
+
+	// Plan every file operation up front so an interrupted run can be resumed or rolled back
+	plan := make([]*models.JournalEntry, len(files))
+	for i, file := range files {
+		plan[i] = &models.JournalEntry{
+			SourcePath: file.Path,
+			DestPath:   filepath.Join(fullPath, filepath.Base(file.Name)),
+			Operation:  operation,
 		}
 	}
+	resumed := o.resumeFromJournal(ctx, dl.ID, plan)
+	if resumed > 0 {
+		log.Printf("Resuming organization of download %s: %d/%d files already in place", dl.ID, resumed, len(plan))
+	}
 
 	// Pre-organization validation: check source files exist and are readable
 	var totalSize int64
-	for _, file := range files {
-		info, err := os.Stat(file.Path)
+	for _, entry := range plan {
+		if entry.Done {
+			continue // Already at the destination (a moved file's source is gone)
+		}
+		info, err := os.Stat(entry.SourcePath)
 		if err != nil {
 			if os.IsNotExist(err) {
-				return fmt.Errorf("source file does not exist: %s", file.Path)
+				return fmt.Errorf("source file does not exist: %s", entry.SourcePath)
 			}
-			return fmt.Errorf("source file is not accessible: %s: %w", file.Path, err)
+			return fmt.Errorf("source file is not accessible: %s: %w", entry.SourcePath, err)
 		}
 		totalSize += info.Size()
 	}
@@ -136,12 +152,35 @@ func (o *OrganizationService) Organize(ctx context.Context, dl *models.Download)
 		len(files), float64(totalSize)/(1024*1024), dl.QBitHash, fullPath)
 
 	// Create destination directory
-	if err := os.MkdirAll(fullPath, 0755); err != nil {
+	if err := perms.MkdirAll(fullPath); err != nil {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	// Track successfully copied files for cleanup on partial failure
+	if o.journal != nil {
+		if err := o.journal.Replace(ctx, dl.ID, plan); err != nil {
+			return fmt.Errorf("failed to record organization journal: %w", err)
+		}
+	}
+
+	// Track successfully copied files for cleanup on partial failure, including ones
+	// copied by an earlier interrupted run, so a failed copy stays all-or-nothing
 	var copiedFiles []string
+	if operation != "move" {
+		for _, entry := range plan {
+			if entry.Done {
+				copiedFiles = append(copiedFiles, entry.DestPath)
+			}
+		}
+	}
+
+	cleanupCopiedFiles := func() {
+		for _, path := range copiedFiles {
+			if removeErr := os.Remove(path); removeErr != nil {
+				log.Printf("Failed to clean up file %s: %v", path, removeErr)
+			}
+		}
+		o.clearJournal(ctx, dl.ID)
+	}
 
 	// Defer cleanup function to handle panic during copy operations
 	defer func() {
@@ -158,8 +197,18 @@ func (o *OrganizationService) Organize(ctx context.Context, dl *models.Download)
 
 	// Copy or move files
 	for i, file := range files {
-		srcPath := file.Path
-		destPath := filepath.Join(fullPath, filepath.Base(file.Name))
+		entry := plan[i]
+		if entry.Done {
+			continue
+		}
+
+		// Stop between files on shutdown; the journal lets the next start pick up from here
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("organization interrupted after %d/%d files: %w", i, len(files), err)
+		}
+
+		srcPath := entry.SourcePath
+		destPath := entry.DestPath
 
 		if operation == "move" {
 			// Move operation: atomic per file, partial success is acceptable
@@ -167,29 +216,276 @@ func (o *OrganizationService) Organize(ctx context.Context, dl *models.Download)
 				log.Printf("Failed to move file %s (%s -> %s): %v", file.Name, srcPath, destPath, err)
 				return fmt.Errorf("failed to move file %s (%s -> %s): %w", file.Name, srcPath, destPath, err)
 			}
+			o.markJournalDone(ctx, entry)
+			if err := perms.ApplyFile(destPath); err != nil {
+				return fmt.Errorf("failed to set permissions on %s: %w", file.Name, err)
+			}
 		} else {
 			// Copy operation: all-or-nothing, clean up on failure
 			if err := copyFile(srcPath, destPath); err != nil {
 				// Cleanup: delete all previously copied files
 				log.Printf("Failed to copy file %s (%s -> %s): %v, cleaning up %d previously copied files",
 					file.Name, srcPath, destPath, err, len(copiedFiles))
-				for _, path := range copiedFiles {
-					if removeErr := os.Remove(path); removeErr != nil {
-						log.Printf("Failed to clean up file %s: %v", path, removeErr)
-					}
-				}
+				cleanupCopiedFiles()
 				return fmt.Errorf("failed to copy file %s (%s -> %s): %w", file.Name, srcPath, destPath, err)
 			}
 			// Track successfully copied file
 			copiedFiles = append(copiedFiles, destPath)
+
+			// A copy the library can't read is as bad as no copy, so roll back on permission failures too
+			if err := perms.ApplyFile(destPath); err != nil {
+				log.Printf("Failed to set permissions on %s: %v, cleaning up %d copied files", destPath, err, len(copiedFiles))
+				cleanupCopiedFiles()
+				return fmt.Errorf("failed to set permissions on %s: %w", file.Name, err)
+			}
+			o.markJournalDone(ctx, entry)
 			log.Printf("Successfully copied file %d/%d: %s", i+1, len(files), file.Name)
 		}
 	}
 
+	// Write a Calibre-style metadata sidecar for ebooks
+	if dl.MediaType == models.MediaTypeEbook {
+		if writeOPF, err := o.configService.Get(ctx, "ebooks.write_opf"); err != nil || writeOPF != "false" {
+			opfPath, err := fileutil.WriteOPF(fullPath, fileutil.OPFMetadata{
+				Identifier:   dl.ID,
+				Title:        dl.Title,
+				Author:       dl.Author,
+				Series:       dl.Series,
+				SeriesNumber: dl.SeriesNumber,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to write metadata sidecar: %w", err)
+			}
+			if err := perms.ApplyFile(opfPath); err != nil {
+				return fmt.Errorf("failed to set permissions on metadata sidecar: %w", err)
+			}
+			log.Printf("Wrote metadata sidecar %s", opfPath)
+		}
+	}
+
+	o.clearJournal(ctx, dl.ID)
+
 	dl.OrganizedPath = fullPath
 	return nil
 }
 
+// resumeFromJournal marks planned entries as done when a previous run of the same download
+// already completed them and the destination file is still present. Returns the number resumed.
+func (o *OrganizationService) resumeFromJournal(ctx context.Context, downloadID string, plan []*models.JournalEntry) int {
+	if o.journal == nil {
+		return 0
+	}
+
+	previous, err := o.journal.ListByDownload(ctx, downloadID)
+	if err != nil {
+		log.Printf("Failed to read organization journal for %s, starting over: %v", downloadID, err)
+		return 0
+	}
+
+	done := make(map[string]bool)
+	for _, entry := range previous {
+		if entry.Done {
+			done[entry.DestPath] = true
+		}
+	}
+
+	resumed := 0
+	for _, entry := range plan {
+		if !done[entry.DestPath] {
+			continue
+		}
+		if _, err := os.Stat(entry.DestPath); err == nil {
+			entry.Done = true
+			resumed++
+		}
+	}
+	return resumed
+}
+
+// markJournalDone records a completed file operation. Failures are only logged: recovery
+// re-copies files whose entry is not done and detects moves from the source being gone.
+func (o *OrganizationService) markJournalDone(ctx context.Context, entry *models.JournalEntry) {
+	entry.Done = true
+	if o.journal == nil || entry.ID == 0 {
+		return
+	}
+	if err := o.journal.MarkDone(ctx, entry.ID); err != nil {
+		log.Printf("Failed to update organization journal for %s: %v", entry.DestPath, err)
+	}
+}
+
+// clearJournal removes the download's journal once its files are fully organized or rolled back
+func (o *OrganizationService) clearJournal(ctx context.Context, downloadID string) {
+	if o.journal == nil {
+		return
+	}
+	if err := o.journal.DeleteByDownload(ctx, downloadID); err != nil {
+		log.Printf("Failed to clear organization journal for %s: %v", downloadID, err)
+	}
+}
+
+// loadPermissions builds the ownership and mode settings for created files from config.
+// Unset keys keep the defaults (process ownership, 0755 directories, 0644 files).
+func (o *OrganizationService) loadPermissions(ctx context.Context) (fileutil.Permissions, error) {
+	perms := fileutil.DefaultPermissions()
+
+	if value, err := o.configService.Get(ctx, "permissions.puid"); err == nil && strings.TrimSpace(value) != "" {
+		uid, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || uid < 0 {
+			return perms, fmt.Errorf("permissions.puid must be a non-negative integer, got %q", value)
+		}
+		perms.UID = uid
+	}
+
+	if value, err := o.configService.Get(ctx, "permissions.pgid"); err == nil && strings.TrimSpace(value) != "" {
+		gid, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || gid < 0 {
+			return perms, fmt.Errorf("permissions.pgid must be a non-negative integer, got %q", value)
+		}
+		perms.GID = gid
+	}
+
+	if value, err := o.configService.Get(ctx, "permissions.dir_mode"); err == nil && strings.TrimSpace(value) != "" {
+		mode, err := parseFileMode(value)
+		if err != nil {
+			return perms, fmt.Errorf("permissions.dir_mode: %w", err)
+		}
+		perms.DirMode = mode
+	}
+
+	if value, err := o.configService.Get(ctx, "permissions.file_mode"); err == nil && strings.TrimSpace(value) != "" {
+		mode, err := parseFileMode(value)
+		if err != nil {
+			return perms, fmt.Errorf("permissions.file_mode: %w", err)
+		}
+		perms.FileMode = mode
+	}
+
+	if value, err := o.configService.Get(ctx, "permissions.inherit_group"); err == nil {
+		perms.InheritGroup = value == "true"
+	}
+
+	return perms, nil
+}
+
+// parseFileMode parses an octal permission string such as "0755" or "644"
+func parseFileMode(value string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(strings.TrimSpace(value), 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("must be an octal mode between 000 and 777, got %q", value)
+	}
+	return os.FileMode(mode), nil
+}
+
+// resolveLocalPath maps a file path reported by qBittorrent to the local filesystem.
+// The longest matching remote mapping for the torrent's save path wins; if none matches,
+// the legacy paths.local_mount prefix is prepended.
+func resolveLocalPath(file *qbittorrent.TorrentFile, mappings []fileutil.PathMapping, mountPoint string) string {
+	if len(mappings) > 0 {
+		if file.SavePath != "" {
+			if localSavePath, ok := fileutil.MapRemotePath(mappings, file.SavePath); ok {
+				return filepath.Join(localSavePath, filepath.FromSlash(file.Name))
+			}
+		} else if localPath, ok := fileutil.MapRemotePath(mappings, file.Path); ok {
+			return localPath
+		}
+	}
+
+	if mountPoint != "" {
+		// qBittorrent reports paths relative to its filesystem
+		// Prepend the local mount point to access them
+		return filepath.Join(mountPoint, file.Path)
+	}
+
+	return file.Path
+}
+
+// destination returns the library directory of dl's media type and the directory the path
+// templates place dl in under it
+func (o *OrganizationService) destination(ctx context.Context, dl *models.Download) (string, string, error) {
+	// Ebooks have their own destination and templates, falling back to the audiobook settings
+	destKey, templateKey, noSeriesTemplateKey := "paths.destination", "paths.template", "paths.no_series_template"
+	if dl.MediaType == models.MediaTypeEbook {
+		destKey, templateKey, noSeriesTemplateKey = "paths.ebook_destination", "paths.ebook_template", "paths.ebook_no_series_template"
+	}
+
+	destBase, err := o.getWithFallback(ctx, destKey, "paths.destination")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get destination path: %w", err)
+	}
+
+	template, err := o.getWithFallback(ctx, templateKey, "paths.template")
+	if err != nil {
+		template = "{author}/{series}/{title}"
+	}
+
+	noSeriesTemplate, err := o.getWithFallback(ctx, noSeriesTemplateKey, "paths.no_series_template")
+	if err != nil {
+		noSeriesTemplate = "{author}/{title}"
+	}
+
+	// Choose template based on whether series exists
+	pathTemplate := template
+	if dl.Series == "" {
+		pathTemplate = noSeriesTemplate
+	}
+
+	// Sanitize variables BEFORE template parsing (preserves directory structure)
+	sanitizedVars := map[string]string{
+		"author":        fileutil.SanitizePath(dl.Author),
+		"series":        fileutil.SanitizePath(dl.Series),
+		"series_number": fileutil.SanitizePath(dl.SeriesNumber),
+		"title":         fileutil.SanitizePath(dl.Title),
+	}
+
+	// Parse template with sanitized variables
+	path := fileutil.ParseTemplate(pathTemplate, sanitizedVars)
+
+	return destBase, filepath.Join(destBase, path), nil
+}
+
+// getWithFallback reads key from config, falling back to fallbackKey when key is unset or empty
+func (o *OrganizationService) getWithFallback(ctx context.Context, key, fallbackKey string) (string, error) {
+	if value, err := o.configService.Get(ctx, key); err == nil && value != "" {
+		return value, nil
+	}
+	return o.configService.Get(ctx, fallbackKey)
+}
+
+// ebookExtensions returns the configured ebook file extensions, lowercased and without dots
+func ebookExtensions(ctx context.Context, cfg configService) []string {
+	value, err := cfg.Get(ctx, "ebooks.file_types")
+	if err != nil || strings.TrimSpace(value) == "" {
+		value = defaultEbookFileTypes
+	}
+
+	var extensions []string
+	for _, ext := range strings.Split(value, ",") {
+		ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+		if ext != "" {
+			extensions = append(extensions, ext)
+		}
+	}
+	return extensions
+}
+
+// filterFilesByExtension keeps only files whose extension is in extensions (case-insensitive)
+func filterFilesByExtension(files []*qbittorrent.TorrentFile, extensions []string) []*qbittorrent.TorrentFile {
+	allowed := make(map[string]bool, len(extensions))
+	for _, ext := range extensions {
+		allowed[ext] = true
+	}
+
+	var filtered []*qbittorrent.TorrentFile
+	for _, file := range files {
+		ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(file.Name), "."))
+		if allowed[ext] {
+			filtered = append(filtered, file)
+		}
+	}
+	return filtered
+}
+
 func copyFile(src, dst string) error {
 	sourceFile, err := os.Open(src)
 	if err != nil {
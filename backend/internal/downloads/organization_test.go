@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 
 	"github.com/nathanael/organizr/internal/models"
@@ -13,10 +14,13 @@ import (
 
 // mockConfigService is a simple mock for config.Service
 type mockConfigService struct {
+	mu      sync.Mutex
 	configs map[string]string
 }
 
 func (m *mockConfigService) Get(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if val, ok := m.configs[key]; ok {
 		return val, nil
 	}
@@ -28,6 +32,8 @@ func (m *mockConfigService) GetAll(ctx context.Context) (map[string]string, erro
 }
 
 func (m *mockConfigService) Set(ctx context.Context, key, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.configs[key] = value
 	return nil
 }
@@ -452,6 +458,177 @@ func TestOrganize(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "ebook keeps only configured file types and writes OPF sidecar",
+			download: &models.Download{
+				ID:           "test-ebook",
+				Title:        "The Hobbit",
+				Author:       "J.R.R. Tolkien",
+				Series:       "Middle-earth",
+				SeriesNumber: "1",
+				MediaType:    models.MediaTypeEbook,
+				QBitHash:     "ebook123",
+			},
+			configs: map[string]string{
+				"paths.destination":        "",
+				"paths.template":           "{author}/{series}/{title}",
+				"paths.no_series_template": "{author}/{title}",
+				"paths.operation":          "copy",
+				"ebooks.file_types":        "epub,azw3",
+			},
+			sourceFiles: map[string]string{
+				"The Hobbit.epub": "epub content",
+				"The Hobbit.AZW3": "azw3 content",
+				"cover.jpg":       "image",
+				"info.nfo":        "nfo",
+			},
+			qbFiles: []*qbittorrent.TorrentFile{
+				{Name: "The Hobbit.epub", Size: 12},
+				{Name: "The Hobbit.AZW3", Size: 12},
+				{Name: "cover.jpg", Size: 5},
+				{Name: "info.nfo", Size: 3},
+			},
+			wantErr: false,
+			verifyFn: func(t *testing.T, destBase string, dl *models.Download) {
+				expectedPath := filepath.Join(destBase, "J.R.R. Tolkien", "Middle-earth", "The Hobbit")
+				if dl.OrganizedPath != expectedPath {
+					t.Errorf("OrganizedPath = %v, want %v", dl.OrganizedPath, expectedPath)
+				}
+
+				for _, name := range []string{"The Hobbit.epub", "The Hobbit.AZW3", "metadata.opf"} {
+					if _, err := os.Stat(filepath.Join(expectedPath, name)); err != nil {
+						t.Errorf("expected %s at destination: %v", name, err)
+					}
+				}
+				for _, name := range []string{"cover.jpg", "info.nfo"} {
+					if _, err := os.Stat(filepath.Join(expectedPath, name)); !os.IsNotExist(err) {
+						t.Errorf("expected %s to be filtered out", name)
+					}
+				}
+
+				opf, err := os.ReadFile(filepath.Join(expectedPath, "metadata.opf"))
+				if err != nil {
+					t.Fatalf("failed to read metadata.opf: %v", err)
+				}
+				if !contains(string(opf), `<meta name="calibre:series" content="Middle-earth"/>`) {
+					t.Errorf("metadata.opf missing series meta:\n%s", opf)
+				}
+			},
+		},
+		{
+			name: "ebook without matching files fails",
+			download: &models.Download{
+				ID:        "test-ebook-empty",
+				Title:     "Audio Only",
+				Author:    "Someone",
+				MediaType: models.MediaTypeEbook,
+				QBitHash:  "ebook456",
+			},
+			configs: map[string]string{
+				"paths.destination": "",
+				"paths.operation":   "copy",
+			},
+			sourceFiles: map[string]string{
+				"track01.mp3": "audio",
+			},
+			qbFiles: []*qbittorrent.TorrentFile{
+				{Name: "track01.mp3", Size: 5},
+			},
+			wantErr:        true,
+			wantErrContain: "no ebook files found",
+		},
+		{
+			name: "ebook with OPF disabled",
+			download: &models.Download{
+				ID:        "test-ebook-noopf",
+				Title:     "Standalone",
+				Author:    "Writer",
+				MediaType: models.MediaTypeEbook,
+				QBitHash:  "ebook789",
+			},
+			configs: map[string]string{
+				"paths.destination":              "",
+				"paths.ebook_no_series_template": "Ebooks/{author}/{title}",
+				"paths.operation":                "copy",
+				"ebooks.write_opf":               "false",
+			},
+			sourceFiles: map[string]string{
+				"Standalone.pdf": "pdf",
+			},
+			qbFiles: []*qbittorrent.TorrentFile{
+				{Name: "Standalone.pdf", Size: 3},
+			},
+			wantErr: false,
+			verifyFn: func(t *testing.T, destBase string, dl *models.Download) {
+				expectedPath := filepath.Join(destBase, "Ebooks", "Writer", "Standalone")
+				if dl.OrganizedPath != expectedPath {
+					t.Errorf("OrganizedPath = %v, want %v", dl.OrganizedPath, expectedPath)
+				}
+				if _, err := os.Stat(filepath.Join(expectedPath, "metadata.opf")); !os.IsNotExist(err) {
+					t.Errorf("expected no metadata.opf when ebooks.write_opf is false")
+				}
+			},
+		},
+		{
+			name: "applies configured file and directory modes",
+			download: &models.Download{
+				ID:       "test-perms",
+				Title:    "Perm Book",
+				Author:   "Perm Author",
+				QBitHash: "perm123",
+			},
+			configs: map[string]string{
+				"paths.destination":        "",
+				"paths.no_series_template": "{author}/{title}",
+				"paths.operation":          "copy",
+				"permissions.dir_mode":     "0750",
+				"permissions.file_mode":    "640",
+				"permissions.pgid":         fmt.Sprintf("%d", os.Getgid()),
+			},
+			sourceFiles: map[string]string{
+				"Perm Book.m4b": "audio",
+			},
+			qbFiles: []*qbittorrent.TorrentFile{
+				{Name: "Perm Book.m4b", Size: 5},
+			},
+			wantErr: false,
+			verifyFn: func(t *testing.T, destBase string, dl *models.Download) {
+				for _, dir := range []string{filepath.Join(destBase, "Perm Author"), dl.OrganizedPath} {
+					info, err := os.Stat(dir)
+					if err != nil {
+						t.Fatalf("stat %s: %v", dir, err)
+					}
+					if info.Mode().Perm() != 0750 {
+						t.Errorf("%s mode = %#o, want 0750", dir, info.Mode().Perm())
+					}
+				}
+
+				info, err := os.Stat(filepath.Join(dl.OrganizedPath, "Perm Book.m4b"))
+				if err != nil {
+					t.Fatalf("stat file: %v", err)
+				}
+				if info.Mode().Perm() != 0640 {
+					t.Errorf("file mode = %#o, want 0640", info.Mode().Perm())
+				}
+			},
+		},
+		{
+			name: "invalid permission config fails organization",
+			download: &models.Download{
+				ID:       "test-bad-perms",
+				Title:    "Bad Perms",
+				Author:   "Author",
+				QBitHash: "bad123",
+			},
+			configs: map[string]string{
+				"paths.destination":     "",
+				"paths.operation":       "copy",
+				"permissions.file_mode": "rw-r--r--",
+			},
+			qbFiles:        []*qbittorrent.TorrentFile{},
+			wantErr:        true,
+			wantErrContain: "invalid permission settings",
+		},
 	}
 
 	for _, tt := range tests {
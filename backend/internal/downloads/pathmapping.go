@@ -0,0 +1,164 @@
+package downloads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/nathanael/organizr/internal/fileutil"
+)
+
+// PathMappingCheck is the validation result for a single remote→local mapping
+type PathMappingCheck struct {
+	Remote           string
+	Local            string
+	LocalExists      bool
+	LocalIsDir       bool
+	LocalReadable    bool
+	MatchedSavePaths []string
+	Errors           []string
+}
+
+// PathMappingReport summarizes how well the mappings cover qBittorrent's save paths
+type PathMappingReport struct {
+	Mappings          []PathMappingCheck
+	UnmappedSavePaths []string
+	QBittorrentError  string
+}
+
+// Valid reports whether every mapping passed and qBittorrent could be checked
+func (r *PathMappingReport) Valid() bool {
+	if r.QBittorrentError != "" {
+		return false
+	}
+	for _, m := range r.Mappings {
+		if len(m.Errors) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidatePathMappings checks each mapping against the local filesystem and against the
+// save paths qBittorrent reports for its torrents. If mappings is nil the configured
+// paths.remote_mappings are validated.
+func (s *Service) ValidatePathMappings(ctx context.Context, mappings []fileutil.PathMapping) (*PathMappingReport, error) {
+	if mappings == nil {
+		value, _ := s.configService.Get(ctx, "paths.remote_mappings")
+		parsed, err := fileutil.ParsePathMappings(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid paths.remote_mappings: %w", err)
+		}
+		mappings = parsed
+	}
+
+	report := &PathMappingReport{
+		Mappings: make([]PathMappingCheck, len(mappings)),
+	}
+
+	for i, m := range mappings {
+		report.Mappings[i] = checkLocalMapping(m)
+	}
+
+	// Collect the distinct save paths qBittorrent knows about
+	savePaths, err := s.qbittorrentSavePaths(ctx)
+	if err != nil {
+		report.QBittorrentError = err.Error()
+		return report, nil
+	}
+
+	for _, savePath := range savePaths {
+		index := fileutil.MatchPathMapping(mappings, savePath)
+		if index < 0 {
+			report.UnmappedSavePaths = append(report.UnmappedSavePaths, savePath)
+			continue
+		}
+
+		check := &report.Mappings[index]
+		check.MatchedSavePaths = append(check.MatchedSavePaths, savePath)
+
+		// The mapping root existing isn't enough if the translated save path is missing
+		localPath, _ := fileutil.MapRemotePath(mappings, savePath)
+		if check.LocalIsDir {
+			if _, err := os.Stat(localPath); err != nil {
+				check.Errors = append(check.Errors, fmt.Sprintf("save path %s maps to %s, which is not accessible: %v", savePath, localPath, err))
+			}
+		}
+	}
+
+	for i := range report.Mappings {
+		if len(report.Mappings[i].MatchedSavePaths) == 0 {
+			report.Mappings[i].Errors = append(report.Mappings[i].Errors, "no qBittorrent save path matches this remote prefix")
+		}
+	}
+
+	return report, nil
+}
+
+// checkLocalMapping verifies the local side of a mapping exists and is a readable directory
+func checkLocalMapping(m fileutil.PathMapping) PathMappingCheck {
+	check := PathMappingCheck{Remote: m.Remote, Local: m.Local}
+
+	info, err := os.Stat(m.Local)
+	if err != nil {
+		if os.IsNotExist(err) {
+			check.Errors = append(check.Errors, fmt.Sprintf("local path %s does not exist", m.Local))
+		} else {
+			check.Errors = append(check.Errors, fmt.Sprintf("local path %s is not accessible: %v", m.Local, err))
+		}
+		return check
+	}
+	check.LocalExists = true
+
+	if !info.IsDir() {
+		check.Errors = append(check.Errors, fmt.Sprintf("local path %s is not a directory", m.Local))
+		return check
+	}
+	check.LocalIsDir = true
+
+	dir, err := os.Open(m.Local)
+	if err != nil {
+		check.Errors = append(check.Errors, fmt.Sprintf("local path %s is not readable: %v", m.Local, err))
+		return check
+	}
+	defer func() {
+		_ = dir.Close() // Read-only handle, close error is not actionable
+	}()
+	if _, err := dir.Readdirnames(1); err != nil && !errors.Is(err, io.EOF) {
+		check.Errors = append(check.Errors, fmt.Sprintf("local path %s is not readable: %v", m.Local, err))
+		return check
+	}
+	check.LocalReadable = true
+
+	return check
+}
+
+// qbittorrentSavePaths returns the sorted, distinct save paths of all torrents plus the default save path
+func (s *Service) qbittorrentSavePaths(ctx context.Context) ([]string, error) {
+	torrents, err := s.qbClient.ListTorrents(ctx, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list qBittorrent torrents: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, t := range torrents {
+		if t.SavePath != "" {
+			seen[t.SavePath] = true
+		}
+	}
+
+	if defaultPath, err := s.qbClient.GetDefaultSavePath(ctx); err == nil && defaultPath != "" {
+		seen[defaultPath] = true
+	}
+
+	savePaths := make([]string, 0, len(seen))
+	for path := range seen {
+		savePaths = append(savePaths, path)
+	}
+	sort.Strings(savePaths)
+
+	return savePaths, nil
+}
@@ -0,0 +1,128 @@
+package downloads
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nathanael/organizr/internal/fileutil"
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/qbittorrent"
+)
+
+func TestValidatePathMappings(t *testing.T) {
+	mount1 := t.TempDir()
+	mount2 := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(mount1, "audiobooks"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	qb, _ := newFakeQBittorrent(t, []qbittorrent.TorrentInfo{
+		{Hash: "a", SavePath: "/downloads/audiobooks"},
+		{Hash: "b", SavePath: "/downloads/missing"},
+		{Hash: "c", SavePath: "/elsewhere"},
+	}, withDefaultSavePath("/downloads/audiobooks"))
+
+	svc := &Service{qbClient: qb}
+
+	mappings := []fileutil.PathMapping{
+		{Remote: "/downloads", Local: mount1},
+		{Remote: "/media", Local: mount2},
+		{Remote: "/nowhere", Local: filepath.Join(mount2, "does-not-exist")},
+	}
+
+	report, err := svc.ValidatePathMappings(context.Background(), mappings)
+	if err != nil {
+		t.Fatalf("ValidatePathMappings() error = %v", err)
+	}
+
+	if report.Valid() {
+		t.Error("expected report to be invalid")
+	}
+
+	downloadsCheck := report.Mappings[0]
+	if !downloadsCheck.LocalExists || !downloadsCheck.LocalIsDir || !downloadsCheck.LocalReadable {
+		t.Errorf("expected /downloads local path to pass filesystem checks: %+v", downloadsCheck)
+	}
+	if len(downloadsCheck.MatchedSavePaths) != 2 {
+		t.Errorf("expected 2 save paths matched by /downloads, got %v", downloadsCheck.MatchedSavePaths)
+	}
+	if len(downloadsCheck.Errors) != 1 {
+		t.Errorf("expected 1 error for missing /downloads/missing, got %v", downloadsCheck.Errors)
+	}
+
+	if len(report.Mappings[1].Errors) != 1 {
+		t.Errorf("expected unused /media mapping to report an error, got %v", report.Mappings[1].Errors)
+	}
+
+	if report.Mappings[2].LocalExists {
+		t.Error("expected missing local path to be reported")
+	}
+
+	if len(report.UnmappedSavePaths) != 1 || report.UnmappedSavePaths[0] != "/elsewhere" {
+		t.Errorf("UnmappedSavePaths = %v, want [/elsewhere]", report.UnmappedSavePaths)
+	}
+}
+
+func TestValidatePathMappings_QBittorrentUnreachable(t *testing.T) {
+	qb, err := qbittorrent.NewClient("http://127.0.0.1:1", "admin", "adminpass")
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	svc := &Service{qbClient: qb}
+	report, err := svc.ValidatePathMappings(context.Background(), []fileutil.PathMapping{
+		{Remote: "/downloads", Local: t.TempDir()},
+	})
+	if err != nil {
+		t.Fatalf("ValidatePathMappings() error = %v", err)
+	}
+
+	if report.QBittorrentError == "" {
+		t.Error("expected qBittorrent error to be reported")
+	}
+	if !report.Mappings[0].LocalReadable {
+		t.Error("expected local checks to still run when qBittorrent is unreachable")
+	}
+}
+
+func TestOrganize_RemotePathMappings(t *testing.T) {
+	mount1 := t.TempDir()
+	mount2 := t.TempDir()
+	destDir := t.TempDir()
+
+	// Torrent lives under a save path only covered by the longer mapping
+	if err := os.MkdirAll(filepath.Join(mount2, "Book"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(mount2, "Book", "book.m4b"), []byte("audio"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	mappingsJSON, _ := json.Marshal([]fileutil.PathMapping{
+		{Remote: "/data", Local: mount1},
+		{Remote: "/data/audiobooks", Local: mount2},
+	})
+
+	svc := newTestOrganizationService(&mockQBClient{
+		files: []*qbittorrent.TorrentFile{
+			{Name: "Book/book.m4b", Path: "/data/audiobooks/Book/book.m4b", SavePath: "/data/audiobooks", Size: 5},
+		},
+	}, newMockConfigService(map[string]string{
+		"paths.destination":        destDir,
+		"paths.no_series_template": "{author}/{title}",
+		"paths.operation":          "copy",
+		"paths.remote_mappings":    string(mappingsJSON),
+	}))
+
+	dl := &models.Download{ID: "map-1", Title: "Book", Author: "Author", QBitHash: "hash"}
+	if err := svc.Organize(context.Background(), dl); err != nil {
+		t.Fatalf("Organize() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(destDir, "Author", "Book", "book.m4b")); err != nil {
+		t.Errorf("expected organized file: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package downloads
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/nathanael/organizr/internal/models"
+)
+
+// missingTorrentMessage is recorded on downloads marked missing
+const missingTorrentMessage = "torrent was removed from qBittorrent; re-add or discard the download"
+
+// reconcileMissing handles an active download whose torrent qBittorrent no longer has. The
+// first sighting starts the grace period, so a torrent briefly gone (e.g. being re-checked or
+// moved between instances) isn't flagged; once the grace period passes the download is
+// marked missing and no longer polled. Reports whether the download was marked missing.
+func (m *Monitor) reconcileMissing(ctx context.Context, dl *models.Download) bool {
+	now := time.Now()
+
+	if dl.MissingSince == nil {
+		log.Printf("Torrent for download %s (%s) not found in qBittorrent, marking missing in %s unless it reappears", dl.ID, dl.Title, m.missingGrace)
+		if err := m.downloadRepo.UpdateMissingSince(ctx, dl.ID, &now); err != nil {
+			log.Printf("Failed to record missing torrent for download %s: %v", dl.ID, err)
+		}
+		return false
+	}
+
+	if now.Sub(*dl.MissingSince) < m.missingGrace {
+		return false
+	}
+
+	log.Printf("Torrent for download %s (%s) missing from qBittorrent since %s, marking download missing", dl.ID, dl.Title, dl.MissingSince.Format(time.RFC3339))
+
+	if err := m.downloadRepo.UpdateError(ctx, dl.ID, missingTorrentMessage); err != nil {
+		log.Printf("Failed to update download error for %s: %v", dl.ID, err)
+	}
+	if err := transition(ctx, m.downloadRepo, dl, models.StatusMissing, models.ActorMonitor, missingTorrentMessage); err != nil {
+		log.Printf("Failed to mark download %s missing: %v", dl.ID, err)
+		return false
+	}
+	return true
+}
+
+// clearMissing resets the grace period of a download whose torrent is back in qBittorrent
+func (m *Monitor) clearMissing(ctx context.Context, dl *models.Download) {
+	if dl.MissingSince == nil {
+		return
+	}
+
+	log.Printf("Torrent for download %s (%s) is back in qBittorrent", dl.ID, dl.Title)
+	if err := m.downloadRepo.UpdateMissingSince(ctx, dl.ID, nil); err != nil {
+		log.Printf("Failed to clear missing torrent for download %s: %v", dl.ID, err)
+	}
+}
@@ -0,0 +1,95 @@
+package downloads
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/qbittorrent"
+)
+
+func newReconcileMonitor(qb *qbittorrent.Client, repo *mockDownloadRepo) *Monitor {
+	return &Monitor{
+		qbClient:     qb,
+		downloadRepo: repo,
+		missingGrace: 5 * time.Minute,
+	}
+}
+
+func TestCheckDownloads_MissingTorrent(t *testing.T) {
+	longAgo := time.Now().Add(-time.Hour)
+	recently := time.Now().Add(-time.Minute)
+
+	qb, _ := newFakeQBittorrent(t, []qbittorrent.TorrentInfo{
+		{Hash: "back", State: "downloading", Progress: 0.5},
+	})
+
+	repo := newMockDownloadRepo()
+	repo.getActiveFunc = func(ctx context.Context) ([]*models.Download, error) {
+		return []*models.Download{
+			{ID: "new", QBitHash: "gone-1", Status: models.StatusDownloading},
+			{ID: "grace", QBitHash: "gone-2", Status: models.StatusDownloading, MissingSince: &recently},
+			{ID: "expired", QBitHash: "gone-3", Status: models.StatusDownloading, MissingSince: &longAgo},
+			{ID: "back", QBitHash: "back", Status: models.StatusDownloading, MissingSince: &recently},
+		}, nil
+	}
+
+	m := newReconcileMonitor(qb, repo)
+	if err := m.checkDownloads(context.Background()); err != nil {
+		t.Fatalf("checkDownloads() error = %v", err)
+	}
+
+	// First sighting starts the grace period
+	if since := repo.missingUpdates["new"]; since == nil {
+		t.Error("expected missing time to be recorded on first sighting")
+	}
+	if _, ok := repo.statusUpdates["new"]; ok {
+		t.Error("download must not be marked missing on first sighting")
+	}
+
+	// Within the grace period nothing changes
+	if _, ok := repo.missingUpdates["grace"]; ok {
+		t.Error("missing time must not be reset during the grace period")
+	}
+	if _, ok := repo.statusUpdates["grace"]; ok {
+		t.Error("download must not be marked missing during the grace period")
+	}
+
+	// After the grace period the download is marked missing
+	if repo.statusUpdates["expired"] != models.StatusMissing {
+		t.Errorf("status = %s, want missing", repo.statusUpdates["expired"])
+	}
+	if repo.errorUpdates["expired"] != missingTorrentMessage {
+		t.Errorf("error = %q, want %q", repo.errorUpdates["expired"], missingTorrentMessage)
+	}
+
+	// A torrent that reappeared clears its missing time
+	if since, ok := repo.missingUpdates["back"]; !ok || since != nil {
+		t.Errorf("expected missing time to be cleared, got %v", since)
+	}
+}
+
+func TestCheckDownloads_UnreachableIsNotMissing(t *testing.T) {
+	qb, err := qbittorrent.NewClient("http://127.0.0.1:1", "admin", "adminpass")
+	if err != nil {
+		t.Fatalf("failed to create qBittorrent client: %v", err)
+	}
+
+	longAgo := time.Now().Add(-time.Hour)
+	repo := newMockDownloadRepo()
+	repo.getActiveFunc = func(ctx context.Context) ([]*models.Download, error) {
+		return []*models.Download{
+			{ID: "dl-1", QBitHash: "abc", Status: models.StatusDownloading, MissingSince: &longAgo},
+		}, nil
+	}
+
+	m := newReconcileMonitor(qb, repo)
+	if err := m.checkDownloads(context.Background()); err != nil {
+		t.Fatalf("checkDownloads() error = %v", err)
+	}
+
+	if len(repo.missingUpdates) != 0 || len(repo.statusUpdates) != 0 {
+		t.Errorf("unreachable qBittorrent must not change downloads: missing=%v status=%v", repo.missingUpdates, repo.statusUpdates)
+	}
+}
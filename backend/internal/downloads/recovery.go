@@ -0,0 +1,213 @@
+package downloads
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nathanael/organizr/internal/models"
+)
+
+// RecoverInterrupted finds downloads left organizing by a previous run (the process stopped
+// mid-organization) and, using each one's organization journal, resumes it or, if resuming
+// fails, rolls back the files it had already placed and marks it failed with the reason.
+// Call before Run; Shutdown waits for recovery like any other in-flight organization.
+func (m *Monitor) RecoverInterrupted(ctx context.Context) error {
+	// Count recovery as one in-flight organization so Shutdown waits for it
+	m.inFlight.Add(1)
+	defer m.inFlight.Done()
+	m.setRecovering(true)
+	defer m.setRecovering(false)
+
+	// Jobs the previous process was running are superseded by recovering their downloads below
+	if m.jobRepo != nil {
+		if n, err := m.jobRepo.FailRunning(ctx, "interrupted by shutdown; recovered on startup"); err != nil {
+			log.Printf("Failed to close interrupted organize jobs: %v", err)
+		} else if n > 0 {
+			log.Printf("Closed %d organize job(s) interrupted by shutdown", n)
+		}
+	}
+
+	stuck, err := m.downloadRepo.GetByStatus(ctx, models.StatusOrganizing)
+	if err != nil {
+		return fmt.Errorf("failed to get interrupted organizations: %w", err)
+	}
+
+	if len(stuck) == 0 {
+		return nil
+	}
+
+	log.Printf("Recovering %d interrupted organization(s)", len(stuck))
+
+	for _, dl := range stuck {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		m.recoverDownload(dl)
+	}
+
+	return nil
+}
+
+func (m *Monitor) recoverDownload(dl *models.Download) {
+	ctx, cancel := context.WithTimeout(m.orgCtx, organizeTimeout)
+	defer cancel()
+
+	if !m.locks.TryLock(dl.ID) {
+		log.Printf("Download %s is already being organized, skipping recovery", dl.ID)
+		return
+	}
+	defer m.locks.Unlock(dl.ID)
+
+	entries, err := m.listJournal(ctx, dl.ID)
+	if err != nil {
+		m.failRecovery(ctx, dl, fmt.Sprintf("organization was interrupted by a restart and its journal could not be read: %v", err))
+		return
+	}
+
+	// Files mid-copy at the time of the crash are incomplete; drop them so they are copied again
+	reconcileJournal(entries)
+	for _, entry := range entries {
+		if entry.Done && entry.ID != 0 && m.journalRepo != nil {
+			if err := m.journalRepo.MarkDone(ctx, entry.ID); err != nil {
+				log.Printf("Failed to update organization journal for %s: %v", entry.DestPath, err)
+			}
+		}
+	}
+
+	log.Printf("Resuming interrupted organization of download %s (%s), %d file(s) journaled", dl.ID, dl.Title, len(entries))
+
+	orgErr := m.orgService.Organize(ctx, dl)
+	if orgErr == nil {
+		if err := transition(ctx, m.downloadRepo, dl, models.StatusOrganized, models.ActorMonitor, "interrupted organization resumed after restart"); err != nil {
+			log.Printf("Failed to update status for download %s: %v", dl.ID, err)
+			return
+		}
+		if err := m.downloadRepo.UpdateOrganizedPath(ctx, dl.ID, dl.OrganizedPath); err != nil {
+			log.Printf("Failed to update organized path for download %s: %v", dl.ID, err)
+		}
+		log.Printf("Recovered download %s, organized to %s", dl.ID, dl.OrganizedPath)
+		return
+	}
+
+	if m.orgCtx.Err() != nil {
+		// Shutting down again before recovery finished; try again next start
+		log.Printf("Recovery of download %s interrupted: %v", dl.ID, orgErr)
+		return
+	}
+
+	// Organize may have rewritten or cleared the journal, so roll back from its current state
+	entries, err = m.listJournal(ctx, dl.ID)
+	if err != nil {
+		m.failRecovery(ctx, dl, fmt.Sprintf("organization was interrupted by a restart and could not be resumed: %v; journal could not be read for rollback: %v", orgErr, err))
+		return
+	}
+
+	reason := fmt.Sprintf("organization was interrupted by a restart and could not be resumed: %v", orgErr)
+	rolledBack, leftovers := rollbackJournal(entries)
+	if len(leftovers) > 0 {
+		reason += fmt.Sprintf("; %d file(s) could not be rolled back: %s", len(leftovers), strings.Join(leftovers, ", "))
+	} else if rolledBack > 0 {
+		reason += fmt.Sprintf("; rolled back %d partially organized file(s)", rolledBack)
+	}
+
+	if m.journalRepo != nil {
+		if err := m.journalRepo.DeleteByDownload(ctx, dl.ID); err != nil {
+			log.Printf("Failed to clear organization journal for %s: %v", dl.ID, err)
+		}
+	}
+
+	m.failRecovery(ctx, dl, reason)
+}
+
+func (m *Monitor) listJournal(ctx context.Context, downloadID string) ([]*models.JournalEntry, error) {
+	if m.journalRepo == nil {
+		return nil, nil
+	}
+	return m.journalRepo.ListByDownload(ctx, downloadID)
+}
+
+func (m *Monitor) failRecovery(ctx context.Context, dl *models.Download, reason string) {
+	log.Printf("Failed to recover download %s: %s", dl.ID, reason)
+	if err := m.downloadRepo.UpdateError(ctx, dl.ID, reason); err != nil {
+		log.Printf("Failed to update download error for %s: %v", dl.ID, err)
+	}
+	if err := transition(ctx, m.downloadRepo, dl, models.StatusFailed, models.ActorMonitor, reason); err != nil {
+		log.Printf("Failed to update download status for %s: %v", dl.ID, err)
+	}
+}
+
+// reconcileJournal brings journal entries in line with the filesystem after a crash.
+// A pending copy's destination may be truncated, so it is removed. A pending move whose
+// source is gone but destination exists completed before the journal was updated.
+func reconcileJournal(entries []*models.JournalEntry) {
+	for _, entry := range entries {
+		if entry.Done {
+			continue
+		}
+
+		if entry.Operation == "move" {
+			if !fileExists(entry.SourcePath) && fileExists(entry.DestPath) {
+				entry.Done = true
+			}
+			continue
+		}
+
+		if fileExists(entry.DestPath) {
+			if err := os.Remove(entry.DestPath); err != nil {
+				log.Printf("Failed to remove partial copy %s: %v", entry.DestPath, err)
+			}
+		}
+	}
+}
+
+// rollbackJournal undoes completed entries: copies are removed when the original is still
+// available, moves are renamed back to their source. Returns the number of files rolled back
+// and the destination paths that had to be left in place.
+func rollbackJournal(entries []*models.JournalEntry) (int, []string) {
+	rolledBack := 0
+	var leftovers []string
+
+	for _, entry := range entries {
+		if !entry.Done || !fileExists(entry.DestPath) {
+			continue
+		}
+
+		if entry.Operation == "move" {
+			if err := os.MkdirAll(filepath.Dir(entry.SourcePath), 0755); err != nil {
+				log.Printf("Failed to restore %s: %v", entry.SourcePath, err)
+				leftovers = append(leftovers, entry.DestPath)
+				continue
+			}
+			if err := os.Rename(entry.DestPath, entry.SourcePath); err != nil {
+				log.Printf("Failed to move %s back to %s: %v", entry.DestPath, entry.SourcePath, err)
+				leftovers = append(leftovers, entry.DestPath)
+				continue
+			}
+			rolledBack++
+			continue
+		}
+
+		// Never delete the only remaining copy of a file
+		if !fileExists(entry.SourcePath) {
+			leftovers = append(leftovers, entry.DestPath)
+			continue
+		}
+		if err := os.Remove(entry.DestPath); err != nil {
+			log.Printf("Failed to remove copied file %s: %v", entry.DestPath, err)
+			leftovers = append(leftovers, entry.DestPath)
+			continue
+		}
+		rolledBack++
+	}
+
+	return rolledBack, leftovers
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
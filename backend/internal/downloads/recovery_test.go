@@ -0,0 +1,291 @@
+package downloads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/qbittorrent"
+)
+
+// mockJournal is an in-memory organization journal
+type mockJournal struct {
+	mu      sync.Mutex
+	nextID  int64
+	entries map[string][]*models.JournalEntry
+}
+
+func newMockJournal() *mockJournal {
+	return &mockJournal{entries: make(map[string][]*models.JournalEntry)}
+}
+
+func (m *mockJournal) Replace(ctx context.Context, downloadID string, entries []*models.JournalEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored := make([]*models.JournalEntry, len(entries))
+	for i, e := range entries {
+		m.nextID++
+		e.ID = m.nextID
+		e.DownloadID = downloadID
+		copied := *e
+		stored[i] = &copied
+	}
+	m.entries[downloadID] = stored
+	return nil
+}
+
+func (m *mockJournal) MarkDone(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, entries := range m.entries {
+		for _, e := range entries {
+			if e.ID == id {
+				e.Done = true
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("journal entry not found: %d", id)
+}
+
+func (m *mockJournal) ListByDownload(ctx context.Context, downloadID string) ([]*models.JournalEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var entries []*models.JournalEntry
+	for _, e := range m.entries[downloadID] {
+		copied := *e
+		entries = append(entries, &copied)
+	}
+	return entries, nil
+}
+
+func (m *mockJournal) DeleteByDownload(ctx context.Context, downloadID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, downloadID)
+	return nil
+}
+
+// newRecoveryMonitor builds a Monitor around mocks for recovery and shutdown tests
+func newRecoveryMonitor(repo *mockDownloadRepo, qb *mockQBClient, configs map[string]string, journal *mockJournal) *Monitor {
+	orgCtx, cancelOrgs := context.WithCancel(context.Background())
+	m := &Monitor{
+		downloadRepo: repo,
+		orgService: &OrganizationService{
+			qbClient:      qb,
+			configService: newMockConfigService(configs),
+		},
+		locks:      NewDownloadLocks(),
+		orgCtx:     orgCtx,
+		cancelOrgs: cancelOrgs,
+		wake:       make(chan struct{}, 1),
+	}
+	if journal != nil {
+		m.journalRepo = journal
+		m.orgService.journal = journal
+	}
+	return m
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+func TestRecoverInterrupted_ResumesCopy(t *testing.T) {
+	sourceDir := t.TempDir()
+	destBase := t.TempDir()
+	destDir := filepath.Join(destBase, "Author", "Book")
+
+	writeTestFile(t, filepath.Join(sourceDir, "Book", "part1.mp3"), "part one")
+	writeTestFile(t, filepath.Join(sourceDir, "Book", "part2.mp3"), "part two")
+
+	// First file finished before the crash, second was cut off mid-copy
+	writeTestFile(t, filepath.Join(destDir, "part1.mp3"), "part one")
+	writeTestFile(t, filepath.Join(destDir, "part2.mp3"), "par")
+
+	journal := newMockJournal()
+	_ = journal.Replace(context.Background(), "dl-1", []*models.JournalEntry{
+		{SourcePath: filepath.Join(sourceDir, "Book", "part1.mp3"), DestPath: filepath.Join(destDir, "part1.mp3"), Operation: "copy", Done: true},
+		{SourcePath: filepath.Join(sourceDir, "Book", "part2.mp3"), DestPath: filepath.Join(destDir, "part2.mp3"), Operation: "copy"},
+	})
+
+	repo := newMockDownloadRepo()
+	repo.getByStatusFunc = func(ctx context.Context, status models.DownloadStatus) ([]*models.Download, error) {
+		if status != models.StatusOrganizing {
+			t.Errorf("GetByStatus called with %s, want organizing", status)
+		}
+		return []*models.Download{{ID: "dl-1", Title: "Book", Author: "Author", QBitHash: "hash", Status: models.StatusOrganizing}}, nil
+	}
+
+	qb := &mockQBClient{files: []*qbittorrent.TorrentFile{
+		{Name: "Book/part1.mp3", Path: filepath.Join(sourceDir, "Book", "part1.mp3")},
+		{Name: "Book/part2.mp3", Path: filepath.Join(sourceDir, "Book", "part2.mp3")},
+	}}
+
+	m := newRecoveryMonitor(repo, qb, map[string]string{
+		"paths.destination":        destBase,
+		"paths.no_series_template": "{author}/{title}",
+		"paths.operation":          "copy",
+	}, journal)
+
+	if err := m.RecoverInterrupted(context.Background()); err != nil {
+		t.Fatalf("RecoverInterrupted() error = %v", err)
+	}
+
+	if repo.statusUpdates["dl-1"] != models.StatusOrganized {
+		t.Errorf("status = %s, want organized", repo.statusUpdates["dl-1"])
+	}
+	if repo.pathUpdates["dl-1"] != destDir {
+		t.Errorf("organized path = %s, want %s", repo.pathUpdates["dl-1"], destDir)
+	}
+
+	content, err := os.ReadFile(filepath.Join(destDir, "part2.mp3"))
+	if err != nil || string(content) != "part two" {
+		t.Errorf("partial copy not redone: %q, %v", content, err)
+	}
+
+	if entries, _ := journal.ListByDownload(context.Background(), "dl-1"); len(entries) != 0 {
+		t.Errorf("expected journal to be cleared, got %d entries", len(entries))
+	}
+}
+
+func TestRecoverInterrupted_RollsBackMove(t *testing.T) {
+	sourceDir := t.TempDir()
+	destDir := filepath.Join(t.TempDir(), "Author", "Book")
+
+	source := filepath.Join(sourceDir, "Book", "book.m4b")
+	dest := filepath.Join(destDir, "book.m4b")
+
+	// The move completed, but the process died before the journal was updated
+	writeTestFile(t, dest, "audio")
+	if err := os.MkdirAll(filepath.Dir(source), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	journal := newMockJournal()
+	_ = journal.Replace(context.Background(), "dl-2", []*models.JournalEntry{
+		{SourcePath: source, DestPath: dest, Operation: "move"},
+	})
+
+	repo := newMockDownloadRepo()
+	repo.getByStatusFunc = func(ctx context.Context, status models.DownloadStatus) ([]*models.Download, error) {
+		return []*models.Download{{ID: "dl-2", Title: "Book", Author: "Author", QBitHash: "hash", Status: models.StatusOrganizing}}, nil
+	}
+
+	// The torrent is gone from qBittorrent, so organization can't be resumed
+	qb := &mockQBClient{err: errors.New("torrent not found")}
+
+	m := newRecoveryMonitor(repo, qb, map[string]string{
+		"paths.destination": t.TempDir(),
+		"paths.operation":   "move",
+	}, journal)
+
+	if err := m.RecoverInterrupted(context.Background()); err != nil {
+		t.Fatalf("RecoverInterrupted() error = %v", err)
+	}
+
+	if repo.statusUpdates["dl-2"] != models.StatusFailed {
+		t.Errorf("status = %s, want failed", repo.statusUpdates["dl-2"])
+	}
+	reason := repo.errorUpdates["dl-2"]
+	if !strings.Contains(reason, "interrupted by a restart") || !strings.Contains(reason, "rolled back 1") {
+		t.Errorf("unexpected failure reason: %q", reason)
+	}
+
+	if _, err := os.Stat(source); err != nil {
+		t.Errorf("expected moved file to be restored to %s: %v", source, err)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be moved back", dest)
+	}
+}
+
+func TestRecoverInterrupted_KeepsOnlyCopy(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "book.m4b")
+	writeTestFile(t, dest, "audio")
+
+	journal := newMockJournal()
+	_ = journal.Replace(context.Background(), "dl-3", []*models.JournalEntry{
+		{SourcePath: filepath.Join(t.TempDir(), "gone.m4b"), DestPath: dest, Operation: "copy", Done: true},
+	})
+
+	repo := newMockDownloadRepo()
+	repo.getByStatusFunc = func(ctx context.Context, status models.DownloadStatus) ([]*models.Download, error) {
+		return []*models.Download{{ID: "dl-3", Title: "Book", Author: "Author", QBitHash: "hash"}}, nil
+	}
+
+	m := newRecoveryMonitor(repo, &mockQBClient{err: errors.New("connection refused")}, map[string]string{
+		"paths.destination": t.TempDir(),
+	}, journal)
+
+	if err := m.RecoverInterrupted(context.Background()); err != nil {
+		t.Fatalf("RecoverInterrupted() error = %v", err)
+	}
+
+	if !strings.Contains(repo.errorUpdates["dl-3"], "could not be rolled back") {
+		t.Errorf("unexpected failure reason: %q", repo.errorUpdates["dl-3"])
+	}
+	if _, err := os.Stat(dest); err != nil {
+		t.Errorf("copy whose source is gone must be kept: %v", err)
+	}
+}
+
+func TestMonitorShutdown_WaitsForOrganizations(t *testing.T) {
+	m := newRecoveryMonitor(newMockDownloadRepo(), &mockQBClient{}, nil, nil)
+
+	finished := make(chan struct{})
+	m.inFlight.Add(1)
+	go func() {
+		defer m.inFlight.Done()
+		time.Sleep(50 * time.Millisecond)
+		close(finished)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := m.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	select {
+	case <-finished:
+	default:
+		t.Error("Shutdown returned before the organization finished")
+	}
+	if m.orgCtx.Err() != nil {
+		t.Error("organizations should not be cancelled when they finish in time")
+	}
+}
+
+func TestMonitorShutdown_CancelsOnTimeout(t *testing.T) {
+	m := newRecoveryMonitor(newMockDownloadRepo(), &mockQBClient{}, nil, nil)
+
+	m.inFlight.Add(1)
+	go func() {
+		defer m.inFlight.Done()
+		<-m.orgCtx.Done()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := m.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown() error = %v, want deadline exceeded", err)
+	}
+	if m.orgCtx.Err() == nil {
+		t.Error("expected in-flight organizations to be cancelled")
+	}
+}
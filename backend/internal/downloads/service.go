@@ -3,9 +3,12 @@ package downloads
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/nathanael/organizr/internal/config"
@@ -13,23 +16,52 @@ import (
 	"github.com/nathanael/organizr/internal/persistence"
 	"github.com/nathanael/organizr/internal/qbittorrent"
 	"github.com/nathanael/organizr/internal/search"
+	"github.com/nathanael/organizr/internal/search/providers"
 )
 
+// ErrOrganizeInProgress is returned when a download is already being organized
+var ErrOrganizeInProgress = errors.New("download is already being organized")
+
+// ErrNotRetryable is returned when a download can't be retried in its current state
+var ErrNotRetryable = errors.New("download cannot be retried")
+
+// ErrNotMissing is returned when re-adding or discarding a download whose torrent isn't missing
+var ErrNotMissing = errors.New("download is not missing")
+
+// ErrNoTorrentSource is returned when a vanished torrent has nothing to be re-added from
+var ErrNoTorrentSource = errors.New("no stored torrent file, torrent URL or magnet link to re-add the torrent from")
+
 type Service struct {
 	db            *sql.DB
 	qbClient      *qbittorrent.Client
 	downloadRepo  persistence.DownloadRepository
+	journalRepo   persistence.OrganizationJournalRepository
+	jobRepo       persistence.OrganizeJobRepository
 	configService *config.Service
-	mamService    *search.MAMService
+	searchService *search.Service
+	locks         *DownloadLocks
+	accountGuard  *accountGuard
+	wedges        wedgeSpender
 }
 
-func NewService(db *sql.DB, qbClient *qbittorrent.Client, downloadRepo persistence.DownloadRepository, configService *config.Service, mamService *search.MAMService) *Service {
+func NewService(db *sql.DB, qbClient *qbittorrent.Client, downloadRepo persistence.DownloadRepository, journalRepo persistence.OrganizationJournalRepository, jobRepo persistence.OrganizeJobRepository, locks *DownloadLocks, configService *config.Service, searchService *search.Service) *Service {
+	var guard *accountGuard
+	var wedges wedgeSpender
+	if searchService != nil {
+		guard = &accountGuard{account: searchService}
+		wedges = searchService
+	}
 	return &Service{
 		db:            db,
 		qbClient:      qbClient,
 		downloadRepo:  downloadRepo,
+		journalRepo:   journalRepo,
+		jobRepo:       jobRepo,
 		configService: configService,
-		mamService:    mamService,
+		searchService: searchService,
+		locks:         locks,
+		accountGuard:  guard,
+		wedges:        wedges,
 	}
 }
 
@@ -38,80 +70,147 @@ func (s *Service) CreateDownload(ctx context.Context, d *models.Download) (*mode
 	if d.Title == "" || d.Author == "" {
 		return nil, fmt.Errorf("title and author are required")
 	}
-	if d.TorrentURL == "" && d.MagnetLink == "" && len(d.TorrentBytes) == 0 {
-		return nil, fmt.Errorf("either torrent URL, magnet link, or torrent bytes is required")
+	if d.TorrentURL == "" && d.MagnetLink == "" && len(d.TorrentBytes) == 0 && d.TorrentID == "" {
+		return nil, fmt.Errorf("either torrent URL, magnet link, torrent ID, or torrent bytes is required")
+	}
+
+	if d.MediaType == "" {
+		d.MediaType = models.MediaTypeAudiobook
+	}
+
+	// Grabs that would get the MAM account in trouble are refused or warned about. The
+	// unsatisfied limit is checked first so a refused grab doesn't waste a wedge.
+	check, warnings, err := s.checkAccount(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	// Spent before the torrent file is downloaded; failing to spend it doesn't stop the grab
+	if s.wantsWedge(ctx, d) {
+		if warning := s.spendWedge(ctx, d); warning != "" {
+			warnings = append(warnings, warning)
+		}
 	}
 
+	// Only a wedge actually spent makes the grab freeleech
+	violations, err := check.checkRatio(d)
+	if err != nil {
+		return nil, err
+	}
+	warnings = append(warnings, violations...)
+	d.Warnings = warnings
+
 	// Generate ID
 	d.ID = uuid.New().String()
 
+	hash, err := s.addTorrent(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	d.QBitHash = hash
+	d.Status = models.StatusQueued
+
+	// Save to database
+	if err := s.downloadRepo.Create(ctx, d); err != nil {
+		// Check for unique constraint violations
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") || strings.Contains(err.Error(), "duplicate") {
+			return nil, fmt.Errorf("download with this ID already exists: %w", err)
+		}
+		return nil, fmt.Errorf("failed to save download to database: %w", err)
+	}
+
+	if s.accountGuard != nil && isMAMGrab(d) {
+		s.accountGuard.record(d.Size, d.Freeleech)
+	}
+
+	reason := "download added"
+	if len(warnings) > 0 {
+		reason += " (warning: " + strings.Join(warnings, "; ") + ")"
+	}
+	actor := d.AddedBy
+	if actor == "" {
+		actor = models.ActorUser
+	}
+	recordCreated(ctx, s.downloadRepo, d, actor, reason)
+
+	return d, nil
+}
+
+// addTorrent adds the download's torrent to qBittorrent and returns its hash
+func (s *Service) addTorrent(ctx context.Context, d *models.Download) (string, error) {
 	var hash string
 	var err error
 
+	// Search results are added from their provider's .torrent file, when it has one
+	if len(d.TorrentBytes) == 0 && d.TorrentID != "" && s.searchService != nil {
+		torrentData, err := s.searchService.DownloadTorrent(ctx, d.Provider, d.TorrentID)
+		switch {
+		case errors.Is(err, search.ErrDownloadByIDUnsupported) || errors.Is(err, providers.ErrNoTorrentFile):
+			// Added through the result's torrent URL or magnet link instead
+		case err != nil:
+			return "", fmt.Errorf("failed to download torrent: %w", err)
+		default:
+			// Kept on the download so a vanished torrent can be re-added without the provider
+			d.TorrentBytes = torrentData
+		}
+	}
+
 	// Determine the download method
 	if len(d.TorrentBytes) > 0 {
 		// Use torrent bytes (from MAM or direct upload)
 		hash, err = s.qbClient.AddTorrentFromFile(ctx, d.TorrentBytes, d.Category)
 		if err != nil {
-			return nil, fmt.Errorf("failed to add torrent from file to qBittorrent: %w", err)
+			return "", fmt.Errorf("failed to add torrent from file to qBittorrent: %w", err)
 		}
 	} else if d.TorrentURL != "" && strings.Contains(d.TorrentURL, "/tor/download.php") {
 		// MAM URL - validate format first
 		if !strings.Contains(d.TorrentURL, "myanonamouse.net") {
-			return nil, fmt.Errorf("invalid MAM torrent URL: must be from myanonamouse.net")
+			return "", fmt.Errorf("invalid MAM torrent URL: must be from myanonamouse.net")
 		}
 
 		// Extract torrent ID from URL
 		torrentID, err := extractTorrentIDFromURL(d.TorrentURL)
 		if err != nil {
-			return nil, fmt.Errorf("invalid MAM torrent URL: %w", err)
+			return "", fmt.Errorf("invalid MAM torrent URL: %w", err)
 		}
 
 		// Download torrent file from MAM
-		torrentData, err := s.mamService.DownloadTorrent(ctx, torrentID)
+		torrentData, err := s.searchService.DownloadTorrent(ctx, providers.MyAnonamouseName, strconv.Itoa(torrentID))
 		if err != nil {
-			// Categorize MAM errors
-			if strings.Contains(err.Error(), "401") || strings.Contains(err.Error(), "403") {
-				return nil, fmt.Errorf("MAM authentication failed: please check your credentials in settings")
-			} else if strings.Contains(err.Error(), "404") {
-				return nil, fmt.Errorf("torrent not found on MAM (ID: %d)", torrentID)
+			// Categorize MAM errors; rate limits keep their wait for the caller
+			if errors.Is(err, providers.ErrAuthFailed) {
+				return "", fmt.Errorf("MAM %w: please check your credentials in settings", providers.ErrAuthFailed)
+			} else if errors.Is(err, providers.ErrNotFound) {
+				return "", fmt.Errorf("torrent %w on MAM (ID: %d)", providers.ErrNotFound, torrentID)
 			}
-			return nil, fmt.Errorf("failed to download torrent from MAM: %w", err)
+			return "", fmt.Errorf("failed to download torrent from MAM: %w", err)
 		}
 
+		// Kept on the download so a vanished torrent can be re-added without MAM
+		d.TorrentBytes = torrentData
+
 		// Add torrent from file data
 		hash, err = s.qbClient.AddTorrentFromFile(ctx, torrentData, d.Category)
 		if err != nil {
 			// Categorize qBittorrent errors
 			if strings.Contains(err.Error(), "authentication failed") || strings.Contains(err.Error(), "invalid username or password") {
-				return nil, fmt.Errorf("qBittorrent authentication failed: check username and password in settings")
+				return "", fmt.Errorf("qBittorrent authentication failed: check username and password in settings")
 			} else if strings.Contains(err.Error(), "connection refused") || strings.Contains(err.Error(), "no such host") {
 				// Get base URL from qbClient if possible, otherwise use generic message
-				return nil, fmt.Errorf("qBittorrent not reachable: ensure qBittorrent is running and Web UI is enabled")
+				return "", fmt.Errorf("qBittorrent not reachable: ensure qBittorrent is running and Web UI is enabled")
 			}
-			return nil, fmt.Errorf("failed to add torrent to qBittorrent: %w", err)
+			return "", fmt.Errorf("failed to add torrent to qBittorrent: %w", err)
 		}
 	} else {
 		// Use magnet link or direct URL
 		hash, err = s.qbClient.AddTorrent(ctx, d.MagnetLink, d.TorrentURL, d.Category)
 		if err != nil {
-			return nil, fmt.Errorf("failed to add torrent to qBittorrent: %w", err)
-		}
-	}
-
-	d.QBitHash = hash
-	d.Status = models.StatusQueued
-
-	// Save to database
-	if err := s.downloadRepo.Create(ctx, d); err != nil {
-		// Check for unique constraint violations
-		if strings.Contains(err.Error(), "UNIQUE constraint failed") || strings.Contains(err.Error(), "duplicate") {
-			return nil, fmt.Errorf("download with this ID already exists: %w", err)
+			return "", fmt.Errorf("failed to add torrent to qBittorrent: %w", err)
 		}
-		return nil, fmt.Errorf("failed to save download to database: %w", err)
 	}
 
-	return d, nil
+	return hash, nil
 }
 
 // extractTorrentIDFromURL extracts the torrent ID from a MAM download URL
@@ -154,7 +253,7 @@ func (s *Service) GetDownload(ctx context.Context, id string) (*models.Download,
 	download, err := s.downloadRepo.GetByID(ctx, id)
 	if err != nil {
 		if strings.Contains(err.Error(), "no rows") || strings.Contains(err.Error(), "not found") {
-			return nil, fmt.Errorf("download not found: %s", id)
+			return nil, fmt.Errorf("%w: %s", persistence.ErrDownloadNotFound, id)
 		}
 		return nil, fmt.Errorf("failed to get download from database: %w", err)
 	}
@@ -173,7 +272,7 @@ func (s *Service) CancelDownload(ctx context.Context, id string) error {
 	download, err := s.downloadRepo.GetByID(ctx, id)
 	if err != nil {
 		if strings.Contains(err.Error(), "no rows") || strings.Contains(err.Error(), "not found") {
-			return fmt.Errorf("download not found: %s", id)
+			return fmt.Errorf("%w: %s", persistence.ErrDownloadNotFound, id)
 		}
 		return fmt.Errorf("failed to get download from database: %w", err)
 	}
@@ -200,20 +299,38 @@ func (s *Service) OrganizeDownload(ctx context.Context, id string) error {
 	download, err := s.downloadRepo.GetByID(ctx, id)
 	if err != nil {
 		if strings.Contains(err.Error(), "no rows") || strings.Contains(err.Error(), "not found") {
-			return fmt.Errorf("download not found: %s", id)
+			return fmt.Errorf("%w: %s", persistence.ErrDownloadNotFound, id)
 		}
 		return fmt.Errorf("failed to get download from database: %w", err)
 	}
 
+	// The monitor's workers may be organizing the same download
+	if !s.locks.TryLock(id) {
+		return ErrOrganizeInProgress
+	}
+	defer s.locks.Unlock(id)
+
+	// Mark as organizing so an interrupted run is picked up by startup recovery
+	if err := transition(ctx, s.downloadRepo, download, models.StatusOrganizing, models.ActorUser, "organization requested"); err != nil {
+		return err
+	}
+
 	// Create organization service and organize
-	orgService := NewOrganizationService(s.qbClient, s.configService)
+	orgService := NewOrganizationService(s.qbClient, s.configService, s.journalRepo)
 	if err := orgService.Organize(ctx, download); err != nil {
+		// Record the failure on the download so it is visible in the UI, not just in the response
+		if updateErr := s.downloadRepo.UpdateError(ctx, id, err.Error()); updateErr != nil {
+			log.Printf("Failed to update download error for %s: %v", id, updateErr)
+		}
+		if updateErr := transition(ctx, s.downloadRepo, download, models.StatusFailed, models.ActorUser, err.Error()); updateErr != nil {
+			log.Printf("Failed to update download status for %s: %v", id, updateErr)
+		}
 		return fmt.Errorf("failed to organize download files: %w", err)
 	}
 
 	// Update status and path in database
-	if err := s.downloadRepo.UpdateStatus(ctx, id, models.StatusOrganized); err != nil {
-		return fmt.Errorf("failed to update download status in database: %w", err)
+	if err := transition(ctx, s.downloadRepo, download, models.StatusOrganized, models.ActorUser, "organized to "+download.OrganizedPath); err != nil {
+		return err
 	}
 
 	if err := s.downloadRepo.UpdateOrganizedPath(ctx, id, download.OrganizedPath); err != nil {
@@ -222,3 +339,184 @@ func (s *Service) OrganizeDownload(ctx context.Context, id string) error {
 
 	return nil
 }
+
+// RetryDownload retries a failed download. If qBittorrent still has the torrent, the download
+// is queued for organization with a fresh attempt budget; if the torrent disappeared, it is
+// re-added and organized once it completes again.
+// Returns the updated download and whether the torrent was re-added.
+func (s *Service) RetryDownload(ctx context.Context, id string) (*models.Download, bool, error) {
+	download, err := s.GetDownload(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if download.Status != models.StatusFailed {
+		return nil, false, fmt.Errorf("%w: status is %s, only failed downloads can be retried", ErrNotRetryable, download.Status)
+	}
+
+	readded := false
+	_, _, err = s.qbClient.GetTorrentStatus(ctx, download.QBitHash)
+	switch {
+	case errors.Is(err, qbittorrent.ErrTorrentNotFound):
+		if err := s.readdTorrent(ctx, download); err != nil {
+			if errors.Is(err, ErrNoTorrentSource) {
+				return nil, false, fmt.Errorf("%w: torrent is no longer in qBittorrent: %w", ErrNotRetryable, err)
+			}
+			return nil, false, err
+		}
+		readded = true
+	case err != nil:
+		return nil, false, fmt.Errorf("failed to check torrent in qBittorrent: %w", err)
+	default:
+		// Moved first so a worker claiming the job finds the download ready to organize
+		if err := transition(ctx, s.downloadRepo, download, models.StatusCompleted, models.ActorUser, "retry requested"); err != nil {
+			return nil, false, err
+		}
+		// Replaces any backed-off retry so the download is organized right away
+		if _, err := s.jobRepo.Schedule(ctx, id, "manual", 1, time.Now()); err != nil {
+			if revertErr := transition(ctx, s.downloadRepo, download, models.StatusFailed, models.ActorUser, "retry could not be queued"); revertErr != nil {
+				log.Printf("Failed to mark download %s failed again after its retry could not be queued: %v", id, revertErr)
+			}
+			return nil, false, fmt.Errorf("failed to queue organization: %w", err)
+		}
+	}
+
+	if err := s.downloadRepo.UpdateError(ctx, id, ""); err != nil {
+		log.Printf("Failed to clear download error for %s: %v", id, err)
+	}
+
+	download, err = s.GetDownload(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+	return download, readded, nil
+}
+
+// ListOrganizeAttempts returns a download's organization attempts, oldest first
+func (s *Service) ListOrganizeAttempts(ctx context.Context, id string) ([]*models.OrganizeJob, error) {
+	if _, err := s.GetDownload(ctx, id); err != nil {
+		return nil, err
+	}
+
+	attempts, err := s.jobRepo.ListByDownload(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organization attempts: %w", err)
+	}
+	return attempts, nil
+}
+
+// GetDownloadHistory returns a download's status transitions, oldest first
+func (s *Service) GetDownloadHistory(ctx context.Context, id string) ([]*models.DownloadEvent, error) {
+	if _, err := s.GetDownload(ctx, id); err != nil {
+		return nil, err
+	}
+
+	events, err := s.downloadRepo.ListEvents(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list download history: %w", err)
+	}
+	return events, nil
+}
+
+// ListTorrentReplacements returns the torrents a download stalled on and had replaced, oldest first
+func (s *Service) ListTorrentReplacements(ctx context.Context, id string) ([]*models.TorrentReplacement, error) {
+	if _, err := s.GetDownload(ctx, id); err != nil {
+		return nil, err
+	}
+
+	replacements, err := s.downloadRepo.ListReplacements(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replaced torrents: %w", err)
+	}
+	return replacements, nil
+}
+
+// ReaddDownload re-adds the torrent of a missing download to qBittorrent. The download goes
+// back to queued and is organized once the torrent completes again.
+func (s *Service) ReaddDownload(ctx context.Context, id string) (*models.Download, error) {
+	download, err := s.GetDownload(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if download.Status != models.StatusMissing {
+		return nil, fmt.Errorf("%w: status is %s", ErrNotMissing, download.Status)
+	}
+
+	if err := s.readdTorrent(ctx, download); err != nil {
+		return nil, err
+	}
+
+	return s.GetDownload(ctx, id)
+}
+
+// DiscardDownload deletes a missing download. Its torrent is already gone from qBittorrent.
+func (s *Service) DiscardDownload(ctx context.Context, id string) error {
+	download, err := s.GetDownload(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if download.Status != models.StatusMissing {
+		return fmt.Errorf("%w: status is %s", ErrNotMissing, download.Status)
+	}
+
+	if err := s.downloadRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete download from database: %w", err)
+	}
+
+	return nil
+}
+
+// readdTorrent adds a vanished torrent back to qBittorrent, preferring the stored .torrent file
+// over the torrent URL or magnet link, and puts the download back in the queue
+func (s *Service) readdTorrent(ctx context.Context, download *models.Download) error {
+	data, err := s.downloadRepo.GetTorrentData(ctx, download.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get stored torrent file: %w", err)
+	}
+	if len(data) == 0 && download.TorrentURL == "" && download.MagnetLink == "" {
+		return ErrNoTorrentSource
+	}
+	download.TorrentBytes = data
+
+	hash, err := s.addTorrent(ctx, download)
+	if err != nil {
+		return fmt.Errorf("failed to re-add torrent: %w", err)
+	}
+	if hash != download.QBitHash {
+		if err := s.downloadRepo.UpdateQBitHash(ctx, download.ID, hash); err != nil {
+			return fmt.Errorf("failed to update torrent hash in database: %w", err)
+		}
+	}
+
+	if err := s.downloadRepo.UpdateMissingSince(ctx, download.ID, nil); err != nil {
+		return fmt.Errorf("failed to clear missing time in database: %w", err)
+	}
+
+	// The monitor organizes it once qBittorrent reports it complete
+	if err := transition(ctx, s.downloadRepo, download, models.StatusQueued, models.ActorUser, "torrent re-added to qBittorrent"); err != nil {
+		return err
+	}
+
+	if err := s.downloadRepo.UpdateError(ctx, download.ID, ""); err != nil {
+		log.Printf("Failed to clear download error for %s: %v", download.ID, err)
+	}
+
+	return nil
+}
+
+// PingDatabase checks the database connection is alive
+func (s *Service) PingDatabase(ctx context.Context) error {
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+	return nil
+}
+
+// PingQBittorrent checks qBittorrent is reachable with the configured credentials. It reuses
+// the client's session rather than logging in on every check.
+func (s *Service) PingQBittorrent(ctx context.Context) error {
+	_, err := s.qbClient.Version(ctx)
+	return err
+}
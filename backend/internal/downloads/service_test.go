@@ -0,0 +1,176 @@
+package downloads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/qbittorrent"
+)
+
+// newRetryService builds a Service for dl around a fake qBittorrent with the given torrents
+func newRetryService(t *testing.T, dl *models.Download, torrents []qbittorrent.TorrentInfo) (*Service, *mockDownloadRepo, *mockJobRepo, *[]string) {
+	t.Helper()
+
+	qb, added := newFakeQBittorrent(t, torrents)
+
+	repo := newMockDownloadRepo()
+	repo.getByIDFunc = func(ctx context.Context, id string) (*models.Download, error) {
+		copied := *dl
+		return &copied, nil
+	}
+	jobs := &mockJobRepo{}
+
+	return &Service{qbClient: qb, downloadRepo: repo, jobRepo: jobs, locks: NewDownloadLocks()}, repo, jobs, added
+}
+
+func TestRetryDownload_QueuesOrganization(t *testing.T) {
+	dl := &models.Download{ID: "dl-1", QBitHash: "abc", Status: models.StatusFailed, ErrorMessage: "disk full"}
+	svc, repo, jobs, added := newRetryService(t, dl, []qbittorrent.TorrentInfo{{Hash: "abc", State: "stalledUP"}})
+
+	// A backed-off retry is replaced by an immediate one
+	_, _ = jobs.Schedule(context.Background(), "dl-1", "retry", 4, time.Now().Add(time.Hour))
+
+	_, readded, err := svc.RetryDownload(context.Background(), "dl-1")
+	if err != nil {
+		t.Fatalf("RetryDownload() error = %v", err)
+	}
+	if readded || len(*added) != 0 {
+		t.Error("torrent still in qBittorrent must not be re-added")
+	}
+
+	if len(jobs.jobs) != 1 {
+		t.Fatalf("expected 1 job, got %d", len(jobs.jobs))
+	}
+	job := jobs.jobs[0]
+	if job.Attempt != 1 || job.Source != "manual" || job.RunAfter.After(time.Now()) {
+		t.Errorf("job = %+v, want an immediate first attempt", job)
+	}
+	if repo.statusUpdates["dl-1"] != models.StatusCompleted {
+		t.Errorf("status = %s, want completed", repo.statusUpdates["dl-1"])
+	}
+	if msg, ok := repo.errorUpdates["dl-1"]; !ok || msg != "" {
+		t.Errorf("expected error message to be cleared, got %q", msg)
+	}
+}
+
+func TestRetryDownload_StatusChanged(t *testing.T) {
+	dl := &models.Download{ID: "dl-1", QBitHash: "abc", Status: models.StatusFailed}
+	svc, repo, jobs, _ := newRetryService(t, dl, []qbittorrent.TorrentInfo{{Hash: "abc", State: "stalledUP"}})
+	// Another retry moved the download on first
+	repo.updateStatusFunc = func(ctx context.Context, id string, status models.DownloadStatus) error {
+		return fmt.Errorf("download %s is no longer failed", id)
+	}
+
+	if _, _, err := svc.RetryDownload(context.Background(), "dl-1"); err == nil {
+		t.Fatal("RetryDownload() succeeded, want the status conflict")
+	}
+	if len(jobs.jobs) != 0 {
+		t.Errorf("expected no organization job, got %d", len(jobs.jobs))
+	}
+}
+
+func TestRetryDownload_ReaddsMissingTorrent(t *testing.T) {
+	magnet := "magnet:?xt=urn:btih:0123456789abcdef0123456789abcdef01234567&dn=book"
+	dl := &models.Download{ID: "dl-1", QBitHash: "old", MagnetLink: magnet, Status: models.StatusFailed}
+	svc, repo, jobs, added := newRetryService(t, dl, nil)
+
+	_, readded, err := svc.RetryDownload(context.Background(), "dl-1")
+	if err != nil {
+		t.Fatalf("RetryDownload() error = %v", err)
+	}
+	if !readded || len(*added) != 1 || (*added)[0] != magnet {
+		t.Fatalf("expected magnet to be re-added, got readded=%v added=%v", readded, *added)
+	}
+	if repo.hashUpdates["dl-1"] != "0123456789abcdef0123456789abcdef01234567" {
+		t.Errorf("hash = %q, want the re-added torrent's hash", repo.hashUpdates["dl-1"])
+	}
+	if repo.statusUpdates["dl-1"] != models.StatusQueued {
+		t.Errorf("status = %s, want queued", repo.statusUpdates["dl-1"])
+	}
+	if len(jobs.jobs) != 0 {
+		t.Error("re-added torrent must be organized by the monitor once complete, not queued now")
+	}
+}
+
+func TestRetryDownload_NotRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		dl   *models.Download
+	}{
+		{"not failed", &models.Download{ID: "dl-1", QBitHash: "abc", Status: models.StatusOrganized}},
+		{"nothing to re-add", &models.Download{ID: "dl-1", QBitHash: "abc", Status: models.StatusFailed}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _, _, _ := newRetryService(t, tt.dl, nil)
+			if _, _, err := svc.RetryDownload(context.Background(), "dl-1"); !errors.Is(err, ErrNotRetryable) {
+				t.Errorf("RetryDownload() error = %v, want ErrNotRetryable", err)
+			}
+		})
+	}
+}
+
+func TestReaddDownload_PrefersStoredTorrentFile(t *testing.T) {
+	dl := &models.Download{ID: "dl-1", QBitHash: "old", TorrentURL: "https://example.com/book.torrent", Status: models.StatusMissing}
+	svc, repo, _, added := newRetryService(t, dl, nil)
+	repo.torrentData["dl-1"] = []byte("torrent-bytes")
+
+	if _, err := svc.ReaddDownload(context.Background(), "dl-1"); err != nil {
+		t.Fatalf("ReaddDownload() error = %v", err)
+	}
+
+	if len(*added) != 1 || (*added)[0] != "file:torrent-bytes" {
+		t.Fatalf("expected stored torrent file to be uploaded, got %v", *added)
+	}
+	if repo.hashUpdates["dl-1"] != "uploaded" {
+		t.Errorf("hash = %q, want uploaded", repo.hashUpdates["dl-1"])
+	}
+	if repo.statusUpdates["dl-1"] != models.StatusQueued {
+		t.Errorf("status = %s, want queued", repo.statusUpdates["dl-1"])
+	}
+	if since, ok := repo.missingUpdates["dl-1"]; !ok || since != nil {
+		t.Errorf("expected missing time to be cleared, got %v", since)
+	}
+}
+
+func TestReaddDownload_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		dl   *models.Download
+		want error
+	}{
+		{"not missing", &models.Download{ID: "dl-1", Status: models.StatusDownloading, MagnetLink: "magnet:?xt=urn:btih:abc"}, ErrNotMissing},
+		{"nothing to re-add", &models.Download{ID: "dl-1", Status: models.StatusMissing}, ErrNoTorrentSource},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _, _, _ := newRetryService(t, tt.dl, nil)
+			if _, err := svc.ReaddDownload(context.Background(), "dl-1"); !errors.Is(err, tt.want) {
+				t.Errorf("ReaddDownload() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscardDownload(t *testing.T) {
+	dl := &models.Download{ID: "dl-1", Status: models.StatusMissing}
+	svc, repo, _, _ := newRetryService(t, dl, nil)
+
+	if err := svc.DiscardDownload(context.Background(), "dl-1"); err != nil {
+		t.Fatalf("DiscardDownload() error = %v", err)
+	}
+	if len(repo.deleted) != 1 || repo.deleted[0] != "dl-1" {
+		t.Errorf("expected dl-1 to be deleted, got %v", repo.deleted)
+	}
+
+	dl.Status = models.StatusQueued
+	if err := svc.DiscardDownload(context.Background(), "dl-1"); !errors.Is(err, ErrNotMissing) {
+		t.Errorf("DiscardDownload() error = %v, want ErrNotMissing", err)
+	}
+}
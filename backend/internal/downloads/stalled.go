@@ -0,0 +1,279 @@
+package downloads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/quality"
+	"github.com/nathanael/organizr/internal/search"
+	"github.com/nathanael/organizr/internal/search/providers"
+)
+
+// ErrNoAlternative is returned when a stalled download's search finds no better release
+var ErrNoAlternative = errors.New("no alternative release found")
+
+// torrentSearcher interface defines the methods we need to find alternative releases
+type torrentSearcher interface {
+	Search(ctx context.Context, q providers.Query) (*search.Results, error)
+	DownloadTorrent(ctx context.Context, provider, torrentID string) ([]byte, error)
+}
+
+// qualityProfiles interface defines the methods we need to rank alternative releases
+type qualityProfiles interface {
+	Default(ctx context.Context) (*models.QualityProfile, error)
+}
+
+// stallableStates are the qBittorrent states of torrents that should be making progress.
+// Queued and paused torrents wait on purpose and are never flagged.
+var stallableStates = map[string]bool{
+	"downloading":  true,
+	"forcedDL":     true,
+	"stalledDL":    true,
+	"metaDL":       true,
+	"forcedMetaDL": true,
+}
+
+// isStalled reports whether a download in qBittorrent state has gone without progress for
+// longer than the stall threshold. progress is what qBittorrent reports now; dl still holds
+// the previous poll's progress.
+func (m *Monitor) isStalled(dl *models.Download, state string, progress float64) bool {
+	if m.stalledAfter <= 0 || !stallableStates[state] || progress > dl.Progress {
+		return false
+	}
+
+	since := dl.CreatedAt
+	if dl.ProgressAt != nil {
+		since = *dl.ProgressAt
+	}
+	if since.IsZero() {
+		return false
+	}
+	return time.Since(since) >= m.stalledAfter
+}
+
+// handleStalled records why a download was marked stalled and, if enabled, swaps in an
+// alternative release
+func (m *Monitor) handleStalled(ctx context.Context, dl *models.Download) {
+	message := fmt.Sprintf("no download progress for %s", m.stalledAfter)
+	if err := m.downloadRepo.UpdateError(ctx, dl.ID, message); err != nil {
+		log.Printf("Failed to update download error for %s: %v", dl.ID, err)
+	}
+
+	replace, err := m.configService.Get(ctx, "monitor.replace_stalled")
+	if err != nil || replace != "true" {
+		return
+	}
+
+	if err := m.replaceStalledTorrent(ctx, dl); err != nil {
+		log.Printf("Could not replace stalled torrent of download %s (%s): %v", dl.ID, dl.Title, err)
+		if updateErr := m.downloadRepo.UpdateError(ctx, dl.ID, fmt.Sprintf("%s; could not replace torrent: %v", message, err)); updateErr != nil {
+			log.Printf("Failed to update download error for %s: %v", dl.ID, updateErr)
+		}
+	}
+}
+
+// replaceStalledTorrent re-runs the search that found a stalled download's torrent and swaps
+// the torrent for the best-seeded release of the same book. The alternative is added and
+// recorded before the stalled torrent is removed from qBittorrent, so a failure part way
+// leaves the download on a torrent that exists. The stalled torrent's partial files are
+// deleted too unless the alternative saves to the same path. The download goes back to queued.
+func (m *Monitor) replaceStalledTorrent(ctx context.Context, dl *models.Download) error {
+	if m.searcher == nil {
+		return fmt.Errorf("search is not configured")
+	}
+
+	// Polled downloads don't carry where their torrent came from
+	full, err := m.downloadRepo.GetByID(ctx, dl.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load download: %w", err)
+	}
+
+	// Repeat the search the download was grabbed from, or search for the book itself
+	query := providers.Query{Text: full.SearchQuery, MediaType: full.MediaType}
+	if query.Text == "" {
+		query.Title = full.Title
+		query.Author = full.Author
+	}
+
+	// Cached results would hide how the alternatives' seeders have changed since
+	results, err := m.searcher.Search(search.BypassCache(ctx), query)
+	if err != nil {
+		return fmt.Errorf("failed to search for alternatives: %w", err)
+	}
+	for _, failure := range results.Failures {
+		log.Printf("Provider %s failed searching alternatives for download %s: %v", failure.Provider, dl.ID, failure.Err)
+	}
+
+	// Never go back to a torrent that already stalled
+	exclude := map[string]bool{}
+	if full.TorrentID != "" {
+		exclude[torrentKey(full.Provider, full.TorrentID)] = true
+	}
+	replaced, err := m.downloadRepo.ListReplacements(ctx, dl.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list replaced torrents: %w", err)
+	}
+	for _, r := range replaced {
+		if r.OldTorrentID != "" {
+			exclude[torrentKey(r.OldProvider, r.OldTorrentID)] = true
+		}
+	}
+
+	var profile *models.QualityProfile
+	if m.profiles != nil {
+		if profile, err = m.profiles.Default(ctx); err != nil {
+			log.Printf("Failed to load quality profile for download %s, picking by seeders: %v", dl.ID, err)
+		}
+	}
+
+	best := pickAlternative(full, results.Results, exclude, profile)
+	if best == nil {
+		return ErrNoAlternative
+	}
+
+	data, err := m.searcher.DownloadTorrent(ctx, best.Provider, best.ID)
+	if err != nil {
+		return fmt.Errorf("failed to download alternative torrent: %w", err)
+	}
+
+	hash, err := m.qbClient.AddTorrentFromFile(ctx, data, full.Category)
+	if err != nil {
+		return fmt.Errorf("failed to add alternative torrent: %w", err)
+	}
+
+	replacement := &models.TorrentReplacement{
+		DownloadID:   dl.ID,
+		OldHash:      full.QBitHash,
+		OldProvider:  full.Provider,
+		OldTorrentID: full.TorrentID,
+		NewHash:      hash,
+		NewProvider:  best.Provider,
+		NewTorrentID: best.ID,
+		Reason:       fmt.Sprintf("no download progress for %s; %q has %d seeders", m.stalledAfter, best.Title, best.Seeders),
+	}
+	if err := m.downloadRepo.ReplaceTorrent(ctx, replacement, data); err != nil {
+		return fmt.Errorf("failed to record replaced torrent: %w", err)
+	}
+
+	// The download points at the alternative now, so a failed removal only leaves a stray torrent
+	if err := m.qbClient.DeleteTorrent(ctx, full.QBitHash, m.deletesStalledFiles(ctx, full.QBitHash, hash)); err != nil {
+		log.Printf("Failed to remove stalled torrent %s of download %s: %v", full.QBitHash, dl.ID, err)
+	}
+
+	reason := fmt.Sprintf("stalled torrent replaced with %q (torrent %s, %d seeders)", best.Title, best.ID, best.Seeders)
+	if err := transition(ctx, m.downloadRepo, dl, models.StatusQueued, models.ActorMonitor, reason); err != nil {
+		return err
+	}
+	if err := m.downloadRepo.UpdateError(ctx, dl.ID, ""); err != nil {
+		log.Printf("Failed to clear download error for %s: %v", dl.ID, err)
+	}
+
+	log.Printf("Replaced stalled torrent %s of download %s (%s) with torrent %s (%d seeders)", full.QBitHash, dl.ID, dl.Title, best.ID, best.Seeders)
+	return nil
+}
+
+// pickAlternative returns the result that is the same book as dl and ranks best by profile,
+// or is best-seeded without one, excluding torrents whose torrentKey is in exclude and
+// releases the profile rejects. If the current torrent is among the results, an alternative
+// must have more seeders than it. Returns nil if nothing qualifies.
+func pickAlternative(dl *models.Download, results []*models.SearchResult, exclude map[string]bool, profile *models.QualityProfile) *models.SearchResult {
+	current := torrentKey(dl.Provider, dl.TorrentID)
+	minSeeders := 1
+	for _, r := range results {
+		if torrentKey(r.Provider, r.ID) == current && r.Seeders >= minSeeders {
+			minSeeders = r.Seeders + 1
+		}
+	}
+
+	var candidates []*models.SearchResult
+	for _, r := range results {
+		if exclude[torrentKey(r.Provider, r.ID)] || r.Seeders < minSeeders || !sameBook(dl, r) {
+			continue
+		}
+		if r.MediaType != "" && dl.MediaType != "" && r.MediaType != dl.MediaType {
+			continue
+		}
+		candidates = append(candidates, r)
+	}
+
+	if profile != nil {
+		return quality.Best(profile, candidates)
+	}
+
+	var best *models.SearchResult
+	for _, r := range candidates {
+		if best == nil || r.Seeders > best.Seeders {
+			best = r
+		}
+	}
+	return best
+}
+
+// torrentKey identifies a torrent across providers. Downloads from before there were several
+// providers have no provider and came from MyAnonamouse.
+func torrentKey(provider, torrentID string) string {
+	if provider == "" {
+		provider = providers.MyAnonamouseName
+	}
+	return provider + ":" + torrentID
+}
+
+// sameBook reports whether a search result looks like the same book as a download
+func sameBook(dl *models.Download, r *models.SearchResult) bool {
+	return MatchesBook(dl.Title, dl.Author, r)
+}
+
+// MatchesBook reports whether a search result looks like the book with the given title and
+// author. Titles match if one contains the other, allowing for edition notes like
+// "(Unabridged)"; authors must match the same way when both are known.
+func MatchesBook(title, author string, r *models.SearchResult) bool {
+	title, other := normalizeForMatch(title), normalizeForMatch(r.Title)
+	if title == "" || other == "" || (!strings.Contains(other, title) && !strings.Contains(title, other)) {
+		return false
+	}
+
+	author, otherAuthor := normalizeForMatch(author), normalizeForMatch(r.Author)
+	if author == "" || otherAuthor == "" {
+		return true
+	}
+	return strings.Contains(otherAuthor, author) || strings.Contains(author, otherAuthor)
+}
+
+// normalizeForMatch lowercases s and reduces it to words of letters and digits
+func normalizeForMatch(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// deletesStalledFiles reports whether a stalled torrent's partial files can be deleted along
+// with it. Files are kept when its replacement saves to the same path, where the partial
+// files may be the replacement's too, or when the save paths can't be compared.
+func (m *Monitor) deletesStalledFiles(ctx context.Context, stalledHash, replacementHash string) bool {
+	torrents, err := m.qbClient.ListTorrents(ctx, "", "")
+	if err != nil {
+		log.Printf("Failed to look up save paths, keeping the files of stalled torrent %s: %v", stalledHash, err)
+		return false
+	}
+
+	savePaths := map[string]string{}
+	for _, torrent := range torrents {
+		savePaths[torrent.Hash] = torrent.SavePath
+	}
+	stalledPath, ok := savePaths[stalledHash]
+	if !ok {
+		return false
+	}
+	replacementPath, ok := savePaths[replacementHash]
+	if !ok {
+		return false
+	}
+	return stalledPath != replacementPath
+}
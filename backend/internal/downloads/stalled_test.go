@@ -0,0 +1,305 @@
+package downloads
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nathanael/organizr/internal/config"
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/qbittorrent"
+	"github.com/nathanael/organizr/internal/search"
+	"github.com/nathanael/organizr/internal/search/providers"
+)
+
+// fakeSearcher returns fixed results and serves "torrent-<id>" as each torrent's data
+type fakeSearcher struct {
+	results    []*models.SearchResult
+	err        error
+	queries    []string
+	downloaded []string
+}
+
+func (f *fakeSearcher) Search(ctx context.Context, q providers.Query) (*search.Results, error) {
+	f.queries = append(f.queries, q.FullText())
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &search.Results{Results: f.results}, nil
+}
+
+func (f *fakeSearcher) DownloadTorrent(ctx context.Context, provider, torrentID string) ([]byte, error) {
+	f.downloaded = append(f.downloaded, torrentID)
+	return []byte("torrent-" + torrentID), nil
+}
+
+func newStalledMonitor(qb *qbittorrent.Client, repo *mockDownloadRepo, searcher torrentSearcher, replace bool) *Monitor {
+	m := newReconcileMonitor(qb, repo)
+	m.stalledAfter = time.Hour
+	m.searcher = searcher
+	m.configService = config.NewService(newMockConfigService(map[string]string{
+		"monitor.replace_stalled": strconv.FormatBool(replace),
+	}))
+	return m
+}
+
+func TestIsStalled(t *testing.T) {
+	m := &Monitor{stalledAfter: time.Hour}
+	longAgo := time.Now().Add(-2 * time.Hour)
+	recently := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name     string
+		dl       *models.Download
+		state    string
+		progress float64
+		want     bool
+	}{
+		{"no progress past threshold", &models.Download{Progress: 0.2, ProgressAt: &longAgo}, "stalledDL", 0.2, true},
+		{"recent progress", &models.Download{Progress: 0.2, ProgressAt: &recently}, "downloading", 0.2, false},
+		{"progress this poll", &models.Download{Progress: 0.2, ProgressAt: &longAgo}, "downloading", 0.3, false},
+		{"never progressed, created long ago", &models.Download{CreatedAt: longAgo}, "metaDL", 0, true},
+		{"never progressed, created recently", &models.Download{CreatedAt: recently}, "metaDL", 0, false},
+		{"queued in qBittorrent", &models.Download{ProgressAt: &longAgo}, "queuedDL", 0, false},
+		{"paused in qBittorrent", &models.Download{ProgressAt: &longAgo}, "pausedDL", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.isStalled(tt.dl, tt.state, tt.progress); got != tt.want {
+				t.Errorf("isStalled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	disabled := &Monitor{}
+	if disabled.isStalled(&models.Download{ProgressAt: &longAgo}, "stalledDL", 0) {
+		t.Error("stall detection must be off when no threshold is set")
+	}
+}
+
+func TestPickAlternative(t *testing.T) {
+	dl := &models.Download{Title: "The Way of Kings", Author: "Brandon Sanderson", MediaType: models.MediaTypeAudiobook, TorrentID: "1"}
+	results := []*models.SearchResult{
+		{ID: "1", Title: "The Way of Kings", Author: "Brandon Sanderson", MediaType: models.MediaTypeAudiobook, Seeders: 3},
+		{ID: "2", Title: "The Way of Kings (Unabridged)", Author: "Brandon Sanderson", MediaType: models.MediaTypeAudiobook, Seeders: 12},
+		{ID: "3", Title: "The Way of Kings", Author: "Brandon Sanderson", MediaType: models.MediaTypeEbook, Seeders: 50},
+		{ID: "4", Title: "Words of Radiance", Author: "Brandon Sanderson", MediaType: models.MediaTypeAudiobook, Seeders: 40},
+		{ID: "5", Title: "The Way of Kings", Author: "Someone Else", MediaType: models.MediaTypeAudiobook, Seeders: 30},
+		{ID: "6", Title: "the way of kings", Author: "B. Sanderson", MediaType: models.MediaTypeAudiobook, Seeders: 2},
+	}
+
+	best := pickAlternative(dl, results, map[string]bool{torrentKey("", "1"): true}, nil)
+	if best == nil || best.ID != "2" {
+		t.Fatalf("pickAlternative() = %+v, want result 2", best)
+	}
+
+	// Excluded torrents are skipped even if best seeded
+	if best := pickAlternative(dl, results, map[string]bool{torrentKey("", "1"): true, torrentKey("", "2"): true}, nil); best != nil {
+		t.Errorf("pickAlternative() = %+v, want nil since the rest are other books, formats or too few seeders", best)
+	}
+
+	// The same ID from another provider is a different torrent
+	other := []*models.SearchResult{{ID: "1", Provider: "Prowlarr", Title: "The Way of Kings", Seeders: 2}}
+	if best := pickAlternative(dl, other, map[string]bool{torrentKey("", "1"): true}, nil); best == nil || best.Provider != "Prowlarr" {
+		t.Errorf("pickAlternative() = %+v, want the other provider's torrent", best)
+	}
+
+	// Dead torrents are never picked
+	dead := []*models.SearchResult{{ID: "7", Title: "The Way of Kings", Seeders: 0}}
+	if best := pickAlternative(&models.Download{Title: "The Way of Kings"}, dead, nil, nil); best != nil {
+		t.Errorf("pickAlternative() = %+v, want nil for a release without seeders", best)
+	}
+
+	// A quality profile ranks the alternatives instead of seeders, and its rejects are skipped
+	profile := &models.QualityProfile{PreferredFormats: []string{"m4b"}, BlockedUploaders: []string{"spammer"}}
+	formats := []*models.SearchResult{
+		{ID: "8", Title: "The Way of Kings", FileType: "mp3", Seeders: 40},
+		{ID: "9", Title: "The Way of Kings", FileType: "m4b", Seeders: 6, Uploader: "spammer"},
+		{ID: "10", Title: "The Way of Kings", FileType: "m4b", Seeders: 5},
+	}
+	if best := pickAlternative(&models.Download{Title: "The Way of Kings"}, formats, nil, profile); best == nil || best.ID != "10" {
+		t.Errorf("pickAlternative() = %+v, want the preferred format from an allowed uploader", best)
+	}
+}
+
+func TestReplaceStalledTorrent(t *testing.T) {
+	dl := &models.Download{
+		ID: "dl-1", Title: "The Way of Kings", Author: "Brandon Sanderson", MediaType: models.MediaTypeAudiobook,
+		QBitHash: "old", TorrentID: "1", SearchQuery: "way of kings", Category: "books", Status: models.StatusStalled,
+	}
+	qb, added := newFakeQBittorrent(t, []qbittorrent.TorrentInfo{{Hash: "old", State: "stalledDL", SavePath: "/downloads/old"}},
+		withDefaultSavePath("/downloads/books"))
+
+	repo := newMockDownloadRepo()
+	repo.getByIDFunc = func(ctx context.Context, id string) (*models.Download, error) {
+		copied := *dl
+		return &copied, nil
+	}
+	searcher := &fakeSearcher{results: []*models.SearchResult{
+		{ID: "1", Title: "The Way of Kings", Author: "Brandon Sanderson", Seeders: 1},
+		{ID: "2", Title: "The Way of Kings", Author: "Brandon Sanderson", Seeders: 9},
+	}}
+
+	m := newStalledMonitor(qb, repo, searcher, true)
+	if err := m.replaceStalledTorrent(context.Background(), dl); err != nil {
+		t.Fatalf("replaceStalledTorrent() error = %v", err)
+	}
+
+	// The original search is re-run rather than the title
+	if len(searcher.queries) != 1 || searcher.queries[0] != "way of kings" {
+		t.Errorf("queries = %v, want the download's search query", searcher.queries)
+	}
+
+	// The stalled torrent and its partial files are removed once the alternative is added
+	want := []string{"file:torrent-2", "delete-files:old"}
+	if strings.Join(*added, ",") != strings.Join(want, ",") {
+		t.Errorf("qBittorrent calls = %v, want %v", *added, want)
+	}
+
+	if len(repo.replacements) != 1 {
+		t.Fatalf("expected 1 replacement, got %d", len(repo.replacements))
+	}
+	r := repo.replacements[0]
+	if r.OldHash != "old" || r.OldTorrentID != "1" || r.NewHash != "uploaded" || r.NewTorrentID != "2" {
+		t.Errorf("replacement = %+v", r)
+	}
+	if string(repo.torrentData["dl-1"]) != "torrent-2" {
+		t.Errorf("torrent data = %q, want the alternative's", repo.torrentData["dl-1"])
+	}
+	if repo.statusUpdates["dl-1"] != models.StatusQueued {
+		t.Errorf("status = %s, want queued", repo.statusUpdates["dl-1"])
+	}
+	if msg, ok := repo.errorUpdates["dl-1"]; !ok || msg != "" {
+		t.Errorf("error message = %q, want it cleared", msg)
+	}
+
+	// A second stall never goes back to a torrent that was already replaced
+	dl.Status = models.StatusStalled
+	dl.QBitHash, dl.TorrentID = "uploaded", "2"
+	err := m.replaceStalledTorrent(context.Background(), dl)
+	if !errors.Is(err, ErrNoAlternative) {
+		t.Errorf("replaceStalledTorrent() error = %v, want ErrNoAlternative", err)
+	}
+}
+
+func TestReplaceStalledTorrent_SharedSavePath(t *testing.T) {
+	dl := &models.Download{
+		ID: "dl-1", Title: "The Way of Kings", Author: "Brandon Sanderson",
+		QBitHash: "old", TorrentID: "1", Category: "books", Status: models.StatusStalled,
+	}
+	qb, added := newFakeQBittorrent(t, []qbittorrent.TorrentInfo{{Hash: "old", State: "stalledDL", SavePath: "/downloads/books"}},
+		withDefaultSavePath("/downloads/books"))
+
+	repo := newMockDownloadRepo()
+	repo.getByIDFunc = func(ctx context.Context, id string) (*models.Download, error) {
+		copied := *dl
+		return &copied, nil
+	}
+	searcher := &fakeSearcher{results: []*models.SearchResult{
+		{ID: "2", Title: "The Way of Kings", Author: "Brandon Sanderson", Seeders: 9},
+	}}
+
+	m := newStalledMonitor(qb, repo, searcher, true)
+	if err := m.replaceStalledTorrent(context.Background(), dl); err != nil {
+		t.Fatalf("replaceStalledTorrent() error = %v", err)
+	}
+
+	// The partial files may be the alternative's too, so only the torrent goes
+	want := []string{"file:torrent-2", "delete:old"}
+	if strings.Join(*added, ",") != strings.Join(want, ",") {
+		t.Errorf("qBittorrent calls = %v, want %v", *added, want)
+	}
+}
+
+func TestCheckDownloads_ReplacementNotStalledAgain(t *testing.T) {
+	longAgo := time.Now().Add(-2 * time.Hour)
+	qb, added := newFakeQBittorrent(t, []qbittorrent.TorrentInfo{{Hash: "old", State: "stalledDL"}}, withAddedState("metaDL"))
+
+	repo := newMockDownloadRepo()
+	repo.created = []*models.Download{{
+		ID: "dl-1", Title: "The Way of Kings", Author: "Brandon Sanderson", QBitHash: "old", TorrentID: "1",
+		Status: models.StatusDownloading, CreatedAt: longAgo,
+	}}
+	// Polls see the download as stored, status and replaced torrent included
+	current := func() *models.Download {
+		copied := *repo.created[0]
+		if status, ok := repo.statusUpdates["dl-1"]; ok {
+			copied.Status = status
+		}
+		return &copied
+	}
+	repo.getActiveFunc = func(ctx context.Context) ([]*models.Download, error) {
+		return []*models.Download{current()}, nil
+	}
+	repo.getByIDFunc = func(ctx context.Context, id string) (*models.Download, error) {
+		return current(), nil
+	}
+	searcher := &fakeSearcher{results: []*models.SearchResult{
+		{ID: "2", Title: "The Way of Kings", Author: "Brandon Sanderson", Seeders: 9},
+		{ID: "3", Title: "The Way of Kings", Author: "Brandon Sanderson", Seeders: 5},
+	}}
+	m := newStalledMonitor(qb, repo, searcher, true)
+
+	if err := m.checkDownloads(context.Background()); err != nil {
+		t.Fatalf("checkDownloads() error = %v", err)
+	}
+	if len(repo.replacements) != 1 || repo.statusUpdates["dl-1"] != models.StatusQueued {
+		t.Fatalf("replacements = %d, status = %s, want the stalled torrent replaced", len(repo.replacements), repo.statusUpdates["dl-1"])
+	}
+
+	// The replacement is still fetching metadata on the next poll, which isn't a stall
+	if err := m.checkDownloads(context.Background()); err != nil {
+		t.Fatalf("checkDownloads() error = %v", err)
+	}
+	if repo.statusUpdates["dl-1"] != models.StatusDownloading {
+		t.Errorf("status = %s, want downloading", repo.statusUpdates["dl-1"])
+	}
+	if len(repo.replacements) != 1 || len(searcher.downloaded) != 1 {
+		t.Errorf("replacements = %d, qBittorrent calls = %v, want the replacement kept", len(repo.replacements), *added)
+	}
+}
+
+func TestCheckDownloads_Stalled(t *testing.T) {
+	longAgo := time.Now().Add(-2 * time.Hour)
+
+	qb, added := newFakeQBittorrent(t, []qbittorrent.TorrentInfo{
+		{Hash: "stuck", State: "stalledDL", Progress: 0.1},
+		{Hash: "moving", State: "downloading", Progress: 0.6},
+	})
+
+	repo := newMockDownloadRepo()
+	repo.getActiveFunc = func(ctx context.Context) ([]*models.Download, error) {
+		return []*models.Download{
+			{ID: "stuck", QBitHash: "stuck", Status: models.StatusDownloading, Progress: 10, ProgressAt: &longAgo},
+			{ID: "moving", QBitHash: "moving", Status: models.StatusStalled, Progress: 50, ProgressAt: &longAgo},
+		}, nil
+	}
+
+	m := newStalledMonitor(qb, repo, &fakeSearcher{}, false)
+	if err := m.checkDownloads(context.Background()); err != nil {
+		t.Fatalf("checkDownloads() error = %v", err)
+	}
+
+	if repo.statusUpdates["stuck"] != models.StatusStalled {
+		t.Errorf("stuck status = %s, want stalled", repo.statusUpdates["stuck"])
+	}
+	if !strings.Contains(repo.errorUpdates["stuck"], "no download progress") {
+		t.Errorf("stuck error = %q, want the stall reason", repo.errorUpdates["stuck"])
+	}
+	// Replacement is off, so the torrent is left alone
+	if len(*added) != 0 {
+		t.Errorf("qBittorrent calls = %v, want none", *added)
+	}
+
+	// Progress moves a stalled download back to downloading
+	if repo.statusUpdates["moving"] != models.StatusDownloading {
+		t.Errorf("moving status = %s, want downloading", repo.statusUpdates["moving"])
+	}
+	if msg, ok := repo.errorUpdates["moving"]; !ok || msg != "" {
+		t.Errorf("moving error = %q, want it cleared", msg)
+	}
+}
@@ -0,0 +1,81 @@
+package downloads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/persistence"
+)
+
+// ErrInvalidTransition is returned when a download can't move from its status to the requested one
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// transitions lists the statuses each status may move to
+var transitions = map[models.DownloadStatus][]models.DownloadStatus{
+	// qBittorrent moves torrents between its queue and downloading, and re-checks finished ones
+	models.StatusQueued:      {models.StatusDownloading, models.StatusStalled, models.StatusCompleted, models.StatusMissing, models.StatusFailed},
+	models.StatusDownloading: {models.StatusQueued, models.StatusStalled, models.StatusCompleted, models.StatusMissing, models.StatusFailed},
+	// Stalled downloads resume, or go back to the queue with an alternative torrent
+	models.StatusStalled:    {models.StatusDownloading, models.StatusQueued, models.StatusCompleted, models.StatusMissing, models.StatusFailed},
+	models.StatusCompleted:  {models.StatusDownloading, models.StatusOrganizing, models.StatusMissing, models.StatusFailed},
+	models.StatusOrganizing: {models.StatusOrganized, models.StatusFailed},
+	// Organized downloads may be organized again on request
+	models.StatusOrganized: {models.StatusOrganizing},
+	// Failed downloads are organized again, retried or re-added
+	models.StatusFailed: {models.StatusOrganizing, models.StatusCompleted, models.StatusQueued},
+	// Missing downloads are re-added or discarded
+	models.StatusMissing: {models.StatusQueued},
+}
+
+// CanTransition reports whether a download may move from one status to another
+func CanTransition(from, to models.DownloadStatus) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// transition moves a download to a new status if that is legal from its current one, recording
+// who moved it and why in its history, and updates dl to match. Moving to the status it already
+// has is a no-op. The update only applies if the stored status still matches dl's, so a stale
+// copy can't skip a state.
+func transition(ctx context.Context, repo persistence.DownloadRepository, dl *models.Download, to models.DownloadStatus, actor models.EventActor, reason string) error {
+	if dl.Status == to {
+		return nil
+	}
+	if !CanTransition(dl.Status, to) {
+		return fmt.Errorf("%w: %s → %s", ErrInvalidTransition, dl.Status, to)
+	}
+
+	event := &models.DownloadEvent{
+		DownloadID: dl.ID,
+		FromStatus: dl.Status,
+		ToStatus:   to,
+		Actor:      actor,
+		Reason:     reason,
+	}
+	if err := repo.TransitionStatus(ctx, event); err != nil {
+		return fmt.Errorf("failed to update download status: %w", err)
+	}
+
+	dl.Status = to
+	return nil
+}
+
+// recordCreated records the creation of a download as the first event of its history
+func recordCreated(ctx context.Context, repo persistence.DownloadRepository, dl *models.Download, actor models.EventActor, reason string) {
+	event := &models.DownloadEvent{
+		DownloadID: dl.ID,
+		ToStatus:   dl.Status,
+		Actor:      actor,
+		Reason:     reason,
+	}
+	if err := repo.AddEvent(ctx, event); err != nil {
+		log.Printf("Failed to record creation of download %s: %v", dl.ID, err)
+	}
+}
@@ -0,0 +1,70 @@
+package downloads
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nathanael/organizr/internal/models"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to models.DownloadStatus
+		want     bool
+	}{
+		{models.StatusQueued, models.StatusDownloading, true},
+		{models.StatusDownloading, models.StatusCompleted, true},
+		{models.StatusCompleted, models.StatusOrganizing, true},
+		{models.StatusOrganizing, models.StatusOrganized, true},
+		{models.StatusOrganizing, models.StatusFailed, true},
+		{models.StatusFailed, models.StatusOrganizing, true},
+		{models.StatusFailed, models.StatusCompleted, true},
+		{models.StatusMissing, models.StatusQueued, true},
+		{models.StatusFailed, models.StatusOrganized, false},
+		{models.StatusQueued, models.StatusOrganizing, false},
+		{models.StatusOrganized, models.StatusQueued, false},
+		{models.StatusMissing, models.StatusOrganizing, false},
+	}
+
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestTransition(t *testing.T) {
+	ctx := context.Background()
+	repo := newMockDownloadRepo()
+	dl := &models.Download{ID: "dl-1", Status: models.StatusFailed}
+
+	// An illegal transition leaves the download alone
+	err := transition(ctx, repo, dl, models.StatusOrganized, models.ActorUser, "")
+	if !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("transition() error = %v, want ErrInvalidTransition", err)
+	}
+	if dl.Status != models.StatusFailed || len(repo.events) != 0 {
+		t.Errorf("illegal transition changed the download: status=%s events=%d", dl.Status, len(repo.events))
+	}
+
+	if err := transition(ctx, repo, dl, models.StatusOrganizing, models.ActorUser, "organization requested"); err != nil {
+		t.Fatalf("transition() error = %v", err)
+	}
+	if dl.Status != models.StatusOrganizing {
+		t.Errorf("status = %s, want organizing", dl.Status)
+	}
+
+	// Staying in the same status records nothing
+	if err := transition(ctx, repo, dl, models.StatusOrganizing, models.ActorMonitor, ""); err != nil {
+		t.Fatalf("transition() error = %v", err)
+	}
+
+	if len(repo.events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(repo.events))
+	}
+	want := models.DownloadEvent{DownloadID: "dl-1", FromStatus: models.StatusFailed, ToStatus: models.StatusOrganizing, Actor: models.ActorUser, Reason: "organization requested"}
+	if *repo.events[0] != want {
+		t.Errorf("event = %+v, want %+v", *repo.events[0], want)
+	}
+}
@@ -0,0 +1,128 @@
+package downloads
+
+import (
+	"sort"
+	"time"
+)
+
+// qbUnhealthyAfter is how many passes in a row must fail to reach qBittorrent before the
+// monitor reports itself degraded
+const qbUnhealthyAfter = 3
+
+// MonitorStatus is a snapshot of what the monitor is doing, for observability
+type MonitorStatus struct {
+	Running  bool
+	Interval time.Duration
+	// Recovering is set while organizations interrupted by the last shutdown are resumed,
+	// before polling starts
+	Recovering bool
+
+	// LastTickAt is when the last polling pass started; nil before the first one
+	LastTickAt       *time.Time
+	LastTickDuration time.Duration
+	Ticks            int64
+
+	// Counts from the last pass: downloads checked, whose state changed, and that couldn't
+	// be checked
+	Checked int
+	Changed int
+	Errored int
+
+	// ConsecutiveQBFailures counts passes in a row in which every status check failed
+	ConsecutiveQBFailures int
+	LastQBError           string
+
+	InFlight []InFlightOrganization
+}
+
+// InFlightOrganization is an organization a worker is currently running
+type InFlightOrganization struct {
+	DownloadID string
+	Title      string
+	JobID      int64
+	StartedAt  time.Time
+}
+
+// Degraded reports whether qBittorrent has been unreachable for several passes in a row
+func (s MonitorStatus) Degraded() bool {
+	return s.ConsecutiveQBFailures >= qbUnhealthyAfter
+}
+
+// Status returns a snapshot of the monitor's activity
+func (m *Monitor) Status() MonitorStatus {
+	m.statusMu.Lock()
+	defer m.statusMu.Unlock()
+
+	status := m.status
+	if status.LastTickAt != nil {
+		at := *status.LastTickAt
+		status.LastTickAt = &at
+	}
+
+	status.InFlight = make([]InFlightOrganization, 0, len(m.inFlightOrgs))
+	for _, org := range m.inFlightOrgs {
+		status.InFlight = append(status.InFlight, org)
+	}
+	sort.Slice(status.InFlight, func(i, j int) bool {
+		return status.InFlight[i].StartedAt.Before(status.InFlight[j].StartedAt)
+	})
+
+	return status
+}
+
+func (m *Monitor) setRunning(running bool, interval time.Duration) {
+	m.statusMu.Lock()
+	defer m.statusMu.Unlock()
+
+	m.status.Running = running
+	m.status.Interval = interval
+}
+
+func (m *Monitor) setRecovering(recovering bool) {
+	m.statusMu.Lock()
+	defer m.statusMu.Unlock()
+
+	m.status.Recovering = recovering
+}
+
+// recordTick records the outcome of a polling pass. A pass in which every status check
+// failed counts as a qBittorrent failure, qbErr being the last error; a pass with nothing
+// to check leaves the failure count alone.
+func (m *Monitor) recordTick(started time.Time, checked, changed, errored int, qbErr error) {
+	m.statusMu.Lock()
+	defer m.statusMu.Unlock()
+
+	m.status.LastTickAt = &started
+	m.status.LastTickDuration = time.Since(started)
+	m.status.Ticks++
+	m.status.Checked = checked
+	m.status.Changed = changed
+	m.status.Errored = errored
+
+	switch {
+	case checked > 0 && errored == checked:
+		m.status.ConsecutiveQBFailures++
+		if qbErr != nil {
+			m.status.LastQBError = qbErr.Error()
+		}
+	case checked > 0:
+		m.status.ConsecutiveQBFailures = 0
+		m.status.LastQBError = ""
+	}
+}
+
+// trackOrganization records an organization as in flight until the returned func is called
+func (m *Monitor) trackOrganization(org InFlightOrganization) (done func()) {
+	m.statusMu.Lock()
+	if m.inFlightOrgs == nil {
+		m.inFlightOrgs = make(map[int64]InFlightOrganization)
+	}
+	m.inFlightOrgs[org.JobID] = org
+	m.statusMu.Unlock()
+
+	return func() {
+		m.statusMu.Lock()
+		delete(m.inFlightOrgs, org.JobID)
+		m.statusMu.Unlock()
+	}
+}
@@ -0,0 +1,101 @@
+package downloads
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/qbittorrent"
+)
+
+func TestMonitorStatus_RecordsTicks(t *testing.T) {
+	qb, _ := newFakeQBittorrent(t, []qbittorrent.TorrentInfo{
+		{Hash: "started", State: "downloading", Progress: 0.5},
+		{Hash: "same", State: "downloading", Progress: 0.1},
+	})
+
+	repo := newMockDownloadRepo()
+	repo.getActiveFunc = func(ctx context.Context) ([]*models.Download, error) {
+		return []*models.Download{
+			{ID: "dl-1", QBitHash: "started", Status: models.StatusQueued},
+			{ID: "dl-2", QBitHash: "same", Status: models.StatusDownloading},
+		}, nil
+	}
+
+	m := newReconcileMonitor(qb, repo)
+	if status := m.Status(); status.LastTickAt != nil || status.Ticks != 0 {
+		t.Fatalf("status before any pass = %+v, want no ticks", status)
+	}
+
+	before := time.Now()
+	if err := m.checkDownloads(context.Background()); err != nil {
+		t.Fatalf("checkDownloads() error = %v", err)
+	}
+
+	status := m.Status()
+	if status.LastTickAt == nil || status.LastTickAt.Before(before) {
+		t.Errorf("last tick = %v, want a time after %v", status.LastTickAt, before)
+	}
+	if status.Ticks != 1 || status.Checked != 2 || status.Changed != 1 || status.Errored != 0 {
+		t.Errorf("status = %+v, want 1 tick checking 2 downloads with 1 changed", status)
+	}
+}
+
+func TestMonitorStatus_CountsConsecutiveQBittorrentFailures(t *testing.T) {
+	unreachable, err := qbittorrent.NewClient("http://127.0.0.1:1", "admin", "adminpass")
+	if err != nil {
+		t.Fatalf("failed to create qBittorrent client: %v", err)
+	}
+
+	repo := newMockDownloadRepo()
+	repo.getActiveFunc = func(ctx context.Context) ([]*models.Download, error) {
+		return []*models.Download{{ID: "dl-1", QBitHash: "abc", Status: models.StatusDownloading}}, nil
+	}
+
+	m := newReconcileMonitor(unreachable, repo)
+	for i := 0; i < qbUnhealthyAfter; i++ {
+		if status := m.Status(); status.Degraded() {
+			t.Fatalf("monitor degraded after %d failed passes", i)
+		}
+		if err := m.checkDownloads(context.Background()); err != nil {
+			t.Fatalf("checkDownloads() error = %v", err)
+		}
+	}
+
+	status := m.Status()
+	if !status.Degraded() || status.ConsecutiveQBFailures != qbUnhealthyAfter || status.Errored != 1 || status.LastQBError == "" {
+		t.Errorf("status = %+v, want degraded after %d failed passes", status, qbUnhealthyAfter)
+	}
+
+	// One successful pass resets the count
+	m.qbClient, _ = newFakeQBittorrent(t, []qbittorrent.TorrentInfo{{Hash: "abc", State: "downloading"}})
+	if err := m.checkDownloads(context.Background()); err != nil {
+		t.Fatalf("checkDownloads() error = %v", err)
+	}
+	if status := m.Status(); status.Degraded() || status.ConsecutiveQBFailures != 0 || status.LastQBError != "" {
+		t.Errorf("status = %+v, want failures reset after a successful pass", status)
+	}
+}
+
+func TestMonitorStatus_TracksInFlightOrganizations(t *testing.T) {
+	m := &Monitor{}
+	started := time.Now()
+
+	doneFirst := m.trackOrganization(InFlightOrganization{DownloadID: "dl-1", JobID: 1, StartedAt: started})
+	doneSecond := m.trackOrganization(InFlightOrganization{DownloadID: "dl-2", JobID: 2, StartedAt: started.Add(-time.Minute)})
+
+	inFlight := m.Status().InFlight
+	if len(inFlight) != 2 || inFlight[0].DownloadID != "dl-2" || inFlight[1].DownloadID != "dl-1" {
+		t.Fatalf("in flight = %+v, want both organizations oldest first", inFlight)
+	}
+
+	doneSecond()
+	if inFlight := m.Status().InFlight; len(inFlight) != 1 || inFlight[0].DownloadID != "dl-1" {
+		t.Errorf("in flight = %+v, want only dl-1 left", inFlight)
+	}
+	doneFirst()
+	if inFlight := m.Status().InFlight; len(inFlight) != 0 {
+		t.Errorf("in flight = %+v, want none", inFlight)
+	}
+}
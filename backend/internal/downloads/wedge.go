@@ -0,0 +1,85 @@
+package downloads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/search/providers"
+)
+
+// Wedge policies, set with mam.wedge_policy
+const (
+	WedgePolicyNever    = "never"
+	WedgePolicyAlways   = "always"
+	WedgePolicyOverSize = "over_size" // Only for torrents larger than mam.wedge_min_size_mb
+)
+
+// wedgeSpender spends MAM freeleech wedges
+type wedgeSpender interface {
+	SpendWedge(ctx context.Context, torrentID string) error
+}
+
+// wantsWedge reports whether a freeleech wedge should be spent on d, following the download's
+// own choice or else mam.wedge_policy
+func (s *Service) wantsWedge(ctx context.Context, d *models.Download) bool {
+	if s.wedges == nil || d.Freeleech || !isMAMGrab(d) {
+		return false
+	}
+	if d.UseWedge != nil {
+		return *d.UseWedge
+	}
+
+	policy, err := s.configService.Get(ctx, "mam.wedge_policy")
+	if err != nil {
+		return false
+	}
+	switch policy {
+	case WedgePolicyAlways:
+		return true
+	case WedgePolicyOverSize:
+		value, err := s.configService.Get(ctx, "mam.wedge_min_size_mb")
+		if err != nil {
+			return false
+		}
+		minSizeMB, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			log.Printf("Invalid mam.wedge_min_size_mb %q, not spending wedges: %v", value, err)
+			return false
+		}
+		return d.Size > minSizeMB*1024*1024
+	default:
+		return false
+	}
+}
+
+// spendWedge makes d's torrent personal freeleech before it is downloaded. Failures are
+// returned as a warning rather than stopping the download from being added.
+func (s *Service) spendWedge(ctx context.Context, d *models.Download) string {
+	torrentID := d.TorrentID
+	if torrentID == "" {
+		id, err := extractTorrentIDFromURL(d.TorrentURL)
+		if err != nil {
+			return fmt.Sprintf("could not spend freeleech wedge: %v", err)
+		}
+		torrentID = strconv.Itoa(id)
+	}
+
+	err := s.wedges.SpendWedge(ctx, torrentID)
+	if errors.Is(err, providers.ErrAlreadyFreeleech) {
+		// Nothing to spend it on
+		d.Freeleech = true
+		return ""
+	}
+	if err != nil {
+		log.Printf("Could not spend freeleech wedge on %s (torrent %s): %v", d.Title, torrentID, err)
+		return fmt.Sprintf("could not spend freeleech wedge: %v", err)
+	}
+
+	d.WedgeUsed = true
+	d.Freeleech = true
+	return ""
+}
@@ -0,0 +1,136 @@
+package downloads
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nathanael/organizr/internal/config"
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/search/providers"
+)
+
+// fakeWedges records the torrents wedges were spent on
+type fakeWedges struct {
+	err   error
+	spent []string
+}
+
+func (f *fakeWedges) SpendWedge(ctx context.Context, torrentID string) error {
+	f.spent = append(f.spent, torrentID)
+	return f.err
+}
+
+func TestWantsWedge(t *testing.T) {
+	yes, no := true, false
+	mamGrab := func(size int64) *models.Download {
+		return &models.Download{TorrentID: "123", Size: size}
+	}
+
+	tests := []struct {
+		name     string
+		policy   string
+		download *models.Download
+		want     bool
+	}{
+		{"Never", WedgePolicyNever, mamGrab(2 * gib), false},
+		{"Always", WedgePolicyAlways, mamGrab(gib / 2), true},
+		{"Over size", WedgePolicyOverSize, mamGrab(2 * gib), true},
+		{"Under size", WedgePolicyOverSize, mamGrab(gib / 2), false},
+		{"Unknown size", WedgePolicyOverSize, mamGrab(0), false},
+		{"Already freeleech", WedgePolicyAlways, &models.Download{TorrentID: "123", Freeleech: true}, false},
+		{"Other provider", WedgePolicyAlways, &models.Download{TorrentID: "1", Provider: "Prowlarr"}, false},
+		{"MAM URL", WedgePolicyAlways, &models.Download{TorrentURL: "https://www.myanonamouse.net/tor/download.php?tid=123"}, true},
+		{"Requested", WedgePolicyNever, &models.Download{TorrentID: "123", UseWedge: &yes}, true},
+		{"Declined", WedgePolicyAlways, &models.Download{TorrentID: "123", UseWedge: &no}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Service{
+				configService: config.NewService(newMockConfigService(map[string]string{
+					"mam.wedge_policy":      tt.policy,
+					"mam.wedge_min_size_mb": "1024",
+				})),
+				wedges: &fakeWedges{},
+			}
+			if got := s.wantsWedge(context.Background(), tt.download); got != tt.want {
+				t.Errorf("wantsWedge() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpendWedge(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantUsed    bool
+		wantWarning bool
+	}{
+		{"Spent", nil, true, false},
+		{"Already freeleech", providers.ErrAlreadyFreeleech, false, false},
+		{"Failed", errors.New("You do not have any FL wedges"), false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wedges := &fakeWedges{err: tt.err}
+			s := &Service{wedges: wedges}
+			d := &models.Download{Title: "Book", TorrentURL: "https://www.myanonamouse.net/tor/download.php?tid=123"}
+
+			warning := s.spendWedge(context.Background(), d)
+			if d.WedgeUsed != tt.wantUsed || (warning != "") != tt.wantWarning {
+				t.Errorf("spendWedge() = %q, WedgeUsed = %v, want used %v and warning %v", warning, d.WedgeUsed, tt.wantUsed, tt.wantWarning)
+			}
+			if len(wedges.spent) != 1 || wedges.spent[0] != "123" {
+				t.Errorf("wedges spent on %v, want torrent 123", wedges.spent)
+			}
+		})
+	}
+}
+
+func TestCreateDownload_GuardsGrabWhenWedgeFails(t *testing.T) {
+	// Fine as freeleech, but the grab would drop the ratio below the floor
+	stats := providers.AccountStats{UploadedBytes: 10 * gib, DownloadedBytes: 8 * gib, UnsatisfiedLimit: 50}
+	wedges := &fakeWedges{err: errors.New("You do not have any FL wedges")}
+	s := &Service{
+		downloadRepo: newMockDownloadRepo(),
+		configService: config.NewService(newMockConfigService(map[string]string{
+			"mam.account_guard": AccountGuardRefuse,
+			"mam.min_ratio":     "1.0",
+			"mam.wedge_policy":  WedgePolicyAlways,
+		})),
+		accountGuard: &accountGuard{account: &fakeAccount{stats: stats}},
+		wedges:       wedges,
+	}
+
+	_, err := s.CreateDownload(context.Background(), &models.Download{Title: "Book", Author: "Author", TorrentID: "123", Size: 3 * gib})
+	if !errors.Is(err, ErrAccountGuard) {
+		t.Fatalf("CreateDownload() error = %v, want %v", err, ErrAccountGuard)
+	}
+	if len(wedges.spent) != 1 {
+		t.Errorf("wedges spent on %v, want one attempt", wedges.spent)
+	}
+}
+
+func TestCreateDownload_UnsatisfiedLimitSavesWedge(t *testing.T) {
+	// Refused whether or not it's freeleech, so no wedge should go on it
+	stats := providers.AccountStats{UploadedBytes: 10 * gib, Unsatisfied: 50, UnsatisfiedLimit: 50}
+	wedges := &fakeWedges{}
+	s := &Service{
+		downloadRepo: newMockDownloadRepo(),
+		configService: config.NewService(newMockConfigService(map[string]string{
+			"mam.account_guard": AccountGuardRefuse,
+			"mam.wedge_policy":  WedgePolicyAlways,
+		})),
+		accountGuard: &accountGuard{account: &fakeAccount{stats: stats}},
+		wedges:       wedges,
+	}
+
+	_, err := s.CreateDownload(context.Background(), &models.Download{Title: "Book", Author: "Author", TorrentID: "123", Size: gib})
+	if !errors.Is(err, ErrAccountGuard) {
+		t.Fatalf("CreateDownload() error = %v, want %v", err, ErrAccountGuard)
+	}
+	if len(wedges.spent) != 0 {
+		t.Errorf("wedges spent on %v, want none", wedges.spent)
+	}
+}
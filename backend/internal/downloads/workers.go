@@ -0,0 +1,185 @@
+package downloads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nathanael/organizr/internal/models"
+)
+
+// jobPollInterval is how often idle workers check the queue, picking up jobs persisted
+// before a restart or ones whose download was locked when first claimed
+const jobPollInterval = 5 * time.Second
+
+// enqueueOrganize queues a download for organization. Downloads that already have a pending
+// or running job are not queued twice.
+func (m *Monitor) enqueueOrganize(ctx context.Context, dl *models.Download, source string) {
+	job, created, err := m.jobRepo.Enqueue(ctx, dl.ID, source)
+	if err != nil {
+		log.Printf("Failed to queue organization for download %s: %v", dl.ID, err)
+		return
+	}
+
+	if !created {
+		log.Printf("Download %s (%s) already queued for organization (job %d, %s)", dl.ID, dl.Title, job.ID, job.Status)
+		return
+	}
+
+	log.Printf("Queued download %s (%s) for organization (job %d)", dl.ID, dl.Title, job.ID)
+
+	// Wake an idle worker; if all are busy the next poll picks the job up
+	notify(m.wake)
+}
+
+// startWorkers starts maxConcurrent workers processing the organize job queue until ctx is
+// cancelled. Workers count as in-flight organizations so Shutdown waits for their current job.
+func (m *Monitor) startWorkers(ctx context.Context) {
+	workers := m.maxConcurrent
+	if workers < 1 {
+		workers = 1
+	}
+
+	log.Printf("Starting %d organization worker(s)", workers)
+
+	m.inFlight.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer m.inFlight.Done()
+			m.runWorker(ctx)
+		}()
+	}
+}
+
+func (m *Monitor) runWorker(ctx context.Context) {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the queue before waiting
+		for ctx.Err() == nil && m.processNextJob(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// processNextJob claims and runs the next pending job. Returns false when there was nothing
+// to run, so the worker waits before polling again.
+func (m *Monitor) processNextJob(ctx context.Context) bool {
+	job, err := m.jobRepo.ClaimNext(ctx)
+	if err != nil {
+		log.Printf("Failed to claim organize job: %v", err)
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	return m.runJob(job)
+}
+
+// runJob organizes the job's download and records the result on the job. Returns false if the
+// job was put back because its download is locked by another organization.
+func (m *Monitor) runJob(job *models.OrganizeJob) bool {
+	// Derive from the monitor's organization context rather than the loop context: stopping the
+	// loop must not abort copies half way. Shutdown cancels orgCtx only if waiting times out.
+	// The timeout prevents indefinite hanging.
+	ctx, cancel := context.WithTimeout(m.orgCtx, organizeTimeout)
+	defer cancel()
+
+	dl, err := m.downloadRepo.GetByID(ctx, job.DownloadID)
+	if err != nil {
+		m.failJob(ctx, job, "failed to load download: "+err.Error(), false)
+		return true
+	}
+
+	// Organized by another path (e.g. a manual request) since the job was queued
+	if dl.Status == models.StatusOrganized {
+		m.completeJob(ctx, job)
+		return true
+	}
+
+	if !m.locks.TryLock(dl.ID) {
+		log.Printf("Download %s is already being organized, deferring job %d", dl.ID, job.ID)
+		if err := m.jobRepo.Release(ctx, job.ID); err != nil {
+			log.Printf("Failed to release organize job %d: %v", job.ID, err)
+		}
+		return false
+	}
+	defer m.locks.Unlock(dl.ID)
+
+	log.Printf("Organizing download %s (%s), job %d", dl.ID, dl.Title, job.ID)
+
+	done := m.trackOrganization(InFlightOrganization{DownloadID: dl.ID, Title: dl.Title, JobID: job.ID, StartedAt: time.Now()})
+	defer done()
+
+	if err := m.organizeDownload(ctx, dl); err != nil {
+		if errors.Is(err, context.Canceled) {
+			// Left running; failed on the next start while recovery resumes the download
+			return true
+		}
+		m.retryOrFail(ctx, job, dl, err)
+		return true
+	}
+
+	m.completeJob(ctx, job)
+	return true
+}
+
+func (m *Monitor) completeJob(ctx context.Context, job *models.OrganizeJob) {
+	if err := m.jobRepo.Complete(ctx, job.ID); err != nil {
+		log.Printf("Failed to complete organize job %d: %v", job.ID, err)
+	}
+}
+
+func (m *Monitor) failJob(ctx context.Context, job *models.OrganizeJob, reason string, transient bool) {
+	if err := m.jobRepo.Fail(ctx, job.ID, reason, transient); err != nil {
+		log.Printf("Failed to record failure of organize job %d: %v", job.ID, err)
+	}
+}
+
+// retryOrFail records a failed attempt and, if the failure is transient and attempts remain,
+// schedules the next one with exponential backoff. The download stays failed meanwhile, with
+// the retry noted in its error message.
+func (m *Monitor) retryOrFail(ctx context.Context, job *models.OrganizeJob, dl *models.Download, orgErr error) {
+	// A timed out organization is worth retrying, but its context can no longer be used
+	ctx = context.WithoutCancel(ctx)
+
+	transient := IsTransient(orgErr)
+	m.failJob(ctx, job, orgErr.Error(), transient)
+
+	if !transient {
+		log.Printf("Organization of download %s failed permanently, not retrying: %v", dl.ID, orgErr)
+		return
+	}
+
+	if job.Attempt >= m.retryMaxAttempts {
+		log.Printf("Giving up organizing download %s after %d attempts", dl.ID, job.Attempt)
+		m.recordFailure(ctx, dl.ID, fmt.Sprintf("%v (gave up after %d attempts)", orgErr, job.Attempt))
+		return
+	}
+
+	delay := retryDelay(m.retryDelay, job.Attempt)
+	next, err := m.jobRepo.Schedule(ctx, dl.ID, "retry", job.Attempt+1, time.Now().Add(delay))
+	if err != nil {
+		log.Printf("Failed to schedule organization retry for download %s: %v", dl.ID, err)
+		return
+	}
+
+	log.Printf("Retrying organization of download %s in %s (attempt %d/%d, job %d)", dl.ID, delay, next.Attempt, m.retryMaxAttempts, next.ID)
+	m.recordFailure(ctx, dl.ID, fmt.Sprintf("%v (attempt %d/%d, retrying in %s)", orgErr, job.Attempt, m.retryMaxAttempts, delay))
+}
+
+func (m *Monitor) recordFailure(ctx context.Context, downloadID, message string) {
+	if err := m.downloadRepo.UpdateError(ctx, downloadID, message); err != nil {
+		log.Printf("Failed to update download error for %s: %v", downloadID, err)
+	}
+}
@@ -0,0 +1,379 @@
+package downloads
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/qbittorrent"
+)
+
+// mockJobRepo is an in-memory organize job queue
+type mockJobRepo struct {
+	mu     sync.Mutex
+	nextID int64
+	jobs   []*models.OrganizeJob
+}
+
+func (m *mockJobRepo) Enqueue(ctx context.Context, downloadID, source string) (*models.OrganizeJob, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, job := range m.jobs {
+		if job.DownloadID == downloadID && (job.Status == models.JobPending || job.Status == models.JobRunning) {
+			return job, false, nil
+		}
+	}
+	m.nextID++
+	job := &models.OrganizeJob{ID: m.nextID, DownloadID: downloadID, Status: models.JobPending, Source: source, Attempt: 1}
+	m.jobs = append(m.jobs, job)
+	return job, true, nil
+}
+
+func (m *mockJobRepo) Schedule(ctx context.Context, downloadID, source string, attempt int, runAfter time.Time) (*models.OrganizeJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, job := range m.jobs {
+		if job.DownloadID == downloadID && job.Status == models.JobRunning {
+			return job, nil
+		}
+		if job.DownloadID == downloadID && job.Status == models.JobPending {
+			job.Source, job.Attempt, job.RunAfter = source, attempt, &runAfter
+			return job, nil
+		}
+	}
+	m.nextID++
+	job := &models.OrganizeJob{ID: m.nextID, DownloadID: downloadID, Status: models.JobPending, Source: source, Attempt: attempt, RunAfter: &runAfter}
+	m.jobs = append(m.jobs, job)
+	return job, nil
+}
+
+func (m *mockJobRepo) ClaimNext(ctx context.Context) (*models.OrganizeJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, job := range m.jobs {
+		if job.Status == models.JobPending && (job.RunAfter == nil || !job.RunAfter.After(time.Now())) {
+			job.Status = models.JobRunning
+			return job, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockJobRepo) Release(ctx context.Context, id int64) error {
+	return m.setStatus(id, models.JobPending, "")
+}
+
+func (m *mockJobRepo) Complete(ctx context.Context, id int64) error {
+	return m.setStatus(id, models.JobDone, "")
+}
+
+func (m *mockJobRepo) Fail(ctx context.Context, id int64, errorMsg string, transient bool) error {
+	if err := m.setStatus(id, models.JobFailed, errorMsg); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, job := range m.jobs {
+		if job.ID == id {
+			job.Transient = transient
+		}
+	}
+	return nil
+}
+
+func (m *mockJobRepo) FailRunning(ctx context.Context, errorMsg string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for _, job := range m.jobs {
+		if job.Status == models.JobRunning {
+			job.Status = models.JobFailed
+			job.Error = errorMsg
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *mockJobRepo) ListByDownload(ctx context.Context, downloadID string) ([]*models.OrganizeJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var jobs []*models.OrganizeJob
+	for _, job := range m.jobs {
+		if job.DownloadID == downloadID {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+func (m *mockJobRepo) setStatus(id int64, status models.JobStatus, errorMsg string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, job := range m.jobs {
+		if job.ID == id {
+			job.Status = status
+			job.Error = errorMsg
+			return nil
+		}
+	}
+	return errors.New("job not found")
+}
+
+func (m *mockJobRepo) countStatus(status models.JobStatus) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, job := range m.jobs {
+		if job.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// blockingQBClient holds GetTorrentFiles until released, tracking how many calls overlap
+type blockingQBClient struct {
+	mu        sync.Mutex
+	active    int
+	maxActive int
+	release   chan struct{}
+}
+
+func (b *blockingQBClient) GetTorrentFiles(ctx context.Context, hash string) ([]*qbittorrent.TorrentFile, error) {
+	b.mu.Lock()
+	b.active++
+	if b.active > b.maxActive {
+		b.maxActive = b.active
+	}
+	b.mu.Unlock()
+
+	<-b.release
+
+	b.mu.Lock()
+	b.active--
+	b.mu.Unlock()
+	return nil, errors.New("torrent not found")
+}
+
+func newWorkerMonitor(t *testing.T, repo *mockDownloadRepo, jobs *mockJobRepo, qb qbittorrentClient, maxConcurrent int) *Monitor {
+	orgCtx, cancelOrgs := context.WithCancel(context.Background())
+	return &Monitor{
+		downloadRepo: repo,
+		jobRepo:      jobs,
+		orgService: &OrganizationService{
+			qbClient:      qb,
+			configService: newMockConfigService(map[string]string{"paths.destination": t.TempDir()}),
+		},
+		locks:         NewDownloadLocks(),
+		maxConcurrent: maxConcurrent,
+		orgCtx:        orgCtx,
+		cancelOrgs:    cancelOrgs,
+		wake:          make(chan struct{}, 1),
+
+		retryMaxAttempts: 1,
+		retryDelay:       time.Minute,
+	}
+}
+
+func TestEnqueueOrganize_Deduplicates(t *testing.T) {
+	jobs := &mockJobRepo{}
+	m := newWorkerMonitor(t, newMockDownloadRepo(), jobs, &mockQBClient{}, 1)
+
+	dl := &models.Download{ID: "dl-1", Title: "Book"}
+	m.enqueueOrganize(context.Background(), dl, "monitor")
+	m.enqueueOrganize(context.Background(), dl, "monitor")
+
+	if len(jobs.jobs) != 1 {
+		t.Fatalf("expected 1 job, got %d", len(jobs.jobs))
+	}
+	if len(m.wake) != 1 {
+		t.Errorf("expected a worker to be woken once, got %d", len(m.wake))
+	}
+}
+
+func TestWorkers_HonorMaxConcurrent(t *testing.T) {
+	repo := newMockDownloadRepo()
+	repo.getByIDFunc = func(ctx context.Context, id string) (*models.Download, error) {
+		return &models.Download{ID: id, Title: id, Author: "Author", QBitHash: id, Status: models.StatusCompleted}, nil
+	}
+	jobs := &mockJobRepo{}
+	qb := &blockingQBClient{release: make(chan struct{})}
+	m := newWorkerMonitor(t, repo, jobs, qb, 2)
+
+	for _, id := range []string{"dl-1", "dl-2", "dl-3", "dl-4", "dl-5"} {
+		_, _, _ = jobs.Enqueue(context.Background(), id, "monitor")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.startWorkers(ctx)
+
+	// Wait for the pool to fill up
+	deadline := time.Now().Add(2 * time.Second)
+	for jobs.countStatus(models.JobRunning) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if running := jobs.countStatus(models.JobRunning); running != 2 {
+		t.Errorf("expected 2 running jobs, got %d", running)
+	}
+
+	close(qb.release)
+
+	deadline = time.Now().Add(2 * time.Second)
+	for jobs.countStatus(models.JobFailed) < 5 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), time.Second)
+	defer cancelShutdown()
+	if err := m.Shutdown(shutdownCtx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	if qb.maxActive != 2 {
+		t.Errorf("max concurrent organizations = %d, want 2", qb.maxActive)
+	}
+	if failed := jobs.countStatus(models.JobFailed); failed != 5 {
+		t.Errorf("expected all 5 jobs to finish as failed, got %d", failed)
+	}
+	if repo.statusUpdates["dl-3"] != models.StatusFailed {
+		t.Errorf("download status = %s, want failed", repo.statusUpdates["dl-3"])
+	}
+}
+
+func TestRunJob_DefersLockedDownload(t *testing.T) {
+	repo := newMockDownloadRepo()
+	repo.getByIDFunc = func(ctx context.Context, id string) (*models.Download, error) {
+		return &models.Download{ID: id, Status: models.StatusCompleted}, nil
+	}
+	jobs := &mockJobRepo{}
+	m := newWorkerMonitor(t, repo, jobs, &mockQBClient{}, 1)
+
+	_, _, _ = jobs.Enqueue(context.Background(), "dl-1", "monitor")
+	m.locks.TryLock("dl-1")
+
+	if m.processNextJob(context.Background()) {
+		t.Error("expected job to be deferred while the download is locked")
+	}
+	if jobs.jobs[0].Status != models.JobPending {
+		t.Errorf("job status = %s, want pending", jobs.jobs[0].Status)
+	}
+	if _, ok := repo.statusUpdates["dl-1"]; ok {
+		t.Error("locked download must not be organized")
+	}
+}
+
+func TestRunJob_SkipsOrganizedDownload(t *testing.T) {
+	repo := newMockDownloadRepo()
+	repo.getByIDFunc = func(ctx context.Context, id string) (*models.Download, error) {
+		return &models.Download{ID: id, Status: models.StatusOrganized}, nil
+	}
+	jobs := &mockJobRepo{}
+	m := newWorkerMonitor(t, repo, jobs, &mockQBClient{}, 1)
+
+	_, _, _ = jobs.Enqueue(context.Background(), "dl-1", "monitor")
+
+	if !m.processNextJob(context.Background()) {
+		t.Fatal("expected job to be processed")
+	}
+	if jobs.jobs[0].Status != models.JobDone {
+		t.Errorf("job status = %s, want done", jobs.jobs[0].Status)
+	}
+	if _, ok := repo.statusUpdates["dl-1"]; ok {
+		t.Error("organized download must not be organized again")
+	}
+}
+
+func TestRunJob_RetriesTransientFailure(t *testing.T) {
+	repo := newMockDownloadRepo()
+	repo.getByIDFunc = func(ctx context.Context, id string) (*models.Download, error) {
+		return &models.Download{ID: id, Title: "Book", Author: "Author", QBitHash: "hash", Status: models.StatusCompleted}, nil
+	}
+	jobs := &mockJobRepo{}
+	m := newWorkerMonitor(t, repo, jobs, &mockQBClient{err: errors.New("connection refused")}, 1)
+	m.retryMaxAttempts = 3
+
+	_, _, _ = jobs.Enqueue(context.Background(), "dl-1", "monitor")
+	m.processNextJob(context.Background())
+
+	if len(jobs.jobs) != 2 {
+		t.Fatalf("expected a retry job to be scheduled, got %d jobs", len(jobs.jobs))
+	}
+	first, retry := jobs.jobs[0], jobs.jobs[1]
+	if first.Status != models.JobFailed || !first.Transient {
+		t.Errorf("first attempt = %+v, want failed and transient", first)
+	}
+	if retry.Status != models.JobPending || retry.Attempt != 2 || retry.RunAfter == nil || !retry.RunAfter.After(time.Now()) {
+		t.Errorf("retry = %+v, want pending attempt 2 in the future", retry)
+	}
+	if !strings.Contains(repo.errorUpdates["dl-1"], "attempt 1/3, retrying in 1m0s") {
+		t.Errorf("unexpected error message: %q", repo.errorUpdates["dl-1"])
+	}
+
+	// Not due yet
+	if m.processNextJob(context.Background()) {
+		t.Error("retry must not run before its backoff expires")
+	}
+}
+
+func TestRunJob_GivesUpAfterMaxAttempts(t *testing.T) {
+	repo := newMockDownloadRepo()
+	repo.getByIDFunc = func(ctx context.Context, id string) (*models.Download, error) {
+		return &models.Download{ID: id, Title: "Book", Author: "Author", QBitHash: "hash", Status: models.StatusFailed}, nil
+	}
+	jobs := &mockJobRepo{}
+	m := newWorkerMonitor(t, repo, jobs, &mockQBClient{err: errors.New("connection refused")}, 1)
+	m.retryMaxAttempts = 3
+
+	_, _ = jobs.Schedule(context.Background(), "dl-1", "retry", 3, time.Now())
+	m.processNextJob(context.Background())
+
+	if len(jobs.jobs) != 1 {
+		t.Errorf("expected no further retries, got %d jobs", len(jobs.jobs))
+	}
+	if !strings.Contains(repo.errorUpdates["dl-1"], "gave up after 3 attempts") {
+		t.Errorf("unexpected error message: %q", repo.errorUpdates["dl-1"])
+	}
+	if repo.statusUpdates["dl-1"] != models.StatusFailed {
+		t.Errorf("status = %s, want failed", repo.statusUpdates["dl-1"])
+	}
+}
+
+func TestRunJob_DoesNotRetryPermanentFailure(t *testing.T) {
+	repo := newMockDownloadRepo()
+	repo.getByIDFunc = func(ctx context.Context, id string) (*models.Download, error) {
+		return &models.Download{ID: id, Title: "Book", Author: "Author", QBitHash: "hash", Status: models.StatusCompleted}, nil
+	}
+	jobs := &mockJobRepo{}
+	m := newWorkerMonitor(t, repo, jobs, &mockQBClient{err: qbittorrent.ErrTorrentNotFound}, 1)
+	m.retryMaxAttempts = 3
+
+	_, _, _ = jobs.Enqueue(context.Background(), "dl-1", "monitor")
+	m.processNextJob(context.Background())
+
+	if len(jobs.jobs) != 1 {
+		t.Errorf("expected no retry for a permanent failure, got %d jobs", len(jobs.jobs))
+	}
+	if jobs.jobs[0].Transient {
+		t.Error("missing torrent must be recorded as a permanent failure")
+	}
+}
+
+func TestOrganizeDownload_InProgress(t *testing.T) {
+	locks := NewDownloadLocks()
+	svc := &Service{downloadRepo: newMockDownloadRepo(), locks: locks}
+	svc.downloadRepo.(*mockDownloadRepo).getByIDFunc = func(ctx context.Context, id string) (*models.Download, error) {
+		return &models.Download{ID: id}, nil
+	}
+
+	locks.TryLock("dl-1")
+	if err := svc.OrganizeDownload(context.Background(), "dl-1"); !errors.Is(err, ErrOrganizeInProgress) {
+		t.Errorf("OrganizeDownload() error = %v, want ErrOrganizeInProgress", err)
+	}
+}
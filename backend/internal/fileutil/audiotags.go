@@ -0,0 +1,298 @@
+package fileutil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+)
+
+// AudioTags holds the book-level tags of an audio file. For audiobooks the album is
+// usually the book title and the album artist the author; the track title is a chapter.
+type AudioTags struct {
+	Title       string
+	Album       string
+	Artist      string
+	AlbumArtist string
+	Composer    string
+	Grouping    string // Often holds the series, e.g. "The Dark Tower #1"
+}
+
+// ErrUnsupportedAudioFormat is returned for files ReadAudioTags can't read tags from
+var ErrUnsupportedAudioFormat = errors.New("unsupported audio format")
+
+// maxTagSize bounds how much of a file is read while looking for tags
+const maxTagSize = 16 << 20
+
+// ReadAudioTags reads ID3v2 tags from MP3 files and iTunes-style metadata from
+// M4A/M4B files
+func ReadAudioTags(path string) (AudioTags, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return AudioTags{}, fmt.Errorf("failed to open audio file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp3":
+		return readID3(f)
+	case ".m4a", ".m4b", ".mp4":
+		info, err := f.Stat()
+		if err != nil {
+			return AudioTags{}, fmt.Errorf("failed to stat audio file: %w", err)
+		}
+		return readMP4Tags(f, info.Size())
+	default:
+		return AudioTags{}, ErrUnsupportedAudioFormat
+	}
+}
+
+// readID3 parses the text frames of an ID3v2.3 or v2.4 tag at the start of r
+func readID3(r io.Reader) (AudioTags, error) {
+	var tags AudioTags
+
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return tags, fmt.Errorf("failed to read ID3 header: %w", err)
+	}
+	if string(header[:3]) != "ID3" {
+		return tags, fmt.Errorf("no ID3v2 tag found")
+	}
+
+	version := header[3]
+	if version != 3 && version != 4 {
+		return tags, fmt.Errorf("unsupported ID3v2.%d tag", version)
+	}
+
+	size := synchsafe(header[6:10])
+	if size > maxTagSize {
+		return tags, fmt.Errorf("ID3 tag too large: %d bytes", size)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return tags, fmt.Errorf("failed to read ID3 tag: %w", err)
+	}
+
+	// Skip the extended header; v2.3 sizes exclude the size field, v2.4 sizes include it
+	if header[5]&0x40 != 0 && len(data) >= 4 {
+		extSize := int(binary.BigEndian.Uint32(data[:4])) + 4
+		if version == 4 {
+			extSize = synchsafe(data[:4])
+		}
+		if extSize > len(data) {
+			return tags, fmt.Errorf("invalid ID3 extended header")
+		}
+		data = data[extSize:]
+	}
+
+	for len(data) >= 10 {
+		id := string(data[:4])
+		if data[0] == 0 {
+			break // Padding
+		}
+
+		frameSize := int(binary.BigEndian.Uint32(data[4:8]))
+		if version == 4 {
+			frameSize = synchsafe(data[4:8])
+		}
+		data = data[10:]
+		if frameSize > len(data) {
+			break
+		}
+		frame := data[:frameSize]
+		data = data[frameSize:]
+
+		switch id {
+		case "TIT2":
+			tags.Title = decodeID3Text(frame)
+		case "TALB":
+			tags.Album = decodeID3Text(frame)
+		case "TPE1":
+			tags.Artist = decodeID3Text(frame)
+		case "TPE2":
+			tags.AlbumArtist = decodeID3Text(frame)
+		case "TCOM":
+			tags.Composer = decodeID3Text(frame)
+		case "TIT1", "GRP1":
+			if tags.Grouping == "" {
+				tags.Grouping = decodeID3Text(frame)
+			}
+		}
+	}
+
+	return tags, nil
+}
+
+func synchsafe(b []byte) int {
+	return int(b[0]&0x7f)<<21 | int(b[1]&0x7f)<<14 | int(b[2]&0x7f)<<7 | int(b[3]&0x7f)
+}
+
+// decodeID3Text decodes a text frame body: an encoding byte followed by the text.
+// Frames holding several values keep only the first.
+func decodeID3Text(frame []byte) string {
+	if len(frame) < 2 {
+		return ""
+	}
+
+	var text string
+	body := frame[1:]
+	switch frame[0] {
+	case 0: // ISO-8859-1
+		runes := make([]rune, len(body))
+		for i, b := range body {
+			runes[i] = rune(b)
+		}
+		text = string(runes)
+	case 1, 2: // UTF-16 with BOM, UTF-16BE
+		bigEndian := frame[0] == 2
+		if len(body) >= 2 && frame[0] == 1 {
+			bigEndian = body[0] == 0xfe && body[1] == 0xff
+			body = body[2:]
+		}
+		units := make([]uint16, 0, len(body)/2)
+		for i := 0; i+1 < len(body); i += 2 {
+			if bigEndian {
+				units = append(units, uint16(body[i])<<8|uint16(body[i+1]))
+			} else {
+				units = append(units, uint16(body[i+1])<<8|uint16(body[i]))
+			}
+		}
+		text = string(utf16.Decode(units))
+	default: // UTF-8
+		text = string(body)
+	}
+
+	if i := strings.IndexRune(text, 0); i >= 0 {
+		text = text[:i]
+	}
+	return strings.TrimSpace(text)
+}
+
+// readMP4Tags reads the moov/udta/meta/ilst items of an MP4 container
+func readMP4Tags(r io.ReaderAt, size int64) (AudioTags, error) {
+	var tags AudioTags
+
+	moov, ok, err := findAtom(r, 0, size, "moov")
+	if err != nil || !ok {
+		return tags, mp4Error(err, "moov")
+	}
+	udta, ok, err := findAtom(r, moov.dataStart, moov.end, "udta")
+	if err != nil || !ok {
+		return tags, mp4Error(err, "udta")
+	}
+	meta, ok, err := findAtom(r, udta.dataStart, udta.end, "meta")
+	if err != nil || !ok {
+		return tags, mp4Error(err, "meta")
+	}
+	// meta is a full box: version and flags precede its children
+	ilst, ok, err := findAtom(r, meta.dataStart+4, meta.end, "ilst")
+	if err != nil || !ok {
+		return tags, mp4Error(err, "ilst")
+	}
+
+	for offset := ilst.dataStart; offset < ilst.end; {
+		item, err := readAtomHeader(r, offset, ilst.end)
+		if err != nil {
+			return tags, err
+		}
+		offset = item.end
+
+		var target *string
+		switch item.kind {
+		case "\xa9nam":
+			target = &tags.Title
+		case "\xa9alb":
+			target = &tags.Album
+		case "\xa9ART":
+			target = &tags.Artist
+		case "aART":
+			target = &tags.AlbumArtist
+		case "\xa9wrt":
+			target = &tags.Composer
+		case "\xa9grp":
+			target = &tags.Grouping
+		default:
+			continue
+		}
+
+		data, ok, err := findAtom(r, item.dataStart, item.end, "data")
+		if err != nil || !ok {
+			continue
+		}
+		// data atoms start with 4 bytes of type and 4 of locale
+		valueSize := data.end - data.dataStart - 8
+		if valueSize <= 0 || valueSize > maxTagSize {
+			continue
+		}
+		value := make([]byte, valueSize)
+		if _, err := r.ReadAt(value, data.dataStart+8); err != nil {
+			continue
+		}
+		*target = strings.TrimSpace(string(bytes.TrimRight(value, "\x00")))
+	}
+
+	return tags, nil
+}
+
+func mp4Error(err error, atom string) error {
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("no %s atom found", atom)
+}
+
+type mp4Atom struct {
+	kind      string
+	dataStart int64
+	end       int64
+}
+
+// findAtom returns the first atom of the given kind between start and end
+func findAtom(r io.ReaderAt, start, end int64, kind string) (mp4Atom, bool, error) {
+	for offset := start; offset < end; {
+		atom, err := readAtomHeader(r, offset, end)
+		if err != nil {
+			return mp4Atom{}, false, err
+		}
+		if atom.kind == kind {
+			return atom, true, nil
+		}
+		offset = atom.end
+	}
+	return mp4Atom{}, false, nil
+}
+
+func readAtomHeader(r io.ReaderAt, offset, limit int64) (mp4Atom, error) {
+	header := make([]byte, 8)
+	if _, err := r.ReadAt(header, offset); err != nil {
+		return mp4Atom{}, fmt.Errorf("failed to read MP4 atom: %w", err)
+	}
+
+	size := int64(binary.BigEndian.Uint32(header[:4]))
+	atom := mp4Atom{kind: string(header[4:8]), dataStart: offset + 8}
+
+	switch size {
+	case 0: // Extends to the end of the enclosing atom
+		atom.end = limit
+	case 1: // 64-bit size follows the type
+		large := make([]byte, 8)
+		if _, err := r.ReadAt(large, offset+8); err != nil {
+			return mp4Atom{}, fmt.Errorf("failed to read MP4 atom size: %w", err)
+		}
+		atom.dataStart += 8
+		atom.end = offset + int64(binary.BigEndian.Uint64(large))
+	default:
+		atom.end = offset + size
+	}
+
+	if atom.end < atom.dataStart || atom.end > limit {
+		return mp4Atom{}, fmt.Errorf("invalid MP4 atom %q at offset %d", atom.kind, offset)
+	}
+	return atom, nil
+}
@@ -0,0 +1,178 @@
+package fileutil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// id3Frame builds an ID3v2 text frame; v2.4 frame sizes are synchsafe
+func id3Frame(version byte, id string, encoding byte, text []byte) []byte {
+	body := append([]byte{encoding}, text...)
+	frame := []byte(id)
+	size := make([]byte, 4)
+	if version == 4 {
+		size = synchsafeBytes(len(body))
+	} else {
+		binary.BigEndian.PutUint32(size, uint32(len(body)))
+	}
+	frame = append(frame, size...)
+	frame = append(frame, 0, 0) // Flags
+	return append(frame, body...)
+}
+
+func synchsafeBytes(n int) []byte {
+	return []byte{byte(n >> 21 & 0x7f), byte(n >> 14 & 0x7f), byte(n >> 7 & 0x7f), byte(n & 0x7f)}
+}
+
+// id3Tag wraps frames in an ID3v2 header followed by some padding and fake audio
+func id3Tag(version byte, frames ...[]byte) []byte {
+	body := bytes.Join(frames, nil)
+	body = append(body, make([]byte, 16)...) // Padding
+	tag := append([]byte{'I', 'D', '3', version, 0, 0}, synchsafeBytes(len(body))...)
+	tag = append(tag, body...)
+	return append(tag, 0xff, 0xfb, 0x90, 0x00)
+}
+
+// utf16LE encodes ASCII text as BOM-prefixed little-endian UTF-16
+func utf16LE(text string) []byte {
+	out := []byte{0xff, 0xfe}
+	for _, r := range text {
+		out = append(out, byte(r), 0)
+	}
+	return out
+}
+
+// mp4Box builds an MP4 atom around its children
+func mp4Box(kind string, children ...[]byte) []byte {
+	body := bytes.Join(children, nil)
+	box := make([]byte, 8, 8+len(body))
+	binary.BigEndian.PutUint32(box, uint32(8+len(body)))
+	copy(box[4:], kind)
+	return append(box, body...)
+}
+
+// mp4Item builds an ilst item holding a UTF-8 data atom
+func mp4Item(kind, value string) []byte {
+	data := append([]byte{0, 0, 0, 1, 0, 0, 0, 0}, value...)
+	return mp4Box(kind, mp4Box("data", data))
+}
+
+func writeTestFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	return path
+}
+
+func TestReadAudioTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		data     []byte
+		want     AudioTags
+	}{
+		{
+			name:     "ID3v2.3 with Latin-1 and UTF-16 frames",
+			fileName: "01 - Chapter 1.mp3",
+			data: id3Tag(3,
+				id3Frame(3, "TIT2", 0, []byte("Chapter 1")),
+				id3Frame(3, "TALB", 1, utf16LE("The Final Empire")),
+				id3Frame(3, "TPE1", 0, []byte("Brandon Sanderson")),
+				id3Frame(3, "TCOM", 0, []byte("Michael Kramer")),
+				id3Frame(3, "TIT1", 0, []byte("Mistborn #1")),
+			),
+			want: AudioTags{
+				Title:    "Chapter 1",
+				Album:    "The Final Empire",
+				Artist:   "Brandon Sanderson",
+				Composer: "Michael Kramer",
+				Grouping: "Mistborn #1",
+			},
+		},
+		{
+			name:     "ID3v2.4 with UTF-8 frames and synchsafe sizes",
+			fileName: "part1.MP3",
+			data: id3Tag(4,
+				id3Frame(4, "TALB", 3, []byte("Les Misérables\x00")),
+				id3Frame(4, "TPE2", 3, []byte("Victor Hugo")),
+			),
+			want: AudioTags{Album: "Les Misérables", AlbumArtist: "Victor Hugo"},
+		},
+		{
+			name:     "M4B iTunes metadata",
+			fileName: "book.m4b",
+			data: append(mp4Box("ftyp", []byte("M4B \x00\x00\x00\x00")),
+				mp4Box("moov",
+					mp4Box("mvhd", make([]byte, 100)),
+					mp4Box("udta",
+						mp4Box("meta", []byte{0, 0, 0, 0},
+							mp4Box("hdlr", make([]byte, 25)),
+							mp4Box("ilst",
+								mp4Item("\xa9nam", "The Gunslinger"),
+								mp4Item("\xa9alb", "The Gunslinger"),
+								mp4Item("aART", "Stephen King"),
+								mp4Item("\xa9ART", "Stephen King; George Guidall"),
+								mp4Item("\xa9grp", "The Dark Tower, Book 1"),
+								mp4Item("cpil", "\x00"),
+							),
+						),
+					),
+				)...,
+			),
+			want: AudioTags{
+				Title:       "The Gunslinger",
+				Album:       "The Gunslinger",
+				Artist:      "Stephen King; George Guidall",
+				AlbumArtist: "Stephen King",
+				Grouping:    "The Dark Tower, Book 1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadAudioTags(writeTestFile(t, tt.fileName, tt.data))
+			if err != nil {
+				t.Fatalf("ReadAudioTags() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ReadAudioTags() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadAudioTags_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		data     []byte
+	}{
+		{"MP3 without ID3v2 tag", "track.mp3", []byte{0xff, 0xfb, 0x90, 0x00, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{"ID3v2.2 tag", "track.mp3", append([]byte{'I', 'D', '3', 2, 0, 0}, synchsafeBytes(0)...)},
+		{"truncated ID3 tag", "track.mp3", append([]byte{'I', 'D', '3', 3, 0, 0}, synchsafeBytes(100)...)},
+		{"M4B without metadata", "book.m4b", mp4Box("moov", mp4Box("mvhd", make([]byte, 100)))},
+		{"M4B with atom overrunning its parent", "book.m4b", append(mp4Box("moov"), 0, 0, 1, 0, 'u', 'd', 't', 'a')},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadAudioTags(writeTestFile(t, tt.fileName, tt.data)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := ReadAudioTags(writeTestFile(t, "track.flac", []byte("fLaC")))
+		if !errors.Is(err, ErrUnsupportedAudioFormat) {
+			t.Errorf("error = %v, want ErrUnsupportedAudioFormat", err)
+		}
+	})
+}
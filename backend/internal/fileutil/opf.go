@@ -0,0 +1,105 @@
+package fileutil
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OPFFilename is the sidecar filename Calibre and Audiobookshelf look for in a book directory.
+const OPFFilename = "metadata.opf"
+
+// OPFMetadata holds the book metadata written to an OPF sidecar.
+type OPFMetadata struct {
+	Identifier   string
+	Title        string
+	Author       string
+	Series       string
+	SeriesNumber string
+	Language     string
+}
+
+// BuildOPF renders metadata as an OPF 2.0 package document in the format Calibre writes.
+// Comma-separated authors are emitted as separate dc:creator elements.
+func BuildOPF(meta OPFMetadata) ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<package xmlns="http://www.idpf.org/2007/opf" unique-identifier="uuid_id" version="2.0">` + "\n")
+	buf.WriteString(`  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:opf="http://www.idpf.org/2007/opf">` + "\n")
+
+	if meta.Identifier != "" {
+		if err := writeOPFElement(&buf, `dc:identifier opf:scheme="uuid" id="uuid_id"`, "dc:identifier", meta.Identifier); err != nil {
+			return nil, err
+		}
+	}
+	if err := writeOPFElement(&buf, "dc:title", "dc:title", meta.Title); err != nil {
+		return nil, err
+	}
+	for _, author := range strings.Split(meta.Author, ",") {
+		author = strings.TrimSpace(author)
+		if author == "" {
+			continue
+		}
+		if err := writeOPFElement(&buf, `dc:creator opf:role="aut"`, "dc:creator", author); err != nil {
+			return nil, err
+		}
+	}
+	if meta.Language != "" {
+		if err := writeOPFElement(&buf, "dc:language", "dc:language", meta.Language); err != nil {
+			return nil, err
+		}
+	}
+	if meta.Series != "" {
+		if err := writeOPFMeta(&buf, "calibre:series", meta.Series); err != nil {
+			return nil, err
+		}
+		if meta.SeriesNumber != "" {
+			if err := writeOPFMeta(&buf, "calibre:series_index", meta.SeriesNumber); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	buf.WriteString("  </metadata>\n")
+	buf.WriteString("  <guide/>\n")
+	buf.WriteString("</package>\n")
+
+	return buf.Bytes(), nil
+}
+
+// WriteOPF writes an OPF sidecar for meta into dir and returns the file path.
+func WriteOPF(dir string, meta OPFMetadata) (string, error) {
+	data, err := BuildOPF(meta)
+	if err != nil {
+		return "", fmt.Errorf("failed to build OPF: %w", err)
+	}
+
+	path := filepath.Join(dir, OPFFilename)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write OPF: %w", err)
+	}
+
+	return path, nil
+}
+
+func writeOPFElement(buf *bytes.Buffer, open, name, value string) error {
+	buf.WriteString("    <" + open + ">")
+	if err := xml.EscapeText(buf, []byte(value)); err != nil {
+		return fmt.Errorf("failed to escape %s: %w", name, err)
+	}
+	buf.WriteString("</" + name + ">\n")
+	return nil
+}
+
+func writeOPFMeta(buf *bytes.Buffer, name, content string) error {
+	buf.WriteString(`    <meta name="` + name + `" content="`)
+	if err := xml.EscapeText(buf, []byte(content)); err != nil {
+		return fmt.Errorf("failed to escape %s: %w", name, err)
+	}
+	buf.WriteString("\"/>\n")
+	return nil
+}
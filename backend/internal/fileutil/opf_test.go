@@ -0,0 +1,112 @@
+package fileutil
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildOPF(t *testing.T) {
+	tests := []struct {
+		name        string
+		meta        OPFMetadata
+		wantContain []string
+		wantAbsent  []string
+	}{
+		{
+			name: "Book with series",
+			meta: OPFMetadata{
+				Identifier:   "abc-123",
+				Title:        "The Gunslinger",
+				Author:       "Stephen King",
+				Series:       "The Dark Tower",
+				SeriesNumber: "1",
+			},
+			wantContain: []string{
+				`<dc:identifier opf:scheme="uuid" id="uuid_id">abc-123</dc:identifier>`,
+				`<dc:title>The Gunslinger</dc:title>`,
+				`<dc:creator opf:role="aut">Stephen King</dc:creator>`,
+				`<meta name="calibre:series" content="The Dark Tower"/>`,
+				`<meta name="calibre:series_index" content="1"/>`,
+			},
+		},
+		{
+			name: "Book without series omits calibre meta",
+			meta: OPFMetadata{
+				Title:  "The Stand",
+				Author: "Stephen King",
+			},
+			wantContain: []string{`<dc:title>The Stand</dc:title>`},
+			wantAbsent:  []string{"calibre:series", "dc:identifier"},
+		},
+		{
+			name: "Multiple authors become separate creators",
+			meta: OPFMetadata{
+				Title:  "Good Omens",
+				Author: "Terry Pratchett, Neil Gaiman",
+			},
+			wantContain: []string{
+				`<dc:creator opf:role="aut">Terry Pratchett</dc:creator>`,
+				`<dc:creator opf:role="aut">Neil Gaiman</dc:creator>`,
+			},
+		},
+		{
+			name: "Special characters are escaped",
+			meta: OPFMetadata{
+				Title:  "Cats & Dogs <Vol 1>",
+				Author: "A \"Quoted\" Author",
+				Series: "Tom & Jerry",
+			},
+			wantContain: []string{
+				`<dc:title>Cats &amp; Dogs &lt;Vol 1&gt;</dc:title>`,
+				`content="Tom &amp; Jerry"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := BuildOPF(tt.meta)
+			if err != nil {
+				t.Fatalf("BuildOPF() error = %v", err)
+			}
+
+			// Output must be well-formed XML
+			var doc struct{}
+			if err := xml.Unmarshal(data, &doc); err != nil {
+				t.Fatalf("BuildOPF() produced invalid XML: %v\n%s", err, data)
+			}
+
+			got := string(data)
+			for _, want := range tt.wantContain {
+				if !strings.Contains(got, want) {
+					t.Errorf("BuildOPF() missing %q in:\n%s", want, got)
+				}
+			}
+			for _, absent := range tt.wantAbsent {
+				if strings.Contains(got, absent) {
+					t.Errorf("BuildOPF() unexpectedly contains %q in:\n%s", absent, got)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteOPF(t *testing.T) {
+	dir := t.TempDir()
+
+	path, err := WriteOPF(dir, OPFMetadata{Title: "Book", Author: "Author"})
+	if err != nil {
+		t.Fatalf("WriteOPF() error = %v", err)
+	}
+
+	if path != filepath.Join(dir, OPFFilename) {
+		t.Errorf("WriteOPF() path = %v, want %v", path, filepath.Join(dir, OPFFilename))
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("OPF file not written: %v", err)
+	}
+}
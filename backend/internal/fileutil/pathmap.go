@@ -0,0 +1,92 @@
+package fileutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// PathMapping translates a path prefix as seen by qBittorrent (Remote) into the
+// path where the same directory is mounted locally (Local).
+type PathMapping struct {
+	Remote string `json:"remote"`
+	Local  string `json:"local"`
+}
+
+// ParsePathMappings decodes the JSON list stored in paths.remote_mappings.
+// An empty value means no mappings.
+func ParsePathMappings(value string) ([]PathMapping, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	var mappings []PathMapping
+	if err := json.Unmarshal([]byte(value), &mappings); err != nil {
+		return nil, fmt.Errorf("path mappings must be a JSON array of {\"remote\", \"local\"} objects: %w", err)
+	}
+
+	for i, m := range mappings {
+		if strings.TrimSpace(m.Remote) == "" {
+			return nil, fmt.Errorf("path mapping %d: remote path is required", i)
+		}
+		if strings.TrimSpace(m.Local) == "" {
+			return nil, fmt.Errorf("path mapping %d: local path is required", i)
+		}
+	}
+
+	return mappings, nil
+}
+
+// MatchPathMapping returns the index of the mapping with the longest remote prefix
+// matching remotePath. Prefixes match whole path components, so "/data" does not
+// match "/database". Remote paths may use either slash style, since qBittorrent can
+// run on another OS. Returns -1 if no mapping applies.
+func MatchPathMapping(mappings []PathMapping, remotePath string) int {
+	path := normalizeRemotePath(remotePath)
+
+	best, bestLen := -1, -1
+	for i, m := range mappings {
+		prefix := normalizeRemotePath(m.Remote)
+		if hasPathPrefix(path, prefix) && len(prefix) > bestLen {
+			best, bestLen = i, len(prefix)
+		}
+	}
+
+	return best
+}
+
+// MapRemotePath rewrites remotePath using the mapping chosen by MatchPathMapping.
+// Returns false if no mapping applies.
+func MapRemotePath(mappings []PathMapping, remotePath string) (string, bool) {
+	index := MatchPathMapping(mappings, remotePath)
+	if index < 0 {
+		return "", false
+	}
+
+	rest := strings.TrimPrefix(normalizeRemotePath(remotePath), normalizeRemotePath(mappings[index].Remote))
+	rest = strings.TrimPrefix(rest, "/")
+	if rest == "" {
+		return filepath.Clean(mappings[index].Local), true
+	}
+	return filepath.Join(mappings[index].Local, filepath.FromSlash(rest)), true
+}
+
+// normalizeRemotePath converts separators to forward slashes and trims trailing slashes
+func normalizeRemotePath(path string) string {
+	path = strings.ReplaceAll(strings.TrimSpace(path), "\\", "/")
+	if trimmed := strings.TrimRight(path, "/"); trimmed != "" {
+		return trimmed
+	}
+	return "/"
+}
+
+func hasPathPrefix(path, prefix string) bool {
+	if prefix == "/" {
+		return strings.HasPrefix(path, "/")
+	}
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || path[len(prefix)] == '/'
+}
@@ -0,0 +1,99 @@
+package fileutil
+
+import "testing"
+
+func TestMapRemotePath(t *testing.T) {
+	mappings := []PathMapping{
+		{Remote: "/downloads", Local: "/mnt/nas1/downloads"},
+		{Remote: "/downloads/audiobooks/", Local: "/mnt/nas2/audiobooks"},
+		{Remote: `D:\Torrents`, Local: "/mnt/windows/torrents"},
+	}
+
+	tests := []struct {
+		name   string
+		remote string
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "Matches shorter prefix",
+			remote: "/downloads/ebooks/Book",
+			want:   "/mnt/nas1/downloads/ebooks/Book",
+			wantOK: true,
+		},
+		{
+			name:   "Longest prefix wins",
+			remote: "/downloads/audiobooks/Author - Title",
+			want:   "/mnt/nas2/audiobooks/Author - Title",
+			wantOK: true,
+		},
+		{
+			name:   "Exact match",
+			remote: "/downloads/audiobooks",
+			want:   "/mnt/nas2/audiobooks",
+			wantOK: true,
+		},
+		{
+			name:   "Prefix must match whole component",
+			remote: "/downloads2/Book",
+			wantOK: false,
+		},
+		{
+			name:   "Windows remote path",
+			remote: `D:\Torrents\audio\Book`,
+			want:   "/mnt/windows/torrents/audio/Book",
+			wantOK: true,
+		},
+		{
+			name:   "No mapping",
+			remote: "/other/Book",
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := MapRemotePath(mappings, tt.remote)
+			if ok != tt.wantOK {
+				t.Fatalf("MapRemotePath() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got != tt.want {
+				t.Errorf("MapRemotePath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMapRemotePath_RootMapping(t *testing.T) {
+	got, ok := MapRemotePath([]PathMapping{{Remote: "/", Local: "/mnt/share"}}, "/data/Book")
+	if !ok || got != "/mnt/share/data/Book" {
+		t.Errorf("MapRemotePath() = %v, %v, want /mnt/share/data/Book, true", got, ok)
+	}
+}
+
+func TestParsePathMappings(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		wantLen   int
+		wantError bool
+	}{
+		{name: "Empty value", value: "", wantLen: 0},
+		{name: "Valid list", value: `[{"remote":"/downloads","local":"/mnt/downloads"}]`, wantLen: 1},
+		{name: "Invalid JSON", value: `/downloads=/mnt`, wantError: true},
+		{name: "Missing local", value: `[{"remote":"/downloads"}]`, wantError: true},
+		{name: "Missing remote", value: `[{"local":"/mnt"}]`, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePathMappings(tt.value)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("ParsePathMappings() error = %v, wantError %v", err, tt.wantError)
+			}
+			if len(got) != tt.wantLen {
+				t.Errorf("ParsePathMappings() returned %d mappings, want %d", len(got), tt.wantLen)
+			}
+		})
+	}
+}
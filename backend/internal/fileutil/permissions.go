@@ -0,0 +1,116 @@
+package fileutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// Permissions describes the ownership and mode applied to directories and files Organizr creates.
+// A UID or GID of -1 leaves that part of the ownership unchanged.
+type Permissions struct {
+	UID      int
+	GID      int
+	DirMode  os.FileMode
+	FileMode os.FileMode
+	// InheritGroup sets the setgid bit on created directories and, when GID is unset,
+	// gives created entries the group of their parent directory.
+	InheritGroup bool
+}
+
+// DefaultPermissions returns the permissions used when nothing is configured:
+// process ownership, 0755 directories and 0644 files.
+func DefaultPermissions() Permissions {
+	return Permissions{
+		UID:      -1,
+		GID:      -1,
+		DirMode:  0755,
+		FileMode: 0644,
+	}
+}
+
+// MkdirAll creates path and any missing parents, applying p to every directory it creates.
+// Directories that already exist are left untouched.
+func (p Permissions) MkdirAll(path string) error {
+	path = filepath.Clean(path)
+
+	info, err := os.Stat(path)
+	if err == nil {
+		if !info.IsDir() {
+			return fmt.Errorf("%s exists and is not a directory", path)
+		}
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	if parent := filepath.Dir(path); parent != path {
+		if err := p.MkdirAll(parent); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Mkdir(path, p.DirMode.Perm()); err != nil {
+		if os.IsExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to create directory %s: %w", path, err)
+	}
+
+	return p.ApplyDir(path)
+}
+
+// ApplyDir sets the configured mode and ownership on a directory.
+func (p Permissions) ApplyDir(path string) error {
+	mode := p.DirMode.Perm()
+	if p.InheritGroup {
+		mode |= os.ModeSetgid
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		return fmt.Errorf("failed to set mode %#o on directory %s: %w", mode.Perm(), path, err)
+	}
+	return p.chown(path)
+}
+
+// ApplyFile sets the configured mode and ownership on a file.
+func (p Permissions) ApplyFile(path string) error {
+	if err := os.Chmod(path, p.FileMode.Perm()); err != nil {
+		return fmt.Errorf("failed to set mode %#o on file %s: %w", p.FileMode.Perm(), path, err)
+	}
+	return p.chown(path)
+}
+
+func (p Permissions) chown(path string) error {
+	uid, gid := p.UID, p.GID
+	if gid < 0 && p.InheritGroup {
+		parentGID, err := groupOf(filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		gid = parentGID
+	}
+
+	if uid < 0 && gid < 0 {
+		return nil
+	}
+
+	if err := os.Chown(path, uid, gid); err != nil {
+		return fmt.Errorf("failed to set ownership %d:%d on %s: %w", uid, gid, path, err)
+	}
+	return nil
+}
+
+// groupOf returns the owning group ID of path
+func groupOf(path string) (int, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return -1, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return -1, fmt.Errorf("cannot determine group of %s on this platform", path)
+	}
+	return int(stat.Gid), nil
+}
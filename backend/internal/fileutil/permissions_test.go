@@ -0,0 +1,107 @@
+package fileutil
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestPermissions_MkdirAll(t *testing.T) {
+	base := t.TempDir()
+
+	perms := DefaultPermissions()
+	perms.DirMode = 0750
+	perms.InheritGroup = true
+
+	target := filepath.Join(base, "Author", "Series", "Title")
+	if err := perms.MkdirAll(target); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+
+	for _, dir := range []string{
+		filepath.Join(base, "Author"),
+		filepath.Join(base, "Author", "Series"),
+		target,
+	} {
+		info, err := os.Stat(dir)
+		if err != nil {
+			t.Fatalf("expected %s to exist: %v", dir, err)
+		}
+		if info.Mode().Perm() != 0750 {
+			t.Errorf("%s mode = %#o, want %#o", dir, info.Mode().Perm(), 0750)
+		}
+		if info.Mode()&os.ModeSetgid == 0 {
+			t.Errorf("%s missing setgid bit with InheritGroup", dir)
+		}
+
+		wantGID := int(mustStat(t, base).Gid)
+		if gid := int(mustStat(t, dir).Gid); gid != wantGID {
+			t.Errorf("%s gid = %d, want inherited %d", dir, gid, wantGID)
+		}
+	}
+
+	// Existing directories are left untouched
+	if err := os.Chmod(target, 0700); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+	if err := perms.MkdirAll(target); err != nil {
+		t.Fatalf("MkdirAll() on existing dir error = %v", err)
+	}
+	if info, _ := os.Stat(target); info.Mode().Perm() != 0700 {
+		t.Errorf("existing directory mode changed to %#o", info.Mode().Perm())
+	}
+}
+
+func TestPermissions_MkdirAll_FileInTheWay(t *testing.T) {
+	base := t.TempDir()
+	file := filepath.Join(base, "file")
+	if err := os.WriteFile(file, []byte("x"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	if err := DefaultPermissions().MkdirAll(filepath.Join(file, "sub")); err == nil {
+		t.Error("MkdirAll() expected error when a parent is a file")
+	}
+}
+
+func TestPermissions_ApplyFile(t *testing.T) {
+	base := t.TempDir()
+	file := filepath.Join(base, "book.m4b")
+	if err := os.WriteFile(file, []byte("audio"), 0600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	perms := DefaultPermissions()
+	perms.FileMode = 0664
+	// Chowning to our own IDs is always permitted
+	perms.UID = os.Getuid()
+	perms.GID = os.Getgid()
+
+	if err := perms.ApplyFile(file); err != nil {
+		t.Fatalf("ApplyFile() error = %v", err)
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if info.Mode().Perm() != 0664 {
+		t.Errorf("file mode = %#o, want %#o", info.Mode().Perm(), 0664)
+	}
+}
+
+func TestPermissions_ApplyFile_Missing(t *testing.T) {
+	if err := DefaultPermissions().ApplyFile(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("ApplyFile() expected error for missing file")
+	}
+}
+
+func mustStat(t *testing.T, path string) *syscall.Stat_t {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat %s: %v", path, err)
+	}
+	return info.Sys().(*syscall.Stat_t)
+}
@@ -8,9 +8,13 @@ type Download struct {
 	Author        string
 	Series        string
 	SeriesNumber  string
+	MediaType     MediaType
 	TorrentURL    string
 	MagnetLink    string
 	TorrentBytes  []byte
+	TorrentID     string // Tracker torrent ID, when added from a search result
+	Provider      string // Search provider the torrent ID belongs to; empty means MyAnonamouse
+	SearchQuery   string // Search that found the torrent, re-run to find alternatives when it stalls
 	Category      string
 	QBitHash      string
 	Status        DownloadStatus
@@ -21,6 +25,16 @@ type Download struct {
 	CreatedAt     time.Time
 	CompletedAt   *time.Time
 	OrganizedAt   *time.Time
+	MissingSince  *time.Time // When the torrent was first found missing from qBittorrent
+	ProgressAt    *time.Time // When download progress last advanced
+	WedgeUsed     bool       // A MAM freeleech wedge was spent on the torrent
+
+	// Not stored; set when adding a download for the MAM account guard
+	Size      int64 // Torrent size in bytes, if known
+	Freeleech bool
+	UseWedge  *bool      // Whether to spend a MAM freeleech wedge; nil follows mam.wedge_policy
+	AddedBy   EventActor // Recorded as adding the download; empty means the user
+	Warnings  []string   // Raised while adding the download, e.g. by the MAM account guard
 }
 
 type DownloadStatus string
@@ -32,4 +46,28 @@ const (
 	StatusOrganizing  DownloadStatus = "organizing"
 	StatusOrganized   DownloadStatus = "organized"
 	StatusFailed      DownloadStatus = "failed"
+	StatusMissing     DownloadStatus = "missing" // Torrent was removed from qBittorrent
+	StatusStalled     DownloadStatus = "stalled" // No download progress for too long
+)
+
+// TorrentReplacement records a stalled download's torrent being swapped for another release
+type TorrentReplacement struct {
+	ID           int64
+	DownloadID   string
+	OldHash      string
+	OldProvider  string
+	OldTorrentID string
+	NewHash      string
+	NewProvider  string
+	NewTorrentID string
+	Reason       string
+	CreatedAt    time.Time
+}
+
+// MediaType distinguishes the kind of book a download or search result holds.
+type MediaType string
+
+const (
+	MediaTypeAudiobook MediaType = "audiobook"
+	MediaTypeEbook     MediaType = "ebook"
 )
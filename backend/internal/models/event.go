@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// DownloadEvent records a download moving from one status to another
+type DownloadEvent struct {
+	ID         int64
+	DownloadID string
+	FromStatus DownloadStatus // Empty for the event that created the download
+	ToStatus   DownloadStatus
+	Actor      EventActor
+	Reason     string
+	CreatedAt  time.Time
+}
+
+// EventActor is what moved a download to a new status
+type EventActor string
+
+const (
+	ActorMonitor EventActor = "monitor" // Polling, organization workers and startup recovery
+	ActorUser    EventActor = "user"    // Actions requested through the API, e.g. adding or retrying a download
+	ActorAPI     EventActor = "api"     // Calls from other programs, e.g. qBittorrent's torrent finished webhook
+	ActorWanted  EventActor = "wanted"  // Scheduled searches of the wanted list
+)
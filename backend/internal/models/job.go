@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// OrganizeJob is a queued request to organize a download's files
+type OrganizeJob struct {
+	ID         int64
+	DownloadID string
+	Status     JobStatus
+	Source     string // What enqueued the job, e.g. "monitor"
+	Attempt    int    // 1 for the first organization attempt, incremented by each retry
+	Error      string
+	Transient  bool // Whether the failure may succeed when retried
+	CreatedAt  time.Time
+	RunAfter   *time.Time // Earliest time a retry may run; nil runs as soon as possible
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+}
+
+type JobStatus string
+
+const (
+	JobPending JobStatus = "pending"
+	JobRunning JobStatus = "running"
+	JobDone    JobStatus = "done"
+	JobFailed  JobStatus = "failed"
+)
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// JournalEntry records one planned file operation of an organization so an
+// interrupted run can be resumed or rolled back after a restart.
+type JournalEntry struct {
+	ID         int64
+	DownloadID string
+	SourcePath string
+	DestPath   string
+	Operation  string // "copy" or "move"
+	Done       bool
+	CreatedAt  time.Time
+}
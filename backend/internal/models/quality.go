@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// QualityProfile describes which releases of a book are preferred, to rank search results
+// and pick one automatically
+type QualityProfile struct {
+	ID   int64
+	Name string
+	// File types in order of preference, e.g. m4b before mp3. Unlisted types score nothing.
+	PreferredFormats []string
+	// Size bounds in megabytes; 0 means unbounded. Releases outside them are rejected.
+	MinSizeMB int64
+	MaxSizeMB int64
+	// Language codes as providers report them, e.g. ENG
+	PreferredLanguages []string
+	// Points added to freeleech releases
+	FreeleechBonus int
+	// Releases with fewer seeders are rejected
+	MinSeeders         int
+	PreferredUploaders []string
+	BlockedUploaders   []string
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+}
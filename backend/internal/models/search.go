@@ -13,7 +13,9 @@ type SearchResult struct {
 	Author     string
 	TorrentURL string
 	MagnetLink string
+	InfoHash   string // BitTorrent info hash, when the provider reports it
 	Provider   string
+	MediaType  MediaType
 
 	// Metadata
 	Series      []SeriesInfo
@@ -24,6 +26,7 @@ type SearchResult struct {
 	Tags        []string
 	Description string
 	Added       string
+	Uploader    string
 
 	// Torrent stats
 	Size           string
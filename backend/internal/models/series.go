@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// FollowedSeries is a series whose missing and newly released entries are added to the
+// wanted list
+type FollowedSeries struct {
+	ID int64
+	// Provider whose series ID this is; only MyAnonamouse numbers its series
+	Provider  string
+	SeriesID  string
+	Name      string
+	MediaType MediaType
+	Category  string // qBittorrent category of the entries' downloads
+	// Profile that picks the entries' releases; nil uses the default profile
+	QualityProfileID *int64
+	// Entries numbered at or below it aren't queued, to follow only new releases; empty
+	// queues every missing entry
+	StartAfter    string
+	LastCheckedAt *time.Time
+	LastError     string // Why the last check failed
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// WantedStatus is where a wanted book is in being found
+type WantedStatus string
+
+const (
+	WantedStatusWanted  WantedStatus = "wanted"  // Searched for until a release is grabbed
+	WantedStatusGrabbed WantedStatus = "grabbed" // A release was added as a download
+)
+
+// WantedBook is a book to search for periodically and download once a release shows up
+type WantedBook struct {
+	ID           int64
+	Title        string
+	Author       string
+	Series       string
+	SeriesNumber string
+	MediaType    MediaType
+	Category     string // qBittorrent category of the download
+	// Profile that picks the release; nil uses the default profile
+	QualityProfileID *int64
+	Status           WantedStatus
+	DownloadID       string // Download of the grabbed release
+	LastSearchedAt   *time.Time
+	LastError        string // Why the last search grabbed nothing
+	// Followed series the book was queued for; nil for books added by hand
+	FollowedSeriesID *int64
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
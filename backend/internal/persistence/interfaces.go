@@ -2,20 +2,65 @@ package persistence
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/nathanael/organizr/internal/models"
 )
 
+// ErrDownloadNotFound is returned for a download ID or torrent hash no download has
+var ErrDownloadNotFound = errors.New("download not found")
+
+// ErrQualityProfileNotFound is returned for a quality profile ID that doesn't exist
+var ErrQualityProfileNotFound = errors.New("quality profile not found")
+
+// ErrQualityProfileExists is returned when a quality profile's name is already taken
+var ErrQualityProfileExists = errors.New("a quality profile with this name already exists")
+
+// ErrWantedBookNotFound is returned for a wanted book ID that doesn't exist
+var ErrWantedBookNotFound = errors.New("wanted book not found")
+
+// ErrWantedBookExists is returned when the same book is already on the wanted list
+var ErrWantedBookExists = errors.New("this book is already on the wanted list")
+
+// ErrFollowedSeriesNotFound is returned for a followed series ID that doesn't exist
+var ErrFollowedSeriesNotFound = errors.New("followed series not found")
+
+// ErrFollowedSeriesExists is returned when a series is already followed for the media type
+var ErrFollowedSeriesExists = errors.New("this series is already followed")
+
+// ErrCacheMiss is returned for a cache entry that doesn't exist or has expired
+var ErrCacheMiss = errors.New("cache miss")
+
 type DownloadRepository interface {
 	Create(ctx context.Context, d *models.Download) error
 	GetByID(ctx context.Context, id string) (*models.Download, error)
+	// GetByQBitHash returns the download tracking a torrent; hashes match case-insensitively.
+	GetByQBitHash(ctx context.Context, hash string) (*models.Download, error)
 	GetActive(ctx context.Context) ([]*models.Download, error)
+	GetByStatus(ctx context.Context, status models.DownloadStatus) ([]*models.Download, error)
 	List(ctx context.Context) ([]*models.Download, error)
-	UpdateStatus(ctx context.Context, id string, status models.DownloadStatus) error
+	// TransitionStatus moves a download from event.FromStatus to event.ToStatus and records the
+	// event, failing if the download's status is no longer event.FromStatus. Moving to completed
+	// also records the completion time.
+	TransitionStatus(ctx context.Context, event *models.DownloadEvent) error
+	// AddEvent records an event without changing the download, e.g. its creation.
+	AddEvent(ctx context.Context, event *models.DownloadEvent) error
+	// ListEvents returns a download's status history, oldest first.
+	ListEvents(ctx context.Context, downloadID string) ([]*models.DownloadEvent, error)
 	UpdateProgress(ctx context.Context, id string, progress float64) error
 	UpdateError(ctx context.Context, id string, errorMsg string) error
 	UpdateOrganizedPath(ctx context.Context, id string, path string) error
-	UpdateCompleted(ctx context.Context, id string) error
+	UpdateQBitHash(ctx context.Context, id string, hash string) error
+	// UpdateMissingSince records when the torrent was first found missing; nil clears it.
+	UpdateMissingSince(ctx context.Context, id string, since *time.Time) error
+	// ReplaceTorrent points a download at a new torrent, resetting its progress, and records the
+	// replacement of the old one.
+	ReplaceTorrent(ctx context.Context, replacement *models.TorrentReplacement, torrentData []byte) error
+	// ListReplacements returns a download's replaced torrents, oldest first.
+	ListReplacements(ctx context.Context, downloadID string) ([]*models.TorrentReplacement, error)
+	// GetTorrentData returns the stored .torrent file, or nil if the download wasn't added from one.
+	GetTorrentData(ctx context.Context, id string) ([]byte, error)
 	Delete(ctx context.Context, id string) error
 }
 
@@ -24,3 +69,84 @@ type ConfigRepository interface {
 	GetAll(ctx context.Context) (map[string]string, error)
 	Set(ctx context.Context, key, value string) error
 }
+
+type OrganizationJournalRepository interface {
+	Replace(ctx context.Context, downloadID string, entries []*models.JournalEntry) error
+	MarkDone(ctx context.Context, id int64) error
+	ListByDownload(ctx context.Context, downloadID string) ([]*models.JournalEntry, error)
+	DeleteByDownload(ctx context.Context, downloadID string) error
+}
+
+type OrganizeJobRepository interface {
+	// Enqueue adds a pending job unless the download already has a pending or running one,
+	// in which case that job is returned and created is false.
+	Enqueue(ctx context.Context, downloadID, source string) (job *models.OrganizeJob, created bool, err error)
+	// Schedule adds a pending job that runs no earlier than runAfter. A pending job for the
+	// download is rescheduled instead; a running one is returned unchanged.
+	Schedule(ctx context.Context, downloadID, source string, attempt int, runAfter time.Time) (*models.OrganizeJob, error)
+	// ClaimNext marks the oldest due pending job running and returns it, or nil if none are due.
+	ClaimNext(ctx context.Context) (*models.OrganizeJob, error)
+	Release(ctx context.Context, id int64) error
+	Complete(ctx context.Context, id int64) error
+	Fail(ctx context.Context, id int64, errorMsg string, transient bool) error
+	// FailRunning fails jobs left running by a previous process.
+	FailRunning(ctx context.Context, errorMsg string) (int64, error)
+	// ListByDownload returns a download's jobs, oldest first, as its attempt history.
+	ListByDownload(ctx context.Context, downloadID string) ([]*models.OrganizeJob, error)
+}
+
+type QualityProfileRepository interface {
+	// List returns every profile, ordered by name.
+	List(ctx context.Context) ([]*models.QualityProfile, error)
+	GetByID(ctx context.Context, id int64) (*models.QualityProfile, error)
+	// Create stores a new profile, setting its ID and timestamps.
+	Create(ctx context.Context, p *models.QualityProfile) error
+	Update(ctx context.Context, p *models.QualityProfile) error
+	Delete(ctx context.Context, id int64) error
+}
+
+type WantedBookRepository interface {
+	// List returns every wanted book, newest first.
+	List(ctx context.Context) ([]*models.WantedBook, error)
+	// ListDue returns the books still wanted that weren't searched for since before, least
+	// recently searched first.
+	ListDue(ctx context.Context, before time.Time) ([]*models.WantedBook, error)
+	GetByID(ctx context.Context, id int64) (*models.WantedBook, error)
+	// Create stores a new wanted book, setting its ID and timestamps.
+	Create(ctx context.Context, b *models.WantedBook) error
+	// Update saves a wanted book's details, status and download.
+	Update(ctx context.Context, b *models.WantedBook) error
+	// RecordSearch notes when a wanted book was searched for and why nothing was grabbed.
+	RecordSearch(ctx context.Context, id int64, searchedAt time.Time, lastError string) error
+	Delete(ctx context.Context, id int64) error
+}
+
+type FollowedSeriesRepository interface {
+	// List returns every followed series, by name.
+	List(ctx context.Context) ([]*models.FollowedSeries, error)
+	// ListDue returns the series that weren't checked since before, least recently checked first.
+	ListDue(ctx context.Context, before time.Time) ([]*models.FollowedSeries, error)
+	GetByID(ctx context.Context, id int64) (*models.FollowedSeries, error)
+	// Create stores a new followed series, setting its ID and timestamps.
+	Create(ctx context.Context, s *models.FollowedSeries) error
+	// Update saves a followed series' details.
+	Update(ctx context.Context, s *models.FollowedSeries) error
+	// RecordCheck notes when a series was checked for new entries and why the check failed.
+	RecordCheck(ctx context.Context, id int64, checkedAt time.Time, lastError string) error
+	// Delete unfollows a series; the wanted books queued for it stay on the wanted list.
+	Delete(ctx context.Context, id int64) error
+}
+
+type SearchCacheRepository interface {
+	// GetSearch returns the results cached under key and when they were stored, or ErrCacheMiss.
+	GetSearch(ctx context.Context, key string) (results []byte, createdAt time.Time, err error)
+	// PutSearch caches results under key until expiresAt, replacing any entry already there.
+	PutSearch(ctx context.Context, key string, results []byte, expiresAt time.Time) error
+	// GetTorrent returns a provider's cached torrent file, or ErrCacheMiss.
+	GetTorrent(ctx context.Context, provider, torrentID string) ([]byte, error)
+	PutTorrent(ctx context.Context, provider, torrentID string, data []byte, expiresAt time.Time) error
+	// DeleteExpired removes searches and torrents that expired by now, returning how many.
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+	ClearSearches(ctx context.Context) error
+	ClearTorrents(ctx context.Context) error
+}
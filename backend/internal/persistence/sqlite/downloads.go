@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/persistence"
 )
 
 type DownloadRepository struct {
@@ -19,12 +20,17 @@ func NewDownloadRepository(db *sql.DB) *DownloadRepository {
 
 func (r *DownloadRepository) Create(ctx context.Context, d *models.Download) error {
 	query := `
-		INSERT INTO downloads (id, title, author, series, series_number, torrent_url, magnet_link, category, qbit_hash, status, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO downloads (id, title, author, series, series_number, media_type, torrent_url, magnet_link, torrent_data, torrent_id, provider, search_query, category, qbit_hash, status, created_at, wedge_used)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
+	mediaType := d.MediaType
+	if mediaType == "" {
+		mediaType = models.MediaTypeAudiobook
+	}
+
 	_, err := r.db.ExecContext(ctx, query,
-		d.ID, d.Title, d.Author, d.Series, d.SeriesNumber, d.TorrentURL, d.MagnetLink, d.Category, d.QBitHash, d.Status, d.CreatedAt,
+		d.ID, d.Title, d.Author, d.Series, d.SeriesNumber, mediaType, d.TorrentURL, d.MagnetLink, d.TorrentBytes, d.TorrentID, d.Provider, d.SearchQuery, d.Category, d.QBitHash, d.Status, d.CreatedAt, d.WedgeUsed,
 	)
 
 	if err != nil {
@@ -36,25 +42,27 @@ func (r *DownloadRepository) Create(ctx context.Context, d *models.Download) err
 
 func (r *DownloadRepository) GetByID(ctx context.Context, id string) (*models.Download, error) {
 	query := `
-		SELECT id, title, author, series, series_number, torrent_url, magnet_link, category, qbit_hash, status, progress,
-		       download_path, organized_path, error_message, created_at, completed_at, organized_at
+		SELECT id, title, author, series, series_number, media_type, torrent_url, magnet_link, category, qbit_hash, status, progress,
+		       download_path, organized_path, error_message, created_at, completed_at, organized_at, missing_since,
+		       torrent_id, provider, search_query, progress_at, wedge_used
 		FROM downloads
 		WHERE id = ?
 	`
 
 	var d models.Download
-	var completedAt, organizedAt sql.NullTime
-	var series, seriesNumber, torrentURL, magnetLink, category sql.NullString
-	var downloadPath, organizedPath, errorMessage sql.NullString
+	var completedAt, organizedAt, missingSince, progressAt sql.NullTime
+	var series, seriesNumber, mediaType, torrentURL, magnetLink, category sql.NullString
+	var downloadPath, organizedPath, errorMessage, torrentID, provider, searchQuery sql.NullString
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&d.ID, &d.Title, &d.Author, &series, &seriesNumber, &torrentURL, &magnetLink, &category, &d.QBitHash,
+		&d.ID, &d.Title, &d.Author, &series, &seriesNumber, &mediaType, &torrentURL, &magnetLink, &category, &d.QBitHash,
 		&d.Status, &d.Progress, &downloadPath, &organizedPath, &errorMessage,
-		&d.CreatedAt, &completedAt, &organizedAt,
+		&d.CreatedAt, &completedAt, &organizedAt, &missingSince,
+		&torrentID, &provider, &searchQuery, &progressAt, &d.WedgeUsed,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("download not found")
+		return nil, persistence.ErrDownloadNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to query download: %w", err)
@@ -67,6 +75,7 @@ func (r *DownloadRepository) GetByID(ctx context.Context, id string) (*models.Do
 	if seriesNumber.Valid {
 		d.SeriesNumber = seriesNumber.String
 	}
+	d.MediaType = scanMediaType(mediaType)
 	if torrentURL.Valid {
 		d.TorrentURL = torrentURL.String
 	}
@@ -91,15 +100,44 @@ func (r *DownloadRepository) GetByID(ctx context.Context, id string) (*models.Do
 	if organizedAt.Valid {
 		d.OrganizedAt = &organizedAt.Time
 	}
+	if missingSince.Valid {
+		d.MissingSince = &missingSince.Time
+	}
+	if torrentID.Valid {
+		d.TorrentID = torrentID.String
+	}
+	if provider.Valid {
+		d.Provider = provider.String
+	}
+	if searchQuery.Valid {
+		d.SearchQuery = searchQuery.String
+	}
+	if progressAt.Valid {
+		d.ProgressAt = &progressAt.Time
+	}
 
 	return &d, nil
 }
 
+// GetByQBitHash returns the download tracking a torrent, matching the hash case-insensitively
+func (r *DownloadRepository) GetByQBitHash(ctx context.Context, hash string) (*models.Download, error) {
+	var id string
+	err := r.db.QueryRowContext(ctx, `SELECT id FROM downloads WHERE qbit_hash = ? COLLATE NOCASE`, hash).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, persistence.ErrDownloadNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query download by hash: %w", err)
+	}
+
+	return r.GetByID(ctx, id)
+}
+
 func (r *DownloadRepository) GetActive(ctx context.Context) ([]*models.Download, error) {
 	query := `
-		SELECT id, title, author, series, series_number, qbit_hash, status, progress
+		SELECT id, title, author, series, series_number, media_type, qbit_hash, status, progress, missing_since, created_at, progress_at
 		FROM downloads
-		WHERE status IN ('queued', 'downloading', 'completed')
+		WHERE status IN ('queued', 'downloading', 'stalled', 'completed')
 		ORDER BY created_at DESC
 	`
 
@@ -117,8 +155,9 @@ func (r *DownloadRepository) GetActive(ctx context.Context) ([]*models.Download,
 	var downloads []*models.Download
 	for rows.Next() {
 		var d models.Download
-		var series, seriesNumber sql.NullString
-		if err := rows.Scan(&d.ID, &d.Title, &d.Author, &series, &seriesNumber, &d.QBitHash, &d.Status, &d.Progress); err != nil {
+		var series, seriesNumber, mediaType sql.NullString
+		var missingSince, progressAt sql.NullTime
+		if err := rows.Scan(&d.ID, &d.Title, &d.Author, &series, &seriesNumber, &mediaType, &d.QBitHash, &d.Status, &d.Progress, &missingSince, &d.CreatedAt, &progressAt); err != nil {
 			return nil, fmt.Errorf("failed to scan download: %w", err)
 		}
 		if series.Valid {
@@ -127,6 +166,52 @@ func (r *DownloadRepository) GetActive(ctx context.Context) ([]*models.Download,
 		if seriesNumber.Valid {
 			d.SeriesNumber = seriesNumber.String
 		}
+		d.MediaType = scanMediaType(mediaType)
+		if missingSince.Valid {
+			d.MissingSince = &missingSince.Time
+		}
+		if progressAt.Valid {
+			d.ProgressAt = &progressAt.Time
+		}
+		downloads = append(downloads, &d)
+	}
+
+	return downloads, nil
+}
+
+func (r *DownloadRepository) GetByStatus(ctx context.Context, status models.DownloadStatus) ([]*models.Download, error) {
+	query := `
+		SELECT id, title, author, series, series_number, media_type, qbit_hash, status, progress
+		FROM downloads
+		WHERE status = ?
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query downloads by status: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			// Log close errors as they may indicate database issues
+			fmt.Printf("failed to close download rows: %v\n", err)
+		}
+	}()
+
+	var downloads []*models.Download
+	for rows.Next() {
+		var d models.Download
+		var series, seriesNumber, mediaType sql.NullString
+		if err := rows.Scan(&d.ID, &d.Title, &d.Author, &series, &seriesNumber, &mediaType, &d.QBitHash, &d.Status, &d.Progress); err != nil {
+			return nil, fmt.Errorf("failed to scan download: %w", err)
+		}
+		if series.Valid {
+			d.Series = series.String
+		}
+		if seriesNumber.Valid {
+			d.SeriesNumber = seriesNumber.String
+		}
+		d.MediaType = scanMediaType(mediaType)
 		downloads = append(downloads, &d)
 	}
 
@@ -135,7 +220,7 @@ func (r *DownloadRepository) GetActive(ctx context.Context) ([]*models.Download,
 
 func (r *DownloadRepository) List(ctx context.Context) ([]*models.Download, error) {
 	query := `
-		SELECT id, title, author, series, series_number, qbit_hash, status, progress, created_at
+		SELECT id, title, author, series, series_number, media_type, qbit_hash, status, progress, created_at, wedge_used
 		FROM downloads
 		ORDER BY created_at DESC
 	`
@@ -154,8 +239,8 @@ func (r *DownloadRepository) List(ctx context.Context) ([]*models.Download, erro
 	var downloads []*models.Download
 	for rows.Next() {
 		var d models.Download
-		var series, seriesNumber sql.NullString
-		if err := rows.Scan(&d.ID, &d.Title, &d.Author, &series, &seriesNumber, &d.QBitHash, &d.Status, &d.Progress, &d.CreatedAt); err != nil {
+		var series, seriesNumber, mediaType sql.NullString
+		if err := rows.Scan(&d.ID, &d.Title, &d.Author, &series, &seriesNumber, &mediaType, &d.QBitHash, &d.Status, &d.Progress, &d.CreatedAt, &d.WedgeUsed); err != nil {
 			return nil, fmt.Errorf("failed to scan download: %w", err)
 		}
 		if series.Valid {
@@ -164,24 +249,117 @@ func (r *DownloadRepository) List(ctx context.Context) ([]*models.Download, erro
 		if seriesNumber.Valid {
 			d.SeriesNumber = seriesNumber.String
 		}
+		d.MediaType = scanMediaType(mediaType)
 		downloads = append(downloads, &d)
 	}
 
 	return downloads, nil
 }
 
-func (r *DownloadRepository) UpdateStatus(ctx context.Context, id string, status models.DownloadStatus) error {
-	query := `UPDATE downloads SET status = ? WHERE id = ?`
-	_, err := r.db.ExecContext(ctx, query, status, id)
+func (r *DownloadRepository) TransitionStatus(ctx context.Context, event *models.DownloadEvent) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin status transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback() // No-op after a successful commit
+	}()
+
+	// Compare and swap, so a transition validated against a stale status can't apply
+	query := `UPDATE downloads SET status = ? WHERE id = ? AND status = ?`
+	if event.ToStatus == models.StatusCompleted {
+		query = `UPDATE downloads SET status = ?, completed_at = CURRENT_TIMESTAMP WHERE id = ? AND status = ?`
+	}
+	result, err := tx.ExecContext(ctx, query, event.ToStatus, event.DownloadID, event.FromStatus)
 	if err != nil {
 		return fmt.Errorf("failed to update status: %w", err)
 	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("download %s is no longer %s", event.DownloadID, event.FromStatus)
+	}
+
+	if err := insertEvent(ctx, tx, event); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit status transition: %w", err)
+	}
 	return nil
 }
 
+func (r *DownloadRepository) AddEvent(ctx context.Context, event *models.DownloadEvent) error {
+	return insertEvent(ctx, r.db, event)
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func insertEvent(ctx context.Context, db execer, event *models.DownloadEvent) error {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO download_events (download_id, from_status, to_status, actor, reason, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	result, err := db.ExecContext(ctx, query, event.DownloadID, event.FromStatus, event.ToStatus, event.Actor, event.Reason, event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert download event: %w", err)
+	}
+	if id, err := result.LastInsertId(); err == nil {
+		event.ID = id
+	}
+	return nil
+}
+
+func (r *DownloadRepository) ListEvents(ctx context.Context, downloadID string) ([]*models.DownloadEvent, error) {
+	query := `
+		SELECT id, download_id, from_status, to_status, actor, reason, created_at
+		FROM download_events
+		WHERE download_id = ?
+		ORDER BY id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, downloadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query download events: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			// Log close errors as they may indicate database issues
+			fmt.Printf("failed to close download event rows: %v\n", err)
+		}
+	}()
+
+	events := []*models.DownloadEvent{}
+	for rows.Next() {
+		var e models.DownloadEvent
+		if err := rows.Scan(&e.ID, &e.DownloadID, &e.FromStatus, &e.ToStatus, &e.Actor, &e.Reason, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan download event: %w", err)
+		}
+		events = append(events, &e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating download events: %w", err)
+	}
+
+	return events, nil
+}
+
 func (r *DownloadRepository) UpdateProgress(ctx context.Context, id string, progress float64) error {
-	query := `UPDATE downloads SET progress = ? WHERE id = ?`
-	_, err := r.db.ExecContext(ctx, query, progress, id)
+	// progress_at only moves when progress advances, so it tells how long a download has been stuck
+	query := `
+		UPDATE downloads
+		SET progress_at = CASE WHEN progress_at IS NULL OR ? > progress THEN ? ELSE progress_at END,
+		    progress = ?
+		WHERE id = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, progress, time.Now(), progress, id)
 	if err != nil {
 		return fmt.Errorf("failed to update progress: %w", err)
 	}
@@ -206,20 +384,154 @@ func (r *DownloadRepository) UpdateOrganizedPath(ctx context.Context, id string,
 	return nil
 }
 
-func (r *DownloadRepository) UpdateCompleted(ctx context.Context, id string) error {
-	query := `UPDATE downloads SET completed_at = ? WHERE id = ?`
-	_, err := r.db.ExecContext(ctx, query, time.Now(), id)
+func (r *DownloadRepository) UpdateQBitHash(ctx context.Context, id string, hash string) error {
+	query := `UPDATE downloads SET qbit_hash = ? WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, query, hash, id)
+	if err != nil {
+		return fmt.Errorf("failed to update qbit hash: %w", err)
+	}
+	return nil
+}
+
+func (r *DownloadRepository) UpdateMissingSince(ctx context.Context, id string, since *time.Time) error {
+	query := `UPDATE downloads SET missing_since = ? WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, query, since, id)
+	if err != nil {
+		return fmt.Errorf("failed to update missing since: %w", err)
+	}
+	return nil
+}
+
+func (r *DownloadRepository) ReplaceTorrent(ctx context.Context, replacement *models.TorrentReplacement, torrentData []byte) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin replacement transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback() // No-op after a successful commit
+	}()
+
+	if replacement.CreatedAt.IsZero() {
+		replacement.CreatedAt = time.Now()
+	}
+
+	// The new torrent starts from scratch; torrent_url and magnet_link pointed at the old one.
+	// Its stall clock starts now, or it would count as stuck since the download was created.
+	query := `
+		UPDATE downloads
+		SET qbit_hash = ?, torrent_id = ?, provider = ?, torrent_data = ?, torrent_url = NULL, magnet_link = NULL,
+		    progress = 0, progress_at = ?, missing_since = NULL
+		WHERE id = ?
+	`
+	if _, err := tx.ExecContext(ctx, query, replacement.NewHash, replacement.NewTorrentID, replacement.NewProvider, torrentData, replacement.CreatedAt, replacement.DownloadID); err != nil {
+		return fmt.Errorf("failed to update download torrent: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO torrent_replacements (download_id, old_hash, old_provider, old_torrent_id, new_hash, new_provider, new_torrent_id, reason, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, replacement.DownloadID, replacement.OldHash, replacement.OldProvider, replacement.OldTorrentID, replacement.NewHash, replacement.NewProvider, replacement.NewTorrentID, replacement.Reason, replacement.CreatedAt)
 	if err != nil {
-		return fmt.Errorf("failed to update completed time: %w", err)
+		return fmt.Errorf("failed to insert torrent replacement: %w", err)
+	}
+	if id, err := result.LastInsertId(); err == nil {
+		replacement.ID = id
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit torrent replacement: %w", err)
 	}
 	return nil
 }
 
+func (r *DownloadRepository) ListReplacements(ctx context.Context, downloadID string) ([]*models.TorrentReplacement, error) {
+	query := `
+		SELECT id, download_id, old_hash, old_provider, old_torrent_id, new_hash, new_provider, new_torrent_id, reason, created_at
+		FROM torrent_replacements
+		WHERE download_id = ?
+		ORDER BY id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, downloadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query torrent replacements: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			// Log close errors as they may indicate database issues
+			fmt.Printf("failed to close torrent replacement rows: %v\n", err)
+		}
+	}()
+
+	replacements := []*models.TorrentReplacement{}
+	for rows.Next() {
+		var rep models.TorrentReplacement
+		if err := rows.Scan(&rep.ID, &rep.DownloadID, &rep.OldHash, &rep.OldProvider, &rep.OldTorrentID, &rep.NewHash, &rep.NewProvider, &rep.NewTorrentID, &rep.Reason, &rep.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan torrent replacement: %w", err)
+		}
+		replacements = append(replacements, &rep)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating torrent replacements: %w", err)
+	}
+
+	return replacements, nil
+}
+
+func (r *DownloadRepository) GetTorrentData(ctx context.Context, id string) ([]byte, error) {
+	query := `SELECT torrent_data FROM downloads WHERE id = ?`
+
+	var data []byte
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, persistence.ErrDownloadNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query torrent data: %w", err)
+	}
+	return data, nil
+}
+
+// downloadChildTables hold rows keyed by download_id with no foreign key to downloads.
+// Deleting a download deletes them too, or they'd be left pointing at nothing.
+var downloadChildTables = []string{
+	"download_events",
+	"organization_journal",
+	"organize_jobs",
+	"torrent_replacements",
+}
+
 func (r *DownloadRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM downloads WHERE id = ?`
-	_, err := r.db.ExecContext(ctx, query, id)
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
+		return fmt.Errorf("failed to begin delete transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback() // No-op after a successful commit
+	}()
+
+	for _, table := range downloadChildTables {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE download_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete download's %s: %w", table, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM downloads WHERE id = ?`, id); err != nil {
 		return fmt.Errorf("failed to delete download: %w", err)
 	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit download deletion: %w", err)
+	}
 	return nil
 }
+
+// scanMediaType maps a nullable media_type column to a MediaType, treating
+// rows created before ebook support as audiobooks.
+func scanMediaType(mediaType sql.NullString) models.MediaType {
+	if !mediaType.Valid || mediaType.String == "" {
+		return models.MediaTypeAudiobook
+	}
+	return models.MediaType(mediaType.String)
+}
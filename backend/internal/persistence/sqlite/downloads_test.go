@@ -28,6 +28,7 @@ func TestDownloadRepository_NullHandling(t *testing.T) {
 			author TEXT NOT NULL,
 			series TEXT,
 			series_number TEXT,
+			media_type TEXT DEFAULT 'audiobook',
 			torrent_url TEXT,
 			magnet_link TEXT,
 			category TEXT,
@@ -39,7 +40,14 @@ func TestDownloadRepository_NullHandling(t *testing.T) {
 			error_message TEXT,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			completed_at TIMESTAMP,
-			organized_at TIMESTAMP
+			organized_at TIMESTAMP,
+			missing_since TIMESTAMP,
+			torrent_data BLOB,
+			progress_at TIMESTAMP,
+			torrent_id TEXT,
+			provider TEXT,
+			search_query TEXT,
+			wedge_used INTEGER NOT NULL DEFAULT 0
 		);
 	`
 	if _, err := db.Exec(schema); err != nil {
@@ -120,14 +128,42 @@ func TestDownloadRepository_NullHandling(t *testing.T) {
 		t.Errorf("Expected series_number %q, got %q", download2.SeriesNumber, retrieved2.SeriesNumber)
 	}
 
+	if retrieved.MediaType != models.MediaTypeAudiobook {
+		t.Errorf("Expected default media_type %q, got %q", models.MediaTypeAudiobook, retrieved.MediaType)
+	}
+
+	// Test 3b: Ebook media type round-trips
+	ebook := &models.Download{
+		ID:        "test-id-3",
+		Title:     "An Ebook",
+		Author:    "Ebook Author",
+		MediaType: models.MediaTypeEbook,
+		QBitHash:  "testhash789",
+		Status:    models.StatusQueued,
+		CreatedAt: time.Now(),
+	}
+
+	if err := repo.Create(ctx, ebook); err != nil {
+		t.Fatalf("Failed to create ebook download: %v", err)
+	}
+
+	retrievedEbook, err := repo.GetByID(ctx, "test-id-3")
+	if err != nil {
+		t.Fatalf("Failed to get ebook download by ID: %v", err)
+	}
+
+	if retrievedEbook.MediaType != models.MediaTypeEbook {
+		t.Errorf("Expected media_type %q, got %q", models.MediaTypeEbook, retrievedEbook.MediaType)
+	}
+
 	// Test 4: List downloads - should handle mixed NULL/non-NULL values
 	allDownloads, err := repo.List(ctx)
 	if err != nil {
 		t.Fatalf("Failed to list downloads: %v", err)
 	}
 
-	if len(allDownloads) != 2 {
-		t.Errorf("Expected 2 downloads, got %d", len(allDownloads))
+	if len(allDownloads) != 3 {
+		t.Errorf("Expected 3 downloads, got %d", len(allDownloads))
 	}
 
 	// Test 5: GetActive - should handle mixed NULL/non-NULL values
@@ -136,9 +172,352 @@ func TestDownloadRepository_NullHandling(t *testing.T) {
 		t.Fatalf("Failed to get active downloads: %v", err)
 	}
 
-	if len(activeDownloads) != 2 {
-		t.Errorf("Expected 2 active downloads, got %d", len(activeDownloads))
+	if len(activeDownloads) != 3 {
+		t.Errorf("Expected 3 active downloads, got %d", len(activeDownloads))
+	}
+
+	// Test 6: Missing time round-trips and can be cleared
+	missingSince := time.Now().Add(-time.Minute)
+	if err := repo.UpdateMissingSince(ctx, "test-id-1", &missingSince); err != nil {
+		t.Fatalf("Failed to update missing since: %v", err)
+	}
+	retrieved, err = repo.GetByID(ctx, "test-id-1")
+	if err != nil {
+		t.Fatalf("Failed to get download by ID: %v", err)
+	}
+	if retrieved.MissingSince == nil || !retrieved.MissingSince.Equal(missingSince) {
+		t.Errorf("Expected missing_since %v, got %v", missingSince, retrieved.MissingSince)
+	}
+	if err := repo.UpdateMissingSince(ctx, "test-id-1", nil); err != nil {
+		t.Fatalf("Failed to clear missing since: %v", err)
+	}
+	retrieved, _ = repo.GetByID(ctx, "test-id-1")
+	if retrieved.MissingSince != nil {
+		t.Errorf("Expected missing_since to be cleared, got %v", retrieved.MissingSince)
+	}
+
+	// Test 7: Torrent files are stored for re-adding
+	withFile := &models.Download{
+		ID:           "test-id-4",
+		Title:        "From File",
+		Author:       "File Author",
+		TorrentBytes: []byte("d8:announce...e"),
+		QBitHash:     "testhashfile",
+		Status:       models.StatusQueued,
+		CreatedAt:    time.Now(),
+	}
+	if err := repo.Create(ctx, withFile); err != nil {
+		t.Fatalf("Failed to create download with torrent file: %v", err)
+	}
+	if data, err := repo.GetTorrentData(ctx, "test-id-4"); err != nil || string(data) != "d8:announce...e" {
+		t.Errorf("Expected stored torrent file, got %q, %v", data, err)
+	}
+	if data, err := repo.GetTorrentData(ctx, "test-id-2"); err != nil || len(data) != 0 {
+		t.Errorf("Expected no torrent file, got %q, %v", data, err)
+	}
+
+	// Test 8: Lookup by hash ignores case
+	byHash, err := repo.GetByQBitHash(ctx, "TESTHASH123")
+	if err != nil || byHash.ID != "test-id-1" {
+		t.Errorf("Expected test-id-1 by hash, got %+v, %v", byHash, err)
+	}
+	if _, err := repo.GetByQBitHash(ctx, "unknown"); err == nil {
+		t.Error("Expected an error for an unknown hash")
 	}
 
 	t.Log("✓ All NULL handling tests passed")
 }
+
+func TestDownloadRepository_TransitionStatus(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Logf("failed to close test database: %v", err)
+		}
+	}()
+	// A single connection keeps every query on the same in-memory database
+	db.SetMaxOpenConns(1)
+
+	schema := `
+		CREATE TABLE downloads (
+			id TEXT PRIMARY KEY,
+			title TEXT NOT NULL,
+			author TEXT NOT NULL,
+			series TEXT,
+			series_number TEXT,
+			media_type TEXT DEFAULT 'audiobook',
+			torrent_url TEXT,
+			magnet_link TEXT,
+			category TEXT,
+			qbit_hash TEXT UNIQUE NOT NULL,
+			status TEXT NOT NULL DEFAULT 'queued',
+			progress REAL DEFAULT 0.0,
+			download_path TEXT,
+			organized_path TEXT,
+			error_message TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			completed_at TIMESTAMP,
+			organized_at TIMESTAMP,
+			missing_since TIMESTAMP,
+			torrent_data BLOB,
+			progress_at TIMESTAMP,
+			torrent_id TEXT,
+			provider TEXT,
+			search_query TEXT,
+			wedge_used INTEGER NOT NULL DEFAULT 0
+		);
+		CREATE TABLE download_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			download_id TEXT NOT NULL,
+			from_status TEXT NOT NULL DEFAULT '',
+			to_status TEXT NOT NULL,
+			actor TEXT NOT NULL,
+			reason TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE torrent_replacements (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			download_id TEXT NOT NULL,
+			old_hash TEXT NOT NULL,
+			old_provider TEXT NOT NULL DEFAULT '',
+			old_torrent_id TEXT NOT NULL DEFAULT '',
+			new_hash TEXT NOT NULL,
+			new_provider TEXT NOT NULL DEFAULT '',
+			new_torrent_id TEXT NOT NULL DEFAULT '',
+			reason TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+
+	repo := NewDownloadRepository(db)
+	ctx := context.Background()
+
+	download := &models.Download{ID: "dl-1", Title: "Dune", Author: "Frank Herbert", QBitHash: "abc", Status: models.StatusDownloading, CreatedAt: time.Now()}
+	if err := repo.Create(ctx, download); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := repo.AddEvent(ctx, &models.DownloadEvent{DownloadID: "dl-1", ToStatus: models.StatusDownloading, Actor: models.ActorUser, Reason: "download added"}); err != nil {
+		t.Fatalf("AddEvent failed: %v", err)
+	}
+
+	// Test 1: Moving to completed updates the status and completion time and records the event
+	event := &models.DownloadEvent{DownloadID: "dl-1", FromStatus: models.StatusDownloading, ToStatus: models.StatusCompleted, Actor: models.ActorMonitor, Reason: "qBittorrent reports uploading"}
+	if err := repo.TransitionStatus(ctx, event); err != nil {
+		t.Fatalf("TransitionStatus failed: %v", err)
+	}
+	if event.ID == 0 {
+		t.Error("expected the event ID to be set")
+	}
+
+	got, err := repo.GetByID(ctx, "dl-1")
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if got.Status != models.StatusCompleted || got.CompletedAt == nil {
+		t.Errorf("download = %+v, want completed with a completion time", got)
+	}
+
+	// Test 2: A transition from a stale status is rejected and not recorded
+	stale := &models.DownloadEvent{DownloadID: "dl-1", FromStatus: models.StatusDownloading, ToStatus: models.StatusFailed, Actor: models.ActorUser}
+	if err := repo.TransitionStatus(ctx, stale); err == nil {
+		t.Error("expected an error moving from a stale status")
+	}
+
+	// Test 3: History lists events oldest first
+	events, err := repo.ListEvents(ctx, "dl-1")
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].FromStatus != "" || events[0].ToStatus != models.StatusDownloading || events[0].Actor != models.ActorUser {
+		t.Errorf("first event = %+v, want the creation", events[0])
+	}
+	if events[1].FromStatus != models.StatusDownloading || events[1].ToStatus != models.StatusCompleted ||
+		events[1].Actor != models.ActorMonitor || events[1].Reason != "qBittorrent reports uploading" {
+		t.Errorf("second event = %+v, want the completion", events[1])
+	}
+
+	if events, err := repo.ListEvents(ctx, "unknown"); err != nil || len(events) != 0 {
+		t.Errorf("ListEvents(unknown) = %v, %v; want no events", events, err)
+	}
+
+	// Test 4: Progress time only moves when progress advances
+	if err := repo.UpdateProgress(ctx, "dl-1", 40); err != nil {
+		t.Fatalf("UpdateProgress failed: %v", err)
+	}
+	got, _ = repo.GetByID(ctx, "dl-1")
+	if got.ProgressAt == nil {
+		t.Fatal("expected progress time to be set when progress advanced")
+	}
+	advanced := *got.ProgressAt
+	time.Sleep(10 * time.Millisecond)
+	if err := repo.UpdateProgress(ctx, "dl-1", 40); err != nil {
+		t.Fatalf("UpdateProgress failed: %v", err)
+	}
+	got, _ = repo.GetByID(ctx, "dl-1")
+	if got.ProgressAt == nil || !got.ProgressAt.Equal(advanced) {
+		t.Errorf("progress time = %v, want %v kept without progress", got.ProgressAt, advanced)
+	}
+
+	// Test 5: Replacing the torrent resets progress, restarts the stall clock and records the replacement
+	replacedAt := time.Now().Truncate(time.Second)
+	replacement := &models.TorrentReplacement{DownloadID: "dl-1", OldHash: "abc", OldTorrentID: "100", NewHash: "def", NewProvider: "Prowlarr", NewTorrentID: "200", Reason: "stalled", CreatedAt: replacedAt}
+	if err := repo.ReplaceTorrent(ctx, replacement, []byte("new torrent")); err != nil {
+		t.Fatalf("ReplaceTorrent failed: %v", err)
+	}
+	got, _ = repo.GetByID(ctx, "dl-1")
+	if got.QBitHash != "def" || got.TorrentID != "200" || got.Provider != "Prowlarr" || got.Progress != 0 {
+		t.Errorf("download = %+v, want the new torrent with progress reset", got)
+	}
+	if got.ProgressAt == nil || !got.ProgressAt.Equal(replacedAt) {
+		t.Errorf("progress at = %v, want the replacement time %v", got.ProgressAt, replacedAt)
+	}
+	if data, _ := repo.GetTorrentData(ctx, "dl-1"); string(data) != "new torrent" {
+		t.Errorf("torrent data = %q, want the new torrent file", data)
+	}
+
+	replacements, err := repo.ListReplacements(ctx, "dl-1")
+	if err != nil {
+		t.Fatalf("ListReplacements failed: %v", err)
+	}
+	if len(replacements) != 1 || replacements[0].OldHash != "abc" || replacements[0].NewTorrentID != "200" || replacements[0].NewProvider != "Prowlarr" || replacements[0].ID == 0 {
+		t.Errorf("replacements = %+v, want the recorded replacement", replacements)
+	}
+}
+
+func TestDownloadRepository_Delete(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Logf("failed to close test database: %v", err)
+		}
+	}()
+	// A single connection keeps every query on the same in-memory database
+	db.SetMaxOpenConns(1)
+
+	schema := `
+		CREATE TABLE downloads (
+			id TEXT PRIMARY KEY,
+			title TEXT NOT NULL,
+			author TEXT NOT NULL,
+			series TEXT,
+			series_number TEXT,
+			media_type TEXT DEFAULT 'audiobook',
+			torrent_url TEXT,
+			magnet_link TEXT,
+			category TEXT,
+			qbit_hash TEXT UNIQUE NOT NULL,
+			status TEXT NOT NULL DEFAULT 'queued',
+			progress REAL DEFAULT 0.0,
+			download_path TEXT,
+			organized_path TEXT,
+			error_message TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			completed_at TIMESTAMP,
+			organized_at TIMESTAMP,
+			missing_since TIMESTAMP,
+			torrent_data BLOB,
+			progress_at TIMESTAMP,
+			torrent_id TEXT,
+			provider TEXT,
+			search_query TEXT,
+			wedge_used INTEGER NOT NULL DEFAULT 0
+		);
+		CREATE TABLE download_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			download_id TEXT NOT NULL,
+			from_status TEXT NOT NULL DEFAULT '',
+			to_status TEXT NOT NULL,
+			actor TEXT NOT NULL,
+			reason TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE organization_journal (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			download_id TEXT NOT NULL,
+			source_path TEXT NOT NULL,
+			dest_path TEXT NOT NULL,
+			operation TEXT NOT NULL,
+			done INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE organize_jobs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			download_id TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			source TEXT NOT NULL DEFAULT 'monitor',
+			error_message TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			started_at TIMESTAMP,
+			finished_at TIMESTAMP,
+			attempt INTEGER NOT NULL DEFAULT 1,
+			run_after TIMESTAMP,
+			transient INTEGER NOT NULL DEFAULT 0
+		);
+		CREATE TABLE torrent_replacements (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			download_id TEXT NOT NULL,
+			old_hash TEXT NOT NULL,
+			old_provider TEXT NOT NULL DEFAULT '',
+			old_torrent_id TEXT NOT NULL DEFAULT '',
+			new_hash TEXT NOT NULL,
+			new_provider TEXT NOT NULL DEFAULT '',
+			new_torrent_id TEXT NOT NULL DEFAULT '',
+			reason TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+
+	repo := NewDownloadRepository(db)
+	ctx := context.Background()
+
+	for _, id := range []string{"dl-1", "dl-2"} {
+		if err := repo.Create(ctx, &models.Download{ID: id, Title: "Dune", Author: "Frank Herbert", QBitHash: "hash-" + id, Status: models.StatusQueued, CreatedAt: time.Now()}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if err := repo.AddEvent(ctx, &models.DownloadEvent{DownloadID: id, ToStatus: models.StatusQueued, Actor: models.ActorUser, Reason: "download added"}); err != nil {
+			t.Fatalf("AddEvent failed: %v", err)
+		}
+		if _, err := db.Exec(`INSERT INTO organization_journal (download_id, source_path, dest_path, operation) VALUES (?, '/downloads/dune.m4b', '/library/dune.m4b', 'copy')`, id); err != nil {
+			t.Fatalf("failed to insert journal entry: %v", err)
+		}
+		// A pending job left behind would be claimed by a worker for a download that's gone
+		if _, _, err := NewOrganizeJobRepository(db).Enqueue(ctx, id, "monitor"); err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+		if err := repo.ReplaceTorrent(ctx, &models.TorrentReplacement{DownloadID: id, OldHash: "hash-" + id, NewHash: "new-" + id}, []byte("torrent")); err != nil {
+			t.Fatalf("ReplaceTorrent failed: %v", err)
+		}
+	}
+
+	if err := repo.Delete(ctx, "dl-1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	// The deleted download's rows go with it; the other download's stay
+	for _, table := range downloadChildTables {
+		for id, want := range map[string]int{"dl-1": 0, "dl-2": 1} {
+			var count int
+			if err := db.QueryRow(`SELECT COUNT(*) FROM `+table+` WHERE download_id = ?`, id).Scan(&count); err != nil {
+				t.Fatalf("failed to count %s: %v", table, err)
+			}
+			if count != want {
+				t.Errorf("%s rows of %s = %d, want %d", table, id, count, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nathanael/organizr/internal/models"
+)
+
+type OrganizeJobRepository struct {
+	db *sql.DB
+}
+
+func NewOrganizeJobRepository(db *sql.DB) *OrganizeJobRepository {
+	return &OrganizeJobRepository{db: db}
+}
+
+const organizeJobColumns = `id, download_id, status, source, attempt, error_message, transient, created_at, run_after, started_at, finished_at`
+
+func (r *OrganizeJobRepository) Enqueue(ctx context.Context, downloadID, source string) (*models.OrganizeJob, bool, error) {
+	// The partial unique index on active jobs makes this a no-op for duplicates
+	query := `
+		INSERT OR IGNORE INTO organize_jobs (download_id, status, source, created_at)
+		VALUES (?, 'pending', ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, downloadID, source, time.Now())
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to enqueue organize job: %w", err)
+	}
+
+	created := false
+	if rows, err := result.RowsAffected(); err == nil && rows > 0 {
+		created = true
+	}
+
+	job, err := r.activeJob(ctx, downloadID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return job, created, nil
+}
+
+func (r *OrganizeJobRepository) Schedule(ctx context.Context, downloadID, source string, attempt int, runAfter time.Time) (*models.OrganizeJob, error) {
+	// The conflict target matches the partial unique index on active jobs; only a pending
+	// job is rescheduled, a running one keeps going
+	query := `
+		INSERT INTO organize_jobs (download_id, status, source, attempt, run_after, created_at)
+		VALUES (?, 'pending', ?, ?, ?, ?)
+		ON CONFLICT (download_id) WHERE status IN ('pending', 'running') DO UPDATE
+		SET source = excluded.source, attempt = excluded.attempt, run_after = excluded.run_after
+		WHERE organize_jobs.status = 'pending'
+	`
+
+	// Stored in UTC so ClaimNext's comparison doesn't depend on the local zone
+	_, err := r.db.ExecContext(ctx, query, downloadID, source, attempt, runAfter.UTC(), time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to schedule organize job: %w", err)
+	}
+
+	return r.activeJob(ctx, downloadID)
+}
+
+func (r *OrganizeJobRepository) activeJob(ctx context.Context, downloadID string) (*models.OrganizeJob, error) {
+	job, err := r.scanJob(r.db.QueryRowContext(ctx, `
+		SELECT `+organizeJobColumns+`
+		FROM organize_jobs
+		WHERE download_id = ? AND status IN ('pending', 'running')
+	`, downloadID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load organize job: %w", err)
+	}
+	return job, nil
+}
+
+func (r *OrganizeJobRepository) ClaimNext(ctx context.Context) (*models.OrganizeJob, error) {
+	// A single UPDATE ... RETURNING keeps concurrent workers from claiming the same job
+	query := `
+		UPDATE organize_jobs
+		SET status = 'running', started_at = ?
+		WHERE id = (
+			SELECT id FROM organize_jobs
+			WHERE status = 'pending' AND (run_after IS NULL OR run_after <= ?)
+			ORDER BY id ASC
+			LIMIT 1
+		)
+		RETURNING ` + organizeJobColumns
+
+	job, err := r.scanJob(r.db.QueryRowContext(ctx, query, time.Now(), time.Now().UTC()))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim organize job: %w", err)
+	}
+
+	return job, nil
+}
+
+// Release returns a running job to the queue without counting it as finished
+func (r *OrganizeJobRepository) Release(ctx context.Context, id int64) error {
+	query := `UPDATE organize_jobs SET status = 'pending', started_at = NULL WHERE id = ? AND status = 'running'`
+	_, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to release organize job: %w", err)
+	}
+	return nil
+}
+
+func (r *OrganizeJobRepository) Complete(ctx context.Context, id int64) error {
+	query := `UPDATE organize_jobs SET status = 'done', finished_at = ? WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, query, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to complete organize job: %w", err)
+	}
+	return nil
+}
+
+func (r *OrganizeJobRepository) Fail(ctx context.Context, id int64, errorMsg string, transient bool) error {
+	query := `UPDATE organize_jobs SET status = 'failed', error_message = ?, transient = ?, finished_at = ? WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, query, errorMsg, transient, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to fail organize job: %w", err)
+	}
+	return nil
+}
+
+func (r *OrganizeJobRepository) FailRunning(ctx context.Context, errorMsg string) (int64, error) {
+	query := `UPDATE organize_jobs SET status = 'failed', error_message = ?, finished_at = ? WHERE status = 'running'`
+	result, err := r.db.ExecContext(ctx, query, errorMsg, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail running organize jobs: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count failed organize jobs: %w", err)
+	}
+	return rows, nil
+}
+
+func (r *OrganizeJobRepository) ListByDownload(ctx context.Context, downloadID string) ([]*models.OrganizeJob, error) {
+	query := `
+		SELECT ` + organizeJobColumns + `
+		FROM organize_jobs
+		WHERE download_id = ?
+		ORDER BY id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, downloadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organize jobs: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			// Log close errors as they may indicate database issues
+			fmt.Printf("failed to close organize job rows: %v\n", err)
+		}
+	}()
+
+	var jobs []*models.OrganizeJob
+	for rows.Next() {
+		job, err := r.scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan organize job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating organize jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// jobScanner is satisfied by both *sql.Row and *sql.Rows
+type jobScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (r *OrganizeJobRepository) scanJob(row jobScanner) (*models.OrganizeJob, error) {
+	var job models.OrganizeJob
+	var errorMessage sql.NullString
+	var runAfter, startedAt, finishedAt sql.NullTime
+
+	if err := row.Scan(&job.ID, &job.DownloadID, &job.Status, &job.Source, &job.Attempt, &errorMessage,
+		&job.Transient, &job.CreatedAt, &runAfter, &startedAt, &finishedAt); err != nil {
+		return nil, err
+	}
+
+	if errorMessage.Valid {
+		job.Error = errorMessage.String
+	}
+	if runAfter.Valid {
+		job.RunAfter = &runAfter.Time
+	}
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+
+	return &job, nil
+}
@@ -0,0 +1,208 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nathanael/organizr/internal/models"
+)
+
+func TestOrganizeJobRepository(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Logf("failed to close test database: %v", err)
+		}
+	}()
+	// A single connection keeps every query on the same in-memory database
+	db.SetMaxOpenConns(1)
+
+	schema := `
+		CREATE TABLE organize_jobs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			download_id TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			source TEXT NOT NULL DEFAULT 'monitor',
+			error_message TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			started_at TIMESTAMP,
+			finished_at TIMESTAMP,
+			attempt INTEGER NOT NULL DEFAULT 1,
+			run_after TIMESTAMP,
+			transient INTEGER NOT NULL DEFAULT 0
+		);
+		CREATE UNIQUE INDEX idx_organize_jobs_active ON organize_jobs(download_id) WHERE status IN ('pending', 'running');
+	`
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+
+	repo := NewOrganizeJobRepository(db)
+	ctx := context.Background()
+
+	// Test 1: Enqueue creates a pending job
+	job, created, err := repo.Enqueue(ctx, "dl-1", "monitor")
+	if err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	if !created || job.Status != models.JobPending || job.Source != "monitor" {
+		t.Errorf("Unexpected job: created=%v %+v", created, job)
+	}
+
+	// Test 2: Enqueueing the same download again is deduplicated
+	dup, created, err := repo.Enqueue(ctx, "dl-1", "manual")
+	if err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	if created || dup.ID != job.ID {
+		t.Errorf("Expected duplicate to return job %d, got created=%v id=%d", job.ID, created, dup.ID)
+	}
+
+	_, _, _ = repo.Enqueue(ctx, "dl-2", "monitor")
+
+	// Test 3: Jobs are claimed oldest first, once
+	claimed, err := repo.ClaimNext(ctx)
+	if err != nil {
+		t.Fatalf("ClaimNext failed: %v", err)
+	}
+	if claimed == nil || claimed.DownloadID != "dl-1" || claimed.Status != models.JobRunning || claimed.StartedAt == nil {
+		t.Fatalf("Unexpected claimed job: %+v", claimed)
+	}
+
+	// Still deduplicated while running
+	if _, created, _ := repo.Enqueue(ctx, "dl-1", "monitor"); created {
+		t.Error("Expected running job to block a new one")
+	}
+
+	second, _ := repo.ClaimNext(ctx)
+	if second == nil || second.DownloadID != "dl-2" {
+		t.Fatalf("Expected dl-2 to be claimed, got %+v", second)
+	}
+	if none, err := repo.ClaimNext(ctx); err != nil || none != nil {
+		t.Errorf("Expected empty queue, got %+v, %v", none, err)
+	}
+
+	// Test 4: Released jobs can be claimed again
+	if err := repo.Release(ctx, second.ID); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if again, _ := repo.ClaimNext(ctx); again == nil || again.ID != second.ID {
+		t.Errorf("Expected released job to be claimable, got %+v", again)
+	}
+
+	// Test 5: Finished jobs no longer block new ones
+	if err := repo.Complete(ctx, claimed.ID); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if _, created, _ := repo.Enqueue(ctx, "dl-1", "manual"); !created {
+		t.Error("Expected a new job after the previous one completed")
+	}
+
+	// Test 6: Jobs left running by a crash are failed
+	failed, err := repo.FailRunning(ctx, "interrupted")
+	if err != nil {
+		t.Fatalf("FailRunning failed: %v", err)
+	}
+	if failed != 1 {
+		t.Errorf("Expected 1 running job to be failed, got %d", failed)
+	}
+	if _, created, _ := repo.Enqueue(ctx, "dl-2", "monitor"); !created {
+		t.Error("Expected a new job after the running one was failed")
+	}
+}
+
+func TestOrganizeJobRepository_Retries(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Logf("failed to close test database: %v", err)
+		}
+	}()
+	db.SetMaxOpenConns(1)
+
+	schema := `
+		CREATE TABLE organize_jobs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			download_id TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			source TEXT NOT NULL DEFAULT 'monitor',
+			error_message TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			started_at TIMESTAMP,
+			finished_at TIMESTAMP,
+			attempt INTEGER NOT NULL DEFAULT 1,
+			run_after TIMESTAMP,
+			transient INTEGER NOT NULL DEFAULT 0
+		);
+		CREATE UNIQUE INDEX idx_organize_jobs_active ON organize_jobs(download_id) WHERE status IN ('pending', 'running');
+	`
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+
+	repo := NewOrganizeJobRepository(db)
+	ctx := context.Background()
+
+	// Test 1: A failed attempt records whether it was transient
+	first, _, _ := repo.Enqueue(ctx, "dl-1", "monitor")
+	if first.Attempt != 1 {
+		t.Errorf("Expected first attempt to be 1, got %d", first.Attempt)
+	}
+	_, _ = repo.ClaimNext(ctx)
+	if err := repo.Fail(ctx, first.ID, "disk full", true); err != nil {
+		t.Fatalf("Fail failed: %v", err)
+	}
+
+	// Test 2: A retry scheduled in the future is not claimed yet
+	retry, err := repo.Schedule(ctx, "dl-1", "retry", 2, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Schedule failed: %v", err)
+	}
+	if retry.Attempt != 2 || retry.Status != models.JobPending || retry.RunAfter == nil {
+		t.Errorf("Unexpected retry job: %+v", retry)
+	}
+	if job, err := repo.ClaimNext(ctx); err != nil || job != nil {
+		t.Errorf("Expected no due jobs, got %+v, %v", job, err)
+	}
+
+	// Test 3: Rescheduling the pending retry makes it due without adding a job
+	now, err := repo.Schedule(ctx, "dl-1", "manual", 1, time.Now())
+	if err != nil {
+		t.Fatalf("Schedule failed: %v", err)
+	}
+	if now.ID != retry.ID || now.Attempt != 1 || now.Source != "manual" {
+		t.Errorf("Expected job %d to be rescheduled, got %+v", retry.ID, now)
+	}
+	claimed, err := repo.ClaimNext(ctx)
+	if err != nil || claimed == nil || claimed.ID != retry.ID {
+		t.Fatalf("Expected rescheduled job to be claimed, got %+v, %v", claimed, err)
+	}
+
+	// Test 4: Scheduling leaves a running job alone
+	running, err := repo.Schedule(ctx, "dl-1", "retry", 3, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Schedule failed: %v", err)
+	}
+	if running.ID != claimed.ID || running.Status != models.JobRunning || running.Attempt != 1 {
+		t.Errorf("Expected running job to be unchanged, got %+v", running)
+	}
+
+	// Test 5: Jobs are listed oldest first as attempt history
+	history, err := repo.ListByDownload(ctx, "dl-1")
+	if err != nil {
+		t.Fatalf("ListByDownload failed: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 attempts, got %d", len(history))
+	}
+	if history[0].Error != "disk full" || !history[0].Transient || history[0].FinishedAt == nil {
+		t.Errorf("Unexpected first attempt: %+v", history[0])
+	}
+}
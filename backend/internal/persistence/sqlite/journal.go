@@ -0,0 +1,108 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nathanael/organizr/internal/models"
+)
+
+type OrganizationJournalRepository struct {
+	db *sql.DB
+}
+
+func NewOrganizationJournalRepository(db *sql.DB) *OrganizationJournalRepository {
+	return &OrganizationJournalRepository{db: db}
+}
+
+// Replace discards any existing journal for the download and records entries in a single
+// transaction, setting each entry's ID.
+func (r *OrganizationJournalRepository) Replace(ctx context.Context, downloadID string, entries []*models.JournalEntry) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin journal transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback() // No-op after a successful commit
+	}()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM organization_journal WHERE download_id = ?`, downloadID); err != nil {
+		return fmt.Errorf("failed to clear journal: %w", err)
+	}
+
+	query := `
+		INSERT INTO organization_journal (download_id, source_path, dest_path, operation, done, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	now := time.Now()
+	for _, e := range entries {
+		result, err := tx.ExecContext(ctx, query, downloadID, e.SourcePath, e.DestPath, e.Operation, e.Done, now)
+		if err != nil {
+			return fmt.Errorf("failed to insert journal entry: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get journal entry id: %w", err)
+		}
+		e.ID = id
+		e.DownloadID = downloadID
+		e.CreatedAt = now
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit journal: %w", err)
+	}
+
+	return nil
+}
+
+func (r *OrganizationJournalRepository) MarkDone(ctx context.Context, id int64) error {
+	query := `UPDATE organization_journal SET done = 1 WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark journal entry done: %w", err)
+	}
+	return nil
+}
+
+func (r *OrganizationJournalRepository) ListByDownload(ctx context.Context, downloadID string) ([]*models.JournalEntry, error) {
+	query := `
+		SELECT id, download_id, source_path, dest_path, operation, done, created_at
+		FROM organization_journal
+		WHERE download_id = ?
+		ORDER BY id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, downloadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query journal: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			// Log close errors as they may indicate database issues
+			fmt.Printf("failed to close journal rows: %v\n", err)
+		}
+	}()
+
+	var entries []*models.JournalEntry
+	for rows.Next() {
+		var e models.JournalEntry
+		if err := rows.Scan(&e.ID, &e.DownloadID, &e.SourcePath, &e.DestPath, &e.Operation, &e.Done, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan journal entry: %w", err)
+		}
+		entries = append(entries, &e)
+	}
+
+	return entries, nil
+}
+
+func (r *OrganizationJournalRepository) DeleteByDownload(ctx context.Context, downloadID string) error {
+	query := `DELETE FROM organization_journal WHERE download_id = ?`
+	_, err := r.db.ExecContext(ctx, query, downloadID)
+	if err != nil {
+		return fmt.Errorf("failed to delete journal: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nathanael/organizr/internal/models"
+)
+
+func TestOrganizationJournalRepository(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Logf("failed to close test database: %v", err)
+		}
+	}()
+
+	schema := `
+		CREATE TABLE organization_journal (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			download_id TEXT NOT NULL,
+			source_path TEXT NOT NULL,
+			dest_path TEXT NOT NULL,
+			operation TEXT NOT NULL,
+			done INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+
+	repo := NewOrganizationJournalRepository(db)
+	ctx := context.Background()
+
+	entries := []*models.JournalEntry{
+		{SourcePath: "/src/a.m4b", DestPath: "/dest/a.m4b", Operation: "copy"},
+		{SourcePath: "/src/b.m4b", DestPath: "/dest/b.m4b", Operation: "copy"},
+	}
+	if err := repo.Replace(ctx, "dl-1", entries); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+	if entries[0].ID == 0 || entries[1].ID == 0 {
+		t.Fatal("Expected entry IDs to be set")
+	}
+
+	if err := repo.MarkDone(ctx, entries[0].ID); err != nil {
+		t.Fatalf("MarkDone failed: %v", err)
+	}
+
+	got, err := repo.ListByDownload(ctx, "dl-1")
+	if err != nil {
+		t.Fatalf("ListByDownload failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(got))
+	}
+	if !got[0].Done || got[1].Done {
+		t.Errorf("Unexpected done flags: %v, %v", got[0].Done, got[1].Done)
+	}
+	if got[1].DestPath != "/dest/b.m4b" || got[1].Operation != "copy" {
+		t.Errorf("Unexpected entry: %+v", got[1])
+	}
+
+	// Replacing discards the previous plan
+	if err := repo.Replace(ctx, "dl-1", []*models.JournalEntry{
+		{SourcePath: "/src/c.m4b", DestPath: "/dest/c.m4b", Operation: "move", Done: true},
+	}); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+	got, _ = repo.ListByDownload(ctx, "dl-1")
+	if len(got) != 1 || !got[0].Done || got[0].Operation != "move" {
+		t.Errorf("Expected single replaced entry, got %+v", got)
+	}
+
+	if err := repo.DeleteByDownload(ctx, "dl-1"); err != nil {
+		t.Fatalf("DeleteByDownload failed: %v", err)
+	}
+	got, _ = repo.ListByDownload(ctx, "dl-1")
+	if len(got) != 0 {
+		t.Errorf("Expected journal to be empty, got %d entries", len(got))
+	}
+}
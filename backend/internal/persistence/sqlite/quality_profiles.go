@@ -0,0 +1,183 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/persistence"
+)
+
+type QualityProfileRepository struct {
+	db *sql.DB
+}
+
+func NewQualityProfileRepository(db *sql.DB) *QualityProfileRepository {
+	return &QualityProfileRepository{db: db}
+}
+
+const qualityProfileColumns = `id, name, preferred_formats, min_size_mb, max_size_mb, preferred_languages, freeleech_bonus, min_seeders, preferred_uploaders, blocked_uploaders, created_at, updated_at`
+
+func (r *QualityProfileRepository) List(ctx context.Context) ([]*models.QualityProfile, error) {
+	query := `SELECT ` + qualityProfileColumns + ` FROM quality_profiles ORDER BY name`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query quality profiles: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			// Log close errors as they may indicate database issues
+			fmt.Printf("failed to close quality profile rows: %v\n", err)
+		}
+	}()
+
+	var profiles []*models.QualityProfile
+	for rows.Next() {
+		p, err := scanQualityProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate quality profiles: %w", err)
+	}
+
+	return profiles, nil
+}
+
+func (r *QualityProfileRepository) GetByID(ctx context.Context, id int64) (*models.QualityProfile, error) {
+	query := `SELECT ` + qualityProfileColumns + ` FROM quality_profiles WHERE id = ?`
+
+	p, err := scanQualityProfile(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, persistence.ErrQualityProfileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *QualityProfileRepository) Create(ctx context.Context, p *models.QualityProfile) error {
+	query := `
+		INSERT INTO quality_profiles (name, preferred_formats, min_size_mb, max_size_mb, preferred_languages,
+			freeleech_bonus, min_seeders, preferred_uploaders, blocked_uploaders, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query,
+		p.Name, encodeList(p.PreferredFormats), p.MinSizeMB, p.MaxSizeMB, encodeList(p.PreferredLanguages),
+		p.FreeleechBonus, p.MinSeeders, encodeList(p.PreferredUploaders), encodeList(p.BlockedUploaders), now, now,
+	)
+	if err != nil {
+		return qualityProfileWriteError("create", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get quality profile ID: %w", err)
+	}
+	p.ID = id
+	p.CreatedAt = now
+	p.UpdatedAt = now
+	return nil
+}
+
+func (r *QualityProfileRepository) Update(ctx context.Context, p *models.QualityProfile) error {
+	query := `
+		UPDATE quality_profiles
+		SET name = ?, preferred_formats = ?, min_size_mb = ?, max_size_mb = ?, preferred_languages = ?,
+			freeleech_bonus = ?, min_seeders = ?, preferred_uploaders = ?, blocked_uploaders = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query,
+		p.Name, encodeList(p.PreferredFormats), p.MinSizeMB, p.MaxSizeMB, encodeList(p.PreferredLanguages),
+		p.FreeleechBonus, p.MinSeeders, encodeList(p.PreferredUploaders), encodeList(p.BlockedUploaders), now, p.ID,
+	)
+	if err != nil {
+		return qualityProfileWriteError("update", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return persistence.ErrQualityProfileNotFound
+	}
+	p.UpdatedAt = now
+	return nil
+}
+
+func (r *QualityProfileRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM quality_profiles WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete quality profile: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return persistence.ErrQualityProfileNotFound
+	}
+	return nil
+}
+
+// qualityProfileWriteError reports a taken name as ErrQualityProfileExists
+func qualityProfileWriteError(operation string, err error) error {
+	if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return persistence.ErrQualityProfileExists
+	}
+	return fmt.Errorf("failed to %s quality profile: %w", operation, err)
+}
+
+type qualityProfileScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanQualityProfile(row qualityProfileScanner) (*models.QualityProfile, error) {
+	var p models.QualityProfile
+	var formats, languages, preferredUploaders, blockedUploaders string
+
+	err := row.Scan(&p.ID, &p.Name, &formats, &p.MinSizeMB, &p.MaxSizeMB, &languages,
+		&p.FreeleechBonus, &p.MinSeeders, &preferredUploaders, &blockedUploaders, &p.CreatedAt, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan quality profile: %w", err)
+	}
+
+	p.PreferredFormats = decodeList(formats)
+	p.PreferredLanguages = decodeList(languages)
+	p.PreferredUploaders = decodeList(preferredUploaders)
+	p.BlockedUploaders = decodeList(blockedUploaders)
+	return &p, nil
+}
+
+// encodeList stores a string list as a JSON array
+func encodeList(items []string) string {
+	if items == nil {
+		items = []string{}
+	}
+	data, _ := json.Marshal(items)
+	return string(data)
+}
+
+// decodeList reads a string list stored by encodeList
+func decodeList(value string) []string {
+	items := []string{}
+	_ = json.Unmarshal([]byte(value), &items)
+	return items
+}
@@ -0,0 +1,109 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/persistence"
+)
+
+func TestQualityProfileRepository(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Logf("failed to close test database: %v", err)
+		}
+	}()
+	// A single connection keeps every query on the same in-memory database
+	db.SetMaxOpenConns(1)
+
+	schema := `
+		CREATE TABLE quality_profiles (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			preferred_formats TEXT NOT NULL DEFAULT '[]',
+			min_size_mb INTEGER NOT NULL DEFAULT 0,
+			max_size_mb INTEGER NOT NULL DEFAULT 0,
+			preferred_languages TEXT NOT NULL DEFAULT '[]',
+			freeleech_bonus INTEGER NOT NULL DEFAULT 0,
+			min_seeders INTEGER NOT NULL DEFAULT 0,
+			preferred_uploaders TEXT NOT NULL DEFAULT '[]',
+			blocked_uploaders TEXT NOT NULL DEFAULT '[]',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+
+	repo := NewQualityProfileRepository(db)
+	ctx := context.Background()
+
+	// Test 1: Create stores the profile with its lists
+	profile := &models.QualityProfile{
+		Name:               "Audiobooks",
+		PreferredFormats:   []string{"m4b", "mp3"},
+		MaxSizeMB:          4000,
+		PreferredLanguages: []string{"ENG"},
+		FreeleechBonus:     20,
+		MinSeeders:         2,
+		BlockedUploaders:   []string{"spammer"},
+	}
+	if err := repo.Create(ctx, profile); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if profile.ID == 0 {
+		t.Fatal("Create did not set the ID")
+	}
+
+	got, err := repo.GetByID(ctx, profile.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if fmt.Sprint(got.PreferredFormats) != "[m4b mp3]" || got.MaxSizeMB != 4000 || got.FreeleechBonus != 20 ||
+		fmt.Sprint(got.BlockedUploaders) != "[spammer]" || len(got.PreferredUploaders) != 0 {
+		t.Errorf("Unexpected profile: %+v", got)
+	}
+
+	// Test 2: Names are unique
+	if err := repo.Create(ctx, &models.QualityProfile{Name: "Audiobooks"}); !errors.Is(err, persistence.ErrQualityProfileExists) {
+		t.Errorf("Create with a taken name: err = %v, want ErrQualityProfileExists", err)
+	}
+
+	// Test 3: Update replaces the profile's settings
+	got.PreferredFormats = []string{"epub"}
+	got.Name = "Ebooks"
+	if err := repo.Update(ctx, got); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	profiles, err := repo.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(profiles) != 1 || profiles[0].Name != "Ebooks" || fmt.Sprint(profiles[0].PreferredFormats) != "[epub]" {
+		t.Errorf("Unexpected profiles after update: %+v", profiles)
+	}
+
+	// Test 4: Missing profiles are reported as not found
+	if _, err := repo.GetByID(ctx, 999); !errors.Is(err, persistence.ErrQualityProfileNotFound) {
+		t.Errorf("GetByID missing: err = %v, want ErrQualityProfileNotFound", err)
+	}
+	if err := repo.Update(ctx, &models.QualityProfile{ID: 999, Name: "Missing"}); !errors.Is(err, persistence.ErrQualityProfileNotFound) {
+		t.Errorf("Update missing: err = %v, want ErrQualityProfileNotFound", err)
+	}
+
+	// Test 5: Delete removes the profile
+	if err := repo.Delete(ctx, profile.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := repo.Delete(ctx, profile.ID); !errors.Is(err, persistence.ErrQualityProfileNotFound) {
+		t.Errorf("Delete twice: err = %v, want ErrQualityProfileNotFound", err)
+	}
+}
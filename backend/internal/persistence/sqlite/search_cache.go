@@ -0,0 +1,109 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nathanael/organizr/internal/persistence"
+)
+
+// SearchCacheRepository stores cached search results and torrent files. Times are stored in
+// UTC so expiry compares correctly as text.
+type SearchCacheRepository struct {
+	db *sql.DB
+}
+
+func NewSearchCacheRepository(db *sql.DB) *SearchCacheRepository {
+	return &SearchCacheRepository{db: db}
+}
+
+func (r *SearchCacheRepository) GetSearch(ctx context.Context, key string) ([]byte, time.Time, error) {
+	query := `SELECT results, created_at FROM search_cache WHERE cache_key = ? AND expires_at > ?`
+
+	var results string
+	var createdAt time.Time
+	err := r.db.QueryRowContext(ctx, query, key, time.Now().UTC()).Scan(&results, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, time.Time{}, persistence.ErrCacheMiss
+	}
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to get cached search: %w", err)
+	}
+	return []byte(results), createdAt, nil
+}
+
+func (r *SearchCacheRepository) PutSearch(ctx context.Context, key string, results []byte, expiresAt time.Time) error {
+	query := `
+		INSERT INTO search_cache (cache_key, results, created_at, expires_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(cache_key) DO UPDATE
+		SET results = excluded.results, created_at = excluded.created_at, expires_at = excluded.expires_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query, key, string(results), time.Now().UTC(), expiresAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to cache search: %w", err)
+	}
+	return nil
+}
+
+func (r *SearchCacheRepository) GetTorrent(ctx context.Context, provider, torrentID string) ([]byte, error) {
+	query := `SELECT data FROM torrent_cache WHERE provider = ? AND torrent_id = ? AND expires_at > ?`
+
+	var data []byte
+	err := r.db.QueryRowContext(ctx, query, provider, torrentID, time.Now().UTC()).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, persistence.ErrCacheMiss
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached torrent: %w", err)
+	}
+	return data, nil
+}
+
+func (r *SearchCacheRepository) PutTorrent(ctx context.Context, provider, torrentID string, data []byte, expiresAt time.Time) error {
+	query := `
+		INSERT INTO torrent_cache (provider, torrent_id, data, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(provider, torrent_id) DO UPDATE
+		SET data = excluded.data, created_at = excluded.created_at, expires_at = excluded.expires_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query, provider, torrentID, data, time.Now().UTC(), expiresAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to cache torrent: %w", err)
+	}
+	return nil
+}
+
+func (r *SearchCacheRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	var deleted int64
+	for _, table := range []string{"search_cache", "torrent_cache"} {
+		result, err := r.db.ExecContext(ctx, `DELETE FROM `+table+` WHERE expires_at <= ?`, now.UTC())
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete expired %s entries: %w", table, err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return deleted, fmt.Errorf("failed to get affected rows: %w", err)
+		}
+		deleted += rows
+	}
+	return deleted, nil
+}
+
+func (r *SearchCacheRepository) ClearSearches(ctx context.Context) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM search_cache`); err != nil {
+		return fmt.Errorf("failed to clear search cache: %w", err)
+	}
+	return nil
+}
+
+func (r *SearchCacheRepository) ClearTorrents(ctx context.Context) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM torrent_cache`); err != nil {
+		return fmt.Errorf("failed to clear torrent cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nathanael/organizr/internal/persistence"
+)
+
+func TestSearchCacheRepository(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Logf("failed to close test database: %v", err)
+		}
+	}()
+	// A single connection keeps every query on the same in-memory database
+	db.SetMaxOpenConns(1)
+
+	schema := `
+		CREATE TABLE search_cache (
+			cache_key TEXT PRIMARY KEY,
+			results TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			expires_at TIMESTAMP NOT NULL
+		);
+		CREATE TABLE torrent_cache (
+			provider TEXT NOT NULL,
+			torrent_id TEXT NOT NULL,
+			data BLOB NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (provider, torrent_id)
+		);
+	`
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+
+	repo := NewSearchCacheRepository(db)
+	ctx := context.Background()
+	later := time.Now().Add(time.Hour)
+
+	// Test 1: Cached searches are returned until they expire
+	if _, _, err := repo.GetSearch(ctx, "dune"); !errors.Is(err, persistence.ErrCacheMiss) {
+		t.Errorf("GetSearch before caching: err = %v, want ErrCacheMiss", err)
+	}
+	if err := repo.PutSearch(ctx, "dune", []byte(`{"results":[]}`), later); err != nil {
+		t.Fatalf("PutSearch failed: %v", err)
+	}
+	results, createdAt, err := repo.GetSearch(ctx, "dune")
+	if err != nil {
+		t.Fatalf("GetSearch failed: %v", err)
+	}
+	if string(results) != `{"results":[]}` || time.Since(createdAt) > time.Minute {
+		t.Errorf("GetSearch = %q, %v", results, createdAt)
+	}
+
+	// Test 2: Caching the same key again replaces the entry
+	if err := repo.PutSearch(ctx, "dune", []byte(`{"results":null}`), time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("PutSearch failed: %v", err)
+	}
+	if _, _, err := repo.GetSearch(ctx, "dune"); !errors.Is(err, persistence.ErrCacheMiss) {
+		t.Errorf("GetSearch after expiry: err = %v, want ErrCacheMiss", err)
+	}
+
+	// Test 3: Torrents are cached per provider
+	if err := repo.PutTorrent(ctx, "MyAnonamouse", "42", []byte("torrent-data"), later); err != nil {
+		t.Fatalf("PutTorrent failed: %v", err)
+	}
+	data, err := repo.GetTorrent(ctx, "MyAnonamouse", "42")
+	if err != nil || string(data) != "torrent-data" {
+		t.Errorf("GetTorrent = %q, %v, want the cached torrent", data, err)
+	}
+	if _, err := repo.GetTorrent(ctx, "Prowlarr", "42"); !errors.Is(err, persistence.ErrCacheMiss) {
+		t.Errorf("GetTorrent for another provider: err = %v, want ErrCacheMiss", err)
+	}
+
+	// Test 4: DeleteExpired removes only expired entries
+	deleted, err := repo.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("DeleteExpired failed: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("DeleteExpired removed %d entries, want the expired search", deleted)
+	}
+	if _, err := repo.GetTorrent(ctx, "MyAnonamouse", "42"); err != nil {
+		t.Errorf("GetTorrent after DeleteExpired: err = %v, want the torrent kept", err)
+	}
+
+	// Test 5: Clearing empties each cache
+	if err := repo.PutSearch(ctx, "dune", []byte(`{}`), later); err != nil {
+		t.Fatalf("PutSearch failed: %v", err)
+	}
+	if err := repo.ClearSearches(ctx); err != nil {
+		t.Fatalf("ClearSearches failed: %v", err)
+	}
+	if _, _, err := repo.GetSearch(ctx, "dune"); !errors.Is(err, persistence.ErrCacheMiss) {
+		t.Errorf("GetSearch after ClearSearches: err = %v, want ErrCacheMiss", err)
+	}
+	if err := repo.ClearTorrents(ctx); err != nil {
+		t.Fatalf("ClearTorrents failed: %v", err)
+	}
+	if _, err := repo.GetTorrent(ctx, "MyAnonamouse", "42"); !errors.Is(err, persistence.ErrCacheMiss) {
+		t.Errorf("GetTorrent after ClearTorrents: err = %v, want ErrCacheMiss", err)
+	}
+}
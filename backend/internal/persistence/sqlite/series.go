@@ -0,0 +1,211 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/persistence"
+)
+
+type FollowedSeriesRepository struct {
+	db *sql.DB
+}
+
+func NewFollowedSeriesRepository(db *sql.DB) *FollowedSeriesRepository {
+	return &FollowedSeriesRepository{db: db}
+}
+
+const followedSeriesColumns = `id, provider, series_id, name, media_type, category, quality_profile_id, start_after, last_checked_at, last_error, created_at, updated_at`
+
+func (r *FollowedSeriesRepository) List(ctx context.Context) ([]*models.FollowedSeries, error) {
+	query := `SELECT ` + followedSeriesColumns + ` FROM followed_series ORDER BY name COLLATE NOCASE, id`
+	return r.query(ctx, query)
+}
+
+func (r *FollowedSeriesRepository) ListDue(ctx context.Context, before time.Time) ([]*models.FollowedSeries, error) {
+	query := `
+		SELECT ` + followedSeriesColumns + ` FROM followed_series
+		WHERE last_checked_at IS NULL OR last_checked_at < ?
+		ORDER BY last_checked_at IS NOT NULL, last_checked_at, id
+	`
+	return r.query(ctx, query, before.UTC())
+}
+
+func (r *FollowedSeriesRepository) query(ctx context.Context, query string, args ...interface{}) ([]*models.FollowedSeries, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query followed series: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			// Log close errors as they may indicate database issues
+			fmt.Printf("failed to close followed series rows: %v\n", err)
+		}
+	}()
+
+	var series []*models.FollowedSeries
+	for rows.Next() {
+		s, err := scanFollowedSeries(rows)
+		if err != nil {
+			return nil, err
+		}
+		series = append(series, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate followed series: %w", err)
+	}
+
+	return series, nil
+}
+
+func (r *FollowedSeriesRepository) GetByID(ctx context.Context, id int64) (*models.FollowedSeries, error) {
+	query := `SELECT ` + followedSeriesColumns + ` FROM followed_series WHERE id = ?`
+
+	s, err := scanFollowedSeries(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, persistence.ErrFollowedSeriesNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *FollowedSeriesRepository) Create(ctx context.Context, s *models.FollowedSeries) error {
+	query := `
+		INSERT INTO followed_series (provider, series_id, name, media_type, category, quality_profile_id, start_after,
+			created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	now := time.Now().UTC()
+	result, err := r.db.ExecContext(ctx, query,
+		s.Provider, s.SeriesID, s.Name, s.MediaType, s.Category, s.QualityProfileID, s.StartAfter, now, now,
+	)
+	if err != nil {
+		return followedSeriesWriteError("create", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get followed series ID: %w", err)
+	}
+	s.ID = id
+	s.CreatedAt = now
+	s.UpdatedAt = now
+	return nil
+}
+
+func (r *FollowedSeriesRepository) Update(ctx context.Context, s *models.FollowedSeries) error {
+	query := `
+		UPDATE followed_series
+		SET provider = ?, series_id = ?, name = ?, media_type = ?, category = ?, quality_profile_id = ?, start_after = ?,
+			updated_at = ?
+		WHERE id = ?
+	`
+
+	now := time.Now().UTC()
+	result, err := r.db.ExecContext(ctx, query,
+		s.Provider, s.SeriesID, s.Name, s.MediaType, s.Category, s.QualityProfileID, s.StartAfter, now, s.ID,
+	)
+	if err != nil {
+		return followedSeriesWriteError("update", err)
+	}
+	if err := requireFollowedSeries(result); err != nil {
+		return err
+	}
+	s.UpdatedAt = now
+	return nil
+}
+
+func (r *FollowedSeriesRepository) RecordCheck(ctx context.Context, id int64, checkedAt time.Time, lastError string) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE followed_series SET last_checked_at = ?, last_error = ? WHERE id = ?`,
+		checkedAt.UTC(), lastError, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record followed series check: %w", err)
+	}
+	return requireFollowedSeries(result)
+}
+
+func (r *FollowedSeriesRepository) Delete(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback() // No-op after a successful commit
+	}()
+
+	// The queued books stay wanted, as books added by hand
+	if _, err := tx.ExecContext(ctx, `UPDATE wanted_books SET followed_series_id = NULL WHERE followed_series_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to unlink wanted books: %w", err)
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM followed_series WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete followed series: %w", err)
+	}
+	if err := requireFollowedSeries(result); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// requireFollowedSeries reports a write that matched no series as ErrFollowedSeriesNotFound
+func requireFollowedSeries(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return persistence.ErrFollowedSeriesNotFound
+	}
+	return nil
+}
+
+// followedSeriesWriteError reports a series already followed as ErrFollowedSeriesExists
+func followedSeriesWriteError(operation string, err error) error {
+	if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return persistence.ErrFollowedSeriesExists
+	}
+	return fmt.Errorf("failed to %s followed series: %w", operation, err)
+}
+
+type followedSeriesScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanFollowedSeries(row followedSeriesScanner) (*models.FollowedSeries, error) {
+	var s models.FollowedSeries
+	var mediaType sql.NullString
+	var profileID sql.NullInt64
+	var lastCheckedAt sql.NullTime
+
+	err := row.Scan(&s.ID, &s.Provider, &s.SeriesID, &s.Name, &mediaType, &s.Category, &profileID, &s.StartAfter,
+		&lastCheckedAt, &s.LastError, &s.CreatedAt, &s.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan followed series: %w", err)
+	}
+
+	s.MediaType = scanMediaType(mediaType)
+	if profileID.Valid {
+		id := profileID.Int64
+		s.QualityProfileID = &id
+	}
+	if lastCheckedAt.Valid {
+		s.LastCheckedAt = &lastCheckedAt.Time
+	}
+	return &s, nil
+}
@@ -0,0 +1,137 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/persistence"
+)
+
+func TestFollowedSeriesRepository(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Logf("failed to close test database: %v", err)
+		}
+	}()
+	// A single connection keeps every query on the same in-memory database
+	db.SetMaxOpenConns(1)
+
+	schema := `
+		CREATE TABLE followed_series (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			provider TEXT NOT NULL,
+			series_id TEXT NOT NULL,
+			name TEXT NOT NULL,
+			media_type TEXT NOT NULL DEFAULT 'audiobook',
+			category TEXT NOT NULL DEFAULT '',
+			quality_profile_id INTEGER,
+			start_after TEXT NOT NULL DEFAULT '',
+			last_checked_at TIMESTAMP,
+			last_error TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (provider, series_id, media_type)
+		);
+
+		CREATE TABLE wanted_books (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			title TEXT NOT NULL,
+			author TEXT NOT NULL,
+			series TEXT NOT NULL DEFAULT '',
+			series_number TEXT NOT NULL DEFAULT '',
+			media_type TEXT NOT NULL DEFAULT 'audiobook',
+			category TEXT NOT NULL DEFAULT '',
+			quality_profile_id INTEGER,
+			status TEXT NOT NULL DEFAULT 'wanted',
+			download_id TEXT NOT NULL DEFAULT '',
+			last_searched_at TIMESTAMP,
+			last_error TEXT NOT NULL DEFAULT '',
+			followed_series_id INTEGER,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (title COLLATE NOCASE, author COLLATE NOCASE, media_type)
+		);
+	`
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+
+	repo := NewFollowedSeriesRepository(db)
+	wantedRepo := NewWantedBookRepository(db)
+	ctx := context.Background()
+
+	profileID := int64(2)
+	dune := &models.FollowedSeries{Provider: "MyAnonamouse", SeriesID: "1234", Name: "Dune", MediaType: models.MediaTypeAudiobook, QualityProfileID: &profileID, StartAfter: "3"}
+	if err := repo.Create(ctx, dune); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := repo.Create(ctx, &models.FollowedSeries{Provider: "MyAnonamouse", SeriesID: "1234", Name: "Dune", MediaType: models.MediaTypeAudiobook}); !errors.Is(err, persistence.ErrFollowedSeriesExists) {
+		t.Errorf("Create() duplicate error = %v, want ErrFollowedSeriesExists", err)
+	}
+
+	// The same series may be followed for ebooks as well
+	ebooks := &models.FollowedSeries{Provider: "MyAnonamouse", SeriesID: "1234", Name: "Dune", MediaType: models.MediaTypeEbook}
+	if err := repo.Create(ctx, ebooks); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, dune.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.SeriesID != "1234" || got.StartAfter != "3" || got.QualityProfileID == nil || *got.QualityProfileID != 2 || got.LastCheckedAt != nil {
+		t.Errorf("GetByID() = %+v", got)
+	}
+
+	// Series never checked are due first
+	now := time.Now()
+	if err := repo.RecordCheck(ctx, dune.ID, now.Add(-2*time.Hour), "search failed"); err != nil {
+		t.Fatalf("RecordCheck() error = %v", err)
+	}
+	due, err := repo.ListDue(ctx, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("ListDue() error = %v", err)
+	}
+	if len(due) != 2 || due[0].ID != ebooks.ID || due[1].ID != dune.ID || due[1].LastError != "search failed" {
+		t.Errorf("ListDue() = %v, want the ebooks then the audiobooks", due)
+	}
+	if due, _ := repo.ListDue(ctx, now.Add(-3*time.Hour)); len(due) != 1 || due[0].ID != ebooks.ID {
+		t.Errorf("ListDue() = %v, want only the unchecked series", due)
+	}
+
+	dune.StartAfter = ""
+	if err := repo.Update(ctx, dune); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got, _ := repo.GetByID(ctx, dune.ID); got.StartAfter != "" || got.LastError != "search failed" {
+		t.Errorf("GetByID() after Update = %+v", got)
+	}
+
+	// Unfollowing keeps the queued books, unlinked
+	book := &models.WantedBook{Title: "Dune Messiah", Author: "Frank Herbert", Series: "Dune", SeriesNumber: "2", MediaType: models.MediaTypeAudiobook, FollowedSeriesID: &dune.ID}
+	if err := wantedRepo.Create(ctx, book); err != nil {
+		t.Fatalf("Create() wanted book error = %v", err)
+	}
+	if got, _ := wantedRepo.GetByID(ctx, book.ID); got.FollowedSeriesID == nil || *got.FollowedSeriesID != dune.ID {
+		t.Errorf("wanted book = %+v, want it linked to the series", got)
+	}
+	if err := repo.Delete(ctx, dune.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := repo.GetByID(ctx, dune.ID); !errors.Is(err, persistence.ErrFollowedSeriesNotFound) {
+		t.Errorf("GetByID() after Delete error = %v, want ErrFollowedSeriesNotFound", err)
+	}
+	if got, err := wantedRepo.GetByID(ctx, book.ID); err != nil || got.FollowedSeriesID != nil {
+		t.Errorf("wanted book after Delete = %+v, %v, want it kept and unlinked", got, err)
+	}
+	if err := repo.Delete(ctx, dune.ID); !errors.Is(err, persistence.ErrFollowedSeriesNotFound) {
+		t.Errorf("Delete() missing error = %v, want ErrFollowedSeriesNotFound", err)
+	}
+}
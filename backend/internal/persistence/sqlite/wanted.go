@@ -0,0 +1,202 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/persistence"
+)
+
+type WantedBookRepository struct {
+	db *sql.DB
+}
+
+func NewWantedBookRepository(db *sql.DB) *WantedBookRepository {
+	return &WantedBookRepository{db: db}
+}
+
+const wantedBookColumns = `id, title, author, series, series_number, media_type, category, quality_profile_id, status, download_id, last_searched_at, last_error, followed_series_id, created_at, updated_at`
+
+func (r *WantedBookRepository) List(ctx context.Context) ([]*models.WantedBook, error) {
+	query := `SELECT ` + wantedBookColumns + ` FROM wanted_books ORDER BY created_at DESC, id DESC`
+	return r.query(ctx, query)
+}
+
+func (r *WantedBookRepository) ListDue(ctx context.Context, before time.Time) ([]*models.WantedBook, error) {
+	query := `
+		SELECT ` + wantedBookColumns + ` FROM wanted_books
+		WHERE status = ? AND (last_searched_at IS NULL OR last_searched_at < ?)
+		ORDER BY last_searched_at IS NOT NULL, last_searched_at, id
+	`
+	return r.query(ctx, query, models.WantedStatusWanted, before.UTC())
+}
+
+func (r *WantedBookRepository) query(ctx context.Context, query string, args ...interface{}) ([]*models.WantedBook, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query wanted books: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			// Log close errors as they may indicate database issues
+			fmt.Printf("failed to close wanted book rows: %v\n", err)
+		}
+	}()
+
+	var books []*models.WantedBook
+	for rows.Next() {
+		b, err := scanWantedBook(rows)
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate wanted books: %w", err)
+	}
+
+	return books, nil
+}
+
+func (r *WantedBookRepository) GetByID(ctx context.Context, id int64) (*models.WantedBook, error) {
+	query := `SELECT ` + wantedBookColumns + ` FROM wanted_books WHERE id = ?`
+
+	b, err := scanWantedBook(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, persistence.ErrWantedBookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (r *WantedBookRepository) Create(ctx context.Context, b *models.WantedBook) error {
+	query := `
+		INSERT INTO wanted_books (title, author, series, series_number, media_type, category, quality_profile_id,
+			status, download_id, followed_series_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	if b.Status == "" {
+		b.Status = models.WantedStatusWanted
+	}
+	now := time.Now().UTC()
+	result, err := r.db.ExecContext(ctx, query,
+		b.Title, b.Author, b.Series, b.SeriesNumber, b.MediaType, b.Category, b.QualityProfileID,
+		b.Status, b.DownloadID, b.FollowedSeriesID, now, now,
+	)
+	if err != nil {
+		return wantedBookWriteError("create", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get wanted book ID: %w", err)
+	}
+	b.ID = id
+	b.CreatedAt = now
+	b.UpdatedAt = now
+	return nil
+}
+
+func (r *WantedBookRepository) Update(ctx context.Context, b *models.WantedBook) error {
+	query := `
+		UPDATE wanted_books
+		SET title = ?, author = ?, series = ?, series_number = ?, media_type = ?, category = ?, quality_profile_id = ?,
+			status = ?, download_id = ?, followed_series_id = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	now := time.Now().UTC()
+	result, err := r.db.ExecContext(ctx, query,
+		b.Title, b.Author, b.Series, b.SeriesNumber, b.MediaType, b.Category, b.QualityProfileID,
+		b.Status, b.DownloadID, b.FollowedSeriesID, now, b.ID,
+	)
+	if err != nil {
+		return wantedBookWriteError("update", err)
+	}
+	if err := requireWantedBook(result); err != nil {
+		return err
+	}
+	b.UpdatedAt = now
+	return nil
+}
+
+func (r *WantedBookRepository) RecordSearch(ctx context.Context, id int64, searchedAt time.Time, lastError string) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE wanted_books SET last_searched_at = ?, last_error = ? WHERE id = ?`,
+		searchedAt.UTC(), lastError, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record wanted book search: %w", err)
+	}
+	return requireWantedBook(result)
+}
+
+func (r *WantedBookRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM wanted_books WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete wanted book: %w", err)
+	}
+	return requireWantedBook(result)
+}
+
+// requireWantedBook reports a write that matched no wanted book as ErrWantedBookNotFound
+func requireWantedBook(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return persistence.ErrWantedBookNotFound
+	}
+	return nil
+}
+
+// wantedBookWriteError reports a book already on the list as ErrWantedBookExists
+func wantedBookWriteError(operation string, err error) error {
+	if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return persistence.ErrWantedBookExists
+	}
+	return fmt.Errorf("failed to %s wanted book: %w", operation, err)
+}
+
+type wantedBookScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWantedBook(row wantedBookScanner) (*models.WantedBook, error) {
+	var b models.WantedBook
+	var mediaType sql.NullString
+	var profileID sql.NullInt64
+	var lastSearchedAt sql.NullTime
+	var seriesID sql.NullInt64
+
+	err := row.Scan(&b.ID, &b.Title, &b.Author, &b.Series, &b.SeriesNumber, &mediaType, &b.Category, &profileID,
+		&b.Status, &b.DownloadID, &lastSearchedAt, &b.LastError, &seriesID, &b.CreatedAt, &b.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan wanted book: %w", err)
+	}
+
+	b.MediaType = scanMediaType(mediaType)
+	if profileID.Valid {
+		id := profileID.Int64
+		b.QualityProfileID = &id
+	}
+	if lastSearchedAt.Valid {
+		b.LastSearchedAt = &lastSearchedAt.Time
+	}
+	if seriesID.Valid {
+		id := seriesID.Int64
+		b.FollowedSeriesID = &id
+	}
+	return &b, nil
+}
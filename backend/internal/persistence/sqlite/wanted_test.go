@@ -0,0 +1,124 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/persistence"
+)
+
+func TestWantedBookRepository(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Logf("failed to close test database: %v", err)
+		}
+	}()
+	// A single connection keeps every query on the same in-memory database
+	db.SetMaxOpenConns(1)
+
+	schema := `
+		CREATE TABLE wanted_books (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			title TEXT NOT NULL,
+			author TEXT NOT NULL,
+			series TEXT NOT NULL DEFAULT '',
+			series_number TEXT NOT NULL DEFAULT '',
+			media_type TEXT NOT NULL DEFAULT 'audiobook',
+			category TEXT NOT NULL DEFAULT '',
+			quality_profile_id INTEGER,
+			status TEXT NOT NULL DEFAULT 'wanted',
+			download_id TEXT NOT NULL DEFAULT '',
+			last_searched_at TIMESTAMP,
+			last_error TEXT NOT NULL DEFAULT '',
+			followed_series_id INTEGER,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (title COLLATE NOCASE, author COLLATE NOCASE, media_type)
+		);
+	`
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+
+	repo := NewWantedBookRepository(db)
+	ctx := context.Background()
+
+	profileID := int64(3)
+	dune := &models.WantedBook{Title: "Dune", Author: "Frank Herbert", Series: "Dune", SeriesNumber: "1", MediaType: models.MediaTypeAudiobook, QualityProfileID: &profileID}
+	if err := repo.Create(ctx, dune); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if dune.ID == 0 || dune.Status != models.WantedStatusWanted {
+		t.Errorf("Create() left ID = %d, status = %q", dune.ID, dune.Status)
+	}
+
+	// The same book differing only in case is a duplicate
+	duplicate := &models.WantedBook{Title: "DUNE", Author: "frank herbert", MediaType: models.MediaTypeAudiobook}
+	if err := repo.Create(ctx, duplicate); !errors.Is(err, persistence.ErrWantedBookExists) {
+		t.Errorf("Create() duplicate error = %v, want ErrWantedBookExists", err)
+	}
+
+	messiah := &models.WantedBook{Title: "Dune Messiah", Author: "Frank Herbert", MediaType: models.MediaTypeEbook}
+	if err := repo.Create(ctx, messiah); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, dune.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.Title != "Dune" || got.SeriesNumber != "1" || got.QualityProfileID == nil || *got.QualityProfileID != 3 || got.LastSearchedAt != nil {
+		t.Errorf("GetByID() = %+v", got)
+	}
+
+	// Books never searched for are due first, then the least recently searched
+	now := time.Now()
+	if err := repo.RecordSearch(ctx, dune.ID, now.Add(-2*time.Hour), "no matching release"); err != nil {
+		t.Fatalf("RecordSearch() error = %v", err)
+	}
+	due, err := repo.ListDue(ctx, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("ListDue() error = %v", err)
+	}
+	if len(due) != 2 || due[0].ID != messiah.ID || due[1].ID != dune.ID || due[1].LastError != "no matching release" {
+		t.Errorf("ListDue() = %v, want Dune Messiah then Dune", due)
+	}
+	if due, _ := repo.ListDue(ctx, now.Add(-3*time.Hour)); len(due) != 1 || due[0].ID != messiah.ID {
+		t.Errorf("ListDue() = %v, want only the unsearched book", due)
+	}
+
+	// Grabbed books are no longer due
+	dune.Status = models.WantedStatusGrabbed
+	dune.DownloadID = "download-1"
+	if err := repo.Update(ctx, dune); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if due, _ := repo.ListDue(ctx, now); len(due) != 1 || due[0].ID != messiah.ID {
+		t.Errorf("ListDue() = %v, want only the book still wanted", due)
+	}
+	if got, _ := repo.GetByID(ctx, dune.ID); got.DownloadID != "download-1" || got.Status != models.WantedStatusGrabbed {
+		t.Errorf("GetByID() after Update = %+v", got)
+	}
+
+	books, err := repo.List(ctx)
+	if err != nil || len(books) != 2 {
+		t.Fatalf("List() = %v, %v, want 2 books", books, err)
+	}
+
+	if err := repo.Delete(ctx, messiah.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := repo.GetByID(ctx, messiah.ID); !errors.Is(err, persistence.ErrWantedBookNotFound) {
+		t.Errorf("GetByID() after Delete error = %v, want ErrWantedBookNotFound", err)
+	}
+	if err := repo.Delete(ctx, messiah.ID); !errors.Is(err, persistence.ErrWantedBookNotFound) {
+		t.Errorf("Delete() missing error = %v, want ErrWantedBookNotFound", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -12,22 +13,49 @@ import (
 	"net/url"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
+// ErrTorrentNotFound is returned when qBittorrent has no torrent with the requested hash
+var ErrTorrentNotFound = errors.New("torrent not found")
+
 type Client struct {
+	mu      sync.RWMutex
+	current *connection
+}
+
+// connection is the server and credentials a Client talks to. It is replaced as a whole
+// by Reconfigure, so requests in flight keep using the one they started with.
+type connection struct {
 	baseURL  string
 	username string
 	password string
 	client   *http.Client
+
+	mu            sync.Mutex
+	loginErr      error     // Why Version last failed to log in
+	loginFailedAt time.Time // When it did
 }
 
+// loginRetryDelay is how long Version waits after a failed login before trying again, so
+// polling it with wrong credentials doesn't get the client's IP banned
+const loginRetryDelay = time.Minute
+
 func NewClient(baseURL, username, password string) (*Client, error) {
+	conn, err := newConnection(baseURL, username, password)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{current: conn}, nil
+}
+
+func newConnection(baseURL, username, password string) (*connection, error) {
 	jar, err := cookiejar.New(nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create cookie jar: %w", err)
 	}
-	return &Client{
+	return &connection{
 		baseURL:  strings.TrimSuffix(baseURL, "/"),
 		username: username,
 		password: password,
@@ -38,19 +66,54 @@ func NewClient(baseURL, username, password string) (*Client, error) {
 	}, nil
 }
 
+// Reconfigure points the client at a new server or credentials without replacing it, so
+// everything sharing the client picks up the change. Returns false if nothing changed.
+func (c *Client) Reconfigure(baseURL, username, password string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	old := c.current
+	if old.baseURL == strings.TrimSuffix(baseURL, "/") && old.username == username && old.password == password {
+		return false, nil
+	}
+
+	// A fresh cookie jar drops the session of the old server or user
+	conn, err := newConnection(baseURL, username, password)
+	if err != nil {
+		return false, err
+	}
+	c.current = conn
+	return true, nil
+}
+
+// BaseURL returns the qBittorrent Web UI URL the client talks to
+func (c *Client) BaseURL() string {
+	return c.conn().baseURL
+}
+
+func (c *Client) conn() *connection {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.current
+}
+
 func (c *Client) Login(ctx context.Context) error {
+	return c.conn().login(ctx)
+}
+
+func (conn *connection) login(ctx context.Context) error {
 	data := url.Values{}
-	data.Set("username", c.username)
-	data.Set("password", c.password)
+	data.Set("username", conn.username)
+	data.Set("password", conn.password)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v2/auth/login", strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", conn.baseURL+"/api/v2/auth/login", strings.NewReader(data.Encode()))
 	if err != nil {
 		return fmt.Errorf("failed to create login request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := c.client.Do(req)
+	resp, err := conn.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to login: %w", err)
 	}
@@ -83,8 +146,67 @@ func (c *Client) Login(ctx context.Context) error {
 	return nil
 }
 
+// Version returns qBittorrent's version. It reuses the client's session, logging in only
+// when qBittorrent has dropped it, and not within loginRetryDelay of a failed login.
+func (c *Client) Version(ctx context.Context) (string, error) {
+	conn := c.conn()
+
+	version, err := conn.version(ctx)
+	if !errors.Is(err, errForbidden) {
+		return version, err
+	}
+
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	if conn.loginErr != nil && time.Since(conn.loginFailedAt) < loginRetryDelay {
+		return "", fmt.Errorf("failed to authenticate: %w", conn.loginErr)
+	}
+	if err := conn.login(ctx); err != nil {
+		conn.loginErr, conn.loginFailedAt = err, time.Now()
+		return "", fmt.Errorf("failed to authenticate: %w", err)
+	}
+	conn.loginErr = nil
+	return conn.version(ctx)
+}
+
+// errForbidden is returned by requests made without a valid session
+var errForbidden = errors.New("not logged in")
+
+func (conn *connection) version(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", conn.baseURL+"/api/v2/app/version", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := conn.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get version: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			// Log close error as it may indicate network issues
+			fmt.Printf("warning: failed to close version response body: %v\n", err)
+		}
+	}()
+
+	if resp.StatusCode == http.StatusForbidden {
+		return "", errForbidden
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("get version failed with status: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return strings.TrimSpace(string(body)), nil
+}
+
 func (c *Client) AddTorrent(ctx context.Context, magnetLink, torrentURL, category string) (string, error) {
-	if err := c.Login(ctx); err != nil {
+	conn := c.conn()
+	if err := conn.login(ctx); err != nil {
 		return "", fmt.Errorf("failed to authenticate: %w", err)
 	}
 
@@ -102,14 +224,14 @@ func (c *Client) AddTorrent(ctx context.Context, magnetLink, torrentURL, categor
 		data.Set("category", category)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v2/torrents/add", strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", conn.baseURL+"/api/v2/torrents/add", strings.NewReader(data.Encode()))
 	if err != nil {
 		return "", fmt.Errorf("failed to create add torrent request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := c.client.Do(req)
+	resp, err := conn.client.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to add torrent: %w", err)
 	}
@@ -145,7 +267,8 @@ func (c *Client) AddTorrentFromFile(ctx context.Context, torrentData []byte, cat
 	}
 
 	// Authenticate first
-	if err := c.Login(ctx); err != nil {
+	conn := c.conn()
+	if err := conn.login(ctx); err != nil {
 		return "", fmt.Errorf("failed to authenticate: %w", err)
 	}
 
@@ -178,7 +301,7 @@ func (c *Client) AddTorrentFromFile(ctx context.Context, torrentData []byte, cat
 	uploadCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(uploadCtx, "POST", c.baseURL+"/api/v2/torrents/add", &buf)
+	req, err := http.NewRequestWithContext(uploadCtx, "POST", conn.baseURL+"/api/v2/torrents/add", &buf)
 	if err != nil {
 		return "", fmt.Errorf("failed to create add torrent request: %w", err)
 	}
@@ -187,7 +310,7 @@ func (c *Client) AddTorrentFromFile(ctx context.Context, torrentData []byte, cat
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 
 	// Send request
-	resp, err := c.client.Do(req)
+	resp, err := conn.client.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to add torrent: %w", err)
 	}
@@ -230,12 +353,12 @@ func (c *Client) AddTorrentFromFile(ctx context.Context, torrentData []byte, cat
 	retryDelay := 500 * time.Millisecond
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		listReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/v2/torrents/info?sort=added_on&reverse=true&limit=10", nil)
+		listReq, err := http.NewRequestWithContext(ctx, "GET", conn.baseURL+"/api/v2/torrents/info?sort=added_on&reverse=true&limit=10", nil)
 		if err != nil {
 			return "", fmt.Errorf("failed to create torrent list request: %w", err)
 		}
 
-		listResp, err := c.client.Do(listReq)
+		listResp, err := conn.client.Do(listReq)
 		if err != nil {
 			return "", fmt.Errorf("failed to query torrent list: %w", err)
 		}
@@ -284,16 +407,17 @@ func (c *Client) AddTorrentFromFile(ctx context.Context, torrentData []byte, cat
 }
 
 func (c *Client) GetTorrentStatus(ctx context.Context, hash string) (string, float64, error) {
-	if err := c.Login(ctx); err != nil {
+	conn := c.conn()
+	if err := conn.login(ctx); err != nil {
 		return "", 0, fmt.Errorf("failed to authenticate: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/v2/torrents/info?hashes="+hash, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", conn.baseURL+"/api/v2/torrents/info?hashes="+hash, nil)
 	if err != nil {
 		return "", 0, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err := conn.client.Do(req)
 	if err != nil {
 		return "", 0, fmt.Errorf("failed to get torrent info: %w", err)
 	}
@@ -314,7 +438,7 @@ func (c *Client) GetTorrentStatus(ctx context.Context, hash string) (string, flo
 	}
 
 	if len(torrents) == 0 {
-		return "", 0, fmt.Errorf("torrent not found")
+		return "", 0, ErrTorrentNotFound
 	}
 
 	torrent := torrents[0]
@@ -325,16 +449,17 @@ func (c *Client) GetTorrentStatus(ctx context.Context, hash string) (string, flo
 }
 
 func (c *Client) GetTorrentFiles(ctx context.Context, hash string) ([]*TorrentFile, error) {
-	if err := c.Login(ctx); err != nil {
+	conn := c.conn()
+	if err := conn.login(ctx); err != nil {
 		return nil, fmt.Errorf("failed to authenticate: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/v2/torrents/files?hash="+hash, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", conn.baseURL+"/api/v2/torrents/files?hash="+hash, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err := conn.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get torrent files: %w", err)
 	}
@@ -345,6 +470,10 @@ func (c *Client) GetTorrentFiles(ctx context.Context, hash string) ([]*TorrentFi
 		}
 	}()
 
+	// qBittorrent answers 404 for hashes it doesn't know
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrTorrentNotFound
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("get torrent files failed with status: %d", resp.StatusCode)
 	}
@@ -359,8 +488,8 @@ func (c *Client) GetTorrentFiles(ctx context.Context, hash string) ([]*TorrentFi
 	}
 
 	// Get torrent info to get save path
-	infoReq, _ := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/v2/torrents/info?hashes="+hash, nil)
-	infoResp, err := c.client.Do(infoReq)
+	infoReq, _ := http.NewRequestWithContext(ctx, "GET", conn.baseURL+"/api/v2/torrents/info?hashes="+hash, nil)
+	infoResp, err := conn.client.Do(infoReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get torrent info: %w", err)
 	}
@@ -377,7 +506,7 @@ func (c *Client) GetTorrentFiles(ctx context.Context, hash string) ([]*TorrentFi
 	}
 
 	if len(torrents) == 0 {
-		return nil, fmt.Errorf("torrent not found")
+		return nil, ErrTorrentNotFound
 	}
 
 	savePath := torrents[0].SavePath
@@ -385,17 +514,103 @@ func (c *Client) GetTorrentFiles(ctx context.Context, hash string) ([]*TorrentFi
 	files := make([]*TorrentFile, len(filesResp))
 	for i, f := range filesResp {
 		files[i] = &TorrentFile{
-			Name: f.Name,
-			Path: savePath + "/" + f.Name,
-			Size: f.Size,
+			Name:     f.Name,
+			Path:     savePath + "/" + f.Name,
+			SavePath: savePath,
+			Size:     f.Size,
 		}
 	}
 
 	return files, nil
 }
 
+// ListTorrents returns all torrents, optionally filtered by category and tag.
+// Empty filters are ignored.
+func (c *Client) ListTorrents(ctx context.Context, category, tag string) ([]TorrentInfo, error) {
+	conn := c.conn()
+	if err := conn.login(ctx); err != nil {
+		return nil, fmt.Errorf("failed to authenticate: %w", err)
+	}
+
+	params := url.Values{}
+	if category != "" {
+		params.Set("category", category)
+	}
+	if tag != "" {
+		params.Set("tag", tag)
+	}
+
+	infoURL := conn.baseURL + "/api/v2/torrents/info"
+	if len(params) > 0 {
+		infoURL += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", infoURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := conn.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list torrents: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			// Log close error as it may indicate network issues
+			fmt.Printf("warning: failed to close torrent list response body: %v\n", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("list torrents failed with status: %d", resp.StatusCode)
+	}
+
+	var torrents []TorrentInfo
+	if err := json.NewDecoder(resp.Body).Decode(&torrents); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return torrents, nil
+}
+
+// GetDefaultSavePath returns qBittorrent's default save path for new torrents
+func (c *Client) GetDefaultSavePath(ctx context.Context) (string, error) {
+	conn := c.conn()
+	if err := conn.login(ctx); err != nil {
+		return "", fmt.Errorf("failed to authenticate: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", conn.baseURL+"/api/v2/app/defaultSavePath", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := conn.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get default save path: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			// Log close error as it may indicate network issues
+			fmt.Printf("warning: failed to close default save path response body: %v\n", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("get default save path failed with status: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return strings.TrimSpace(string(body)), nil
+}
+
 func (c *Client) DeleteTorrent(ctx context.Context, hash string, deleteFiles bool) error {
-	if err := c.Login(ctx); err != nil {
+	conn := c.conn()
+	if err := conn.login(ctx); err != nil {
 		return fmt.Errorf("failed to authenticate: %w", err)
 	}
 
@@ -407,14 +622,14 @@ func (c *Client) DeleteTorrent(ctx context.Context, hash string, deleteFiles boo
 		data.Set("deleteFiles", "false")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v2/torrents/delete", strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", conn.baseURL+"/api/v2/torrents/delete", strings.NewReader(data.Encode()))
 	if err != nil {
 		return fmt.Errorf("failed to create delete request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := c.client.Do(req)
+	resp, err := conn.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to delete torrent: %w", err)
 	}
@@ -6,15 +6,18 @@ type TorrentInfo struct {
 	State      string  `json:"state"`
 	Progress   float64 `json:"progress"`
 	SavePath   string  `json:"save_path"`
+	Category   string  `json:"category"`
+	Tags       string  `json:"tags"`
 	Downloaded int64   `json:"downloaded"`
 	Size       int64   `json:"size"`
 	AddedOn    int64   `json:"added_on"`
 }
 
 type TorrentFile struct {
-	Name string
-	Path string
-	Size int64
+	Name     string
+	Path     string
+	SavePath string
+	Size     int64
 }
 
 type LoginRequest struct {
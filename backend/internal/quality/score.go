@@ -0,0 +1,189 @@
+package quality
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nathanael/organizr/internal/models"
+)
+
+// Points awarded for each preference a release meets
+const (
+	// Per rank below the end of the preferred formats list, so the first format scores most
+	formatPoints            = 10
+	languagePoints          = 15
+	preferredUploaderPoints = 25
+	// One point per this many seeders, up to maxSeederPoints, to favour healthy releases
+	seedersPerPoint = 5
+	maxSeederPoints = 10
+)
+
+// Reason explains part of a score
+type Reason struct {
+	Points  int
+	Message string
+}
+
+// Score is how well a release matches a profile. Rejected releases break one of the
+// profile's limits and are never picked automatically.
+type Score struct {
+	Total    int
+	Rejected bool
+	Reasons  []Reason
+}
+
+func (s *Score) add(points int, format string, args ...interface{}) {
+	s.Total += points
+	s.Reasons = append(s.Reasons, Reason{Points: points, Message: fmt.Sprintf(format, args...)})
+}
+
+func (s *Score) reject(format string, args ...interface{}) {
+	s.Rejected = true
+	s.Reasons = append(s.Reasons, Reason{Message: fmt.Sprintf(format, args...)})
+}
+
+// Evaluate scores a search result against a profile. A nil profile scores every result 0.
+func Evaluate(p *models.QualityProfile, r *models.SearchResult) Score {
+	var s Score
+	if p == nil {
+		return s
+	}
+
+	if uploader := strings.TrimSpace(r.Uploader); uploader != "" {
+		if containsFold(p.BlockedUploaders, uploader) {
+			s.reject("uploader %s is blocked", uploader)
+		} else if containsFold(p.PreferredUploaders, uploader) {
+			s.add(preferredUploaderPoints, "uploader %s is preferred", uploader)
+		}
+	}
+
+	if r.Seeders < p.MinSeeders {
+		s.reject("%d seeders, fewer than the minimum of %d", r.Seeders, p.MinSeeders)
+	}
+
+	if p.MinSizeMB > 0 || p.MaxSizeMB > 0 {
+		if size, ok := ParseSize(r.Size); ok {
+			sizeMB := size / (1024 * 1024)
+			switch {
+			case p.MinSizeMB > 0 && sizeMB < p.MinSizeMB:
+				s.reject("%s is smaller than %d MB", r.Size, p.MinSizeMB)
+			case p.MaxSizeMB > 0 && sizeMB > p.MaxSizeMB:
+				s.reject("%s is larger than %d MB", r.Size, p.MaxSizeMB)
+			}
+		}
+	}
+
+	if rank, format := formatRank(p.PreferredFormats, r.FileType); rank >= 0 {
+		s.add((len(p.PreferredFormats)-rank)*formatPoints, "format %s is preference %d", format, rank+1)
+	}
+
+	if r.Language != "" && containsFold(p.PreferredLanguages, r.Language) {
+		s.add(languagePoints, "language %s is preferred", r.Language)
+	}
+
+	if r.Freeleech && p.FreeleechBonus != 0 {
+		s.add(p.FreeleechBonus, "freeleech")
+	}
+
+	if points := min(r.Seeders/seedersPerPoint, maxSeederPoints); points > 0 {
+		s.add(points, "%d seeders", r.Seeders)
+	}
+
+	return s
+}
+
+// formatRank returns the position in preferred of the best format a result's file type lists,
+// or -1 if it has none of them. File types can list several formats, e.g. "mp3 m4b".
+func formatRank(preferred []string, fileType string) (int, string) {
+	formats := strings.FieldsFunc(strings.ToLower(fileType), func(r rune) bool {
+		return r == ' ' || r == ',' || r == '/'
+	})
+	for i, want := range preferred {
+		for _, format := range formats {
+			if strings.EqualFold(strings.TrimPrefix(format, "."), want) {
+				return i, want
+			}
+		}
+	}
+	return -1, ""
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Ranked is a search result with its score
+type Ranked struct {
+	Result *models.SearchResult
+	Score  Score
+}
+
+// Rank scores results against a profile and orders them best first: releases that aren't
+// rejected, then by score, then by seeders. Results that tie keep their order.
+func Rank(p *models.QualityProfile, results []*models.SearchResult) []Ranked {
+	ranked := make([]Ranked, len(results))
+	for i, r := range results {
+		ranked[i] = Ranked{Result: r, Score: Evaluate(p, r)}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Score.Rejected != b.Score.Rejected {
+			return !a.Score.Rejected
+		}
+		if a.Score.Total != b.Score.Total {
+			return a.Score.Total > b.Score.Total
+		}
+		return a.Result.Seeders > b.Result.Seeders
+	})
+	return ranked
+}
+
+// Best returns the best result that the profile doesn't reject, or nil if there is none
+func Best(p *models.QualityProfile, results []*models.SearchResult) *models.SearchResult {
+	ranked := Rank(p, results)
+	if len(ranked) == 0 || ranked[0].Score.Rejected {
+		return nil
+	}
+	return ranked[0].Result
+}
+
+var sizeUnits = map[string]int64{
+	"b":   1,
+	"kb":  1000,
+	"kib": 1024,
+	"mb":  1000 * 1000,
+	"mib": 1024 * 1024,
+	"gb":  1000 * 1000 * 1000,
+	"gib": 1024 * 1024 * 1024,
+	"tb":  1000 * 1000 * 1000 * 1000,
+	"tib": 1024 * 1024 * 1024 * 1024,
+}
+
+// ParseSize parses a size as providers format it, e.g. "1.2 GiB" or "450 MB", into bytes
+func ParseSize(size string) (int64, bool) {
+	size = strings.TrimSpace(size)
+	i := strings.IndexFunc(size, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != ','
+	})
+	if i <= 0 {
+		return 0, false
+	}
+
+	value, err := strconv.ParseFloat(strings.ReplaceAll(size[:i], ",", ""), 64)
+	if err != nil {
+		return 0, false
+	}
+	unit, ok := sizeUnits[strings.ToLower(strings.TrimSpace(size[i:]))]
+	if !ok {
+		return 0, false
+	}
+	return int64(value * float64(unit)), true
+}
@@ -0,0 +1,152 @@
+package quality
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nathanael/organizr/internal/models"
+)
+
+func TestEvaluate(t *testing.T) {
+	profile := &models.QualityProfile{
+		PreferredFormats:   []string{"m4b", "mp3"},
+		MinSizeMB:          50,
+		MaxSizeMB:          2000,
+		PreferredLanguages: []string{"ENG"},
+		FreeleechBonus:     30,
+		MinSeeders:         2,
+		PreferredUploaders: []string{"TrustedRipper"},
+		BlockedUploaders:   []string{"Spammer"},
+	}
+
+	tests := []struct {
+		name         string
+		result       models.SearchResult
+		wantTotal    int
+		wantRejected bool
+		wantReason   string
+	}{
+		{
+			name:      "Preferred everything",
+			result:    models.SearchResult{FileType: "m4b", Language: "eng", Freeleech: true, Uploader: "trustedripper", Size: "800 MiB", Seeders: 20},
+			wantTotal: 20 + 15 + 30 + 25 + 4,
+		},
+		{
+			name:       "Second format",
+			result:     models.SearchResult{FileType: "mp3", Size: "800 MiB", Seeders: 3},
+			wantTotal:  10,
+			wantReason: "format mp3 is preference 2",
+		},
+		{
+			name:       "Several formats use the best",
+			result:     models.SearchResult{FileType: "mp3 m4b", Size: "800 MiB", Seeders: 3},
+			wantTotal:  20,
+			wantReason: "format m4b is preference 1",
+		},
+		{
+			name:         "Too few seeders",
+			result:       models.SearchResult{FileType: "m4b", Size: "800 MiB", Seeders: 1},
+			wantTotal:    20,
+			wantRejected: true,
+			wantReason:   "fewer than the minimum of 2",
+		},
+		{
+			name:         "Too large",
+			result:       models.SearchResult{Size: "2.5 GiB", Seeders: 3},
+			wantRejected: true,
+			wantReason:   "larger than 2000 MB",
+		},
+		{
+			name:         "Too small",
+			result:       models.SearchResult{Size: "12 MB", Seeders: 3},
+			wantRejected: true,
+			wantReason:   "smaller than 50 MB",
+		},
+		{
+			name:         "Blocked uploader",
+			result:       models.SearchResult{Uploader: "spammer", Size: "800 MiB", Seeders: 3},
+			wantRejected: true,
+			wantReason:   "uploader spammer is blocked",
+		},
+		{
+			name:      "Unknown size isn't rejected",
+			result:    models.SearchResult{Seeders: 3},
+			wantTotal: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := Evaluate(profile, &tt.result)
+			if score.Total != tt.wantTotal || score.Rejected != tt.wantRejected {
+				t.Errorf("Evaluate() = %d (rejected %v), want %d (rejected %v); reasons %+v",
+					score.Total, score.Rejected, tt.wantTotal, tt.wantRejected, score.Reasons)
+			}
+			if tt.wantReason != "" && !hasReason(score, tt.wantReason) {
+				t.Errorf("Evaluate() reasons = %+v, want one containing %q", score.Reasons, tt.wantReason)
+			}
+		})
+	}
+
+	if score := Evaluate(nil, &models.SearchResult{FileType: "m4b", Seeders: 100}); score.Total != 0 || score.Rejected {
+		t.Errorf("Evaluate(nil) = %+v, want a zero score", score)
+	}
+}
+
+func hasReason(score Score, substr string) bool {
+	for _, reason := range score.Reasons {
+		if strings.Contains(reason.Message, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRankAndBest(t *testing.T) {
+	profile := &models.QualityProfile{PreferredFormats: []string{"m4b"}, MinSeeders: 1}
+	mp3 := &models.SearchResult{ID: "mp3", FileType: "mp3", Seeders: 40}
+	m4b := &models.SearchResult{ID: "m4b", FileType: "m4b", Seeders: 5}
+	dead := &models.SearchResult{ID: "dead", FileType: "m4b", Seeders: 0}
+	other := &models.SearchResult{ID: "other", FileType: "mp3", Seeders: 9}
+
+	ranked := Rank(profile, []*models.SearchResult{dead, mp3, other, m4b})
+
+	var ids []string
+	for _, r := range ranked {
+		ids = append(ids, r.Result.ID)
+	}
+	// m4b outscores mp3's seeders, the mp3 releases tie on score and order by seeders, and
+	// the rejected release comes last
+	if strings.Join(ids, ",") != "m4b,mp3,other,dead" {
+		t.Errorf("Rank() order = %v", ids)
+	}
+
+	if best := Best(profile, []*models.SearchResult{dead, mp3, m4b}); best != m4b {
+		t.Errorf("Best() = %+v, want the m4b release", best)
+	}
+	if best := Best(profile, []*models.SearchResult{dead}); best != nil {
+		t.Errorf("Best() = %+v, want nil when every release is rejected", best)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size   string
+		want   int64
+		wantOK bool
+	}{
+		{"1.5 GiB", 1610612736, true},
+		{"450 MB", 450000000, true},
+		{"881.5 MiB", 924319744, true},
+		{"1,024 KiB", 1048576, true},
+		{"12b", 12, true},
+		{"", 0, false},
+		{"big", 0, false},
+		{"12 parsecs", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseSize(tt.size)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseSize(%q) = %d, %v, want %d, %v", tt.size, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
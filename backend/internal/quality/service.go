@@ -0,0 +1,140 @@
+package quality
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/nathanael/organizr/internal/config"
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/persistence"
+)
+
+// DefaultProfileKey is the config key holding the ID of the profile used when a search or
+// automated grab doesn't name one
+const DefaultProfileKey = "search.quality_profile"
+
+// ErrInvalidProfile is returned for a profile that fails validation
+var ErrInvalidProfile = errors.New("invalid quality profile")
+
+// Service manages quality profiles
+type Service struct {
+	repo          persistence.QualityProfileRepository
+	configService *config.Service
+}
+
+func NewService(repo persistence.QualityProfileRepository, configService *config.Service) *Service {
+	return &Service{repo: repo, configService: configService}
+}
+
+func (s *Service) List(ctx context.Context) ([]*models.QualityProfile, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *Service) Get(ctx context.Context, id int64) (*models.QualityProfile, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *Service) Create(ctx context.Context, p *models.QualityProfile) error {
+	if err := normalize(p); err != nil {
+		return err
+	}
+	return s.repo.Create(ctx, p)
+}
+
+func (s *Service) Update(ctx context.Context, p *models.QualityProfile) error {
+	if err := normalize(p); err != nil {
+		return err
+	}
+	return s.repo.Update(ctx, p)
+}
+
+// Delete removes a profile, and stops using it by default if it was the default
+func (s *Service) Delete(ctx context.Context, id int64) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	if defaultID, ok := s.defaultID(ctx); ok && defaultID == id {
+		if err := s.configService.Set(ctx, DefaultProfileKey, ""); err != nil {
+			return fmt.Errorf("failed to clear default quality profile: %w", err)
+		}
+	}
+	return nil
+}
+
+// Default returns the default profile, or nil if none is set
+func (s *Service) Default(ctx context.Context) (*models.QualityProfile, error) {
+	id, ok := s.defaultID(ctx)
+	if !ok {
+		return nil, nil
+	}
+
+	p, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, persistence.ErrQualityProfileNotFound) {
+		log.Printf("Default quality profile %d no longer exists, results are not ranked", id)
+		return nil, nil
+	}
+	return p, err
+}
+
+func (s *Service) defaultID(ctx context.Context) (int64, bool) {
+	value, err := s.configService.Get(ctx, DefaultProfileKey)
+	if err != nil || strings.TrimSpace(value) == "" {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		log.Printf("Invalid %s %q, results are not ranked", DefaultProfileKey, value)
+		return 0, false
+	}
+	return id, true
+}
+
+// normalize validates a profile and tidies its lists: entries are trimmed, empty and
+// duplicate entries dropped, and formats lowercased without a leading dot
+func normalize(p *models.QualityProfile) error {
+	p.Name = strings.TrimSpace(p.Name)
+	switch {
+	case p.Name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidProfile)
+	case len(p.Name) > 100:
+		return fmt.Errorf("%w: name must be 100 characters or less", ErrInvalidProfile)
+	case p.MinSizeMB < 0 || p.MaxSizeMB < 0:
+		return fmt.Errorf("%w: sizes cannot be negative", ErrInvalidProfile)
+	case p.MaxSizeMB > 0 && p.MinSizeMB > p.MaxSizeMB:
+		return fmt.Errorf("%w: min_size_mb cannot be larger than max_size_mb", ErrInvalidProfile)
+	case p.MinSeeders < 0:
+		return fmt.Errorf("%w: min_seeders cannot be negative", ErrInvalidProfile)
+	}
+
+	formats := make([]string, len(p.PreferredFormats))
+	for i, format := range p.PreferredFormats {
+		formats[i] = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(format)), ".")
+	}
+	p.PreferredFormats = tidyList(formats)
+	p.PreferredLanguages = tidyList(p.PreferredLanguages)
+	p.PreferredUploaders = tidyList(p.PreferredUploaders)
+	p.BlockedUploaders = tidyList(p.BlockedUploaders)
+
+	for _, uploader := range p.PreferredUploaders {
+		if containsFold(p.BlockedUploaders, uploader) {
+			return fmt.Errorf("%w: uploader %s is both preferred and blocked", ErrInvalidProfile, uploader)
+		}
+	}
+	return nil
+}
+
+func tidyList(items []string) []string {
+	tidy := []string{}
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item != "" && !containsFold(tidy, item) {
+			tidy = append(tidy, item)
+		}
+	}
+	return tidy
+}
@@ -0,0 +1,138 @@
+package quality
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/nathanael/organizr/internal/config"
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/persistence"
+)
+
+// mockProfileRepo is an in-memory quality profile repository
+type mockProfileRepo struct {
+	profiles map[int64]*models.QualityProfile
+	nextID   int64
+}
+
+func newMockProfileRepo() *mockProfileRepo {
+	return &mockProfileRepo{profiles: make(map[int64]*models.QualityProfile)}
+}
+
+func (m *mockProfileRepo) List(ctx context.Context) ([]*models.QualityProfile, error) {
+	var profiles []*models.QualityProfile
+	for _, p := range m.profiles {
+		profiles = append(profiles, p)
+	}
+	return profiles, nil
+}
+
+func (m *mockProfileRepo) GetByID(ctx context.Context, id int64) (*models.QualityProfile, error) {
+	if p, ok := m.profiles[id]; ok {
+		return p, nil
+	}
+	return nil, persistence.ErrQualityProfileNotFound
+}
+
+func (m *mockProfileRepo) Create(ctx context.Context, p *models.QualityProfile) error {
+	m.nextID++
+	p.ID = m.nextID
+	m.profiles[p.ID] = p
+	return nil
+}
+
+func (m *mockProfileRepo) Update(ctx context.Context, p *models.QualityProfile) error {
+	if _, ok := m.profiles[p.ID]; !ok {
+		return persistence.ErrQualityProfileNotFound
+	}
+	m.profiles[p.ID] = p
+	return nil
+}
+
+func (m *mockProfileRepo) Delete(ctx context.Context, id int64) error {
+	if _, ok := m.profiles[id]; !ok {
+		return persistence.ErrQualityProfileNotFound
+	}
+	delete(m.profiles, id)
+	return nil
+}
+
+// mockConfigRepo is an in-memory config repository
+type mockConfigRepo struct {
+	configs map[string]string
+}
+
+func (m *mockConfigRepo) Get(ctx context.Context, key string) (string, error) {
+	if val, ok := m.configs[key]; ok {
+		return val, nil
+	}
+	return "", fmt.Errorf("config key not found: %s", key)
+}
+
+func (m *mockConfigRepo) GetAll(ctx context.Context) (map[string]string, error) {
+	return m.configs, nil
+}
+
+func (m *mockConfigRepo) Set(ctx context.Context, key, value string) error {
+	m.configs[key] = value
+	return nil
+}
+
+func TestService_CreateNormalizesAndValidates(t *testing.T) {
+	s := NewService(newMockProfileRepo(), config.NewService(&mockConfigRepo{configs: map[string]string{}}))
+	ctx := context.Background()
+
+	p := &models.QualityProfile{
+		Name:             "  Audiobooks ",
+		PreferredFormats: []string{" M4B", ".mp3", "m4b", ""},
+		BlockedUploaders: []string{"spammer", "Spammer"},
+	}
+	if err := s.Create(ctx, p); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if p.Name != "Audiobooks" || fmt.Sprint(p.PreferredFormats) != "[m4b mp3]" || fmt.Sprint(p.BlockedUploaders) != "[spammer]" {
+		t.Errorf("Create() stored %+v, want trimmed and deduplicated lists", p)
+	}
+
+	invalid := []*models.QualityProfile{
+		{Name: ""},
+		{Name: "Sizes", MinSizeMB: 500, MaxSizeMB: 100},
+		{Name: "Seeders", MinSeeders: -1},
+		{Name: "Uploaders", PreferredUploaders: []string{"ripper"}, BlockedUploaders: []string{"Ripper"}},
+	}
+	for _, p := range invalid {
+		if err := s.Create(ctx, p); !errors.Is(err, ErrInvalidProfile) {
+			t.Errorf("Create(%+v) error = %v, want ErrInvalidProfile", p, err)
+		}
+	}
+}
+
+func TestService_Default(t *testing.T) {
+	configs := map[string]string{DefaultProfileKey: ""}
+	s := NewService(newMockProfileRepo(), config.NewService(&mockConfigRepo{configs: configs}))
+	ctx := context.Background()
+
+	if p, err := s.Default(ctx); p != nil || err != nil {
+		t.Errorf("Default() = %+v, %v, want none while unset", p, err)
+	}
+
+	p := &models.QualityProfile{Name: "Audiobooks"}
+	if err := s.Create(ctx, p); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	configs[DefaultProfileKey] = fmt.Sprint(p.ID)
+
+	if got, err := s.Default(ctx); err != nil || got != p {
+		t.Errorf("Default() = %+v, %v, want the configured profile", got, err)
+	}
+
+	// Deleting the default profile stops using it
+	if err := s.Delete(ctx, p.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if configs[DefaultProfileKey] != "" {
+		t.Errorf("%s = %q after deleting its profile, want it cleared", DefaultProfileKey, configs[DefaultProfileKey])
+	}
+}
@@ -0,0 +1,171 @@
+package search
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/persistence"
+	"github.com/nathanael/organizr/internal/search/providers"
+)
+
+const (
+	defaultSearchCacheTTL  = 15 * time.Minute
+	defaultTorrentCacheTTL = 24 * time.Hour
+)
+
+type bypassCacheKey struct{}
+
+// BypassCache returns a context whose searches and torrent downloads skip cached responses
+// and go to the providers. What they fetch is still cached for later requests.
+func BypassCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassCacheKey{}, true)
+}
+
+// bypassesCache reports whether ctx was returned by BypassCache
+func bypassesCache(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassCacheKey{}).(bool)
+	return bypass
+}
+
+// cachedPage is how merged search results are stored in the cache. Searches with failed
+// providers aren't cached, so there are no failures to store.
+type cachedPage struct {
+	Results    []*models.SearchResult `json:"results"`
+	Total      int                    `json:"total"`
+	TotalFound int                    `json:"total_found"`
+}
+
+// cacheKey identifies a query in the cache. Queries that differ only in case, spacing or
+// the order of their filter lists share a key.
+func cacheKey(q providers.Query) string {
+	normalized := struct {
+		Text       string               `json:"text"`
+		Title      string               `json:"title"`
+		Author     string               `json:"author"`
+		MediaType  models.MediaType     `json:"media_type"`
+		SearchType providers.SearchType `json:"search_type"`
+		SearchIn   []string             `json:"search_in"`
+		Languages  []int                `json:"languages"`
+		Categories []int                `json:"categories"`
+		FileType   string               `json:"file_type"`
+		Sort       providers.SortType   `json:"sort"`
+		Offset     int                  `json:"offset"`
+		Limit      int                  `json:"limit"`
+	}{
+		Text:       normalizeText(q.Text),
+		Title:      normalizeText(q.Title),
+		Author:     normalizeText(q.Author),
+		MediaType:  q.MediaType,
+		SearchType: q.SearchType,
+		Languages:  slices.Sorted(slices.Values(q.Languages)),
+		Categories: slices.Sorted(slices.Values(q.Categories)),
+		FileType:   normalizeText(q.FileType),
+		Sort:       q.Sort,
+		Offset:     q.Offset,
+		Limit:      q.PageSize(),
+	}
+	for _, field := range q.SearchIn {
+		normalized.SearchIn = append(normalized.SearchIn, string(field))
+	}
+	slices.Sort(normalized.SearchIn)
+
+	data, _ := json.Marshal(normalized)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeText lowercases text and collapses its whitespace
+func normalizeText(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
+
+// cacheTTL returns the cache lifetime configured under key as a count of unit. Zero
+// disables the cache.
+func (s *Service) cacheTTL(ctx context.Context, key string, unit, fallback time.Duration) time.Duration {
+	value, err := s.configService.Get(ctx, key)
+	if err != nil {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return fallback
+	}
+	return time.Duration(n) * unit
+}
+
+// cachedSearch returns the cached results for q, or nil when there are none
+func (s *Service) cachedSearch(ctx context.Context, q providers.Query) *Results {
+	data, createdAt, err := s.cache.GetSearch(ctx, cacheKey(q))
+	if err != nil {
+		if !errors.Is(err, persistence.ErrCacheMiss) {
+			log.Printf("Failed to read search cache: %v", err)
+		}
+		return nil
+	}
+
+	var page cachedPage
+	if err := json.Unmarshal(data, &page); err != nil {
+		log.Printf("Failed to decode cached search: %v", err)
+		return nil
+	}
+	return &Results{Results: page.Results, Total: page.Total, TotalFound: page.TotalFound, CachedAt: createdAt}
+}
+
+// cacheSearch stores complete results for q, and drops expired entries while at it
+func (s *Service) cacheSearch(ctx context.Context, q providers.Query, results *Results, ttl time.Duration) {
+	if len(results.Failures) > 0 {
+		return
+	}
+
+	data, err := json.Marshal(cachedPage{Results: results.Results, Total: results.Total, TotalFound: results.TotalFound})
+	if err != nil {
+		log.Printf("Failed to encode search for the cache: %v", err)
+		return
+	}
+	if err := s.cache.PutSearch(ctx, cacheKey(q), data, time.Now().Add(ttl)); err != nil {
+		log.Printf("Failed to cache search: %v", err)
+		return
+	}
+	if _, err := s.cache.DeleteExpired(ctx, time.Now()); err != nil {
+		log.Printf("Failed to delete expired cache entries: %v", err)
+	}
+}
+
+// cachedTorrent returns a cached torrent file, or nil when there is none
+func (s *Service) cachedTorrent(ctx context.Context, provider, torrentID string) []byte {
+	data, err := s.cache.GetTorrent(ctx, provider, torrentID)
+	if err != nil {
+		if !errors.Is(err, persistence.ErrCacheMiss) {
+			log.Printf("Failed to read torrent cache: %v", err)
+		}
+		return nil
+	}
+	return data
+}
+
+// cacheTorrent stores a downloaded torrent file
+func (s *Service) cacheTorrent(ctx context.Context, provider, torrentID string, data []byte, ttl time.Duration) {
+	if err := s.cache.PutTorrent(ctx, provider, torrentID, data, time.Now().Add(ttl)); err != nil {
+		log.Printf("Failed to cache torrent: %v", err)
+	}
+}
+
+// ClearCache drops every cached search and torrent file
+func (s *Service) ClearCache(ctx context.Context) error {
+	if s.cache == nil {
+		return nil
+	}
+	if err := s.cache.ClearSearches(ctx); err != nil {
+		return err
+	}
+	return s.cache.ClearTorrents(ctx)
+}
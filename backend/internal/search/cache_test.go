@@ -0,0 +1,206 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nathanael/organizr/internal/config"
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/persistence"
+	"github.com/nathanael/organizr/internal/search/providers"
+)
+
+type cacheEntry struct {
+	data      []byte
+	createdAt time.Time
+	expiresAt time.Time
+}
+
+// mockSearchCache is an in-memory search cache
+type mockSearchCache struct {
+	searches map[string]cacheEntry
+	torrents map[string]cacheEntry
+}
+
+func newMockSearchCache() *mockSearchCache {
+	return &mockSearchCache{searches: map[string]cacheEntry{}, torrents: map[string]cacheEntry{}}
+}
+
+func (m *mockSearchCache) GetSearch(ctx context.Context, key string) ([]byte, time.Time, error) {
+	e, ok := m.searches[key]
+	if !ok || !e.expiresAt.After(time.Now()) {
+		return nil, time.Time{}, persistence.ErrCacheMiss
+	}
+	return e.data, e.createdAt, nil
+}
+
+func (m *mockSearchCache) PutSearch(ctx context.Context, key string, results []byte, expiresAt time.Time) error {
+	m.searches[key] = cacheEntry{data: results, createdAt: time.Now(), expiresAt: expiresAt}
+	return nil
+}
+
+func (m *mockSearchCache) GetTorrent(ctx context.Context, provider, torrentID string) ([]byte, error) {
+	e, ok := m.torrents[provider+"/"+torrentID]
+	if !ok || !e.expiresAt.After(time.Now()) {
+		return nil, persistence.ErrCacheMiss
+	}
+	return e.data, nil
+}
+
+func (m *mockSearchCache) PutTorrent(ctx context.Context, provider, torrentID string, data []byte, expiresAt time.Time) error {
+	m.torrents[provider+"/"+torrentID] = cacheEntry{data: data, createdAt: time.Now(), expiresAt: expiresAt}
+	return nil
+}
+
+func (m *mockSearchCache) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockSearchCache) ClearSearches(ctx context.Context) error {
+	m.searches = map[string]cacheEntry{}
+	return nil
+}
+
+func (m *mockSearchCache) ClearTorrents(ctx context.Context) error {
+	m.torrents = map[string]cacheEntry{}
+	return nil
+}
+
+func newCachedTestService(configs map[string]string, cache *mockSearchCache, ps ...providers.Provider) (*Service, *config.Service) {
+	configService := config.NewService(&mockConfigRepo{configs: configs})
+	s := NewService(configService, cache)
+	s.registry = NewRegistry(ps...)
+	return s, configService
+}
+
+func TestCacheKey(t *testing.T) {
+	a := providers.Query{Text: "  The Way  of Kings", Languages: []int{2, 1}, SearchIn: []providers.SearchIn{"title", "author"}}
+	b := providers.Query{Text: "the way of kings", Languages: []int{1, 2}, SearchIn: []providers.SearchIn{"author", "title"}, Limit: providers.MaxSearchLimit}
+	if cacheKey(a) != cacheKey(b) {
+		t.Error("cacheKey() differs for queries that only differ in case, spacing, list order and the default limit")
+	}
+
+	for name, q := range map[string]providers.Query{
+		"media type": {Text: "the way of kings", MediaType: models.MediaTypeEbook},
+		"offset":     {Text: "the way of kings", Offset: 100},
+		"title":      {Title: "the way of kings"},
+	} {
+		if cacheKey(q) == cacheKey(b) {
+			t.Errorf("cacheKey() is the same for a query with a different %s", name)
+		}
+	}
+}
+
+func TestService_Search_Cache(t *testing.T) {
+	mam := &fakeProvider{name: "MyAnonamouse", results: []*models.SearchResult{{ID: "1", Title: "Dune", Seeders: 5}}}
+	cache := newMockSearchCache()
+	s, configService := newCachedTestService(map[string]string{"search.cache_ttl_minutes": "15"}, cache, mam)
+	ctx := context.Background()
+
+	first, err := s.Search(ctx, providers.Query{Text: "Dune"})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if !first.CachedAt.IsZero() {
+		t.Error("first search was served from the cache")
+	}
+
+	// The same search, however it's spelled, is answered from the cache
+	second, err := s.Search(ctx, providers.Query{Text: " dune "})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if mam.searches != 1 || second.CachedAt.IsZero() {
+		t.Errorf("provider searched %d times, cached at %v, want the second search cached", mam.searches, second.CachedAt)
+	}
+	if len(second.Results) != 1 || second.Results[0].ID != "1" || second.Results[0].Provider != "MyAnonamouse" || second.Total != 1 {
+		t.Errorf("cached results = %+v, want the provider's", second)
+	}
+
+	// Bypassing the cache searches again
+	if _, err := s.Search(BypassCache(ctx), providers.Query{Text: "dune"}); err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if mam.searches != 2 {
+		t.Errorf("provider searched %d times, want the bypass to reach it", mam.searches)
+	}
+
+	// Settings the providers don't read keep them and the cache
+	registry := s.registry
+	if err := configService.Set(ctx, "mam.account_guard", "refuse"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if len(cache.searches) != 1 || s.registry != registry {
+		t.Errorf("cache holds %d searches after an account guard change, want the providers and cache kept", len(cache.searches))
+	}
+
+	// Changing provider settings drops cached searches
+	if err := configService.Set(ctx, "mam.secret", "new"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if len(cache.searches) != 0 {
+		t.Errorf("cache holds %d searches after a provider change, want none", len(cache.searches))
+	}
+}
+
+func TestService_Search_CacheSkipsFailures(t *testing.T) {
+	mam := &fakeProvider{name: "MyAnonamouse", results: []*models.SearchResult{{ID: "1", Title: "Dune"}}}
+	broken := &fakeProvider{name: "Broken", err: errors.New("indexer unavailable")}
+	cache := newMockSearchCache()
+	s, _ := newCachedTestService(map[string]string{}, cache, mam, broken)
+
+	if _, err := s.Search(context.Background(), providers.Query{Text: "dune"}); err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(cache.searches) != 0 {
+		t.Error("partial results were cached")
+	}
+}
+
+func TestService_Search_CacheDisabled(t *testing.T) {
+	mam := &fakeProvider{name: "MyAnonamouse", results: []*models.SearchResult{{ID: "1", Title: "Dune"}}}
+	cache := newMockSearchCache()
+	s, _ := newCachedTestService(map[string]string{"search.cache_ttl_minutes": "0"}, cache, mam)
+
+	for range 2 {
+		if _, err := s.Search(context.Background(), providers.Query{Text: "dune"}); err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+	}
+	if mam.searches != 2 || len(cache.searches) != 0 {
+		t.Errorf("provider searched %d times with %d cached, want every search to reach it", mam.searches, len(cache.searches))
+	}
+}
+
+func TestService_DownloadTorrent_Cache(t *testing.T) {
+	mam := &fakeProvider{name: providers.MyAnonamouseName, downloadByID: true}
+	cache := newMockSearchCache()
+	s, _ := newCachedTestService(map[string]string{"search.torrent_cache_ttl_hours": "24"}, cache, mam)
+	ctx := context.Background()
+
+	for range 2 {
+		data, err := s.DownloadTorrent(ctx, "", "42")
+		if err != nil || string(data) != "MyAnonamouse-42" {
+			t.Fatalf("DownloadTorrent() = %q, %v", data, err)
+		}
+	}
+	if mam.downloads != 1 {
+		t.Errorf("provider downloaded %d times, want the torrent cached", mam.downloads)
+	}
+
+	if _, err := s.DownloadTorrent(BypassCache(ctx), "", "42"); err != nil {
+		t.Fatalf("DownloadTorrent() error = %v", err)
+	}
+	if mam.downloads != 2 {
+		t.Errorf("provider downloaded %d times, want the bypass to reach it", mam.downloads)
+	}
+
+	if err := s.ClearCache(ctx); err != nil {
+		t.Fatalf("ClearCache() error = %v", err)
+	}
+	if _, err := s.DownloadTorrent(ctx, "", "42"); err != nil || mam.downloads != 3 {
+		t.Errorf("DownloadTorrent() after ClearCache: downloads = %d, err = %v, want the provider hit", mam.downloads, err)
+	}
+}
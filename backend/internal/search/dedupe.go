@@ -0,0 +1,72 @@
+package search
+
+import (
+	"strings"
+
+	"github.com/nathanael/organizr/internal/models"
+)
+
+// dedupeResults drops listings of a torrent another provider already returned, keeping the
+// best-seeded listing in the place of the first. Listings are the same torrent when their
+// info hashes match or, when a hash is missing, their title, author, size and media type do.
+// Results from one provider are never merged with each other.
+func dedupeResults(results []*models.SearchResult) []*models.SearchResult {
+	deduped := make([]*models.SearchResult, 0, len(results))
+	byKey := make(map[string]int) // Dedupe key to index in deduped
+
+	for _, r := range results {
+		keys := dedupeKeys(r)
+
+		existing := -1
+		for _, key := range keys {
+			if i, ok := byKey[key]; ok && sameTorrent(deduped[i], r) {
+				existing = i
+				break
+			}
+		}
+
+		if existing < 0 {
+			deduped = append(deduped, r)
+			existing = len(deduped) - 1
+		} else if r.Seeders > deduped[existing].Seeders {
+			deduped[existing] = r
+		}
+
+		for _, key := range keys {
+			if _, ok := byKey[key]; !ok {
+				byKey[key] = existing
+			}
+		}
+	}
+
+	return deduped
+}
+
+// sameTorrent reports whether two listings matching a dedupe key are the same torrent:
+// they come from different providers and don't have different info hashes
+func sameTorrent(a, b *models.SearchResult) bool {
+	if a.Provider == b.Provider {
+		return false
+	}
+	return a.InfoHash == "" || b.InfoHash == "" || strings.EqualFold(a.InfoHash, b.InfoHash)
+}
+
+// dedupeKeys returns the keys identifying a result's torrent across providers
+func dedupeKeys(r *models.SearchResult) []string {
+	var keys []string
+	if r.InfoHash != "" {
+		keys = append(keys, "hash:"+strings.ToLower(r.InfoHash))
+	}
+
+	title := normalizeForKey(r.Title)
+	size := normalizeForKey(r.Size)
+	if title != "" && size != "" {
+		keys = append(keys, strings.Join([]string{"book", title, normalizeForKey(r.Author), size, string(r.MediaType)}, "|"))
+	}
+	return keys
+}
+
+// normalizeForKey lowercases s and collapses whitespace
+func normalizeForKey(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
@@ -0,0 +1,101 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrAuthFailed is matched by errors of providers that rejected the configured credentials
+	ErrAuthFailed = errors.New("authentication failed")
+	// ErrRateLimited is matched by errors of requests the provider, or its rate limit, turned away
+	ErrRateLimited = errors.New("rate limited")
+	// ErrNotFound is matched by errors of requests for torrents the provider doesn't have
+	ErrNotFound = errors.New("not found")
+)
+
+// maxErrorBody is how much of an error response's body is kept in the error
+const maxErrorBody = 512
+
+// Error is a failed provider request. errors.Is matches it against its Kind, so callers can
+// tell credential, rate limit and missing torrent failures apart.
+type Error struct {
+	Provider string
+	// ErrAuthFailed, ErrRateLimited, ErrNotFound, or nil for other failures
+	Kind error
+	// HTTP status of the provider's response; zero if it didn't answer
+	StatusCode int
+	// How long to wait before retrying a rate-limited request, if known
+	RetryAfter time.Duration
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.Kind == nil {
+		return e.Message
+	}
+	return e.Kind.Error() + ": " + e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// RetryAfter returns how long to wait before retrying a rate-limited request, or zero if err
+// doesn't say
+func RetryAfter(err error) time.Duration {
+	var providerErr *Error
+	if errors.As(err, &providerErr) {
+		return providerErr.RetryAfter
+	}
+	return 0
+}
+
+// statusError turns an unsuccessful response into an Error, keeping the start of its body
+func statusError(provider string, resp *http.Response) *Error {
+	e := &Error{
+		Provider:   provider,
+		StatusCode: resp.StatusCode,
+		Message:    fmt.Sprintf("API returned status %d", resp.StatusCode),
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		e.Kind = ErrAuthFailed
+	case http.StatusTooManyRequests:
+		e.Kind = ErrRateLimited
+		e.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	case http.StatusNotFound:
+		e.Kind = ErrNotFound
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if err == nil && len(strings.TrimSpace(string(body))) > 0 {
+		e.Message += ": " + strings.TrimSpace(string(body))
+	}
+	return e
+}
+
+// parseRetryAfter reads a Retry-After header, given in seconds or as an HTTP date. It returns
+// zero for a missing or invalid header.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
@@ -3,48 +3,113 @@ package providers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/nathanael/organizr/internal/models"
 )
 
+// MyAnonamouseName is the name of the MyAnonamouse provider
+const MyAnonamouseName = "MyAnonamouse"
+
+// DefaultMAMRequestsPerMinute is how many requests a minute MAM gets unless configured
+// otherwise; mamBurst of them can go out at once
+const (
+	DefaultMAMRequestsPerMinute = 60
+	mamBurst                    = 10
+)
+
+// MyAnonamouseConfig configures the MyAnonamouse provider
+type MyAnonamouseConfig struct {
+	BaseURL string
+	// mam_id session cookie; MAM rotates it, see OnSecretRotated
+	Secret string
+	// Dynamic seedbox IP registration endpoint
+	SeedboxURL string
+	// Most requests a minute; zero only backs off when MAM answers 429 Too Many Requests
+	RequestsPerMinute int
+}
+
 type MyAnonamouseProvider struct {
-	baseUrl string
-	secret  string
-	client  *http.Client
+	baseUrl    string
+	seedboxURL string
+	client     *http.Client
+	limiter    *rateLimiter
+
+	secretMu        sync.Mutex
+	secret          string
+	onSecretRotated func(secret string)
 }
 
-func NewMyAnonamouseProvider(baseUrl, secret string) *MyAnonamouseProvider {
+func NewMyAnonamouseProvider(cfg MyAnonamouseConfig) *MyAnonamouseProvider {
 	return &MyAnonamouseProvider{
-		baseUrl: baseUrl,
-		secret:  secret,
-		client:  &http.Client{Timeout: 30 * time.Second},
+		baseUrl:    cfg.BaseURL,
+		seedboxURL: cfg.SeedboxURL,
+		secret:     cfg.Secret,
+		client:     &http.Client{Timeout: 30 * time.Second},
+		limiter:    newRateLimiter(MyAnonamouseName, cfg.RequestsPerMinute, mamBurst),
 	}
 }
 
 func (p *MyAnonamouseProvider) Name() string {
-	return "MyAnonamouse"
+	return MyAnonamouseName
 }
 
-func (p *MyAnonamouseProvider) Search(ctx context.Context, query string) ([]*models.SearchResult, error) {
+func (p *MyAnonamouseProvider) Capabilities() Capabilities {
+	return Capabilities{
+		MediaTypes:   []models.MediaType{models.MediaTypeAudiobook, models.MediaTypeEbook},
+		DownloadByID: true,
+		Filters:      true,
+	}
+}
+
+// defaultSearchIn are the fields searched unless the query picks its own
+var defaultSearchIn = []SearchIn{SearchInTitle, SearchInAuthor, SearchInSeries, SearchInNarrator}
+
+// Search queries MAM for torrents matching the query's text, title and author, which MAM
+// matches against titles, authors, series and narrators alike unless the query picks other
+// fields. An empty media type searches both audiobooks and ebooks.
+func (p *MyAnonamouseProvider) Search(ctx context.Context, q Query) (*Page, error) {
+	searchType := q.SearchType
+	if searchType == "" {
+		searchType = SearchTypeAll
+	}
+	searchIn := q.SearchIn
+	if len(searchIn) == 0 {
+		searchIn = defaultSearchIn
+	}
+
+	// MAM has no file type filter, so the file type is searched for in file types
+	text := q.FullText()
+	if q.FileType != "" {
+		text = strings.TrimSpace(text + " " + q.FileType)
+		searchIn = append(append([]SearchIn{}, searchIn...), SearchInFileTypes)
+	}
+
 	// Build search parameters
 	params := SearchParams{
 		Description: true,
 		DLLink:      true,
 		ISBN:        false,
-		PerPage:     100,
+		PerPage:     q.PageSize(),
 		Torrents: []TorrentSearchParams{
 			{
-				MainCat:    []MainCategory{CategoryAudiobooks},
-				SearchIn:   []SearchIn{SearchInTitle, SearchInAuthor, SearchInSeries, SearchInNarrator},
-				SearchType: SearchTypeAll,
-				Text:       query,
+				MainCat:     categoriesForMediaType(q.MediaType),
+				Categories:  q.Categories,
+				Languages:   q.Languages,
+				SearchIn:    searchIn,
+				SearchType:  searchType,
+				SortType:    q.Sort,
+				Text:        text,
+				StartNumber: q.Offset,
 			},
 		},
 	}
@@ -59,10 +124,9 @@ func (p *MyAnonamouseProvider) Search(ctx context.Context, query string) ([]*mod
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Cookie", fmt.Sprintf("mam_id=%s", p.secret))
 
 	// Make request
-	resp, err := p.client.Do(req)
+	resp, err := p.send(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -74,7 +138,7 @@ func (p *MyAnonamouseProvider) Search(ctx context.Context, query string) ([]*mod
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		return nil, statusError(p.Name(), resp)
 	}
 
 	// Parse response
@@ -89,13 +153,15 @@ func (p *MyAnonamouseProvider) Search(ctx context.Context, query string) ([]*mod
 		result := &models.SearchResult{
 			ID:             fmt.Sprintf("%d", torrent.ID),
 			Title:          torrent.Title,
-			Author:         formatAuthorInfo(torrent.AuthorInfo),
+			Author:         formatNameInfo(torrent.AuthorInfo),
+			Narrator:       formatNameInfo(torrent.NarratorInfo),
 			Series:         parseSeriesInfo(torrent.SeriesInfo),
 			Category:       torrent.CategoryName,
 			FileType:       torrent.FileType,
 			Language:       torrent.LanguageCode,
 			Tags:           parseTags(torrent.Tags),
 			Added:          torrent.Added,
+			Uploader:       torrent.OwnerName,
 			Size:           torrent.Size,
 			Seeders:        torrent.Seeders,
 			Leechers:       torrent.Leechers,
@@ -105,6 +171,7 @@ func (p *MyAnonamouseProvider) Search(ctx context.Context, query string) ([]*mod
 			FreeleechVIP:   torrent.FLVIP == 1,
 			VIP:            torrent.VIP == 1,
 			Provider:       p.Name(),
+			MediaType:      mediaTypeForCategory(MainCategory(torrent.MainCategory)),
 		}
 
 		// Add download link if available
@@ -120,11 +187,15 @@ func (p *MyAnonamouseProvider) Search(ctx context.Context, query string) ([]*mod
 		results = append(results, result)
 	}
 
-	return results, nil
+	return &Page{Results: results, Total: searchResp.Total, TotalFound: searchResp.TotalFound}, nil
 }
 
-func (p *MyAnonamouseProvider) DownloadTorrent(ctx context.Context, torrent_id int) ([]byte, error) {
-	downloadUrl := fmt.Sprintf("%s/tor/download.php?tid=%d", p.baseUrl, torrent_id)
+func (p *MyAnonamouseProvider) DownloadTorrent(ctx context.Context, torrentID string) ([]byte, error) {
+	id, err := strconv.Atoi(torrentID)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q", ErrInvalidTorrentID, torrentID)
+	}
+	downloadUrl := fmt.Sprintf("%s/tor/download.php?tid=%d", p.baseUrl, id)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", downloadUrl, nil)
 
@@ -132,9 +203,8 @@ func (p *MyAnonamouseProvider) DownloadTorrent(ctx context.Context, torrent_id i
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Cookie", fmt.Sprintf("mam_id=%s", p.secret))
 
-	resp, err := p.client.Do(req)
+	resp, err := p.send(req)
 	if err != nil {
 		return nil, fmt.Errorf("connection failed: %w", err)
 	}
@@ -146,12 +216,7 @@ func (p *MyAnonamouseProvider) DownloadTorrent(ctx context.Context, torrent_id i
 		}
 	}()
 	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			// If we can't read the error response, return the original HTTP error
-			return nil, fmt.Errorf("API error: status=%d (failed to read error response: %w)", resp.StatusCode, err)
-		}
-		return nil, fmt.Errorf("API error: status=%d, body=%s", resp.StatusCode, string(body))
+		return nil, statusError(p.Name(), resp)
 	}
 
 	respBody, err := io.ReadAll(resp.Body)
@@ -171,9 +236,8 @@ func (p *MyAnonamouseProvider) TestConnection(ctx context.Context) error {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Cookie", fmt.Sprintf("mam_id=%s", p.secret))
 
-	resp, err := p.client.Do(req)
+	resp, err := p.send(req)
 	if err != nil {
 		return fmt.Errorf("connection failed: %w", err)
 	}
@@ -184,12 +248,12 @@ func (p *MyAnonamouseProvider) TestConnection(ctx context.Context) error {
 		}
 	}()
 
-	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
-		return fmt.Errorf("authentication failed: invalid credentials")
-	}
-
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API returned status %d", resp.StatusCode)
+		statusErr := statusError(p.Name(), resp)
+		if errors.Is(statusErr, ErrAuthFailed) {
+			statusErr.Message = "invalid credentials"
+		}
+		return statusErr
 	}
 
 	return nil
@@ -205,8 +269,11 @@ type SearchParams struct {
 
 type TorrentSearchParams struct {
 	MainCat     []MainCategory `json:"main_cat"`
+	Categories  []int          `json:"cat"`
+	Languages   []int          `json:"browse_lang"`
 	SearchIn    []SearchIn     `json:"srchIn"`
 	SearchType  SearchType     `json:"searchType"`
+	SortType    SortType       `json:"sortType"`
 	Text        string         `json:"text"`
 	StartNumber int            `json:"startNumber"`
 	Hash        string         `json:"hash"`
@@ -225,6 +292,17 @@ const (
 	SearchInTitle       SearchIn = "title"
 )
 
+var validSearchIn = map[SearchIn]bool{
+	SearchInAuthor:      true,
+	SearchInDescription: true,
+	SearchInFilenames:   true,
+	SearchInFileTypes:   true,
+	SearchInNarrator:    true,
+	SearchInSeries:      true,
+	SearchInTags:        true,
+	SearchInTitle:       true,
+}
+
 type SearchType string
 
 const (
@@ -237,6 +315,51 @@ const (
 	SearchTypeNotVIP       SearchType = "nVIP"
 )
 
+var validSearchTypes = map[SearchType]bool{
+	SearchTypeAll:          true,
+	SearchTypeActive:       true,
+	SearchTypeInActive:     true,
+	SearchTypeFreeleech:    true,
+	SearchTypeFreeleechVIP: true,
+	SearchTypeVIP:          true,
+	SearchTypeNotVIP:       true,
+}
+
+// SortType orders MAM search results
+type SortType string
+
+const (
+	SortDefault      SortType = "default"
+	SortTitleAsc     SortType = "titleAsc"
+	SortTitleDesc    SortType = "titleDesc"
+	SortSizeAsc      SortType = "sizeAsc"
+	SortSizeDesc     SortType = "sizeDesc"
+	SortSeedersAsc   SortType = "seedersAsc"
+	SortSeedersDesc  SortType = "seedersDesc"
+	SortLeechersAsc  SortType = "leechersAsc"
+	SortLeechersDesc SortType = "leechersDesc"
+	SortSnatchedAsc  SortType = "snatchedAsc"
+	SortSnatchedDesc SortType = "snatchedDesc"
+	SortDateAsc      SortType = "dateAsc"
+	SortDateDesc     SortType = "dateDesc"
+)
+
+var validSortTypes = map[SortType]bool{
+	SortDefault:      true,
+	SortTitleAsc:     true,
+	SortTitleDesc:    true,
+	SortSizeAsc:      true,
+	SortSizeDesc:     true,
+	SortSeedersAsc:   true,
+	SortSeedersDesc:  true,
+	SortLeechersAsc:  true,
+	SortLeechersDesc: true,
+	SortSnatchedAsc:  true,
+	SortSnatchedDesc: true,
+	SortDateAsc:      true,
+	SortDateDesc:     true,
+}
+
 type MainCategory int
 
 const (
@@ -244,6 +367,26 @@ const (
 	CategoryBooks      MainCategory = 14
 )
 
+// categoriesForMediaType returns the MAM main categories to search for a media type
+func categoriesForMediaType(mediaType models.MediaType) []MainCategory {
+	switch mediaType {
+	case models.MediaTypeAudiobook:
+		return []MainCategory{CategoryAudiobooks}
+	case models.MediaTypeEbook:
+		return []MainCategory{CategoryBooks}
+	default:
+		return []MainCategory{CategoryAudiobooks, CategoryBooks}
+	}
+}
+
+// mediaTypeForCategory maps a MAM main category back to a media type
+func mediaTypeForCategory(category MainCategory) models.MediaType {
+	if category == CategoryBooks {
+		return models.MediaTypeEbook
+	}
+	return models.MediaTypeAudiobook
+}
+
 type SearchResponse struct {
 	Data       []TorrentDetails `json:"data"`
 	Total      int              `json:"total"`
@@ -267,6 +410,7 @@ type TorrentDetails struct {
 	Leechers          int     `json:"leechers"`
 	MainCategory      int     `json:"main_cat"`
 	MySnatched        int     `json:"my_snatched"`
+	NarratorInfo      string  `json:"narrator_info"`
 	NumFiles          int     `json:"numfiles"`
 	OwnerID           int     `json:"owner"`
 	OwnerName         string  `json:"owner_name"`
@@ -280,20 +424,37 @@ type TorrentDetails struct {
 	VIP               int     `json:"vip"`
 }
 
-func formatAuthorInfo(authorInfo string) string {
-	if authorInfo == "" {
+// formatNameInfo joins the names in MAM's author_info or narrator_info, a JSON object of
+// ID to name, in ID order
+func formatNameInfo(nameInfo string) string {
+	if nameInfo == "" {
 		return ""
 	}
-	authorMap := make(map[string]string)
-	if err := json.Unmarshal([]byte(authorInfo), &authorMap); err != nil {
+	nameMap := make(map[string]string)
+	if err := json.Unmarshal([]byte(nameInfo), &nameMap); err != nil {
 		return ""
 	}
-	authors := []string{}
-	for _, author := range authorMap {
-		authors = append(authors, author)
+
+	ids := make([]string, 0, len(nameMap))
+	for id := range nameMap {
+		ids = append(ids, id)
 	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, errA := strconv.Atoi(ids[i])
+		b, errB := strconv.Atoi(ids[j])
+		if errA != nil || errB != nil {
+			return ids[i] < ids[j]
+		}
+		return a < b
+	})
 
-	return strings.Join(authors, ", ")
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if name := strings.TrimSpace(nameMap[id]); name != "" {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
 }
 
 func parseSeriesInfo(seriesInfo string) []models.SeriesInfo {
@@ -373,6 +534,12 @@ func formatSearchParamsToURLValues(params SearchParams) string {
 				values.Add("tor[main_cat][]", strconv.Itoa(int(category)))
 			}
 		}
+		for _, category := range torrent.Categories {
+			values.Add("tor[cat][]", strconv.Itoa(category))
+		}
+		for _, language := range torrent.Languages {
+			values.Add("tor[browse_lang][]", strconv.Itoa(language))
+		}
 		if len(torrent.SearchIn) > 0 {
 			for _, searchIn := range torrent.SearchIn {
 				values.Add(fmt.Sprintf("tor[srchIn][%s]", string(searchIn)), "true")
@@ -381,6 +548,9 @@ func formatSearchParamsToURLValues(params SearchParams) string {
 		if torrent.SearchType != "" {
 			values.Set("tor[searchType]", string(torrent.SearchType))
 		}
+		if torrent.SortType != "" {
+			values.Set("tor[sortType]", string(torrent.SortType))
+		}
 		if torrent.Text != "" {
 			values.Set("tor[text]", torrent.Text)
 		}
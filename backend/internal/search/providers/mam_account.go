@@ -0,0 +1,175 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AccountStats is the state of the MAM account the provider is signed in as
+type AccountStats struct {
+	Username string
+	UserID   int
+	Class    string
+	// Uploaded divided by downloaded; +Inf before anything was downloaded
+	Ratio           float64
+	Uploaded        string // Human-readable, e.g. "15.2 GiB"
+	Downloaded      string
+	UploadedBytes   int64
+	DownloadedBytes int64
+	BonusPoints     float64
+	// Torrents downloaded but not yet seeded long enough, and how many are allowed at once
+	Unsatisfied      int
+	UnsatisfiedLimit int
+	// Freeleech wedges available to spend
+	Wedges int
+}
+
+type accountResponse struct {
+	Username        string          `json:"username"`
+	UserID          int             `json:"uid"`
+	Class           string          `json:"classname"`
+	Ratio           json.RawMessage `json:"ratio"`
+	Uploaded        string          `json:"uploaded"`
+	Downloaded      string          `json:"downloaded"`
+	UploadedBytes   json.Number     `json:"uploaded_bytes"`
+	DownloadedBytes json.Number     `json:"downloaded_bytes"`
+	BonusPoints     json.Number     `json:"seedbonus"`
+	Wedges          int             `json:"wedges"`
+	Unsatisfied     struct {
+		Count int `json:"count"`
+		Limit int `json:"limit"`
+	} `json:"unsat"`
+}
+
+// AccountStats fetches the account's ratio, transfer totals, bonus points and unsatisfied
+// torrents from MAM
+func (p *MyAnonamouseProvider) AccountStats(ctx context.Context) (*AccountStats, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/jsonLoad.php?snatch_summary", p.baseUrl), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.send(req)
+	if err != nil {
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			// Log close error as it may indicate network issues
+			fmt.Printf("warning: failed to close response body: %v\n", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(p.Name(), resp)
+	}
+
+	var body accountResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	stats := &AccountStats{
+		Username:         body.Username,
+		UserID:           body.UserID,
+		Class:            body.Class,
+		Uploaded:         body.Uploaded,
+		Downloaded:       body.Downloaded,
+		Unsatisfied:      body.Unsatisfied.Count,
+		UnsatisfiedLimit: body.Unsatisfied.Limit,
+		Wedges:           body.Wedges,
+	}
+	stats.UploadedBytes, _ = body.UploadedBytes.Int64()
+	stats.DownloadedBytes, _ = body.DownloadedBytes.Int64()
+	stats.BonusPoints, _ = body.BonusPoints.Float64()
+	stats.Ratio = parseRatio(body.Ratio, stats.UploadedBytes, stats.DownloadedBytes)
+	return stats, nil
+}
+
+// parseRatio reads MAM's ratio, a number or a string such as "Inf.", falling back to the
+// transfer totals
+func parseRatio(raw json.RawMessage, uploaded, downloaded int64) float64 {
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err == nil {
+		switch v := value.(type) {
+		case float64:
+			return v
+		case string:
+			v = strings.TrimSuffix(strings.TrimSpace(v), ".")
+			if strings.EqualFold(v, "inf") {
+				return math.Inf(1)
+			}
+			if ratio, err := strconv.ParseFloat(strings.ReplaceAll(v, ",", ""), 64); err == nil {
+				return ratio
+			}
+		}
+	}
+
+	if downloaded == 0 {
+		return math.Inf(1)
+	}
+	return float64(uploaded) / float64(downloaded)
+}
+
+// ErrAlreadyFreeleech is matched by wedge purchases for torrents that are already freeleech
+var ErrAlreadyFreeleech = errors.New("torrent is already freeleech")
+
+type bonusBuyResponse struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error"`
+}
+
+// SpendWedge spends a freeleech wedge to make a torrent personal freeleech, so downloading it
+// doesn't count against the account's ratio
+func (p *MyAnonamouseProvider) SpendWedge(ctx context.Context, torrentID string) error {
+	id, err := strconv.Atoi(torrentID)
+	if err != nil {
+		return fmt.Errorf("%w %q", ErrInvalidTorrentID, torrentID)
+	}
+
+	params := url.Values{}
+	params.Set("spendtype", "personalFL")
+	params.Set("torrentid", strconv.Itoa(id))
+	params.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/json/bonusBuy.php/?%s", p.baseUrl, params.Encode()), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := p.send(req)
+	if err != nil {
+		return fmt.Errorf("connection failed: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			// Log close error as it may indicate network issues
+			fmt.Printf("warning: failed to close response body: %v\n", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError(p.Name(), resp)
+	}
+
+	var body bonusBuyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if !body.Success {
+		if strings.Contains(strings.ToLower(body.Error), "already") {
+			return fmt.Errorf("%w: %s", ErrAlreadyFreeleech, body.Error)
+		}
+		return &Error{Provider: p.Name(), StatusCode: resp.StatusCode, Message: "wedge purchase failed: " + body.Error}
+	}
+	return nil
+}
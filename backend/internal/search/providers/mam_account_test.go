@@ -0,0 +1,95 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMyAnonamouseProvider_AccountStats(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantRatio float64
+	}{
+		{"Numeric ratio", `{"username":"reader","uid":12345,"classname":"Power User","ratio":3.42,"uploaded":"152.3 GiB","downloaded":"44.5 GiB","uploaded_bytes":163532701286,"downloaded_bytes":47781511987,"seedbonus":25310.5,"wedges":3,"unsat":{"count":12,"limit":50}}`, 3.42},
+		{"String ratio", `{"ratio":"1,024.50","uploaded_bytes":2048,"downloaded_bytes":2}`, 1024.5},
+		{"Infinite ratio", `{"ratio":"Inf.","uploaded_bytes":2048,"downloaded_bytes":0}`, math.Inf(1)},
+		{"Missing ratio", `{"uploaded_bytes":3000,"downloaded_bytes":1000}`, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/jsonLoad.php" {
+					t.Errorf("path = %s, want /jsonLoad.php", r.URL.Path)
+				}
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			p := NewMyAnonamouseProvider(MyAnonamouseConfig{BaseURL: server.URL, Secret: "secret"})
+			stats, err := p.AccountStats(context.Background())
+			if err != nil {
+				t.Fatalf("AccountStats() error = %v", err)
+			}
+			if stats.Ratio != tt.wantRatio {
+				t.Errorf("Ratio = %v, want %v", stats.Ratio, tt.wantRatio)
+			}
+		})
+	}
+
+	t.Run("Fields", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(tests[0].body))
+		}))
+		defer server.Close()
+
+		p := NewMyAnonamouseProvider(MyAnonamouseConfig{BaseURL: server.URL, Secret: "secret"})
+		stats, err := p.AccountStats(context.Background())
+		if err != nil {
+			t.Fatalf("AccountStats() error = %v", err)
+		}
+		want := AccountStats{
+			Username: "reader", UserID: 12345, Class: "Power User", Ratio: 3.42,
+			Uploaded: "152.3 GiB", Downloaded: "44.5 GiB",
+			UploadedBytes: 163532701286, DownloadedBytes: 47781511987,
+			BonusPoints: 25310.5, Unsatisfied: 12, UnsatisfiedLimit: 50, Wedges: 3,
+		}
+		if *stats != want {
+			t.Errorf("AccountStats() = %+v, want %+v", *stats, want)
+		}
+	})
+}
+
+func TestMyAnonamouseProvider_SpendWedge(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		wantErr       bool
+		wantFreeleech bool // Failed with ErrAlreadyFreeleech
+	}{
+		{"Spent", `{"success":true,"type":"personal FL"}`, false, false},
+		{"Already freeleech", `{"success":false,"error":"This torrent is already personal freeleech"}`, true, true},
+		{"No wedges", `{"success":false,"error":"You do not have any FL wedges"}`, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/json/bonusBuy.php/" || r.URL.Query().Get("spendtype") != "personalFL" || r.URL.Query().Get("torrentid") != "123" {
+					t.Errorf("request = %s, want a personalFL purchase for torrent 123", r.URL)
+				}
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			p := NewMyAnonamouseProvider(MyAnonamouseConfig{BaseURL: server.URL, Secret: "secret"})
+			err := p.SpendWedge(context.Background(), "123")
+			if (err != nil) != tt.wantErr || errors.Is(err, ErrAlreadyFreeleech) != tt.wantFreeleech {
+				t.Errorf("SpendWedge() error = %v, wantErr %v, want ErrAlreadyFreeleech %v", err, tt.wantErr, tt.wantFreeleech)
+			}
+		})
+	}
+}
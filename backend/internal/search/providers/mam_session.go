@@ -0,0 +1,137 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// mamSessionCookie is the cookie MAM authenticates requests with
+const mamSessionCookie = "mam_id"
+
+// DefaultMAMSeedboxURL is MAM's dynamic seedbox IP registration endpoint
+const DefaultMAMSeedboxURL = "https://t.myanonamouse.net/json/dynamicSeedbox.php"
+
+// OnSecretRotated registers fn to be called with the new mam_id whenever MAM rotates the
+// session cookie, so it can be saved for the next start
+func (p *MyAnonamouseProvider) OnSecretRotated(fn func(secret string)) {
+	p.secretMu.Lock()
+	defer p.secretMu.Unlock()
+	p.onSecretRotated = fn
+}
+
+// Secret returns the mam_id the provider currently sends
+func (p *MyAnonamouseProvider) Secret() string {
+	p.secretMu.Lock()
+	defer p.secretMu.Unlock()
+	return p.secret
+}
+
+// send sends req with the current session cookie and keeps the cookie MAM rotates it to
+func (p *MyAnonamouseProvider) send(req *http.Request) (*http.Response, error) {
+	req.AddCookie(&http.Cookie{Name: mamSessionCookie, Value: p.Secret()})
+
+	resp, err := p.limiter.do(p.client, req)
+	if err != nil {
+		return nil, err
+	}
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == mamSessionCookie && cookie.Value != "" {
+			p.rotateSecret(cookie.Value)
+		}
+	}
+	return resp, nil
+}
+
+// rotateSecret switches to a new mam_id and reports it
+func (p *MyAnonamouseProvider) rotateSecret(secret string) {
+	p.secretMu.Lock()
+	if secret == p.secret {
+		p.secretMu.Unlock()
+		return
+	}
+	p.secret = secret
+	onSecretRotated := p.onSecretRotated
+	p.secretMu.Unlock()
+
+	if onSecretRotated != nil {
+		onSecretRotated(secret)
+	}
+}
+
+// SeedboxRegistration is MAM's answer to a dynamic seedbox IP registration
+type SeedboxRegistration struct {
+	// False when the IP was already registered
+	Changed bool
+	Message string
+	IP      string
+	ASN     int
+	AS      string
+}
+
+type seedboxResponse struct {
+	Success bool   `json:"Success"`
+	Message string `json:"msg"`
+	IP      string `json:"ip"`
+	ASN     int    `json:"ASN"`
+	AS      string `json:"AS"`
+}
+
+// RegisterSeedboxIP registers the IP the request comes from as the session's seedbox IP,
+// for sessions created as dynamic seedbox sessions. MAM allows one change an hour; more
+// frequent changes fail with ErrRateLimited.
+func (p *MyAnonamouseProvider) RegisterSeedboxIP(ctx context.Context) (*SeedboxRegistration, error) {
+	seedboxURL := p.seedboxURL
+	if seedboxURL == "" {
+		seedboxURL = DefaultMAMSeedboxURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", seedboxURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := p.send(req)
+	if err != nil {
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			// Log close error as it may indicate network issues
+			fmt.Printf("warning: failed to close response body: %v\n", err)
+		}
+	}()
+
+	var body seedboxResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, statusError(p.Name(), resp)
+		}
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if !body.Success {
+		e := &Error{Provider: p.Name(), StatusCode: resp.StatusCode, Message: body.Message}
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests || strings.Contains(strings.ToLower(body.Message), "too recent"):
+			e.Kind = ErrRateLimited
+			e.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+			e.Kind = ErrAuthFailed
+		default:
+			e.Message = "seedbox registration failed: " + body.Message
+		}
+		return nil, e
+	}
+
+	return &SeedboxRegistration{
+		Changed: !strings.EqualFold(body.Message, "No change"),
+		Message: body.Message,
+		IP:      body.IP,
+		ASN:     body.ASN,
+		AS:      body.AS,
+	}, nil
+}
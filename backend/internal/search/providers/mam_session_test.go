@@ -0,0 +1,86 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMyAnonamouseProvider_RotatesSecret(t *testing.T) {
+	var sent []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("mam_id")
+		if err != nil {
+			t.Errorf("request without mam_id cookie: %v", err)
+			return
+		}
+		sent = append(sent, cookie.Value)
+		http.SetCookie(w, &http.Cookie{Name: "mam_id", Value: "rotated"})
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	p := NewMyAnonamouseProvider(MyAnonamouseConfig{BaseURL: server.URL, Secret: "original"})
+	var saved []string
+	p.OnSecretRotated(func(secret string) { saved = append(saved, secret) })
+	ctx := context.Background()
+
+	for range 2 {
+		if err := p.TestConnection(ctx); err != nil {
+			t.Fatalf("TestConnection() error = %v", err)
+		}
+	}
+
+	// The rotated cookie is sent from the next request on, and reported once
+	if len(sent) != 2 || sent[0] != "original" || sent[1] != "rotated" {
+		t.Errorf("cookies sent = %v, want original then rotated", sent)
+	}
+	if len(saved) != 1 || saved[0] != "rotated" || p.Secret() != "rotated" {
+		t.Errorf("saved = %v, Secret() = %q, want the rotated secret reported once", saved, p.Secret())
+	}
+}
+
+func TestMyAnonamouseProvider_RegisterSeedboxIP(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantChanged bool
+		wantErr     error
+	}{
+		{"Registered", http.StatusOK, `{"Success":true,"msg":"Completed","ip":"203.0.113.7","ASN":64500,"AS":"Example Hosting"}`, true, nil},
+		{"Unchanged", http.StatusOK, `{"Success":true,"msg":"No change","ip":"203.0.113.7","ASN":64500,"AS":"Example Hosting"}`, false, nil},
+		{"Too recent", http.StatusOK, `{"Success":false,"msg":"Last change too recent"}`, false, ErrRateLimited},
+		{"No session", http.StatusForbidden, `{"Success":false,"msg":"No Session Cookie"}`, false, ErrAuthFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/json/dynamicSeedbox.php" {
+					http.NotFound(w, r)
+					return
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			p := NewMyAnonamouseProvider(MyAnonamouseConfig{BaseURL: server.URL, Secret: "secret", SeedboxURL: server.URL + "/json/dynamicSeedbox.php"})
+			got, err := p.RegisterSeedboxIP(context.Background())
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("RegisterSeedboxIP() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RegisterSeedboxIP() error = %v", err)
+			}
+			if got.Changed != tt.wantChanged || got.IP != "203.0.113.7" || got.ASN != 64500 {
+				t.Errorf("RegisterSeedboxIP() = %+v", got)
+			}
+		})
+	}
+}
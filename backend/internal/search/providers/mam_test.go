@@ -1,6 +1,11 @@
 package providers
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/nathanael/organizr/internal/models"
@@ -59,3 +64,76 @@ func Test_parseSeriesInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestMyAnonamouseProvider_Search_FiltersAndPaging(t *testing.T) {
+	var got url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query()
+		_, _ = w.Write([]byte(`{"data":[{"id":7,"title":"Dune","main_cat":13,"narrator_info":"{\"5\":\"Scott Brick\"}"}],"total":1000,"total_found":2345}`))
+	}))
+	defer server.Close()
+
+	p := NewMyAnonamouseProvider(MyAnonamouseConfig{BaseURL: server.URL, Secret: "secret"})
+	page, err := p.Search(context.Background(), Query{
+		Text:       "herbert",
+		MediaType:  models.MediaTypeAudiobook,
+		SearchType: SearchTypeFreeleech,
+		SearchIn:   []SearchIn{SearchInAuthor},
+		Languages:  []int{1},
+		Categories: []int{39, 49},
+		FileType:   "m4b",
+		Sort:       SortSeedersDesc,
+		Offset:     50,
+		Limit:      25,
+	})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+
+	if page.Total != 1000 || page.TotalFound != 2345 || len(page.Results) != 1 {
+		t.Fatalf("page = %d results, totals %d/%d", len(page.Results), page.Total, page.TotalFound)
+	}
+	if page.Results[0].Narrator != "Scott Brick" {
+		t.Errorf("Narrator = %q, want the parsed narrator_info", page.Results[0].Narrator)
+	}
+
+	want := map[string][]string{
+		"tor[searchType]":        {"fl"},
+		"tor[srchIn][author]":    {"true"},
+		"tor[srchIn][fileTypes]": {"true"},
+		"tor[browse_lang][]":     {"1"},
+		"tor[cat][]":             {"39", "49"},
+		"tor[sortType]":          {"seedersDesc"},
+		"tor[text]":              {"herbert m4b"},
+		"tor[startNumber]":       {"50"},
+		"perpage":                {"25"},
+	}
+	for key, values := range want {
+		if fmt.Sprint(got[key]) != fmt.Sprint(values) {
+			t.Errorf("%s = %v, want %v", key, got[key], values)
+		}
+	}
+	if got.Has("tor[srchIn][title]") {
+		t.Error("searched titles, want only the chosen fields")
+	}
+}
+
+func Test_formatNameInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		nameInfo string
+		want     string
+	}{
+		{name: "Single narrator", nameInfo: `{"1234":"Michael Kramer"}`, want: "Michael Kramer"},
+		{name: "Several in ID order", nameInfo: `{"20":"Kate Reading","3":"Michael Kramer"}`, want: "Michael Kramer, Kate Reading"},
+		{name: "Empty", nameInfo: "", want: ""},
+		{name: "Invalid", nameInfo: "not json", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatNameInfo(tt.nameInfo); got != tt.want {
+				t.Errorf("formatNameInfo() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nathanael/organizr/internal/models"
+)
+
+// ErrInvalidTorrentID is returned by DownloadTorrent for an ID the provider can't have issued
+var ErrInvalidTorrentID = errors.New("invalid torrent ID")
+
+// MaxSearchLimit is the most results a provider returns for one page of a search
+const MaxSearchLimit = 100
+
+// Provider is a torrent index that can be searched for books
+type Provider interface {
+	// Name identifies the provider in search results and download requests
+	Name() string
+	Capabilities() Capabilities
+	Search(ctx context.Context, q Query) (*Page, error)
+	// DownloadTorrent fetches the .torrent file of a search result by its ID. Only providers
+	// with the DownloadByID capability support it.
+	DownloadTorrent(ctx context.Context, torrentID string) ([]byte, error)
+	TestConnection(ctx context.Context) error
+}
+
+// Query is a search for books. Providers that can search by title and author use them;
+// others search their text along with the rest.
+type Query struct {
+	Text   string
+	Title  string
+	Author string
+	// Restricts the search unless empty
+	MediaType models.MediaType
+
+	// Filters, applied by providers with the Filters capability. Empty values don't filter.
+	SearchType SearchType
+	SearchIn   []SearchIn // Fields the text is matched against
+	Languages  []int      // MAM language IDs
+	Categories []int      // MAM subcategory IDs
+	FileType   string     // e.g. m4b, epub
+	// Result order, for providers with the Filters capability; others return their own order
+	Sort SortType
+
+	// Paging. A zero Limit returns MaxSearchLimit results.
+	Offset int
+	Limit  int
+}
+
+// Page is one page of a provider's results for a query
+type Page struct {
+	Results []*models.SearchResult
+	// Matches the provider can page through, and all matches, which can be more for
+	// providers that cap how far a search pages
+	Total      int
+	TotalFound int
+}
+
+// FullText returns the query's text, title and author as one free-text query
+func (q Query) FullText() string {
+	return strings.Join(strings.Fields(strings.Join([]string{q.Text, q.Title, q.Author}, " ")), " ")
+}
+
+// IsEmpty reports whether the query has nothing to search for
+func (q Query) IsEmpty() bool {
+	return q.FullText() == ""
+}
+
+// HasFilters reports whether the query filters beyond its media type
+func (q Query) HasFilters() bool {
+	return q.SearchType != "" || len(q.SearchIn) > 0 || len(q.Languages) > 0 || len(q.Categories) > 0 || q.FileType != ""
+}
+
+// PageSize returns the number of results to request
+func (q Query) PageSize() int {
+	if q.Limit <= 0 || q.Limit > MaxSearchLimit {
+		return MaxSearchLimit
+	}
+	return q.Limit
+}
+
+// Validate checks the query's filters and paging
+func (q Query) Validate() error {
+	if q.SearchType != "" && !validSearchTypes[q.SearchType] {
+		return fmt.Errorf("unknown search type %q", q.SearchType)
+	}
+	for _, field := range q.SearchIn {
+		if !validSearchIn[field] {
+			return fmt.Errorf("unknown search field %q", field)
+		}
+	}
+	if q.Sort != "" && !validSortTypes[q.Sort] {
+		return fmt.Errorf("unknown sort order %q", q.Sort)
+	}
+	if q.Offset < 0 {
+		return fmt.Errorf("offset cannot be negative")
+	}
+	if q.Limit < 0 || q.Limit > MaxSearchLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxSearchLimit)
+	}
+	return nil
+}
+
+// Capabilities describes what a provider supports
+type Capabilities struct {
+	// Media types the provider can search
+	MediaTypes []models.MediaType
+	// Whether DownloadTorrent can fetch .torrent files by result ID. Results of other
+	// providers are added to qBittorrent through their torrent URL or magnet link.
+	DownloadByID bool
+	// Whether Search applies the query's filters and sort order
+	Filters bool
+}
+
+// Supports reports whether the provider can search mediaType; an empty mediaType is
+// supported by every provider
+func (c Capabilities) Supports(mediaType models.MediaType) bool {
+	if mediaType == "" {
+		return true
+	}
+	for _, mt := range c.MediaTypes {
+		if mt == mediaType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// maxRateLimitRetries is how often a request answered with 429 Too Many Requests is retried
+	maxRateLimitRetries = 3
+	// initialBackoff is the first wait after a 429 without a Retry-After; it doubles per retry
+	initialBackoff = time.Second
+	// maxRateLimitWait is the longest a request waits for its turn; requests that would wait
+	// longer fail with ErrRateLimited instead
+	maxRateLimitWait = 2 * time.Minute
+)
+
+// rateLimiter is a token bucket shared by a provider's requests. A 429 response holds every
+// request of the provider until its Retry-After has passed.
+type rateLimiter struct {
+	provider string
+	rate     float64 // Tokens added per second; zero leaves requests unlimited
+	burst    float64
+
+	mu           sync.Mutex
+	tokens       float64
+	last         time.Time
+	blockedUntil time.Time
+}
+
+// newRateLimiter allows requestsPerMinute requests a minute, with bursts of up to burst
+// requests. Zero requestsPerMinute only honors the provider's 429 responses.
+func newRateLimiter(provider string, requestsPerMinute, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		provider: provider,
+		rate:     float64(requestsPerMinute) / 60,
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		last:     time.Now(),
+	}
+}
+
+// reserve takes a token and returns how long the request must wait before using it
+func (l *rateLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var wait time.Duration
+	if l.rate > 0 {
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+		l.tokens--
+		if l.tokens < 0 {
+			wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+		}
+	}
+	if blocked := l.blockedUntil.Sub(now); blocked > wait {
+		wait = blocked
+	}
+	return wait
+}
+
+// cancel returns a token taken by reserve for a request that wasn't sent
+func (l *rateLimiter) cancel() {
+	if l.rate == 0 {
+		return
+	}
+	l.mu.Lock()
+	l.tokens = min(l.burst, l.tokens+1)
+	l.mu.Unlock()
+}
+
+// pause holds requests for d
+func (l *rateLimiter) pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.blockedUntil) {
+		l.blockedUntil = until
+	}
+}
+
+// canWait reports whether a request may wait delay for its turn
+func (l *rateLimiter) canWait(ctx context.Context, delay time.Duration) bool {
+	if delay > maxRateLimitWait {
+		return false
+	}
+	deadline, hasDeadline := ctx.Deadline()
+	return !hasDeadline || time.Now().Add(delay).Before(deadline)
+}
+
+// wait blocks until the next request may be sent. It fails with ErrRateLimited rather than
+// wait past maxRateLimitWait or ctx's deadline.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	now := time.Now()
+	delay := l.reserve(now)
+	if delay <= 0 {
+		return nil
+	}
+
+	if !l.canWait(ctx, delay) {
+		l.cancel()
+		return &Error{
+			Provider:   l.provider,
+			Kind:       ErrRateLimited,
+			RetryAfter: delay,
+			Message:    fmt.Sprintf("request limit reached, retry in %s", delay.Round(time.Second)),
+		}
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.cancel()
+		return ctx.Err()
+	}
+}
+
+// do sends req once the rate limit allows it. Requests answered with 429 Too Many Requests
+// are retried after the response's Retry-After, or with exponential backoff without one, and
+// hold the provider's other requests meanwhile. The last 429, or one whose wait is too long
+// for the request, is returned for the caller to report.
+func (l *rateLimiter) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	backoff := initialBackoff
+	for attempt := 0; ; attempt++ {
+		if err := l.wait(req.Context()); err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(req.Clone(req.Context()))
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusTooManyRequests {
+			return resp, nil
+		}
+
+		delay := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if delay <= 0 {
+			delay = backoff
+		}
+		l.pause(delay)
+		if attempt == maxRateLimitRetries || !l.canWait(req.Context(), delay) {
+			return resp, nil
+		}
+
+		if err := resp.Body.Close(); err != nil {
+			// Log close error as it may indicate network issues
+			fmt.Printf("warning: failed to close response body: %v\n", err)
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,145 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Reserve(t *testing.T) {
+	l := newRateLimiter("MyAnonamouse", 60, 2)
+	now := l.last
+
+	// The burst goes out at once, then requests are spaced at the rate
+	if d := l.reserve(now); d != 0 {
+		t.Errorf("1st reserve() = %s, want 0", d)
+	}
+	if d := l.reserve(now); d != 0 {
+		t.Errorf("2nd reserve() = %s, want 0", d)
+	}
+	if d := l.reserve(now); d != time.Second {
+		t.Errorf("3rd reserve() = %s, want 1s at 60 requests a minute", d)
+	}
+
+	// Tokens refill over time
+	if d := l.reserve(now.Add(3 * time.Second)); d != 0 {
+		t.Errorf("reserve() after refill = %s, want 0", d)
+	}
+
+	unlimited := newRateLimiter("Prowlarr", 0, 1)
+	for range 10 {
+		if d := unlimited.reserve(time.Now()); d != 0 {
+			t.Fatalf("unlimited reserve() = %s, want 0", d)
+		}
+	}
+}
+
+func TestRateLimiter_WaitFailsPastDeadline(t *testing.T) {
+	l := newRateLimiter("MyAnonamouse", 1, 1)
+	if err := l.wait(context.Background()); err != nil {
+		t.Fatalf("wait() error = %v", err)
+	}
+
+	// The next token is a minute away, past the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := l.wait(ctx)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("wait() error = %v, want ErrRateLimited", err)
+	}
+	if d := RetryAfter(err); d < 59*time.Second || d > time.Minute {
+		t.Errorf("RetryAfter() = %s, want about a minute", d)
+	}
+
+	// The token of the request that wasn't sent is given back
+	if l.tokens < -0.01 || l.tokens > 0.01 {
+		t.Errorf("tokens = %f, want the failed wait's token returned", l.tokens)
+	}
+}
+
+func TestMyAnonamouseProvider_RetriesTooManyRequests(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte("torrent-data"))
+	}))
+	defer server.Close()
+
+	p := NewMyAnonamouseProvider(MyAnonamouseConfig{BaseURL: server.URL, Secret: "secret"})
+	start := time.Now()
+	data, err := p.DownloadTorrent(context.Background(), "42")
+	if err != nil || string(data) != "torrent-data" {
+		t.Fatalf("DownloadTorrent() = %q, %v, want the torrent after a retry", data, err)
+	}
+	if requests.Load() != 2 || time.Since(start) < time.Second {
+		t.Errorf("%d requests in %s, want a retry after Retry-After", requests.Load(), time.Since(start))
+	}
+}
+
+func TestMyAnonamouseProvider_StructuredErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		want       error
+	}{
+		{"Unauthorized", http.StatusUnauthorized, "", ErrAuthFailed},
+		{"Forbidden", http.StatusForbidden, "", ErrAuthFailed},
+		{"Not found", http.StatusNotFound, "", ErrNotFound},
+		{"Rate limited", http.StatusTooManyRequests, "600", ErrRateLimited},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			p := NewMyAnonamouseProvider(MyAnonamouseConfig{BaseURL: server.URL, Secret: "secret"})
+			_, err := p.DownloadTorrent(context.Background(), "42")
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("DownloadTorrent() error = %v, want %v", err, tt.want)
+			}
+
+			var providerErr *Error
+			if !errors.As(err, &providerErr) || providerErr.StatusCode != tt.status || providerErr.Provider != MyAnonamouseName {
+				t.Errorf("error = %#v, want a provider error with status %d", err, tt.status)
+			}
+			// A Retry-After beyond the longest wait isn't retried, and is passed on
+			if tt.retryAfter != "" && RetryAfter(err) != 10*time.Minute {
+				t.Errorf("RetryAfter() = %s, want 10m", RetryAfter(err))
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"-5", 0},
+		{"Mon, 01 Jan 2024 12:00:30 GMT", 30 * time.Second},
+		{"Mon, 01 Jan 2024 11:00:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,530 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nathanael/organizr/internal/models"
+)
+
+// Newznab categories searched for each media type unless an indexer overrides them
+const (
+	TorznabCategoryAudiobook = 3030 // Audio/Audiobook
+	TorznabCategoryEbook     = 7020 // Books/EBook
+)
+
+// ErrNoTorrentFile is returned when a result has no .torrent file to download; it can only
+// be added through its magnet link
+var ErrNoTorrentFile = errors.New("result has no torrent file")
+
+// TorznabIndexer configures a Torznab indexer, such as one exposed by Prowlarr or Jackett
+type TorznabIndexer struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"` // Torznab API endpoint, e.g. http://prowlarr:9696/1/api
+	APIKey string `json:"api_key"`
+	// Categories searched for each media type; omitted uses the standard category, an
+	// empty list stops the indexer from being searched for that media type
+	AudiobookCategories []int `json:"audiobook_categories,omitempty"`
+	EbookCategories     []int `json:"ebook_categories,omitempty"`
+	// Omitted means enabled
+	Enabled *bool `json:"enabled,omitempty"`
+	// Most requests a minute sent to the indexer; omitted or zero leaves them unlimited
+	RequestsPerMinute int `json:"requests_per_minute,omitempty"`
+}
+
+// IsEnabled reports whether the indexer should be searched
+func (i TorznabIndexer) IsEnabled() bool {
+	return i.Enabled == nil || *i.Enabled
+}
+
+// categories returns the categories to search for mediaType; empty searches all media types
+func (i TorznabIndexer) categories(mediaType models.MediaType) []int {
+	audiobook := i.AudiobookCategories
+	if audiobook == nil {
+		audiobook = []int{TorznabCategoryAudiobook}
+	}
+	ebook := i.EbookCategories
+	if ebook == nil {
+		ebook = []int{TorznabCategoryEbook}
+	}
+
+	switch mediaType {
+	case models.MediaTypeAudiobook:
+		return audiobook
+	case models.MediaTypeEbook:
+		return ebook
+	default:
+		return append(append([]int{}, audiobook...), ebook...)
+	}
+}
+
+// ParseTorznabIndexers parses the torznab.indexers config value, a JSON array of indexers.
+// An empty value configures none.
+func ParseTorznabIndexers(value string) ([]TorznabIndexer, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	var indexers []TorznabIndexer
+	if err := json.Unmarshal([]byte(value), &indexers); err != nil {
+		return nil, fmt.Errorf("torznab indexers must be a JSON array of {\"name\", \"url\", \"api_key\"} objects: %w", err)
+	}
+
+	names := make(map[string]bool)
+	for i, indexer := range indexers {
+		name := strings.TrimSpace(indexer.Name)
+		if name == "" {
+			return nil, fmt.Errorf("torznab indexer %d: name is required", i)
+		}
+		if name == MyAnonamouseName || names[name] {
+			return nil, fmt.Errorf("torznab indexer %d: name %q is already used", i, name)
+		}
+		names[name] = true
+
+		u, err := url.Parse(indexer.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("torznab indexer %d: url must be an http(s) URL", i)
+		}
+		if indexer.RequestsPerMinute < 0 {
+			return nil, fmt.Errorf("torznab indexer %d: requests_per_minute can't be negative", i)
+		}
+	}
+
+	return indexers, nil
+}
+
+// torznabBurst is how many requests can go out at once to a rate-limited indexer
+const torznabBurst = 5
+
+// TorznabProvider searches a Torznab indexer
+type TorznabProvider struct {
+	indexer TorznabIndexer
+	client  *http.Client
+	limiter *rateLimiter
+
+	capsMu sync.Mutex
+	caps   *torznabCaps // Fetched on first search
+}
+
+func NewTorznabProvider(indexer TorznabIndexer) *TorznabProvider {
+	return &TorznabProvider{
+		indexer: indexer,
+		limiter: newRateLimiter(indexer.Name, indexer.RequestsPerMinute, torznabBurst),
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+			// Prowlarr redirects downloads of magnet-only releases to the magnet link
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if req.URL.Scheme == "magnet" {
+					return http.ErrUseLastResponse
+				}
+				if len(via) >= 10 {
+					return errors.New("stopped after 10 redirects")
+				}
+				return nil
+			},
+		},
+	}
+}
+
+func (p *TorznabProvider) Name() string {
+	return p.indexer.Name
+}
+
+func (p *TorznabProvider) Capabilities() Capabilities {
+	var mediaTypes []models.MediaType
+	if len(p.indexer.categories(models.MediaTypeAudiobook)) > 0 {
+		mediaTypes = append(mediaTypes, models.MediaTypeAudiobook)
+	}
+	if len(p.indexer.categories(models.MediaTypeEbook)) > 0 {
+		mediaTypes = append(mediaTypes, models.MediaTypeEbook)
+	}
+	return Capabilities{MediaTypes: mediaTypes, DownloadByID: true}
+}
+
+// Search queries the indexer. Title and author are searched with t=book when the indexer
+// supports them, otherwise they are added to the free-text query of a t=search. Torznab has
+// no equivalent of the query's filters or sort order.
+func (p *TorznabProvider) Search(ctx context.Context, q Query) (*Page, error) {
+	params := url.Values{}
+	params.Set("extended", "1")
+	params.Set("limit", strconv.Itoa(q.PageSize()))
+	if q.Offset > 0 {
+		params.Set("offset", strconv.Itoa(q.Offset))
+	}
+
+	var cats []string
+	for _, cat := range p.indexer.categories(q.MediaType) {
+		cats = append(cats, strconv.Itoa(cat))
+	}
+	if len(cats) > 0 {
+		params.Set("cat", strings.Join(cats, ","))
+	}
+
+	text := q.Text
+	caps, err := p.capabilities(ctx)
+	if err == nil && caps.Searching.BookSearch.Available == "yes" && (q.Title != "" || q.Author != "") {
+		params.Set("t", "book")
+		supported := caps.Searching.BookSearch.params()
+		for _, field := range []struct{ name, value string }{{"title", q.Title}, {"author", q.Author}} {
+			if field.value == "" {
+				continue
+			}
+			if supported[field.name] {
+				params.Set(field.name, field.value)
+			} else {
+				text = strings.TrimSpace(text + " " + field.value)
+			}
+		}
+	} else {
+		params.Set("t", "search")
+		text = q.FullText()
+	}
+	if text != "" {
+		params.Set("q", text)
+	}
+
+	var feed torznabFeed
+	if err := p.get(ctx, params, &feed); err != nil {
+		return nil, err
+	}
+
+	results := make([]*models.SearchResult, 0, len(feed.Channel.Items))
+	for _, item := range feed.Channel.Items {
+		if result := p.toSearchResult(item, q.MediaType); result != nil {
+			results = append(results, result)
+		}
+	}
+
+	// Indexers that don't report a total have no more results than they returned
+	total := q.Offset + len(feed.Channel.Items)
+	if feed.Channel.Response.Total != nil {
+		total = *feed.Channel.Response.Total
+	}
+	return &Page{Results: results, Total: total, TotalFound: total}, nil
+}
+
+// toSearchResult converts a feed item, returning nil for items that can't be downloaded
+func (p *TorznabProvider) toSearchResult(item torznabItem, mediaType models.MediaType) *models.SearchResult {
+	attrs := item.attrs()
+
+	result := &models.SearchResult{
+		Title:       item.Title,
+		Author:      attrs["author"],
+		Provider:    p.Name(),
+		InfoHash:    strings.ToLower(attrs["infohash"]),
+		MagnetLink:  attrs["magneturl"],
+		Description: item.Description,
+		Added:       item.PubDate,
+		Seeders:     atoiOrZero(attrs["seeders"]),
+		// grabs counts snatches, the nearest thing to MAM's completions
+		TimesCompleted: atoiOrZero(attrs["grabs"]),
+		NumFiles:       atoiOrZero(attrs["files"]),
+		Freeleech:      attrs["downloadvolumefactor"] == "0",
+		MediaType:      torznabMediaType(item.categories(), mediaType),
+	}
+
+	// peers includes seeders
+	if peers := atoiOrZero(attrs["peers"]); peers > result.Seeders {
+		result.Leechers = peers - result.Seeders
+	}
+
+	size := item.Size
+	if size == 0 {
+		size = item.Enclosure.Length
+	}
+	if size == 0 {
+		size, _ = strconv.ParseInt(attrs["size"], 10, 64)
+	}
+	if size > 0 {
+		result.Size = formatSize(size)
+	}
+
+	// The link is a .torrent download, though some indexers put the magnet link there
+	link := item.Link
+	if link == "" {
+		link = item.Enclosure.URL
+	}
+	if strings.HasPrefix(link, "magnet:") {
+		if result.MagnetLink == "" {
+			result.MagnetLink = link
+		}
+		link = ""
+	}
+	if link != "" {
+		result.TorrentURL = link
+	}
+
+	// The download link identifies the result, so DownloadTorrent can fetch it later
+	switch {
+	case result.TorrentURL != "":
+		result.ID = result.TorrentURL
+	case result.MagnetLink != "":
+		result.ID = result.MagnetLink
+	default:
+		return nil
+	}
+
+	return result
+}
+
+// DownloadTorrent fetches the .torrent file of a result. Result IDs are their download links,
+// which must point at the indexer's host.
+func (p *TorznabProvider) DownloadTorrent(ctx context.Context, torrentID string) ([]byte, error) {
+	link, err := url.Parse(torrentID)
+	if err != nil || (link.Scheme != "http" && link.Scheme != "https") {
+		return nil, fmt.Errorf("%w: %s has no torrent file for this result, add it by magnet link", ErrNoTorrentFile, p.Name())
+	}
+	indexerURL, err := url.Parse(p.indexer.URL)
+	if err != nil || !strings.EqualFold(link.Host, indexerURL.Host) {
+		return nil, fmt.Errorf("%w %q: not a download link of %s", ErrInvalidTorrentID, torrentID, p.Name())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", link.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := p.limiter.do(p.client, req)
+	if err != nil {
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			// Log close error as it may indicate network issues
+			fmt.Printf("warning: failed to close response body: %v\n", err)
+		}
+	}()
+
+	if strings.HasPrefix(resp.Header.Get("Location"), "magnet:") {
+		return nil, fmt.Errorf("%w: %s redirected to a magnet link", ErrNoTorrentFile, p.Name())
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(p.Name(), resp)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	return data, nil
+}
+
+// TestConnection fetches the indexer's capabilities, which checks the API key
+func (p *TorznabProvider) TestConnection(ctx context.Context) error {
+	var caps torznabCaps
+	if err := p.get(ctx, url.Values{"t": {"caps"}}, &caps); err != nil {
+		return err
+	}
+
+	p.capsMu.Lock()
+	p.caps = &caps
+	p.capsMu.Unlock()
+	return nil
+}
+
+// capabilities returns the indexer's capabilities, fetching them once
+func (p *TorznabProvider) capabilities(ctx context.Context) (*torznabCaps, error) {
+	p.capsMu.Lock()
+	defer p.capsMu.Unlock()
+
+	if p.caps != nil {
+		return p.caps, nil
+	}
+
+	var caps torznabCaps
+	if err := p.get(ctx, url.Values{"t": {"caps"}}, &caps); err != nil {
+		return nil, err
+	}
+	p.caps = &caps
+	return p.caps, nil
+}
+
+// get calls the Torznab API and decodes the XML response into v, turning Torznab error
+// responses into errors
+func (p *TorznabProvider) get(ctx context.Context, params url.Values, v interface{}) error {
+	params.Set("apikey", p.indexer.APIKey)
+
+	apiURL := p.indexer.URL
+	if strings.Contains(apiURL, "?") {
+		apiURL += "&" + params.Encode()
+	} else {
+		apiURL += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := p.limiter.do(p.client, req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			// Log close error as it may indicate network issues
+			fmt.Printf("warning: failed to close response body: %v\n", err)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	// Errors come back as <error code="100" description="..."/>, with or without an error status
+	var apiErr torznabError
+	if xml.Unmarshal(body, &apiErr) == nil && apiErr.XMLName.Local == "error" {
+		e := &Error{Provider: p.Name(), StatusCode: resp.StatusCode, Message: apiErr.Description}
+		switch apiErr.Code {
+		case "100", "101", "102":
+			e.Kind = ErrAuthFailed
+		case "500", "501":
+			// Request and download limits
+			e.Kind = ErrRateLimited
+			e.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		default:
+			e.Message = fmt.Sprintf("API error %s: %s", apiErr.Code, apiErr.Description)
+		}
+		return e
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		statusErr := statusError(p.Name(), resp)
+		if errors.Is(statusErr, ErrAuthFailed) {
+			statusErr.Message = "invalid API key"
+		}
+		return statusErr
+	}
+
+	if err := xml.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+type torznabError struct {
+	XMLName     xml.Name
+	Code        string `xml:"code,attr"`
+	Description string `xml:"description,attr"`
+}
+
+type torznabCaps struct {
+	Searching struct {
+		Search     torznabSearchCap `xml:"search"`
+		BookSearch torznabSearchCap `xml:"book-search"`
+	} `xml:"searching"`
+}
+
+type torznabSearchCap struct {
+	Available       string `xml:"available,attr"`
+	SupportedParams string `xml:"supportedParams,attr"`
+}
+
+// params returns the supported search parameters
+func (c torznabSearchCap) params() map[string]bool {
+	params := make(map[string]bool)
+	for _, param := range strings.Split(c.SupportedParams, ",") {
+		params[strings.TrimSpace(param)] = true
+	}
+	return params
+}
+
+type torznabFeed struct {
+	Channel struct {
+		// newznab:response, with the number of matches
+		Response struct {
+			Total *int `xml:"total,attr"`
+		} `xml:"response"`
+		Items []torznabItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type torznabItem struct {
+	Title       string `xml:"title"`
+	GUID        string `xml:"guid"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+	Size        int64  `xml:"size"`
+	Enclosure   struct {
+		URL    string `xml:"url,attr"`
+		Length int64  `xml:"length,attr"`
+	} `xml:"enclosure"`
+	// torznab:attr elements; the namespace prefix varies between indexers
+	Attrs []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value,attr"`
+	} `xml:"attr"`
+}
+
+// attrs returns the item's attributes by name. Repeated attributes keep their first value;
+// use categories for the category list.
+func (i torznabItem) attrs() map[string]string {
+	attrs := make(map[string]string, len(i.Attrs))
+	for _, a := range i.Attrs {
+		if _, ok := attrs[a.Name]; !ok {
+			attrs[a.Name] = a.Value
+		}
+	}
+	return attrs
+}
+
+// categories returns the item's category IDs
+func (i torznabItem) categories() []int {
+	var cats []int
+	for _, a := range i.Attrs {
+		if a.Name == "category" {
+			if cat, err := strconv.Atoi(a.Value); err == nil {
+				cats = append(cats, cat)
+			}
+		}
+	}
+	return cats
+}
+
+// torznabMediaType maps categories to a media type: Books (7000-7999) are ebooks and Audio
+// is audiobooks. Items without either use the searched media type, or audiobook.
+func torznabMediaType(cats []int, searched models.MediaType) models.MediaType {
+	for _, cat := range cats {
+		switch {
+		case cat >= 7000 && cat < 8000:
+			return models.MediaTypeEbook
+		case cat >= 3000 && cat < 4000:
+			return models.MediaTypeAudiobook
+		}
+	}
+	if searched != "" {
+		return searched
+	}
+	return models.MediaTypeAudiobook
+}
+
+// formatSize formats a byte count the way MAM reports sizes, e.g. "1.2 GiB"
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+func atoiOrZero(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,221 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/nathanael/organizr/internal/models"
+)
+
+const torznabCapsXML = `<?xml version="1.0" encoding="UTF-8"?>
+<caps>
+  <searching>
+    <search available="yes" supportedParams="q"/>
+    <book-search available="yes" supportedParams="q,title,author"/>
+  </searching>
+</caps>`
+
+const torznabFeedXML = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:torznab="http://torznab.com/schemas/2015/feed" xmlns:newznab="http://www.newznab.com/DTD/2010/feeds/attributes/">
+  <channel>
+    <newznab:response offset="0" total="250"/>
+    <item>
+      <title>Frank Herbert - Dune [Unabridged]</title>
+      <guid>https://tracker.example/details/1</guid>
+      <link>{server}/download/1.torrent</link>
+      <pubDate>Mon, 01 Jan 2024 00:00:00 +0000</pubDate>
+      <size>1288490188</size>
+      <torznab:attr name="category" value="3030"/>
+      <torznab:attr name="seeders" value="12"/>
+      <torznab:attr name="peers" value="15"/>
+      <torznab:attr name="grabs" value="40"/>
+      <torznab:attr name="infohash" value="ABCDEF0123"/>
+      <torznab:attr name="downloadvolumefactor" value="0"/>
+    </item>
+    <item>
+      <title>Dune (epub)</title>
+      <guid>https://tracker.example/details/2</guid>
+      <link>magnet:?xt=urn:btih:456</link>
+      <torznab:attr name="category" value="7020"/>
+      <torznab:attr name="seeders" value="3"/>
+    </item>
+    <item>
+      <title>No link</title>
+      <torznab:attr name="category" value="3030"/>
+    </item>
+  </channel>
+</rss>`
+
+// newTorznabServer serves caps, a fixed feed and torrent downloads, recording each API query
+func newTorznabServer(t *testing.T, queries *[]url.Values) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api":
+			if r.URL.Query().Get("apikey") != "key" {
+				_, _ = w.Write([]byte(`<error code="100" description="Invalid API Key"/>`))
+				return
+			}
+			if queries != nil {
+				*queries = append(*queries, r.URL.Query())
+			}
+			if r.URL.Query().Get("t") == "caps" {
+				_, _ = w.Write([]byte(torznabCapsXML))
+				return
+			}
+			_, _ = w.Write([]byte(strings.ReplaceAll(torznabFeedXML, "{server}", server.URL)))
+		case "/download/1.torrent":
+			_, _ = w.Write([]byte("torrent-data"))
+		case "/download/magnet":
+			http.Redirect(w, r, "magnet:?xt=urn:btih:789", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestTorznabProvider_Search(t *testing.T) {
+	var queries []url.Values
+	server := newTorznabServer(t, &queries)
+	p := NewTorznabProvider(TorznabIndexer{Name: "Prowlarr", URL: server.URL + "/api", APIKey: "key"})
+
+	page, err := p.Search(context.Background(), Query{Title: "Dune", Author: "Frank Herbert"})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	results := page.Results
+
+	// Title and author go to a book search when the indexer supports them
+	search := queries[len(queries)-1]
+	if search.Get("t") != "book" || search.Get("title") != "Dune" || search.Get("author") != "Frank Herbert" {
+		t.Errorf("search query = %v, want a book search by title and author", search)
+	}
+	if search.Get("cat") != "3030,7020" {
+		t.Errorf("cat = %q, want both media types' categories", search.Get("cat"))
+	}
+
+	// The item without a link is dropped
+	if len(results) != 2 {
+		t.Fatalf("Search() returned %d results, want 2", len(results))
+	}
+
+	audiobook := results[0]
+	if audiobook.ID != server.URL+"/download/1.torrent" || audiobook.TorrentURL != audiobook.ID {
+		t.Errorf("ID = %q, TorrentURL = %q, want the download link", audiobook.ID, audiobook.TorrentURL)
+	}
+	if audiobook.Seeders != 12 || audiobook.Leechers != 3 || audiobook.TimesCompleted != 40 {
+		t.Errorf("seeders/leechers/completed = %d/%d/%d, want 12/3/40", audiobook.Seeders, audiobook.Leechers, audiobook.TimesCompleted)
+	}
+	if audiobook.InfoHash != "abcdef0123" || !audiobook.Freeleech || audiobook.Size != "1.2 GiB" {
+		t.Errorf("hash/freeleech/size = %q/%v/%q", audiobook.InfoHash, audiobook.Freeleech, audiobook.Size)
+	}
+	if audiobook.MediaType != models.MediaTypeAudiobook || audiobook.Provider != "Prowlarr" {
+		t.Errorf("media type/provider = %q/%q", audiobook.MediaType, audiobook.Provider)
+	}
+
+	ebook := results[1]
+	if ebook.TorrentURL != "" || ebook.MagnetLink != "magnet:?xt=urn:btih:456" || ebook.ID != ebook.MagnetLink {
+		t.Errorf("magnet-only result = %+v, want it identified by its magnet link", ebook)
+	}
+	if ebook.MediaType != models.MediaTypeEbook {
+		t.Errorf("media type = %q, want ebook from its category", ebook.MediaType)
+	}
+}
+
+func TestTorznabProvider_Search_FreeText(t *testing.T) {
+	var queries []url.Values
+	server := newTorznabServer(t, &queries)
+	empty := []int{}
+	p := NewTorznabProvider(TorznabIndexer{Name: "Jackett", URL: server.URL + "/api", APIKey: "key", EbookCategories: empty})
+
+	page, err := p.Search(context.Background(), Query{Text: "dune", MediaType: models.MediaTypeAudiobook, Offset: 100, Limit: 50})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if page.Total != 250 || page.TotalFound != 250 {
+		t.Errorf("totals = %d/%d, want the response's total", page.Total, page.TotalFound)
+	}
+
+	search := queries[len(queries)-1]
+	if search.Get("t") != "search" || search.Get("q") != "dune" || search.Get("cat") != "3030" {
+		t.Errorf("search query = %v, want a free-text audiobook search", search)
+	}
+	if search.Get("offset") != "100" || search.Get("limit") != "50" {
+		t.Errorf("offset/limit = %s/%s, want 100/50", search.Get("offset"), search.Get("limit"))
+	}
+
+	if caps := p.Capabilities(); caps.Supports(models.MediaTypeEbook) || !caps.Supports(models.MediaTypeAudiobook) {
+		t.Errorf("Capabilities() = %+v, want audiobooks only", caps)
+	}
+}
+
+func TestTorznabProvider_AuthenticationError(t *testing.T) {
+	server := newTorznabServer(t, nil)
+	p := NewTorznabProvider(TorznabIndexer{Name: "Prowlarr", URL: server.URL + "/api", APIKey: "wrong"})
+
+	err := p.TestConnection(context.Background())
+	if !errors.Is(err, ErrAuthFailed) || !strings.Contains(err.Error(), "Invalid API Key") {
+		t.Errorf("TestConnection() error = %v, want an authentication failure", err)
+	}
+}
+
+func TestTorznabProvider_DownloadTorrent(t *testing.T) {
+	server := newTorznabServer(t, nil)
+	p := NewTorznabProvider(TorznabIndexer{Name: "Prowlarr", URL: server.URL + "/api", APIKey: "key"})
+	ctx := context.Background()
+
+	data, err := p.DownloadTorrent(ctx, server.URL+"/download/1.torrent")
+	if err != nil || string(data) != "torrent-data" {
+		t.Errorf("DownloadTorrent() = %q, %v, want the torrent file", data, err)
+	}
+
+	// Links elsewhere aren't fetched
+	if _, err := p.DownloadTorrent(ctx, "https://elsewhere.example/download/1.torrent"); !errors.Is(err, ErrInvalidTorrentID) {
+		t.Errorf("DownloadTorrent() error = %v, want ErrInvalidTorrentID", err)
+	}
+
+	// Magnet-only results have no file
+	if _, err := p.DownloadTorrent(ctx, "magnet:?xt=urn:btih:456"); !errors.Is(err, ErrNoTorrentFile) {
+		t.Errorf("DownloadTorrent() error = %v, want ErrNoTorrentFile", err)
+	}
+	if _, err := p.DownloadTorrent(ctx, server.URL+"/download/magnet"); !errors.Is(err, ErrNoTorrentFile) {
+		t.Errorf("DownloadTorrent() error = %v, want ErrNoTorrentFile for a magnet redirect", err)
+	}
+}
+
+func TestParseTorznabIndexers(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{name: "Empty", value: "", want: 0},
+		{name: "Valid", value: `[{"name":"Prowlarr","url":"http://prowlarr:9696/1/api","api_key":"k"},{"name":"Jackett","url":"https://jackett/api","api_key":"k","enabled":false}]`, want: 2},
+		{name: "Not JSON", value: "prowlarr", wantErr: true},
+		{name: "Missing name", value: `[{"url":"http://prowlarr/api"}]`, wantErr: true},
+		{name: "Duplicate name", value: `[{"name":"A","url":"http://a/api"},{"name":"A","url":"http://b/api"}]`, wantErr: true},
+		{name: "MyAnonamouse name", value: `[{"name":"MyAnonamouse","url":"http://a/api"}]`, wantErr: true},
+		{name: "Invalid URL", value: `[{"name":"A","url":"prowlarr:9696"}]`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTorznabIndexers(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTorznabIndexers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("ParseTorznabIndexers() returned %d indexers, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package search
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/nathanael/organizr/internal/search/providers"
+)
+
+// Registry holds the enabled search providers, in the order their results are merged
+type Registry struct {
+	providers []providers.Provider
+}
+
+// NewRegistry creates a registry of the given providers
+func NewRegistry(ps ...providers.Provider) *Registry {
+	return &Registry{providers: ps}
+}
+
+// Providers returns the registered providers in merge order
+func (r *Registry) Providers() []providers.Provider {
+	return r.providers
+}
+
+// Get returns the provider with the given name
+func (r *Registry) Get(name string) (providers.Provider, bool) {
+	for _, p := range r.providers {
+		if p.Name() == name {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// providerFactory builds the providers of one kind from config. Kinds that aren't
+// configured or are disabled build none.
+type providerFactory func(configs map[string]string) ([]providers.Provider, error)
+
+// providerFactories lists every kind of provider, in merge order
+var providerFactories = []providerFactory{
+	myAnonamouseProviders,
+	torznabProviders,
+}
+
+// LoadRegistry builds a registry of the providers enabled in configs
+func LoadRegistry(configs map[string]string) (*Registry, error) {
+	registry := &Registry{}
+	for _, factory := range providerFactories {
+		ps, err := factory(configs)
+		if err != nil {
+			return nil, err
+		}
+		registry.providers = append(registry.providers, ps...)
+	}
+	return registry, nil
+}
+
+// myAnonamouseProviders builds the MyAnonamouse provider once a secret is set
+func myAnonamouseProviders(configs map[string]string) ([]providers.Provider, error) {
+	if configs["mam.enabled"] == "false" || configs["mam.secret"] == "" {
+		return nil, nil
+	}
+
+	baseURL := configs["mam.baseurl"]
+	if baseURL == "" {
+		return nil, fmt.Errorf("MAM base URL not configured")
+	}
+
+	requestsPerMinute := providers.DefaultMAMRequestsPerMinute
+	if value := configs["mam.requests_per_minute"]; value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid mam.requests_per_minute %q: must be a non-negative integer", value)
+		}
+		requestsPerMinute = n
+	}
+
+	return []providers.Provider{providers.NewMyAnonamouseProvider(providers.MyAnonamouseConfig{
+		BaseURL:           baseURL,
+		Secret:            configs["mam.secret"],
+		SeedboxURL:        configs["mam.seedbox_url"],
+		RequestsPerMinute: requestsPerMinute,
+	})}, nil
+}
+
+// torznabProviders builds a provider for each enabled Torznab indexer
+func torznabProviders(configs map[string]string) ([]providers.Provider, error) {
+	indexers, err := providers.ParseTorznabIndexers(configs["torznab.indexers"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid torznab.indexers: %w", err)
+	}
+
+	var ps []providers.Provider
+	for _, indexer := range indexers {
+		if indexer.IsEnabled() {
+			ps = append(ps, providers.NewTorznabProvider(indexer))
+		}
+	}
+	return ps, nil
+}
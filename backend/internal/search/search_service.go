@@ -2,73 +2,425 @@ package search
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"maps"
+	"strconv"
+	"sync"
+	"time"
 
+	"github.com/nathanael/organizr/internal/config"
 	"github.com/nathanael/organizr/internal/models"
 	"github.com/nathanael/organizr/internal/persistence"
 	"github.com/nathanael/organizr/internal/search/providers"
 )
 
-// MAMService handles torrent search and download operations for MyAnonamouse
-type MAMService struct {
-	configRepo persistence.ConfigRepository
-	provider   *providers.MyAnonamouseProvider
+var (
+	// ErrNoProviders is returned when no enabled search provider can handle a request
+	ErrNoProviders = errors.New("no search providers configured")
+	// ErrProviderNotFound is returned for a provider name that isn't configured
+	ErrProviderNotFound = errors.New("search provider not found")
+	// ErrDownloadByIDUnsupported is returned when a provider can't fetch torrents by ID;
+	// its results must be added through their torrent URL or magnet link
+	ErrDownloadByIDUnsupported = errors.New("provider does not support downloading torrents by ID")
+)
+
+// defaultProviderTimeout bounds each provider's part of a search
+const defaultProviderTimeout = 30 * time.Second
+
+// Results are the merged results of a search across providers
+type Results struct {
+	Results []*models.SearchResult
+	// Matches the providers that answered can page through, and all their matches
+	Total      int
+	TotalFound int
+	// Providers that failed; their results are missing from Results
+	Failures []ProviderError
+	// When the results were cached, or zero if the providers were just searched
+	CachedAt time.Time
+}
+
+// ProviderError is a search provider's failure
+type ProviderError struct {
+	Provider string
+	Err      error
+}
+
+// Service searches and downloads torrents through the providers configured in the database,
+// caching their responses
+type Service struct {
+	configService *config.Service
+	cache         persistence.SearchCacheRepository // nil disables caching
+
+	mu       sync.Mutex
+	registry *Registry // Loaded on first use and again after provider settings change
+}
+
+// NewService creates a search service. Provider settings changed through configService
+// apply from the next request, and drop the searches cached under the old settings. A nil
+// cache disables caching.
+func NewService(configService *config.Service, cache persistence.SearchCacheRepository) *Service {
+	s := &Service{configService: configService, cache: cache}
+	configService.Subscribe(func(key, value string) {
+		if !isProviderConfigKey(key) {
+			return
+		}
+		s.mu.Lock()
+		s.registry = nil
+		s.mu.Unlock()
+
+		if cache != nil {
+			if err := cache.ClearSearches(context.Background()); err != nil {
+				log.Printf("Failed to clear search cache: %v", err)
+			}
+		}
+	})
+	return s
 }
 
-// NewMAMService creates a new MAM service
-func NewMAMService(configRepo persistence.ConfigRepository) *MAMService {
-	return &MAMService{
-		configRepo: configRepo,
+// providerConfigKeys are the settings LoadRegistry builds the providers from. Other mam.*
+// settings, e.g. the account guard's, are read per grab and mustn't reset the providers' rate
+// limits or the cache.
+var providerConfigKeys = map[string]bool{
+	"mam.enabled":             true,
+	"mam.baseurl":             true,
+	"mam.secret":              true,
+	"mam.seedbox_url":         true,
+	"mam.requests_per_minute": true,
+	"torznab.indexers":        true,
+}
+
+// isProviderConfigKey reports whether key configures a search provider
+func isProviderConfigKey(key string) bool {
+	return providerConfigKeys[key]
+}
+
+// loadRegistry returns the provider registry, building it from config if needed
+func (s *Service) loadRegistry(ctx context.Context) (*Registry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.registry != nil {
+		return s.registry, nil
 	}
+
+	configs, err := s.configService.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load search provider config: %w", err)
+	}
+	configs = maps.Clone(configs)
+	configured := configs["mam.secret"]
+	if rotated := currentRotatedSecret(configs); rotated != "" {
+		configs["mam.secret"] = rotated
+	}
+	registry, err := LoadRegistry(configs)
+	if err != nil {
+		return nil, err
+	}
+	if p, ok := registry.Get(providers.MyAnonamouseName); ok {
+		if mam, ok := p.(*providers.MyAnonamouseProvider); ok {
+			mam.OnSecretRotated(func(secret string) {
+				s.saveRotatedSecret(configured, secret)
+			})
+		}
+	}
+	s.registry = registry
+	return registry, nil
 }
 
-// initProvider lazy-loads the MAM configuration
-func (s *MAMService) initProvider(ctx context.Context) error {
-	if s.provider != nil {
-		return nil
+// rotatedSecret is the mam_id MAM rotated the session to, saved as mam.rotated_secret. It is
+// kept apart from mam.secret, which MAM_SECRET overrides, so the session survives restarts
+// however the secret is configured.
+type rotatedSecret struct {
+	Secret string `json:"secret"`
+	// SHA-256 of the configured mam.secret the session was rotated from
+	From string `json:"from"`
+}
+
+// currentRotatedSecret returns the saved rotated secret, unless mam.secret has been changed
+// since the session was rotated
+func currentRotatedSecret(configs map[string]string) string {
+	value := configs["mam.rotated_secret"]
+	if value == "" || configs["mam.secret"] == "" {
+		return ""
+	}
+	var rotated rotatedSecret
+	if err := json.Unmarshal([]byte(value), &rotated); err != nil {
+		log.Printf("Ignoring invalid mam.rotated_secret: %v", err)
+		return ""
 	}
+	if rotated.From != secretHash(configs["mam.secret"]) {
+		return ""
+	}
+	return rotated.Secret
+}
+
+func secretHash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// secretSaveTimeout bounds saving a rotated MAM secret
+const secretSaveTimeout = 10 * time.Second
+
+// saveRotatedSecret stores the mam_id MAM rotated a session started from the configured
+// secret to. Saving it doesn't reload the providers.
+func (s *Service) saveRotatedSecret(configured, secret string) {
+	value, err := json.Marshal(rotatedSecret{Secret: secret, From: secretHash(configured)})
+	if err != nil {
+		log.Printf("Failed to save rotated MAM secret: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretSaveTimeout)
+	defer cancel()
+	if err := s.configService.Set(ctx, "mam.rotated_secret", string(value)); err != nil {
+		log.Printf("Failed to save rotated MAM secret: %v", err)
+	}
+}
+
+// RegisterSeedboxIP registers this server's IP as the MAM session's dynamic seedbox IP
+func (s *Service) RegisterSeedboxIP(ctx context.Context) (*providers.SeedboxRegistration, error) {
+	mam, err := s.myAnonamouse(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return mam.RegisterSeedboxIP(ctx)
+}
+
+// AccountStats fetches the MAM account's ratio, bonus points and unsatisfied torrents
+func (s *Service) AccountStats(ctx context.Context) (*providers.AccountStats, error) {
+	mam, err := s.myAnonamouse(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return mam.AccountStats(ctx)
+}
+
+// SpendWedge spends a MAM freeleech wedge on a torrent, making it personal freeleech
+func (s *Service) SpendWedge(ctx context.Context, torrentID string) error {
+	mam, err := s.myAnonamouse(ctx)
+	if err != nil {
+		return err
+	}
+	return mam.SpendWedge(ctx, torrentID)
+}
 
-	baseURL, err := s.configRepo.Get(ctx, "mam.baseurl")
+// myAnonamouse returns the MyAnonamouse provider, or ErrProviderNotFound when it isn't enabled
+func (s *Service) myAnonamouse(ctx context.Context) (*providers.MyAnonamouseProvider, error) {
+	registry, err := s.loadRegistry(ctx)
 	if err != nil {
-		return fmt.Errorf("MAM base URL not configured: %w", err)
+		return nil, err
+	}
+
+	p, _ := registry.Get(providers.MyAnonamouseName)
+	mam, ok := p.(*providers.MyAnonamouseProvider)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrProviderNotFound, providers.MyAnonamouseName)
 	}
+	return mam, nil
+}
 
-	secret, err := s.configRepo.Get(ctx, "mam.secret")
+// providerTimeout returns the configured per-provider search timeout
+func (s *Service) providerTimeout(ctx context.Context) time.Duration {
+	timeoutStr, err := s.configService.Get(ctx, "search.provider_timeout_seconds")
 	if err != nil {
-		return fmt.Errorf("MAM secret not configured: %w", err)
+		return defaultProviderTimeout
 	}
+	seconds, err := strconv.Atoi(timeoutStr)
+	if err != nil || seconds <= 0 {
+		return defaultProviderTimeout
+	}
+	return time.Duration(seconds) * time.Second
+}
 
-	s.provider = providers.NewMyAnonamouseProvider(baseURL, secret)
-	return nil
+// Providers returns the enabled search providers
+func (s *Service) Providers(ctx context.Context) ([]providers.Provider, error) {
+	registry, err := s.loadRegistry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return registry.Providers(), nil
 }
 
-// Search performs a torrent search on MyAnonamouse
-func (s *MAMService) Search(ctx context.Context, query string) ([]*models.SearchResult, error) {
-	if query == "" {
+// Search queries every enabled provider that supports the query's media type, and its
+// filters if it has any, at once. Each provider gets its own timeout; providers that fail are
+// reported in the results' Failures, and the search only fails if all of them do. The same
+// torrent listed by several providers is returned once. Results are served from the cache
+// for search.cache_ttl_minutes unless ctx bypasses it; searches with failed providers aren't
+// cached.
+func (s *Service) Search(ctx context.Context, q providers.Query) (*Results, error) {
+	if q.IsEmpty() {
 		return nil, fmt.Errorf("search query cannot be empty")
 	}
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
 
-	if err := s.initProvider(ctx); err != nil {
+	registry, err := s.loadRegistry(ctx)
+	if err != nil {
 		return nil, err
 	}
 
-	return s.provider.Search(ctx, query)
+	var candidates []providers.Provider
+	for _, p := range registry.Providers() {
+		caps := p.Capabilities()
+		if caps.Supports(q.MediaType) && (caps.Filters || !q.HasFilters()) {
+			candidates = append(candidates, p)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNoProviders
+	}
+
+	var ttl time.Duration
+	if s.cache != nil {
+		ttl = s.cacheTTL(ctx, "search.cache_ttl_minutes", time.Minute, defaultSearchCacheTTL)
+	}
+	if ttl > 0 && !bypassesCache(ctx) {
+		if cached := s.cachedSearch(ctx, q); cached != nil {
+			return cached, nil
+		}
+	}
+
+	results, err := fanOut(ctx, candidates, s.providerTimeout(ctx), func(ctx context.Context, p providers.Provider) (*providers.Page, error) {
+		return p.Search(ctx, q)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ttl > 0 {
+		s.cacheSearch(ctx, q, results, ttl)
+	}
+	return results, nil
 }
 
-// TestConnection validates MyAnonamouse credentials
-func (s *MAMService) TestConnection(ctx context.Context) error {
-	if err := s.initProvider(ctx); err != nil {
-		return err
+// fanOut runs search against every provider concurrently and merges the results in
+// provider order
+func fanOut(ctx context.Context, ps []providers.Provider, timeout time.Duration, search func(context.Context, providers.Provider) (*providers.Page, error)) (*Results, error) {
+	type outcome struct {
+		page *providers.Page
+		err  error
+	}
+	outcomes := make([]outcome, len(ps))
+
+	var wg sync.WaitGroup
+	for i, p := range ps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			providerCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			page, err := search(providerCtx, p)
+			if err != nil && errors.Is(providerCtx.Err(), context.DeadlineExceeded) {
+				err = fmt.Errorf("timed out after %s: %w", timeout, err)
+			}
+			outcomes[i] = outcome{page: page, err: err}
+		}()
 	}
+	wg.Wait()
 
-	return s.provider.TestConnection(ctx)
+	merged := &Results{}
+	var all []*models.SearchResult
+	var errs []error
+	for i, o := range outcomes {
+		name := ps[i].Name()
+		if o.err != nil {
+			merged.Failures = append(merged.Failures, ProviderError{Provider: name, Err: o.err})
+			errs = append(errs, fmt.Errorf("%s: %w", name, o.err))
+			continue
+		}
+		for _, r := range o.page.Results {
+			if r.Provider == "" {
+				r.Provider = name
+			}
+		}
+		all = append(all, o.page.Results...)
+		merged.Total += o.page.Total
+		merged.TotalFound += o.page.TotalFound
+	}
+
+	if len(merged.Failures) == len(ps) {
+		return nil, fmt.Errorf("all search providers failed: %w", errors.Join(errs...))
+	}
+
+	merged.Results = dedupeResults(all)
+	return merged, nil
+}
+
+// TestConnection checks every enabled provider's connection and credentials, returning
+// each provider's error, or nil if it connected
+func (s *Service) TestConnection(ctx context.Context) (map[string]error, error) {
+	registry, err := s.loadRegistry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(registry.Providers()) == 0 {
+		return nil, ErrNoProviders
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]error, len(registry.Providers()))
+	for _, p := range registry.Providers() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := p.TestConnection(ctx)
+			mu.Lock()
+			results[p.Name()] = err
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return results, nil
 }
 
-// DownloadTorrent fetches the torrent file bytes for a given torrent ID
-func (s *MAMService) DownloadTorrent(ctx context.Context, torrentID int) ([]byte, error) {
-	if err := s.initProvider(ctx); err != nil {
+// DownloadTorrent fetches the torrent file bytes of a search result from the provider that
+// returned it. An empty provider means MyAnonamouse, the only provider before there were
+// several. Torrent files are cached for search.torrent_cache_ttl_hours unless ctx bypasses
+// the cache.
+func (s *Service) DownloadTorrent(ctx context.Context, provider, torrentID string) ([]byte, error) {
+	if provider == "" {
+		provider = providers.MyAnonamouseName
+	}
+
+	registry, err := s.loadRegistry(ctx)
+	if err != nil {
 		return nil, err
 	}
 
-	return s.provider.DownloadTorrent(ctx, torrentID)
+	p, ok := registry.Get(provider)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrProviderNotFound, provider)
+	}
+	if !p.Capabilities().DownloadByID {
+		return nil, fmt.Errorf("%w: %s", ErrDownloadByIDUnsupported, provider)
+	}
+
+	var ttl time.Duration
+	if s.cache != nil {
+		ttl = s.cacheTTL(ctx, "search.torrent_cache_ttl_hours", time.Hour, defaultTorrentCacheTTL)
+	}
+	if ttl > 0 && !bypassesCache(ctx) {
+		if data := s.cachedTorrent(ctx, provider, torrentID); data != nil {
+			return data, nil
+		}
+	}
+
+	data, err := p.DownloadTorrent(ctx, torrentID)
+	if err != nil {
+		return nil, err
+	}
+	if ttl > 0 {
+		s.cacheTorrent(ctx, provider, torrentID, data, ttl)
+	}
+	return data, nil
 }
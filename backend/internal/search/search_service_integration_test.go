@@ -7,7 +7,10 @@ import (
 	"os"
 	"testing"
 
+	"github.com/nathanael/organizr/internal/config"
+	"github.com/nathanael/organizr/internal/models"
 	"github.com/nathanael/organizr/internal/persistence/sqlite"
+	"github.com/nathanael/organizr/internal/search/providers"
 )
 
 // This is an integration test that calls the REAL MAM API
@@ -40,17 +43,20 @@ func TestMAMSearchIntegration(t *testing.T) {
 	// Create config repository
 	configRepo := sqlite.NewConfigRepository(db)
 
-	// Create MAM service
-	mamService := NewMAMService(configRepo)
+	// Create search service
+	searchService := NewService(config.NewService(configRepo), nil)
 
 	ctx := context.Background()
 
 	// Test connection first
 	t.Run("TestConnection", func(t *testing.T) {
-		err := mamService.TestConnection(ctx)
+		results, err := searchService.TestConnection(ctx)
 		if err != nil {
 			t.Skipf("Skipping integration test: %v", err)
 		}
+		if err := results[providers.MyAnonamouseName]; err != nil {
+			t.Skipf("Skipping integration test: %v", err)
+		}
 		t.Log("✓ Successfully connected to MAM API")
 	})
 
@@ -59,12 +65,12 @@ func TestMAMSearchIntegration(t *testing.T) {
 	t.Run(fmt.Sprintf("Search_%s", searchQuery), func(t *testing.T) {
 		t.Logf("Searching for: %s", searchQuery)
 
-		results, err := mamService.Search(ctx, searchQuery)
+		results, err := searchService.Search(ctx, providers.Query{Text: searchQuery, MediaType: models.MediaTypeAudiobook})
 		if err != nil {
 			t.Fatalf("Search failed: %v", err)
 		}
 
-		t.Logf("Found %d results", len(results))
+		t.Logf("Found %d results", len(results.Results))
 	})
 }
 
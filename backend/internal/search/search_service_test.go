@@ -0,0 +1,309 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nathanael/organizr/internal/config"
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/search/providers"
+)
+
+// mockConfigRepo is an in-memory config repository
+type mockConfigRepo struct {
+	configs map[string]string
+}
+
+func (m *mockConfigRepo) Get(ctx context.Context, key string) (string, error) {
+	if val, ok := m.configs[key]; ok {
+		return val, nil
+	}
+	return "", fmt.Errorf("config key not found: %s", key)
+}
+
+func (m *mockConfigRepo) GetAll(ctx context.Context) (map[string]string, error) {
+	return m.configs, nil
+}
+
+func (m *mockConfigRepo) Set(ctx context.Context, key, value string) error {
+	m.configs[key] = value
+	return nil
+}
+
+// fakeProvider returns fixed results, optionally after a delay, and serves "<name>-<id>" as
+// each torrent's data
+type fakeProvider struct {
+	name         string
+	results      []*models.SearchResult
+	err          error
+	delay        time.Duration
+	mediaTypes   []models.MediaType
+	downloadByID bool
+	filters      bool
+
+	searches  int
+	downloads int
+}
+
+func (p *fakeProvider) Name() string { return p.name }
+
+func (p *fakeProvider) Capabilities() providers.Capabilities {
+	mediaTypes := p.mediaTypes
+	if mediaTypes == nil {
+		mediaTypes = []models.MediaType{models.MediaTypeAudiobook, models.MediaTypeEbook}
+	}
+	return providers.Capabilities{MediaTypes: mediaTypes, DownloadByID: p.downloadByID, Filters: p.filters}
+}
+
+func (p *fakeProvider) Search(ctx context.Context, q providers.Query) (*providers.Page, error) {
+	p.searches++
+	select {
+	case <-time.After(p.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &providers.Page{Results: p.results, Total: len(p.results), TotalFound: len(p.results) * 2}, nil
+}
+
+func (p *fakeProvider) DownloadTorrent(ctx context.Context, torrentID string) ([]byte, error) {
+	p.downloads++
+	return []byte(p.name + "-" + torrentID), nil
+}
+
+func (p *fakeProvider) TestConnection(ctx context.Context) error {
+	return p.err
+}
+
+func newTestService(configs map[string]string, ps ...providers.Provider) *Service {
+	s := NewService(config.NewService(&mockConfigRepo{configs: configs}), nil)
+	s.registry = NewRegistry(ps...)
+	return s
+}
+
+func TestService_Search_FansOutAndReportsFailures(t *testing.T) {
+	mam := &fakeProvider{name: "MyAnonamouse", results: []*models.SearchResult{
+		{ID: "1", Title: "Dune", Author: "Frank Herbert", Size: "1.2 GiB", Seeders: 5},
+	}}
+	slow := &fakeProvider{name: "Slow", delay: 10 * time.Second, results: []*models.SearchResult{{ID: "9", Title: "Dune"}}}
+	broken := &fakeProvider{name: "Broken", err: errors.New("indexer unavailable")}
+	prowlarr := &fakeProvider{name: "Prowlarr", results: []*models.SearchResult{
+		{ID: "a", Title: "Dune Messiah", Author: "Frank Herbert", Size: "900 MiB", Seeders: 3},
+	}}
+
+	s := newTestService(map[string]string{"search.provider_timeout_seconds": "1"}, mam, slow, broken, prowlarr)
+
+	start := time.Now()
+	results, err := s.Search(context.Background(), providers.Query{Text: "dune"})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Search() took %s, want the slow provider cut off", elapsed)
+	}
+
+	// Results merge in provider order and are tagged with their provider
+	if len(results.Results) != 2 || results.Results[0].ID != "1" || results.Results[1].ID != "a" {
+		t.Fatalf("results = %+v, want MyAnonamouse's then Prowlarr's", results.Results)
+	}
+	if results.Results[0].Provider != "MyAnonamouse" || results.Results[1].Provider != "Prowlarr" {
+		t.Errorf("providers = %s, %s", results.Results[0].Provider, results.Results[1].Provider)
+	}
+
+	if len(results.Failures) != 2 || results.Failures[0].Provider != "Slow" || results.Failures[1].Provider != "Broken" {
+		t.Fatalf("failures = %+v, want Slow and Broken", results.Failures)
+	}
+	if !errors.Is(results.Failures[0].Err, context.DeadlineExceeded) {
+		t.Errorf("slow provider error = %v, want a timeout", results.Failures[0].Err)
+	}
+}
+
+func TestService_Search_FailsWhenAllProvidersFail(t *testing.T) {
+	s := newTestService(map[string]string{},
+		&fakeProvider{name: "A", err: errors.New("down")},
+		&fakeProvider{name: "B", err: errors.New("also down")},
+	)
+
+	if _, err := s.Search(context.Background(), providers.Query{Text: "dune"}); err == nil {
+		t.Error("expected an error when every provider fails")
+	}
+}
+
+func TestService_Search_SkipsProvidersWithoutMediaType(t *testing.T) {
+	ebooks := &fakeProvider{name: "Ebooks", mediaTypes: []models.MediaType{models.MediaTypeEbook}, results: []*models.SearchResult{{ID: "1", Title: "Dune"}}}
+
+	s := newTestService(map[string]string{}, ebooks)
+	if _, err := s.Search(context.Background(), providers.Query{Text: "dune", MediaType: models.MediaTypeAudiobook}); !errors.Is(err, ErrNoProviders) {
+		t.Errorf("Search() error = %v, want ErrNoProviders", err)
+	}
+
+	results, err := s.Search(context.Background(), providers.Query{Text: "dune", MediaType: models.MediaTypeEbook})
+	if err != nil || len(results.Results) != 1 {
+		t.Errorf("Search() = %+v, %v, want the ebook provider's result", results, err)
+	}
+}
+
+func TestService_Search_FiltersAndTotals(t *testing.T) {
+	mam := &fakeProvider{name: "MyAnonamouse", filters: true, results: []*models.SearchResult{{ID: "1", Title: "Dune"}, {ID: "2", Title: "Dune Messiah"}}}
+	prowlarr := &fakeProvider{name: "Prowlarr", results: []*models.SearchResult{{ID: "a", Title: "Children of Dune"}}}
+	s := newTestService(map[string]string{}, mam, prowlarr)
+	ctx := context.Background()
+
+	results, err := s.Search(ctx, providers.Query{Text: "dune"})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if results.Total != 3 || results.TotalFound != 6 {
+		t.Errorf("totals = %d/%d, want both providers' totals summed", results.Total, results.TotalFound)
+	}
+
+	// Providers that can't apply the filters sit filtered searches out
+	results, err = s.Search(ctx, providers.Query{Text: "dune", SearchType: providers.SearchTypeFreeleech})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(results.Results) != 2 || results.Total != 2 {
+		t.Errorf("filtered search = %d results, total %d, want MyAnonamouse's only", len(results.Results), results.Total)
+	}
+
+	if _, err := s.Search(ctx, providers.Query{Text: "dune", Sort: "bogus"}); err == nil {
+		t.Error("expected an error for an unknown sort order")
+	}
+}
+
+func TestDedupeResults(t *testing.T) {
+	results := []*models.SearchResult{
+		{ID: "1", Provider: "MAM", Title: "Dune", Author: "Frank Herbert", Size: "1.2 GiB", Seeders: 5},
+		{ID: "2", Provider: "MAM", Title: "Dune", Author: "Frank Herbert", Size: "1.2 GiB", Seeders: 1},
+		{ID: "a", Provider: "Prowlarr", Title: "dune", Author: "Frank  Herbert", Size: "1.2 GiB", Seeders: 8},
+		{ID: "b", Provider: "Prowlarr", Title: "Dune Messiah", InfoHash: "ABCDEF", Size: "900 MiB", Seeders: 2},
+		{ID: "x", Provider: "Jackett", Title: "Dune Messiah [Unabridged]", InfoHash: "abcdef", Size: "901 MiB", Seeders: 1},
+		{ID: "y", Provider: "Jackett", Title: "Dune", Author: "Frank Herbert", InfoHash: "123456", Size: "1.2 GiB", Seeders: 30},
+	}
+
+	got := dedupeResults(results)
+
+	var ids []string
+	for _, r := range got {
+		ids = append(ids, r.Provider+":"+r.ID)
+	}
+	// MAM's two listings of the same book stay apart. Prowlarr's and Jackett's listings of
+	// MAM's first merge into it, keeping Jackett's best-seeded one, and Jackett's listing with
+	// Prowlarr's info hash is dropped.
+	want := []string{"Jackett:y", "MAM:2", "Prowlarr:b"}
+	if fmt.Sprint(ids) != fmt.Sprint(want) {
+		t.Errorf("dedupeResults() = %v, want %v", ids, want)
+	}
+}
+
+func TestService_DownloadTorrent(t *testing.T) {
+	mam := &fakeProvider{name: providers.MyAnonamouseName, downloadByID: true}
+	prowlarr := &fakeProvider{name: "Prowlarr"}
+	s := newTestService(map[string]string{}, mam, prowlarr)
+	ctx := context.Background()
+
+	// No provider means MyAnonamouse
+	data, err := s.DownloadTorrent(ctx, "", "42")
+	if err != nil || string(data) != "MyAnonamouse-42" {
+		t.Errorf("DownloadTorrent() = %q, %v, want MyAnonamouse's torrent", data, err)
+	}
+
+	if _, err := s.DownloadTorrent(ctx, "Prowlarr", "42"); !errors.Is(err, ErrDownloadByIDUnsupported) {
+		t.Errorf("DownloadTorrent() error = %v, want ErrDownloadByIDUnsupported", err)
+	}
+	if _, err := s.DownloadTorrent(ctx, "Unknown", "42"); !errors.Is(err, ErrProviderNotFound) {
+		t.Errorf("DownloadTorrent() error = %v, want ErrProviderNotFound", err)
+	}
+}
+
+func TestService_ReloadsProvidersOnConfigChange(t *testing.T) {
+	configService := config.NewService(&mockConfigRepo{configs: map[string]string{
+		"mam.baseurl": "https://www.myanonamouse.net",
+		"mam.secret":  "",
+	}})
+	s := NewService(configService, nil)
+	ctx := context.Background()
+
+	ps, err := s.Providers(ctx)
+	if err != nil || len(ps) != 0 {
+		t.Fatalf("Providers() = %v, %v, want none without a MAM secret", ps, err)
+	}
+
+	if err := configService.Set(ctx, "mam.secret", "secret"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	ps, err = s.Providers(ctx)
+	if err != nil || len(ps) != 1 || ps[0].Name() != providers.MyAnonamouseName {
+		t.Fatalf("Providers() = %v, %v, want MyAnonamouse once its secret is set", ps, err)
+	}
+
+	if err := configService.Set(ctx, "mam.enabled", "false"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if ps, _ := s.Providers(ctx); len(ps) != 0 {
+		t.Errorf("Providers() = %v, want none once MyAnonamouse is disabled", ps)
+	}
+}
+
+func TestService_SavesRotatedMAMSecret(t *testing.T) {
+	repo := &mockConfigRepo{configs: map[string]string{
+		"mam.baseurl": "https://www.myanonamouse.net",
+		"mam.secret":  "original",
+	}}
+	configService := config.NewService(repo)
+	s := NewService(configService, nil)
+	ctx := context.Background()
+
+	ps, err := s.Providers(ctx)
+	if err != nil || len(ps) != 1 {
+		t.Fatalf("Providers() = %v, %v, want MyAnonamouse", ps, err)
+	}
+	mam := ps[0].(*providers.MyAnonamouseProvider)
+
+	// A rotated secret is saved without rebuilding the provider
+	s.saveRotatedSecret("original", "rotated")
+	if repo.configs["mam.secret"] != "original" || repo.configs["mam.rotated_secret"] == "" {
+		t.Errorf("configs = %v, want the rotated secret saved apart from mam.secret", repo.configs)
+	}
+	if ps, _ := s.Providers(ctx); len(ps) != 1 || ps[0] != mam {
+		t.Error("Providers() rebuilt the provider after a rotation")
+	}
+
+	// Setting a new secret replaces the provider and the rotated session
+	if err := configService.Set(ctx, "mam.secret", "replaced"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	ps, _ = s.Providers(ctx)
+	if len(ps) != 1 || ps[0] == mam || ps[0].(*providers.MyAnonamouseProvider).Secret() != "replaced" {
+		t.Error("Providers() kept the old provider after the secret changed")
+	}
+}
+
+func TestService_RotatedMAMSecretSurvivesRestart(t *testing.T) {
+	t.Setenv("MAM_SECRET", "original")
+	repo := &mockConfigRepo{configs: map[string]string{"mam.baseurl": "https://www.myanonamouse.net"}}
+	ctx := context.Background()
+
+	NewService(config.NewService(repo), nil).saveRotatedSecret("original", "rotated")
+
+	// After a restart the rotated session is used over MAM_SECRET
+	ps, err := NewService(config.NewService(repo), nil).Providers(ctx)
+	if err != nil || len(ps) != 1 {
+		t.Fatalf("Providers() = %v, %v, want MyAnonamouse", ps, err)
+	}
+	if secret := ps[0].(*providers.MyAnonamouseProvider).Secret(); secret != "rotated" {
+		t.Errorf("secret = %q, want the rotated one", secret)
+	}
+
+	// Until MAM_SECRET is changed
+	t.Setenv("MAM_SECRET", "replaced")
+	ps, _ = NewService(config.NewService(repo), nil).Providers(ctx)
+	if len(ps) != 1 || ps[0].(*providers.MyAnonamouseProvider).Secret() != "replaced" {
+		t.Errorf("Providers() = %v, want the new MAM_SECRET used", ps)
+	}
+}
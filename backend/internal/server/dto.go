@@ -1,9 +1,15 @@
 package server
 
 import (
+	"math"
+	"sort"
 	"time"
 
+	"github.com/nathanael/organizr/internal/downloads"
 	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/quality"
+	"github.com/nathanael/organizr/internal/search"
+	"github.com/nathanael/organizr/internal/search/providers"
 )
 
 // DTO (Data Transfer Object) Conventions
@@ -30,7 +36,9 @@ type downloadDTO struct {
 	Author        string     `json:"author"`
 	Series        string     `json:"series,omitempty"`
 	SeriesNumber  string     `json:"series_number,omitempty"`
+	MediaType     string     `json:"media_type"`
 	Category      string     `json:"category,omitempty"`
+	TorrentID     string     `json:"torrent_id,omitempty"`
 	Status        string     `json:"status"`
 	Progress      float64    `json:"progress"`
 	OrganizedPath string     `json:"organized_path,omitempty"`
@@ -38,6 +46,9 @@ type downloadDTO struct {
 	CreatedAt     time.Time  `json:"created_at"`
 	CompletedAt   *time.Time `json:"completed_at,omitempty"`
 	OrganizedAt   *time.Time `json:"organized_at,omitempty"`
+	MissingSince  *time.Time `json:"missing_since,omitempty"`
+	WedgeUsed     bool       `json:"wedge_used"`
+	Warnings      []string   `json:"warnings,omitempty"`
 }
 
 func toDTO(d *models.Download) downloadDTO {
@@ -47,7 +58,9 @@ func toDTO(d *models.Download) downloadDTO {
 		Author:        d.Author,
 		Series:        d.Series,
 		SeriesNumber:  d.SeriesNumber,
+		MediaType:     string(d.MediaType),
 		Category:      d.Category,
+		TorrentID:     d.TorrentID,
 		Status:        string(d.Status),
 		Progress:      d.Progress,
 		OrganizedPath: d.OrganizedPath,
@@ -55,6 +68,9 @@ func toDTO(d *models.Download) downloadDTO {
 		CreatedAt:     d.CreatedAt,
 		CompletedAt:   d.CompletedAt,
 		OrganizedAt:   d.OrganizedAt,
+		MissingSince:  d.MissingSince,
+		WedgeUsed:     d.WedgeUsed,
+		Warnings:      d.Warnings,
 	}
 }
 
@@ -66,29 +82,303 @@ func toDTOList(downloads []*models.Download) []downloadDTO {
 	return dtos
 }
 
+type organizeAttemptDTO struct {
+	Attempt    int        `json:"attempt"`
+	Status     string     `json:"status"`
+	Source     string     `json:"source"`
+	Error      string     `json:"error,omitempty"`
+	Transient  bool       `json:"transient"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RunAfter   *time.Time `json:"run_after,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+func organizeAttemptsToDTOList(jobs []*models.OrganizeJob) []organizeAttemptDTO {
+	dtos := make([]organizeAttemptDTO, len(jobs))
+	for i, j := range jobs {
+		dtos[i] = organizeAttemptDTO{
+			Attempt:    j.Attempt,
+			Status:     string(j.Status),
+			Source:     j.Source,
+			Error:      j.Error,
+			Transient:  j.Transient,
+			CreatedAt:  j.CreatedAt,
+			RunAfter:   j.RunAfter,
+			StartedAt:  j.StartedAt,
+			FinishedAt: j.FinishedAt,
+		}
+	}
+	return dtos
+}
+
+type downloadEventDTO struct {
+	ID         int64     `json:"id"`
+	FromStatus string    `json:"from_status,omitempty"`
+	ToStatus   string    `json:"to_status"`
+	Actor      string    `json:"actor"`
+	Reason     string    `json:"reason,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func downloadEventsToDTOList(events []*models.DownloadEvent) []downloadEventDTO {
+	dtos := make([]downloadEventDTO, len(events))
+	for i, e := range events {
+		dtos[i] = downloadEventDTO{
+			ID:         e.ID,
+			FromStatus: string(e.FromStatus),
+			ToStatus:   string(e.ToStatus),
+			Actor:      string(e.Actor),
+			Reason:     e.Reason,
+			CreatedAt:  e.CreatedAt,
+		}
+	}
+	return dtos
+}
+
+type torrentReplacementDTO struct {
+	OldHash      string    `json:"old_hash"`
+	OldTorrentID string    `json:"old_torrent_id,omitempty"`
+	NewHash      string    `json:"new_hash"`
+	NewTorrentID string    `json:"new_torrent_id,omitempty"`
+	Reason       string    `json:"reason,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func torrentReplacementsToDTOList(replacements []*models.TorrentReplacement) []torrentReplacementDTO {
+	dtos := make([]torrentReplacementDTO, len(replacements))
+	for i, r := range replacements {
+		dtos[i] = torrentReplacementDTO{
+			OldHash:      r.OldHash,
+			OldTorrentID: r.OldTorrentID,
+			NewHash:      r.NewHash,
+			NewTorrentID: r.NewTorrentID,
+			Reason:       r.Reason,
+			CreatedAt:    r.CreatedAt,
+		}
+	}
+	return dtos
+}
+
 type searchResultDTO struct {
-	ID         string              `json:"id"`
-	Title      string              `json:"title"`
-	Author     string              `json:"author"`
-	Series     []models.SeriesInfo `json:"series"`
-	TorrentURL string              `json:"torrent_url,omitempty"`
-	MagnetLink string              `json:"magnet_link,omitempty"`
-	Size       string              `json:"size"`
-	Seeders    int                 `json:"seeders"`
-	Provider   string              `json:"provider"`
+	ID             string              `json:"id"`
+	Title          string              `json:"title"`
+	Author         string              `json:"author"`
+	Narrator       string              `json:"narrator,omitempty"`
+	Series         []models.SeriesInfo `json:"series"`
+	TorrentURL     string              `json:"torrent_url,omitempty"`
+	MagnetLink     string              `json:"magnet_link,omitempty"`
+	InfoHash       string              `json:"info_hash,omitempty"`
+	Provider       string              `json:"provider"`
+	MediaType      string              `json:"media_type,omitempty"`
+	Category       string              `json:"category,omitempty"`
+	FileType       string              `json:"file_type,omitempty"`
+	Language       string              `json:"language,omitempty"`
+	Tags           []string            `json:"tags"`
+	Description    string              `json:"description,omitempty"`
+	Added          string              `json:"added,omitempty"`
+	Uploader       string              `json:"uploader,omitempty"`
+	Size           string              `json:"size"`
+	Seeders        int                 `json:"seeders"`
+	Leechers       int                 `json:"leechers"`
+	NumFiles       int                 `json:"num_files"`
+	TimesCompleted int                 `json:"times_completed"`
+	Freeleech      bool                `json:"freeleech"`
+	FreeleechVIP   bool                `json:"freeleech_vip"`
+	VIP            bool                `json:"vip"`
+	// Set when a quality profile scored the result
+	Score *scoreDTO `json:"score,omitempty"`
+}
+
+type scoreDTO struct {
+	Total    int              `json:"total"`
+	Rejected bool             `json:"rejected"`
+	Reasons  []scoreReasonDTO `json:"reasons"`
+}
+
+type scoreReasonDTO struct {
+	Points  int    `json:"points"`
+	Message string `json:"message"`
+}
+
+func scoreToDTO(s quality.Score) *scoreDTO {
+	reasons := make([]scoreReasonDTO, len(s.Reasons))
+	for i, r := range s.Reasons {
+		reasons[i] = scoreReasonDTO{Points: r.Points, Message: r.Message}
+	}
+	return &scoreDTO{Total: s.Total, Rejected: s.Rejected, Reasons: reasons}
+}
+
+func rankedResultsToDTOList(ranked []quality.Ranked) []searchResultDTO {
+	dtos := make([]searchResultDTO, len(ranked))
+	for i, r := range ranked {
+		dtos[i] = searchResultToDTO(r.Result)
+		dtos[i].Score = scoreToDTO(r.Score)
+	}
+	return dtos
+}
+
+type qualityProfileDTO struct {
+	ID                 int64     `json:"id"`
+	Name               string    `json:"name"`
+	PreferredFormats   []string  `json:"preferred_formats"`
+	MinSizeMB          int64     `json:"min_size_mb"`
+	MaxSizeMB          int64     `json:"max_size_mb"`
+	PreferredLanguages []string  `json:"preferred_languages"`
+	FreeleechBonus     int       `json:"freeleech_bonus"`
+	MinSeeders         int       `json:"min_seeders"`
+	PreferredUploaders []string  `json:"preferred_uploaders"`
+	BlockedUploaders   []string  `json:"blocked_uploaders"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+func qualityProfileToDTO(p *models.QualityProfile) qualityProfileDTO {
+	return qualityProfileDTO{
+		ID:                 p.ID,
+		Name:               p.Name,
+		PreferredFormats:   emptyIfNil(p.PreferredFormats),
+		MinSizeMB:          p.MinSizeMB,
+		MaxSizeMB:          p.MaxSizeMB,
+		PreferredLanguages: emptyIfNil(p.PreferredLanguages),
+		FreeleechBonus:     p.FreeleechBonus,
+		MinSeeders:         p.MinSeeders,
+		PreferredUploaders: emptyIfNil(p.PreferredUploaders),
+		BlockedUploaders:   emptyIfNil(p.BlockedUploaders),
+		CreatedAt:          p.CreatedAt,
+		UpdatedAt:          p.UpdatedAt,
+	}
+}
+
+func qualityProfilesToDTOList(profiles []*models.QualityProfile) []qualityProfileDTO {
+	dtos := make([]qualityProfileDTO, len(profiles))
+	for i, p := range profiles {
+		dtos[i] = qualityProfileToDTO(p)
+	}
+	return dtos
+}
+
+type wantedBookDTO struct {
+	ID               int64      `json:"id"`
+	Title            string     `json:"title"`
+	Author           string     `json:"author"`
+	Series           string     `json:"series,omitempty"`
+	SeriesNumber     string     `json:"series_number,omitempty"`
+	MediaType        string     `json:"media_type"`
+	Category         string     `json:"category,omitempty"`
+	QualityProfileID *int64     `json:"quality_profile_id,omitempty"`
+	Status           string     `json:"status"`
+	DownloadID       string     `json:"download_id,omitempty"`
+	LastSearchedAt   *time.Time `json:"last_searched_at,omitempty"`
+	LastError        string     `json:"last_error,omitempty"`
+	FollowedSeriesID *int64     `json:"followed_series_id,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+func wantedBookToDTO(b *models.WantedBook) wantedBookDTO {
+	return wantedBookDTO{
+		ID:               b.ID,
+		Title:            b.Title,
+		Author:           b.Author,
+		Series:           b.Series,
+		SeriesNumber:     b.SeriesNumber,
+		MediaType:        string(b.MediaType),
+		Category:         b.Category,
+		QualityProfileID: b.QualityProfileID,
+		Status:           string(b.Status),
+		DownloadID:       b.DownloadID,
+		LastSearchedAt:   b.LastSearchedAt,
+		LastError:        b.LastError,
+		FollowedSeriesID: b.FollowedSeriesID,
+		CreatedAt:        b.CreatedAt,
+		UpdatedAt:        b.UpdatedAt,
+	}
+}
+
+func wantedBooksToDTOList(books []*models.WantedBook) []wantedBookDTO {
+	dtos := make([]wantedBookDTO, len(books))
+	for i, b := range books {
+		dtos[i] = wantedBookToDTO(b)
+	}
+	return dtos
+}
+
+type followedSeriesDTO struct {
+	ID               int64      `json:"id"`
+	Provider         string     `json:"provider"`
+	SeriesID         string     `json:"series_id"`
+	Name             string     `json:"name"`
+	MediaType        string     `json:"media_type"`
+	Category         string     `json:"category,omitempty"`
+	QualityProfileID *int64     `json:"quality_profile_id,omitempty"`
+	StartAfter       string     `json:"start_after,omitempty"`
+	LastCheckedAt    *time.Time `json:"last_checked_at,omitempty"`
+	LastError        string     `json:"last_error,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+func followedSeriesToDTO(fs *models.FollowedSeries) followedSeriesDTO {
+	return followedSeriesDTO{
+		ID:               fs.ID,
+		Provider:         fs.Provider,
+		SeriesID:         fs.SeriesID,
+		Name:             fs.Name,
+		MediaType:        string(fs.MediaType),
+		Category:         fs.Category,
+		QualityProfileID: fs.QualityProfileID,
+		StartAfter:       fs.StartAfter,
+		LastCheckedAt:    fs.LastCheckedAt,
+		LastError:        fs.LastError,
+		CreatedAt:        fs.CreatedAt,
+		UpdatedAt:        fs.UpdatedAt,
+	}
+}
+
+func followedSeriesToDTOList(series []*models.FollowedSeries) []followedSeriesDTO {
+	dtos := make([]followedSeriesDTO, len(series))
+	for i, fs := range series {
+		dtos[i] = followedSeriesToDTO(fs)
+	}
+	return dtos
+}
+
+// emptyIfNil returns an empty list for nil, so lists encode as [] rather than null
+func emptyIfNil(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+	return items
 }
 
 func searchResultToDTO(s *models.SearchResult) searchResultDTO {
 	return searchResultDTO{
-		ID:         s.ID,
-		Title:      s.Title,
-		Author:     s.Author,
-		Series:     s.Series,
-		TorrentURL: s.TorrentURL,
-		MagnetLink: s.MagnetLink,
-		Size:       s.Size,
-		Seeders:    s.Seeders,
-		Provider:   s.Provider,
+		ID:             s.ID,
+		Title:          s.Title,
+		Author:         s.Author,
+		Narrator:       s.Narrator,
+		Series:         s.Series,
+		TorrentURL:     s.TorrentURL,
+		MagnetLink:     s.MagnetLink,
+		InfoHash:       s.InfoHash,
+		Provider:       s.Provider,
+		MediaType:      string(s.MediaType),
+		Category:       s.Category,
+		FileType:       s.FileType,
+		Language:       s.Language,
+		Tags:           emptyIfNil(s.Tags),
+		Description:    s.Description,
+		Added:          s.Added,
+		Uploader:       s.Uploader,
+		Size:           s.Size,
+		Seeders:        s.Seeders,
+		Leechers:       s.Leechers,
+		NumFiles:       s.NumFiles,
+		TimesCompleted: s.TimesCompleted,
+		Freeleech:      s.Freeleech,
+		FreeleechVIP:   s.FreeleechVIP,
+		VIP:            s.VIP,
 	}
 }
 
@@ -99,3 +389,228 @@ func searchResultsToDTOList(results []*models.SearchResult) []searchResultDTO {
 	}
 	return dtos
 }
+
+type providerErrorDTO struct {
+	Provider string `json:"provider"`
+	Error    string `json:"error"`
+}
+
+func providerErrorsToDTOList(failures []search.ProviderError) []providerErrorDTO {
+	dtos := make([]providerErrorDTO, len(failures))
+	for i, f := range failures {
+		dtos[i] = providerErrorDTO{Provider: f.Provider, Error: f.Err.Error()}
+	}
+	return dtos
+}
+
+type searchProviderDTO struct {
+	Name         string   `json:"name"`
+	MediaTypes   []string `json:"media_types"`
+	DownloadByID bool     `json:"download_by_id"`
+}
+
+func searchProvidersToDTOList(ps []providers.Provider) []searchProviderDTO {
+	dtos := make([]searchProviderDTO, len(ps))
+	for i, p := range ps {
+		caps := p.Capabilities()
+		mediaTypes := make([]string, len(caps.MediaTypes))
+		for j, mt := range caps.MediaTypes {
+			mediaTypes[j] = string(mt)
+		}
+		dtos[i] = searchProviderDTO{Name: p.Name(), MediaTypes: mediaTypes, DownloadByID: caps.DownloadByID}
+	}
+	return dtos
+}
+
+type providerConnectionDTO struct {
+	Provider string `json:"provider"`
+	Success  bool   `json:"success"`
+	Message  string `json:"message,omitempty"`
+}
+
+// providerConnectionsToDTOList lists connection test results sorted by provider name
+func providerConnectionsToDTOList(results map[string]error) []providerConnectionDTO {
+	dtos := make([]providerConnectionDTO, 0, len(results))
+	for provider, err := range results {
+		dto := providerConnectionDTO{Provider: provider, Success: err == nil}
+		if err != nil {
+			dto.Message = err.Error()
+		}
+		dtos = append(dtos, dto)
+	}
+	sort.Slice(dtos, func(i, j int) bool { return dtos[i].Provider < dtos[j].Provider })
+	return dtos
+}
+
+type pathMappingCheckDTO struct {
+	Remote           string   `json:"remote"`
+	Local            string   `json:"local"`
+	LocalExists      bool     `json:"local_exists"`
+	LocalIsDir       bool     `json:"local_is_dir"`
+	LocalReadable    bool     `json:"local_readable"`
+	MatchedSavePaths []string `json:"matched_save_paths"`
+	Errors           []string `json:"errors"`
+}
+
+func pathMappingChecksToDTOList(checks []downloads.PathMappingCheck) []pathMappingCheckDTO {
+	dtos := make([]pathMappingCheckDTO, len(checks))
+	for i, c := range checks {
+		dtos[i] = pathMappingCheckDTO{
+			Remote:           c.Remote,
+			Local:            c.Local,
+			LocalExists:      c.LocalExists,
+			LocalIsDir:       c.LocalIsDir,
+			LocalReadable:    c.LocalReadable,
+			MatchedSavePaths: nonNilStrings(c.MatchedSavePaths),
+			Errors:           nonNilStrings(c.Errors),
+		}
+	}
+	return dtos
+}
+
+// nonNilStrings keeps empty lists serialized as [] rather than null
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+type importCandidateDTO struct {
+	Hash     string       `json:"hash"`
+	Name     string       `json:"name"`
+	Category string       `json:"category,omitempty"`
+	Tags     string       `json:"tags,omitempty"`
+	State    string       `json:"state"`
+	Progress float64      `json:"progress"`
+	Size     int64        `json:"size"`
+	SavePath string       `json:"save_path"`
+	AddedOn  time.Time    `json:"added_on"`
+	Guess    bookGuessDTO `json:"guess"`
+	TagError string       `json:"tag_error,omitempty"`
+}
+
+type bookGuessDTO struct {
+	Title        string `json:"title"`
+	Author       string `json:"author"`
+	Series       string `json:"series,omitempty"`
+	SeriesNumber string `json:"series_number,omitempty"`
+	MediaType    string `json:"media_type"`
+	FromTags     bool   `json:"from_tags"`
+}
+
+func importCandidatesToDTOList(candidates []*downloads.ImportCandidate) []importCandidateDTO {
+	dtos := make([]importCandidateDTO, len(candidates))
+	for i, c := range candidates {
+		dtos[i] = importCandidateDTO{
+			Hash:     c.Hash,
+			Name:     c.Name,
+			Category: c.Category,
+			Tags:     c.Tags,
+			State:    c.State,
+			Progress: c.Progress,
+			Size:     c.Size,
+			SavePath: c.SavePath,
+			AddedOn:  c.AddedOn,
+			Guess: bookGuessDTO{
+				Title:        c.Guess.Title,
+				Author:       c.Guess.Author,
+				Series:       c.Guess.Series,
+				SeriesNumber: c.Guess.SeriesNumber,
+				MediaType:    string(c.Guess.MediaType),
+				FromTags:     c.Guess.FromTags,
+			},
+			TagError: c.TagError,
+		}
+	}
+	return dtos
+}
+
+type monitorStatusDTO struct {
+	Running               bool                      `json:"running"`
+	Recovering            bool                      `json:"recovering"`
+	Degraded              bool                      `json:"degraded"`
+	IntervalSeconds       int                       `json:"interval_seconds"`
+	LastTickAt            *time.Time                `json:"last_tick_at,omitempty"`
+	LastTickDurationMs    int64                     `json:"last_tick_duration_ms"`
+	Ticks                 int64                     `json:"ticks"`
+	Checked               int                       `json:"checked"`
+	Changed               int                       `json:"changed"`
+	Errored               int                       `json:"errored"`
+	ConsecutiveQBFailures int                       `json:"consecutive_qbittorrent_failures"`
+	LastQBError           string                    `json:"last_qbittorrent_error,omitempty"`
+	InFlightOrganizations []inFlightOrganizationDTO `json:"in_flight_organizations"`
+}
+
+type inFlightOrganizationDTO struct {
+	DownloadID string    `json:"download_id"`
+	Title      string    `json:"title"`
+	JobID      int64     `json:"job_id"`
+	StartedAt  time.Time `json:"started_at"`
+}
+
+func monitorStatusToDTO(status downloads.MonitorStatus) monitorStatusDTO {
+	orgs := make([]inFlightOrganizationDTO, len(status.InFlight))
+	for i, org := range status.InFlight {
+		orgs[i] = inFlightOrganizationDTO{
+			DownloadID: org.DownloadID,
+			Title:      org.Title,
+			JobID:      org.JobID,
+			StartedAt:  org.StartedAt,
+		}
+	}
+
+	return monitorStatusDTO{
+		Running:               status.Running,
+		Recovering:            status.Recovering,
+		Degraded:              status.Degraded(),
+		IntervalSeconds:       int(status.Interval / time.Second),
+		LastTickAt:            status.LastTickAt,
+		LastTickDurationMs:    status.LastTickDuration.Milliseconds(),
+		Ticks:                 status.Ticks,
+		Checked:               status.Checked,
+		Changed:               status.Changed,
+		Errored:               status.Errored,
+		ConsecutiveQBFailures: status.ConsecutiveQBFailures,
+		LastQBError:           status.LastQBError,
+		InFlightOrganizations: orgs,
+	}
+}
+
+// accountDTO is the MAM account's state. Ratio is null before anything was downloaded.
+type accountDTO struct {
+	Username         string   `json:"username"`
+	UserID           int      `json:"user_id"`
+	Class            string   `json:"class"`
+	Ratio            *float64 `json:"ratio"`
+	Uploaded         string   `json:"uploaded"`
+	Downloaded       string   `json:"downloaded"`
+	UploadedBytes    int64    `json:"uploaded_bytes"`
+	DownloadedBytes  int64    `json:"downloaded_bytes"`
+	BonusPoints      float64  `json:"bonus_points"`
+	Unsatisfied      int      `json:"unsatisfied"`
+	UnsatisfiedLimit int      `json:"unsatisfied_limit"`
+	Wedges           int      `json:"wedges"`
+}
+
+func toAccountDTO(a *providers.AccountStats) accountDTO {
+	dto := accountDTO{
+		Username:         a.Username,
+		UserID:           a.UserID,
+		Class:            a.Class,
+		Uploaded:         a.Uploaded,
+		Downloaded:       a.Downloaded,
+		UploadedBytes:    a.UploadedBytes,
+		DownloadedBytes:  a.DownloadedBytes,
+		BonusPoints:      a.BonusPoints,
+		Unsatisfied:      a.Unsatisfied,
+		UnsatisfiedLimit: a.UnsatisfiedLimit,
+		Wedges:           a.Wedges,
+	}
+	// JSON has no infinity
+	if !math.IsInf(a.Ratio, 0) && !math.IsNaN(a.Ratio) {
+		ratio := a.Ratio
+		dto.Ratio = &ratio
+	}
+	return dto
+}
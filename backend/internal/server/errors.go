@@ -3,7 +3,10 @@ package server
 import (
 	"encoding/json"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 // ErrorResponse represents a standardized API error response
@@ -52,6 +55,28 @@ func respondWithNotFound(w http.ResponseWriter, resource string, err error) {
 	respondWithError(w, http.StatusNotFound, message, err)
 }
 
+// respondWithConflict sends a standardized 409 Conflict response
+// reason: explanation of the conflicting state
+func respondWithConflict(w http.ResponseWriter, reason string, err error) {
+	respondWithError(w, http.StatusConflict, reason, err)
+}
+
+// respondWithUnauthorized sends a standardized 401 Unauthorized response
+// reason: why the credentials were rejected
+func respondWithUnauthorized(w http.ResponseWriter, reason string) {
+	respondWithError(w, http.StatusUnauthorized, reason, nil)
+}
+
+// respondWithTooManyRequests sends a standardized 429 Too Many Requests response, with a
+// Retry-After header when the wait is known
+// reason: which limit was reached
+func respondWithTooManyRequests(w http.ResponseWriter, reason string, retryAfter time.Duration, err error) {
+	if retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+	respondWithError(w, http.StatusTooManyRequests, reason, err)
+}
+
 // respondWithBadRequest sends a standardized 400 Bad Request response
 // reason: explanation of why the request was invalid
 func respondWithBadRequest(w http.ResponseWriter, reason string, err error) {
@@ -1,45 +1,102 @@
 package server
 
 import (
+	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/nathanael/organizr/internal/downloads"
 	"github.com/nathanael/organizr/internal/fileutil"
 	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/persistence"
 	"github.com/nathanael/organizr/internal/qbittorrent"
+	"github.com/nathanael/organizr/internal/quality"
+	"github.com/nathanael/organizr/internal/search"
+	"github.com/nathanael/organizr/internal/search/providers"
+	"github.com/nathanael/organizr/internal/wanted"
 )
 
+// healthCheckTimeout bounds each dependency check of the health endpoint
+const healthCheckTimeout = 5 * time.Second
+
 // handleHealth godoc
 // @Summary Health check
-// @Description Check API and dependency health status
+// @Description Check API and dependency health status. The database and qBittorrent are pinged, and the monitor reports degraded after several polls in a row failed to reach qBittorrent. Unreachable qBittorrent or a degraded monitor make the service degraded; an unreachable database or stopped monitor make it unhealthy. A monitor recovering interrupted organizations at startup is neither.
 // @Tags system
 // @Produce json
-// @Success 200 {object} HealthResponse
+// @Success 200 {object} HealthResponse "Healthy or degraded"
+// @Failure 503 {object} HealthResponse "Unhealthy"
 // @Router /health [get]
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	resp := HealthResponse{
 		Status:      "healthy",
 		Database:    "ok",
-		QBittorrent: "unknown",
+		QBittorrent: "ok",
 		Monitor:     "running",
 	}
+	degraded, unhealthy := false, false
 
-	respondWithJSON(w, http.StatusOK, resp)
+	dbCtx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+	if err := s.downloadService.PingDatabase(dbCtx); err != nil {
+		resp.Database = "error: " + err.Error()
+		unhealthy = true
+	}
+
+	qbCtx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+	if err := s.downloadService.PingQBittorrent(qbCtx); err != nil {
+		resp.QBittorrent = "error: " + err.Error()
+		degraded = true
+	}
+
+	status := s.monitor.Status()
+	switch {
+	case status.Recovering:
+		// Resuming interrupted copies can take a while; restarting now would interrupt them again
+		resp.Monitor = "recovering"
+	case !status.Running:
+		resp.Monitor = "stopped"
+		unhealthy = true
+	case status.Degraded():
+		resp.Monitor = "degraded"
+		degraded = true
+	}
+
+	code := http.StatusOK
+	switch {
+	case unhealthy:
+		resp.Status = "unhealthy"
+		code = http.StatusServiceUnavailable
+	case degraded:
+		resp.Status = "degraded"
+	}
+
+	respondWithJSON(w, code, resp)
 }
 
 // handleCreateDownload godoc
 // @Summary Create a new download
-// @Description Create a new audiobook download from torrent URL, magnet link, or torrent ID
+// @Description Create a new audiobook or ebook download from torrent URL, magnet link, or torrent ID
 // @Tags downloads
 // @Accept json
 // @Produce json
 // @Param request body CreateDownloadRequest true "Download request"
 // @Success 201 {object} CreateDownloadResponse
 // @Failure 400 {object} ErrorResponse "Invalid request body or validation failed"
+// @Failure 404 {object} ErrorResponse "Torrent not found on the provider"
+// @Failure 409 {object} ErrorResponse "Refused by the MAM account guard"
+// @Failure 429 {object} ErrorResponse "Search provider rate limit reached; see Retry-After"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /downloads [post]
 func (s *Server) handleCreateDownload(w http.ResponseWriter, r *http.Request) {
@@ -55,43 +112,51 @@ func (s *Server) handleCreateDownload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Download torrent file if torrent ID is provided
-	var torrentBytes []byte
-	if req.TorrentID != "" {
-		torrentID, err := strconv.Atoi(req.TorrentID)
-		if err != nil {
-			respondWithValidationError(w, "torrent ID", err)
-			return
-		}
-
-		torrentBytes, err = s.searchService.DownloadTorrent(r.Context(), torrentID)
-		if err != nil {
-			respondWithInternalError(w, "download torrent", err)
-			return
-		}
-	}
-
 	download := &models.Download{
 		Title:        req.Title,
 		Author:       req.Author,
 		Series:       req.Series,
 		SeriesNumber: req.SeriesNumber,
+		MediaType:    models.MediaType(req.MediaType),
 		TorrentURL:   req.TorrentURL,
 		MagnetLink:   req.MagnetLink,
-		TorrentBytes: torrentBytes,
+		TorrentID:    req.TorrentID,
+		Provider:     req.Provider,
+		SearchQuery:  req.SearchQuery,
 		Category:     req.Category,
+		Size:         requestSize(req),
+		Freeleech:    req.Freeleech,
+		UseWedge:     req.UseWedge,
 		CreatedAt:    time.Now(),
 	}
 
-	created, err := s.downloadService.CreateDownload(r.Context(), download)
+	// The torrent file of a torrent ID is fetched from the provider, or its cache
+	created, err := s.downloadService.CreateDownload(searchContext(r), download)
 	if err != nil {
-		respondWithInternalError(w, "create download", err)
+		switch {
+		case errors.Is(err, providers.ErrInvalidTorrentID) || errors.Is(err, search.ErrProviderNotFound):
+			respondWithValidationError(w, "torrent ID", err)
+		case errors.Is(err, providers.ErrNotFound):
+			respondWithNotFound(w, "torrent", err)
+		case errors.Is(err, providers.ErrRateLimited):
+			respondWithTooManyRequests(w, "search provider rate limit reached", providers.RetryAfter(err), err)
+		case errors.Is(err, downloads.ErrAccountGuard):
+			respondWithConflict(w, "grab would put the MAM account at risk", err)
+		default:
+			respondWithInternalError(w, "create download", err)
+		}
 		return
 	}
 
 	respondWithJSON(w, http.StatusCreated, CreateDownloadResponse{Download: toDTO(created)})
 }
 
+// requestSize parses a download request's size, zero if it has none or it can't be read
+func requestSize(req CreateDownloadRequest) int64 {
+	size, _ := quality.ParseSize(req.Size)
+	return size
+}
+
 // handleListDownloads godoc
 // @Summary List all downloads
 // @Description Get a list of all downloads with their status and progress
@@ -177,6 +242,7 @@ func (s *Server) handleCancelDownload(w http.ResponseWriter, r *http.Request) {
 // @Param id path string true "Download ID (UUID)"
 // @Success 200 "Download organized successfully"
 // @Failure 400 {object} ErrorResponse "Invalid download ID"
+// @Failure 409 {object} ErrorResponse "Download is already being organized or cannot be organized in its current status"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /downloads/{id}/organize [post]
 func (s *Server) handleOrganize(w http.ResponseWriter, r *http.Request) {
@@ -192,6 +258,14 @@ func (s *Server) handleOrganize(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := s.downloadService.OrganizeDownload(r.Context(), id); err != nil {
+		if errors.Is(err, downloads.ErrOrganizeInProgress) {
+			respondWithConflict(w, "download is already being organized", err)
+			return
+		}
+		if errors.Is(err, downloads.ErrInvalidTransition) {
+			respondWithConflict(w, "download cannot be organized in its current status", err)
+			return
+		}
 		respondWithInternalError(w, "organize download", err)
 		return
 	}
@@ -199,6 +273,234 @@ func (s *Server) handleOrganize(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// handleRetryDownload godoc
+// @Summary Retry a failed download
+// @Description Retry organizing a failed download right away with a fresh attempt budget. If qBittorrent no longer has the torrent, it is re-added from the stored torrent URL or magnet link and organized once it completes.
+// @Tags downloads
+// @Produce json
+// @Param id path string true "Download ID (UUID)"
+// @Success 202 {object} RetryDownloadResponse
+// @Failure 400 {object} ErrorResponse "Invalid download ID"
+// @Failure 404 {object} ErrorResponse "Download not found"
+// @Failure 409 {object} ErrorResponse "Download cannot be retried"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /downloads/{id}/retry [post]
+func (s *Server) handleRetryDownload(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		respondWithValidationError(w, "download ID", nil)
+		return
+	}
+
+	if err := validateUUID(id); err != nil {
+		respondWithValidationError(w, "download ID", err)
+		return
+	}
+
+	download, readded, err := s.downloadService.RetryDownload(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, downloads.ErrNotRetryable):
+			respondWithConflict(w, "download cannot be retried", err)
+		case errors.Is(err, persistence.ErrDownloadNotFound):
+			respondWithNotFound(w, "download", err)
+		default:
+			respondWithInternalError(w, "retry download", err)
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusAccepted, RetryDownloadResponse{Download: toDTO(download), Readded: readded})
+}
+
+// handleReaddDownload godoc
+// @Summary Re-add a missing download
+// @Description Re-add the torrent of a download marked missing to qBittorrent, from the stored torrent file or else its torrent URL or magnet link. The download goes back to queued and is organized once it completes.
+// @Tags downloads
+// @Produce json
+// @Param id path string true "Download ID (UUID)"
+// @Success 202 {object} GetDownloadResponse
+// @Failure 400 {object} ErrorResponse "Invalid download ID"
+// @Failure 404 {object} ErrorResponse "Download not found"
+// @Failure 409 {object} ErrorResponse "Download is not missing or cannot be re-added"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /downloads/{id}/readd [post]
+func (s *Server) handleReaddDownload(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		respondWithValidationError(w, "download ID", nil)
+		return
+	}
+
+	if err := validateUUID(id); err != nil {
+		respondWithValidationError(w, "download ID", err)
+		return
+	}
+
+	download, err := s.downloadService.ReaddDownload(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, downloads.ErrNotMissing):
+			respondWithConflict(w, "download is not missing", err)
+		case errors.Is(err, downloads.ErrNoTorrentSource):
+			respondWithConflict(w, "download cannot be re-added", err)
+		case errors.Is(err, persistence.ErrDownloadNotFound):
+			respondWithNotFound(w, "download", err)
+		default:
+			respondWithInternalError(w, "re-add download", err)
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusAccepted, GetDownloadResponse{Download: toDTO(download)})
+}
+
+// handleDiscardDownload godoc
+// @Summary Discard a missing download
+// @Description Delete a download marked missing whose torrent was removed from qBittorrent
+// @Tags downloads
+// @Param id path string true "Download ID (UUID)"
+// @Success 204 "Download discarded"
+// @Failure 400 {object} ErrorResponse "Invalid download ID"
+// @Failure 404 {object} ErrorResponse "Download not found"
+// @Failure 409 {object} ErrorResponse "Download is not missing"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /downloads/{id}/discard [post]
+func (s *Server) handleDiscardDownload(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		respondWithValidationError(w, "download ID", nil)
+		return
+	}
+
+	if err := validateUUID(id); err != nil {
+		respondWithValidationError(w, "download ID", err)
+		return
+	}
+
+	if err := s.downloadService.DiscardDownload(r.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, downloads.ErrNotMissing):
+			respondWithConflict(w, "download is not missing", err)
+		case errors.Is(err, persistence.ErrDownloadNotFound):
+			respondWithNotFound(w, "download", err)
+		default:
+			respondWithInternalError(w, "discard download", err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleListOrganizeAttempts godoc
+// @Summary List organization attempts
+// @Description List a download's organization attempts, oldest first, including failures, whether they were transient and when a scheduled retry runs
+// @Tags downloads
+// @Produce json
+// @Param id path string true "Download ID (UUID)"
+// @Success 200 {object} ListOrganizeAttemptsResponse
+// @Failure 400 {object} ErrorResponse "Invalid download ID"
+// @Failure 404 {object} ErrorResponse "Download not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /downloads/{id}/attempts [get]
+func (s *Server) handleListOrganizeAttempts(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		respondWithValidationError(w, "download ID", nil)
+		return
+	}
+
+	if err := validateUUID(id); err != nil {
+		respondWithValidationError(w, "download ID", err)
+		return
+	}
+
+	attempts, err := s.downloadService.ListOrganizeAttempts(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, persistence.ErrDownloadNotFound) {
+			respondWithNotFound(w, "download", err)
+			return
+		}
+		respondWithInternalError(w, "list organization attempts", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ListOrganizeAttemptsResponse{Attempts: organizeAttemptsToDTOList(attempts)})
+}
+
+// handleGetDownloadHistory godoc
+// @Summary Get download history
+// @Description List a download's status transitions, oldest first, with what made each one (monitor, user or api) and why
+// @Tags downloads
+// @Produce json
+// @Param id path string true "Download ID (UUID)"
+// @Success 200 {object} DownloadHistoryResponse
+// @Failure 400 {object} ErrorResponse "Invalid download ID"
+// @Failure 404 {object} ErrorResponse "Download not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /downloads/{id}/history [get]
+func (s *Server) handleGetDownloadHistory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		respondWithValidationError(w, "download ID", nil)
+		return
+	}
+
+	if err := validateUUID(id); err != nil {
+		respondWithValidationError(w, "download ID", err)
+		return
+	}
+
+	events, err := s.downloadService.GetDownloadHistory(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, persistence.ErrDownloadNotFound) {
+			respondWithNotFound(w, "download", err)
+			return
+		}
+		respondWithInternalError(w, "get download history", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, DownloadHistoryResponse{Events: downloadEventsToDTOList(events)})
+}
+
+// handleListTorrentReplacements godoc
+// @Summary List replaced torrents
+// @Description List the torrents a download stalled on and had swapped for an alternative release, oldest first
+// @Tags downloads
+// @Produce json
+// @Param id path string true "Download ID (UUID)"
+// @Success 200 {object} ListTorrentReplacementsResponse
+// @Failure 400 {object} ErrorResponse "Invalid download ID"
+// @Failure 404 {object} ErrorResponse "Download not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /downloads/{id}/replacements [get]
+func (s *Server) handleListTorrentReplacements(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		respondWithValidationError(w, "download ID", nil)
+		return
+	}
+
+	if err := validateUUID(id); err != nil {
+		respondWithValidationError(w, "download ID", err)
+		return
+	}
+
+	replacements, err := s.downloadService.ListTorrentReplacements(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, persistence.ErrDownloadNotFound) {
+			respondWithNotFound(w, "download", err)
+			return
+		}
+		respondWithInternalError(w, "list replaced torrents", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ListTorrentReplacementsResponse{Replacements: torrentReplacementsToDTOList(replacements)})
+}
+
 // handleGetConfig godoc
 // @Summary Get a configuration value
 // @Description Get the value of a specific configuration key
@@ -295,6 +597,84 @@ func (s *Server) handleUpdateConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if key == "paths.remote_mappings" {
+		if _, err := fileutil.ParsePathMappings(req.Value); err != nil {
+			respondWithBadRequest(w, "invalid path mappings", err)
+			return
+		}
+	}
+
+	if key == quality.DefaultProfileKey && req.Value != "" {
+		id, err := strconv.ParseInt(req.Value, 10, 64)
+		if err == nil {
+			_, err = s.qualityService.Get(r.Context(), id)
+		}
+		if err != nil {
+			respondWithBadRequest(w, "search.quality_profile must be an existing quality profile ID or empty", err)
+			return
+		}
+	}
+
+	if key == "mam.requests_per_minute" {
+		if n, err := strconv.Atoi(req.Value); err != nil || n < 0 {
+			respondWithBadRequest(w, "mam.requests_per_minute must be a non-negative integer", err)
+			return
+		}
+	}
+
+	if key == "mam.account_guard" {
+		switch req.Value {
+		case downloads.AccountGuardOff, downloads.AccountGuardWarn, downloads.AccountGuardRefuse:
+		default:
+			respondWithBadRequest(w, "mam.account_guard must be off, warn or refuse", nil)
+			return
+		}
+	}
+
+	if key == "mam.min_ratio" {
+		if ratio, err := strconv.ParseFloat(req.Value, 64); err != nil || ratio < 0 {
+			respondWithBadRequest(w, "mam.min_ratio must be a non-negative number", err)
+			return
+		}
+	}
+
+	if key == "mam.wedge_policy" {
+		switch req.Value {
+		case downloads.WedgePolicyNever, downloads.WedgePolicyAlways, downloads.WedgePolicyOverSize:
+		default:
+			respondWithBadRequest(w, "mam.wedge_policy must be never, always or over_size", nil)
+			return
+		}
+	}
+
+	if key == "mam.wedge_min_size_mb" {
+		if n, err := strconv.ParseInt(req.Value, 10, 64); err != nil || n < 0 {
+			respondWithBadRequest(w, "mam.wedge_min_size_mb must be a non-negative integer", err)
+			return
+		}
+	}
+
+	if key == "wanted.search_interval_minutes" {
+		if n, err := strconv.ParseInt(req.Value, 10, 64); err != nil || n < 0 {
+			respondWithBadRequest(w, "wanted.search_interval_minutes must be a non-negative integer", err)
+			return
+		}
+	}
+
+	if key == "series.check_interval_minutes" {
+		if n, err := strconv.ParseInt(req.Value, 10, 64); err != nil || n < 0 {
+			respondWithBadRequest(w, "series.check_interval_minutes must be a non-negative integer", err)
+			return
+		}
+	}
+
+	if key == "torznab.indexers" {
+		if _, err := providers.ParseTorznabIndexers(req.Value); err != nil {
+			respondWithBadRequest(w, "invalid Torznab indexers", err)
+			return
+		}
+	}
+
 	if err := s.configService.Set(r.Context(), key, req.Value); err != nil {
 		respondWithInternalError(w, "update config", err)
 		return
@@ -305,76 +685,879 @@ func (s *Server) handleUpdateConfig(w http.ResponseWriter, r *http.Request) {
 
 // handleSearch godoc
 // @Summary Search for audiobooks
-// @Description Search for audiobooks on configured providers (e.g., MyAnonamouse)
+// @Description Search all enabled providers (e.g., MyAnonamouse) at once. Providers that fail are listed in failed_providers; the search only fails if all of them do.
 // @Tags search
 // @Produce json
-// @Param q query string true "Search query" minlength(2)
+// @Param q query string false "Search query, required unless title or author is given" minlength(2)
+// @Param title query string false "Book title, for providers that search by title"
+// @Param author query string false "Book author, for providers that search by author"
+// @Param media_type query string false "Media type: audiobook (default), ebook or all"
+// @Param search_type query string false "MAM search type: all, active, inactive, fl, fl-VIP, VIP or nVIP"
+// @Param search_in query string false "Comma-separated fields to search: title, author, series, narrator, description, filenames, fileTypes, tags"
+// @Param language query string false "Comma-separated MAM language IDs"
+// @Param category query string false "Comma-separated MAM category IDs"
+// @Param file_type query string false "File type, e.g. m4b or epub"
+// @Param sort query string false "Sort order, e.g. seedersDesc or dateDesc"
+// @Param page query int false "Page number, starting at 1"
+// @Param offset query int false "Results to skip; can't be combined with page"
+// @Param limit query int false "Results per page (max 100)"
+// @Param profile query string false "Quality profile ID that scores and ranks the results, or none; defaults to search.quality_profile"
+// @Param Cache-Control header string false "no-cache skips cached results and searches the providers again"
 // @Success 200 {object} SearchResponse
 // @Failure 400 {object} ErrorResponse "Invalid query parameter"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /search [get]
 func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query().Get("q")
-	if query == "" {
+	query := providers.Query{
+		Text:   strings.TrimSpace(r.URL.Query().Get("q")),
+		Title:  strings.TrimSpace(r.URL.Query().Get("title")),
+		Author: strings.TrimSpace(r.URL.Query().Get("author")),
+	}
+	if query.IsEmpty() {
 		respondWithValidationError(w, "query parameter 'q'", nil)
 		return
 	}
 
-	if len(query) < 2 {
+	if query.Text != "" && len(query.Text) < 2 {
 		respondWithValidationError(w, "query length", nil)
 		return
 	}
 
-	results, err := s.searchService.Search(r.Context(), query)
+	mediaType, err := parseSearchMediaType(r.URL.Query().Get("media_type"))
 	if err != nil {
-		respondWithInternalError(w, "search", err)
+		respondWithValidationError(w, "query parameter 'media_type'", err)
 		return
 	}
+	query.MediaType = mediaType
 
-	respondWithJSON(w, http.StatusOK, SearchResponse{
-		Results: searchResultsToDTOList(results),
-		Count:   len(results),
-	})
-}
+	if err := parseSearchFilters(r.URL.Query(), &query); err != nil {
+		respondWithValidationError(w, "search filters", err)
+		return
+	}
 
-// handleTestConnection godoc
-// @Summary Test search provider connection
-// @Description Test connectivity to the configured search provider (e.g., MyAnonamouse)
-// @Tags search
-// @Produce json
-// @Success 200 {object} TestConnectionResponse
-// @Router /search/test [post]
-func (s *Server) handleTestConnection(w http.ResponseWriter, r *http.Request) {
-	err := s.searchService.TestConnection(r.Context())
+	profile, err := s.searchQualityProfile(r.Context(), r.URL.Query().Get("profile"))
 	if err != nil {
-		respondWithJSON(w, http.StatusOK, TestConnectionResponse{
-			Success: false,
-			Message: err.Error(),
-		})
+		if errors.Is(err, persistence.ErrQualityProfileNotFound) {
+			respondWithValidationError(w, "query parameter 'profile'", err)
+			return
+		}
+		respondWithInternalError(w, "load quality profile", err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, TestConnectionResponse{
-		Success: true,
-		Message: "Connection successful",
-	})
-}
-
-// handleTestQBittorrentConnection godoc
-// @Summary Test qBittorrent connection
-// @Description Test connectivity to the configured qBittorrent instance
-// @Tags qbittorrent
-// @Produce json
-// @Success 200 {object} TestConnectionResponse
-// @Router /qbittorrent/test [get]
-func (s *Server) handleTestQBittorrentConnection(w http.ResponseWriter, r *http.Request) {
-	// Get qBittorrent config from ConfigService
-	url, err := s.configService.Get(r.Context(), "qbittorrent.url")
-	if err != nil || url == "" {
-		respondWithJSON(w, http.StatusOK, TestConnectionResponse{
-			Success: false,
-			Message: "qBittorrent URL not configured",
-		})
+	results, err := s.searchService.Search(searchContext(r), query)
+	if err != nil {
+		respondWithInternalError(w, "search", err)
+		return
+	}
+
+	resp := SearchResponse{
+		Results:         searchResultsToDTOList(results.Results),
+		Count:           len(results.Results),
+		Total:           results.Total,
+		TotalFound:      results.TotalFound,
+		Offset:          query.Offset,
+		Limit:           query.PageSize(),
+		FailedProviders: providerErrorsToDTOList(results.Failures),
+	}
+	if !results.CachedAt.IsZero() {
+		resp.CachedAt = &results.CachedAt
+	}
+	if profile != nil {
+		ranked := quality.Rank(profile, results.Results)
+		// An explicit sort order keeps the provider's order; results are still scored
+		if query.Sort != "" {
+			for i, r := range results.Results {
+				ranked[i] = quality.Ranked{Result: r, Score: quality.Evaluate(profile, r)}
+			}
+		}
+		resp.Results = rankedResultsToDTOList(ranked)
+		profileDTO := qualityProfileToDTO(profile)
+		resp.QualityProfile = &profileDTO
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// searchContext returns the request's context, bypassing the search cache when the client
+// asks for fresh results with Cache-Control: no-cache, no-store or max-age=0, or Pragma: no-cache
+func searchContext(r *http.Request) context.Context {
+	for _, directive := range strings.Split(r.Header.Get("Cache-Control"), ",") {
+		switch strings.ToLower(strings.TrimSpace(directive)) {
+		case "no-cache", "no-store", "max-age=0":
+			return search.BypassCache(r.Context())
+		}
+	}
+	if strings.EqualFold(strings.TrimSpace(r.Header.Get("Pragma")), "no-cache") {
+		return search.BypassCache(r.Context())
+	}
+	return r.Context()
+}
+
+// searchQualityProfile returns the quality profile a search asked for: the profile with the
+// given ID, none for "none", or the default profile when value is empty
+func (s *Server) searchQualityProfile(ctx context.Context, value string) (*models.QualityProfile, error) {
+	if s.qualityService == nil || value == "none" {
+		return nil, nil
+	}
+	if value == "" {
+		return s.qualityService.Default(ctx)
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q is not a profile ID", persistence.ErrQualityProfileNotFound, value)
+	}
+	return s.qualityService.Get(ctx, id)
+}
+
+// handleListSearchProviders godoc
+// @Summary List search providers
+// @Description List the enabled search providers and what they support
+// @Tags search
+// @Produce json
+// @Success 200 {object} ListSearchProvidersResponse
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /search/providers [get]
+func (s *Server) handleListSearchProviders(w http.ResponseWriter, r *http.Request) {
+	ps, err := s.searchService.Providers(r.Context())
+	if err != nil {
+		respondWithInternalError(w, "list search providers", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ListSearchProvidersResponse{Providers: searchProvidersToDTOList(ps)})
+}
+
+// handleClearSearchCache godoc
+// @Summary Clear the search cache
+// @Description Drop every cached search and .torrent file, so the next requests go to the providers
+// @Tags search
+// @Success 204 "Cache cleared"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /search/cache [delete]
+func (s *Server) handleClearSearchCache(w http.ResponseWriter, r *http.Request) {
+	if err := s.searchService.ClearCache(r.Context()); err != nil {
+		respondWithInternalError(w, "clear search cache", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRegisterSeedboxIP godoc
+// @Summary Register the seedbox IP with MyAnonamouse
+// @Description Register this server's public IP as the seedbox IP of a dynamic seedbox MAM session. MAM allows one change an hour.
+// @Tags search
+// @Produce json
+// @Success 200 {object} RegisterSeedboxIPResponse
+// @Failure 400 {object} ErrorResponse "MyAnonamouse is not configured"
+// @Failure 429 {object} ErrorResponse "The IP was changed too recently"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /search/seedbox [post]
+func (s *Server) handleRegisterSeedboxIP(w http.ResponseWriter, r *http.Request) {
+	registration, err := s.searchService.RegisterSeedboxIP(r.Context())
+	if err != nil {
+		switch {
+		case errors.Is(err, search.ErrProviderNotFound):
+			respondWithBadRequest(w, "MyAnonamouse is not configured", err)
+		case errors.Is(err, providers.ErrRateLimited):
+			respondWithTooManyRequests(w, "seedbox IP changed too recently", providers.RetryAfter(err), err)
+		default:
+			respondWithInternalError(w, "register seedbox IP", err)
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, RegisterSeedboxIPResponse{
+		Changed: registration.Changed,
+		Message: registration.Message,
+		IP:      registration.IP,
+		ASN:     registration.ASN,
+		AS:      registration.AS,
+	})
+}
+
+// handleGetAccount godoc
+// @Summary Get the MyAnonamouse account status
+// @Description Get the MAM account's ratio, transfer totals, bonus points, freeleech wedges and unsatisfied torrents
+// @Tags search
+// @Produce json
+// @Success 200 {object} AccountResponse
+// @Failure 400 {object} ErrorResponse "MyAnonamouse is not configured"
+// @Failure 429 {object} ErrorResponse "Search provider rate limit reached; see Retry-After"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /search/account [get]
+func (s *Server) handleGetAccount(w http.ResponseWriter, r *http.Request) {
+	stats, err := s.searchService.AccountStats(r.Context())
+	if err != nil {
+		switch {
+		case errors.Is(err, search.ErrProviderNotFound):
+			respondWithBadRequest(w, "MyAnonamouse is not configured", err)
+		case errors.Is(err, providers.ErrRateLimited):
+			respondWithTooManyRequests(w, "search provider rate limit reached", providers.RetryAfter(err), err)
+		default:
+			respondWithInternalError(w, "get MAM account", err)
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, AccountResponse{Account: toAccountDTO(stats)})
+}
+
+// handleTestConnection godoc
+// @Summary Test search provider connections
+// @Description Test connectivity to every enabled search provider (e.g., MyAnonamouse). Succeeds only if all of them connect.
+// @Tags search
+// @Produce json
+// @Success 200 {object} TestConnectionResponse
+// @Router /search/test [post]
+func (s *Server) handleTestConnection(w http.ResponseWriter, r *http.Request) {
+	results, err := s.searchService.TestConnection(r.Context())
+	if err != nil {
+		respondWithJSON(w, http.StatusOK, TestConnectionResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	connections := providerConnectionsToDTOList(results)
+	var failures []string
+	for _, c := range connections {
+		if !c.Success {
+			failures = append(failures, c.Provider+": "+c.Message)
+		}
+	}
+	if len(failures) > 0 {
+		respondWithJSON(w, http.StatusOK, TestConnectionResponse{
+			Success:   false,
+			Message:   strings.Join(failures, "; "),
+			Providers: connections,
+		})
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, TestConnectionResponse{
+		Success:   true,
+		Message:   "Connection successful",
+		Providers: connections,
+	})
+}
+
+// handleListQualityProfiles godoc
+// @Summary List quality profiles
+// @Description List the quality profiles that rank search results and pick releases automatically
+// @Tags quality-profiles
+// @Produce json
+// @Success 200 {object} ListQualityProfilesResponse
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /quality-profiles [get]
+func (s *Server) handleListQualityProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := s.qualityService.List(r.Context())
+	if err != nil {
+		respondWithInternalError(w, "list quality profiles", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ListQualityProfilesResponse{Profiles: qualityProfilesToDTOList(profiles)})
+}
+
+// handleCreateQualityProfile godoc
+// @Summary Create a quality profile
+// @Description Create a quality profile of preferred formats, languages and uploaders, size and seeder limits and a freeleech bonus
+// @Tags quality-profiles
+// @Accept json
+// @Produce json
+// @Param request body CreateQualityProfileRequest true "Quality profile"
+// @Success 201 {object} CreateQualityProfileResponse
+// @Failure 400 {object} ErrorResponse "Invalid request body or validation failed"
+// @Failure 409 {object} ErrorResponse "A profile with this name already exists"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /quality-profiles [post]
+func (s *Server) handleCreateQualityProfile(w http.ResponseWriter, r *http.Request) {
+	var req CreateQualityProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithBadRequest(w, "invalid request body", err)
+		return
+	}
+
+	profile := qualityProfileFromRequest(req)
+	if err := s.qualityService.Create(r.Context(), profile); err != nil {
+		respondWithQualityProfileError(w, "create quality profile", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, CreateQualityProfileResponse{Profile: qualityProfileToDTO(profile)})
+}
+
+// handleGetQualityProfile godoc
+// @Summary Get a quality profile
+// @Tags quality-profiles
+// @Produce json
+// @Param id path int true "Quality profile ID"
+// @Success 200 {object} GetQualityProfileResponse
+// @Failure 400 {object} ErrorResponse "Invalid profile ID"
+// @Failure 404 {object} ErrorResponse "Quality profile not found"
+// @Router /quality-profiles/{id} [get]
+func (s *Server) handleGetQualityProfile(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseQualityProfileID(w, r)
+	if !ok {
+		return
+	}
+
+	profile, err := s.qualityService.Get(r.Context(), id)
+	if err != nil {
+		respondWithQualityProfileError(w, "get quality profile", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, GetQualityProfileResponse{Profile: qualityProfileToDTO(profile)})
+}
+
+// handleUpdateQualityProfile godoc
+// @Summary Update a quality profile
+// @Description Replace every setting of a quality profile
+// @Tags quality-profiles
+// @Accept json
+// @Produce json
+// @Param id path int true "Quality profile ID"
+// @Param request body UpdateQualityProfileRequest true "Quality profile"
+// @Success 200 {object} UpdateQualityProfileResponse
+// @Failure 400 {object} ErrorResponse "Invalid request body or validation failed"
+// @Failure 404 {object} ErrorResponse "Quality profile not found"
+// @Failure 409 {object} ErrorResponse "A profile with this name already exists"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /quality-profiles/{id} [put]
+func (s *Server) handleUpdateQualityProfile(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseQualityProfileID(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateQualityProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithBadRequest(w, "invalid request body", err)
+		return
+	}
+
+	existing, err := s.qualityService.Get(r.Context(), id)
+	if err != nil {
+		respondWithQualityProfileError(w, "get quality profile", err)
+		return
+	}
+
+	profile := qualityProfileFromRequest(CreateQualityProfileRequest(req))
+	profile.ID = id
+	profile.CreatedAt = existing.CreatedAt
+	if err := s.qualityService.Update(r.Context(), profile); err != nil {
+		respondWithQualityProfileError(w, "update quality profile", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, UpdateQualityProfileResponse{Profile: qualityProfileToDTO(profile)})
+}
+
+// handleDeleteQualityProfile godoc
+// @Summary Delete a quality profile
+// @Description Delete a quality profile. Deleting the default profile leaves search results unranked.
+// @Tags quality-profiles
+// @Param id path int true "Quality profile ID"
+// @Success 204 "Quality profile deleted"
+// @Failure 400 {object} ErrorResponse "Invalid profile ID"
+// @Failure 404 {object} ErrorResponse "Quality profile not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /quality-profiles/{id} [delete]
+func (s *Server) handleDeleteQualityProfile(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseQualityProfileID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.qualityService.Delete(r.Context(), id); err != nil {
+		respondWithQualityProfileError(w, "delete quality profile", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseQualityProfileID reads the profile ID path parameter, responding with an error if
+// it is invalid
+func parseQualityProfileID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		respondWithValidationError(w, "quality profile ID", err)
+		return 0, false
+	}
+	return id, true
+}
+
+// respondWithQualityProfileError maps quality profile errors to responses
+func respondWithQualityProfileError(w http.ResponseWriter, operation string, err error) {
+	switch {
+	case errors.Is(err, quality.ErrInvalidProfile):
+		respondWithBadRequest(w, "invalid quality profile", err)
+	case errors.Is(err, persistence.ErrQualityProfileNotFound):
+		respondWithNotFound(w, "quality profile", err)
+	case errors.Is(err, persistence.ErrQualityProfileExists):
+		respondWithConflict(w, "a quality profile with this name already exists", err)
+	default:
+		respondWithInternalError(w, operation, err)
+	}
+}
+
+func qualityProfileFromRequest(req CreateQualityProfileRequest) *models.QualityProfile {
+	return &models.QualityProfile{
+		Name:               req.Name,
+		PreferredFormats:   req.PreferredFormats,
+		MinSizeMB:          req.MinSizeMB,
+		MaxSizeMB:          req.MaxSizeMB,
+		PreferredLanguages: req.PreferredLanguages,
+		FreeleechBonus:     req.FreeleechBonus,
+		MinSeeders:         req.MinSeeders,
+		PreferredUploaders: req.PreferredUploaders,
+		BlockedUploaders:   req.BlockedUploaders,
+	}
+}
+
+// handleListWantedBooks godoc
+// @Summary List wanted books
+// @Description List the books on the wanted list, newest first, with the outcome of their last search
+// @Tags wanted
+// @Produce json
+// @Success 200 {object} ListWantedBooksResponse
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /wanted [get]
+func (s *Server) handleListWantedBooks(w http.ResponseWriter, r *http.Request) {
+	books, err := s.wantedService.List(r.Context())
+	if err != nil {
+		respondWithInternalError(w, "list wanted books", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ListWantedBooksResponse{Books: wantedBooksToDTOList(books)})
+}
+
+// handleCreateWantedBook godoc
+// @Summary Add a wanted book
+// @Description Add a book to the wanted list. It is searched for right away and then on the wanted.search_interval_minutes schedule until a release is grabbed.
+// @Tags wanted
+// @Accept json
+// @Produce json
+// @Param request body CreateWantedBookRequest true "Wanted book"
+// @Success 201 {object} WantedBookResponse
+// @Failure 400 {object} ErrorResponse "Invalid request body or validation failed"
+// @Failure 409 {object} ErrorResponse "The book is already on the wanted list"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /wanted [post]
+func (s *Server) handleCreateWantedBook(w http.ResponseWriter, r *http.Request) {
+	var req CreateWantedBookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithBadRequest(w, "invalid request body", err)
+		return
+	}
+
+	book := wantedBookFromRequest(req)
+	if err := s.wantedService.Create(r.Context(), book); err != nil {
+		respondWithWantedBookError(w, "create wanted book", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, WantedBookResponse{Book: wantedBookToDTO(book)})
+}
+
+// handleGetWantedBook godoc
+// @Summary Get a wanted book
+// @Tags wanted
+// @Produce json
+// @Param id path int true "Wanted book ID"
+// @Success 200 {object} WantedBookResponse
+// @Failure 400 {object} ErrorResponse "Invalid wanted book ID"
+// @Failure 404 {object} ErrorResponse "Wanted book not found"
+// @Router /wanted/{id} [get]
+func (s *Server) handleGetWantedBook(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWantedBookID(w, r)
+	if !ok {
+		return
+	}
+
+	book, err := s.wantedService.Get(r.Context(), id)
+	if err != nil {
+		respondWithWantedBookError(w, "get wanted book", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, WantedBookResponse{Book: wantedBookToDTO(book)})
+}
+
+// handleUpdateWantedBook godoc
+// @Summary Update a wanted book
+// @Description Replace a wanted book's details. Setting a grabbed book's status back to wanted unlinks its download and searches for it again.
+// @Tags wanted
+// @Accept json
+// @Produce json
+// @Param id path int true "Wanted book ID"
+// @Param request body UpdateWantedBookRequest true "Wanted book"
+// @Success 200 {object} WantedBookResponse
+// @Failure 400 {object} ErrorResponse "Invalid request body or validation failed"
+// @Failure 404 {object} ErrorResponse "Wanted book not found"
+// @Failure 409 {object} ErrorResponse "The book is already on the wanted list"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /wanted/{id} [put]
+func (s *Server) handleUpdateWantedBook(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWantedBookID(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateWantedBookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithBadRequest(w, "invalid request body", err)
+		return
+	}
+
+	existing, err := s.wantedService.Get(r.Context(), id)
+	if err != nil {
+		respondWithWantedBookError(w, "get wanted book", err)
+		return
+	}
+
+	book := wantedBookFromRequest(req.CreateWantedBookRequest)
+	book.ID = id
+	book.Status = existing.Status
+	if req.Status != "" {
+		book.Status = models.WantedStatus(req.Status)
+	}
+	book.DownloadID = existing.DownloadID
+	book.LastSearchedAt = existing.LastSearchedAt
+	book.LastError = existing.LastError
+	book.FollowedSeriesID = existing.FollowedSeriesID
+	book.CreatedAt = existing.CreatedAt
+	if err := s.wantedService.Update(r.Context(), book); err != nil {
+		respondWithWantedBookError(w, "update wanted book", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, WantedBookResponse{Book: wantedBookToDTO(book)})
+}
+
+// handleDeleteWantedBook godoc
+// @Summary Remove a wanted book
+// @Description Remove a book from the wanted list. A download already grabbed for it is kept.
+// @Tags wanted
+// @Param id path int true "Wanted book ID"
+// @Success 204 "Wanted book removed"
+// @Failure 400 {object} ErrorResponse "Invalid wanted book ID"
+// @Failure 404 {object} ErrorResponse "Wanted book not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /wanted/{id} [delete]
+func (s *Server) handleDeleteWantedBook(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWantedBookID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.wantedService.Delete(r.Context(), id); err != nil {
+		respondWithWantedBookError(w, "delete wanted book", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSearchWantedBook godoc
+// @Summary Search for a wanted book now
+// @Description Search every provider for a wanted book without waiting for its schedule and grab the release its quality profile ranks best. A search that finds nothing still succeeds, with last_error saying so.
+// @Tags wanted
+// @Produce json
+// @Param id path int true "Wanted book ID"
+// @Success 200 {object} WantedBookResponse
+// @Failure 400 {object} ErrorResponse "Invalid wanted book ID"
+// @Failure 404 {object} ErrorResponse "Wanted book not found"
+// @Failure 409 {object} ErrorResponse "A release of the book was already grabbed"
+// @Failure 500 {object} ErrorResponse "Search or adding the release failed"
+// @Router /wanted/{id}/search [post]
+func (s *Server) handleSearchWantedBook(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWantedBookID(w, r)
+	if !ok {
+		return
+	}
+
+	book, err := s.wantedService.Search(r.Context(), id)
+	if err != nil {
+		respondWithWantedBookError(w, "search for wanted book", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, WantedBookResponse{Book: wantedBookToDTO(book)})
+}
+
+// parseWantedBookID reads the wanted book ID path parameter, responding with an error if it
+// is invalid
+func parseWantedBookID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		respondWithValidationError(w, "wanted book ID", err)
+		return 0, false
+	}
+	return id, true
+}
+
+// respondWithWantedBookError maps wanted list errors to responses
+func respondWithWantedBookError(w http.ResponseWriter, operation string, err error) {
+	switch {
+	case errors.Is(err, wanted.ErrInvalidWantedBook):
+		respondWithBadRequest(w, "invalid wanted book", err)
+	case errors.Is(err, persistence.ErrWantedBookNotFound):
+		respondWithNotFound(w, "wanted book", err)
+	case errors.Is(err, persistence.ErrWantedBookExists):
+		respondWithConflict(w, "this book is already on the wanted list", err)
+	case errors.Is(err, wanted.ErrAlreadyGrabbed):
+		respondWithConflict(w, "a release of this book was already grabbed", err)
+	default:
+		respondWithInternalError(w, operation, err)
+	}
+}
+
+func wantedBookFromRequest(req CreateWantedBookRequest) *models.WantedBook {
+	return &models.WantedBook{
+		Title:            req.Title,
+		Author:           req.Author,
+		Series:           req.Series,
+		SeriesNumber:     req.SeriesNumber,
+		MediaType:        models.MediaType(req.MediaType),
+		Category:         req.Category,
+		QualityProfileID: req.QualityProfileID,
+	}
+}
+
+// handleListFollowedSeries godoc
+// @Summary List followed series
+// @Description List the series whose missing and newly released entries are added to the wanted list, with the outcome of their last check
+// @Tags series
+// @Produce json
+// @Success 200 {object} ListFollowedSeriesResponse
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /series [get]
+func (s *Server) handleListFollowedSeries(w http.ResponseWriter, r *http.Request) {
+	series, err := s.wantedService.ListSeries(r.Context())
+	if err != nil {
+		respondWithInternalError(w, "list followed series", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ListFollowedSeriesResponse{Series: followedSeriesToDTOList(series)})
+}
+
+// handleFollowSeries godoc
+// @Summary Follow a series
+// @Description Follow a series by its MyAnonamouse series ID. It is checked right away and then on the series.check_interval_minutes schedule; entries that aren't downloaded, in the library or wanted already are added to the wanted list under the series' quality profile.
+// @Tags series
+// @Accept json
+// @Produce json
+// @Param request body FollowSeriesRequest true "Series to follow"
+// @Success 201 {object} FollowedSeriesResponse
+// @Failure 400 {object} ErrorResponse "Invalid request body or validation failed"
+// @Failure 409 {object} ErrorResponse "The series is already followed"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /series [post]
+func (s *Server) handleFollowSeries(w http.ResponseWriter, r *http.Request) {
+	var req FollowSeriesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithBadRequest(w, "invalid request body", err)
+		return
+	}
+
+	series := followedSeriesFromRequest(req)
+	if err := s.wantedService.FollowSeries(r.Context(), series); err != nil {
+		respondWithFollowedSeriesError(w, "follow series", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, FollowedSeriesResponse{Series: followedSeriesToDTO(series)})
+}
+
+// handleGetFollowedSeries godoc
+// @Summary Get a followed series
+// @Tags series
+// @Produce json
+// @Param id path int true "Followed series ID"
+// @Success 200 {object} FollowedSeriesResponse
+// @Failure 400 {object} ErrorResponse "Invalid followed series ID"
+// @Failure 404 {object} ErrorResponse "Followed series not found"
+// @Router /series/{id} [get]
+func (s *Server) handleGetFollowedSeries(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseFollowedSeriesID(w, r)
+	if !ok {
+		return
+	}
+
+	series, err := s.wantedService.GetSeries(r.Context(), id)
+	if err != nil {
+		respondWithFollowedSeriesError(w, "get followed series", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, FollowedSeriesResponse{Series: followedSeriesToDTO(series)})
+}
+
+// handleUpdateFollowedSeries godoc
+// @Summary Update a followed series
+// @Description Replace a followed series' details. Books already queued for it keep theirs.
+// @Tags series
+// @Accept json
+// @Produce json
+// @Param id path int true "Followed series ID"
+// @Param request body UpdateFollowedSeriesRequest true "Followed series"
+// @Success 200 {object} FollowedSeriesResponse
+// @Failure 400 {object} ErrorResponse "Invalid request body or validation failed"
+// @Failure 404 {object} ErrorResponse "Followed series not found"
+// @Failure 409 {object} ErrorResponse "The series is already followed"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /series/{id} [put]
+func (s *Server) handleUpdateFollowedSeries(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseFollowedSeriesID(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateFollowedSeriesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithBadRequest(w, "invalid request body", err)
+		return
+	}
+
+	existing, err := s.wantedService.GetSeries(r.Context(), id)
+	if err != nil {
+		respondWithFollowedSeriesError(w, "get followed series", err)
+		return
+	}
+
+	series := followedSeriesFromRequest(FollowSeriesRequest(req))
+	series.ID = id
+	series.LastCheckedAt = existing.LastCheckedAt
+	series.LastError = existing.LastError
+	series.CreatedAt = existing.CreatedAt
+	if err := s.wantedService.UpdateSeries(r.Context(), series); err != nil {
+		respondWithFollowedSeriesError(w, "update followed series", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, FollowedSeriesResponse{Series: followedSeriesToDTO(series)})
+}
+
+// handleUnfollowSeries godoc
+// @Summary Unfollow a series
+// @Description Stop following a series. Books already queued for it stay on the wanted list.
+// @Tags series
+// @Param id path int true "Followed series ID"
+// @Success 204 "Series unfollowed"
+// @Failure 400 {object} ErrorResponse "Invalid followed series ID"
+// @Failure 404 {object} ErrorResponse "Followed series not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /series/{id} [delete]
+func (s *Server) handleUnfollowSeries(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseFollowedSeriesID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.wantedService.UnfollowSeries(r.Context(), id); err != nil {
+		respondWithFollowedSeriesError(w, "unfollow series", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleCheckFollowedSeries godoc
+// @Summary Check a followed series now
+// @Description Search for a followed series' entries without waiting for its schedule and add the missing ones to the wanted list
+// @Tags series
+// @Produce json
+// @Param id path int true "Followed series ID"
+// @Success 200 {object} CheckFollowedSeriesResponse
+// @Failure 400 {object} ErrorResponse "Invalid followed series ID"
+// @Failure 404 {object} ErrorResponse "Followed series not found"
+// @Failure 500 {object} ErrorResponse "Search or queueing an entry failed"
+// @Router /series/{id}/check [post]
+func (s *Server) handleCheckFollowedSeries(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseFollowedSeriesID(w, r)
+	if !ok {
+		return
+	}
+
+	queued, err := s.wantedService.CheckSeries(r.Context(), id)
+	if err != nil {
+		respondWithFollowedSeriesError(w, "check followed series", err)
+		return
+	}
+
+	series, err := s.wantedService.GetSeries(r.Context(), id)
+	if err != nil {
+		respondWithFollowedSeriesError(w, "get followed series", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, CheckFollowedSeriesResponse{
+		Series: followedSeriesToDTO(series),
+		Queued: wantedBooksToDTOList(queued),
+	})
+}
+
+// parseFollowedSeriesID reads the followed series ID path parameter, responding with an error
+// if it is invalid
+func parseFollowedSeriesID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		respondWithValidationError(w, "followed series ID", err)
+		return 0, false
+	}
+	return id, true
+}
+
+// respondWithFollowedSeriesError maps followed series errors to responses
+func respondWithFollowedSeriesError(w http.ResponseWriter, operation string, err error) {
+	switch {
+	case errors.Is(err, wanted.ErrInvalidFollowedSeries):
+		respondWithBadRequest(w, "invalid followed series", err)
+	case errors.Is(err, persistence.ErrFollowedSeriesNotFound):
+		respondWithNotFound(w, "followed series", err)
+	case errors.Is(err, persistence.ErrFollowedSeriesExists):
+		respondWithConflict(w, "this series is already followed", err)
+	default:
+		respondWithInternalError(w, operation, err)
+	}
+}
+
+func followedSeriesFromRequest(req FollowSeriesRequest) *models.FollowedSeries {
+	return &models.FollowedSeries{
+		Provider:         req.Provider,
+		SeriesID:         req.SeriesID,
+		Name:             req.Name,
+		MediaType:        models.MediaType(req.MediaType),
+		Category:         req.Category,
+		QualityProfileID: req.QualityProfileID,
+		StartAfter:       req.StartAfter,
+	}
+}
+
+// handleTestQBittorrentConnection godoc
+// @Summary Test qBittorrent connection
+// @Description Test connectivity to the configured qBittorrent instance
+// @Tags qbittorrent
+// @Produce json
+// @Success 200 {object} TestConnectionResponse
+// @Router /qbittorrent/test [get]
+func (s *Server) handleTestQBittorrentConnection(w http.ResponseWriter, r *http.Request) {
+	// Get qBittorrent config from ConfigService
+	url, err := s.configService.Get(r.Context(), "qbittorrent.url")
+	if err != nil || url == "" {
+		respondWithJSON(w, http.StatusOK, TestConnectionResponse{
+			Success: false,
+			Message: "qBittorrent URL not configured",
+		})
 		return
 	}
 
@@ -464,6 +1647,55 @@ func (s *Server) handlePreviewPath(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleValidatePathMappings godoc
+// @Summary Validate remote path mappings
+// @Description Check remote path mappings against the local filesystem and qBittorrent's save paths. Validates the configured paths.remote_mappings when no mappings are supplied.
+// @Tags config
+// @Accept json
+// @Produce json
+// @Param request body ValidatePathMappingsRequest false "Mappings to validate"
+// @Success 200 {object} ValidatePathMappingsResponse
+// @Failure 400 {object} ErrorResponse "Invalid request body or mappings"
+// @Router /config/path-mappings/validate [post]
+func (s *Server) handleValidatePathMappings(w http.ResponseWriter, r *http.Request) {
+	var req ValidatePathMappingsRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			respondWithBadRequest(w, "invalid request body", err)
+			return
+		}
+	}
+
+	for i, m := range req.Mappings {
+		if strings.TrimSpace(m.Remote) == "" || strings.TrimSpace(m.Local) == "" {
+			respondWithBadRequest(w, fmt.Sprintf("path mapping %d: remote and local paths are required", i), nil)
+			return
+		}
+	}
+
+	report, err := s.downloadService.ValidatePathMappings(r.Context(), req.Mappings)
+	if err != nil {
+		respondWithBadRequest(w, "invalid path mappings", err)
+		return
+	}
+
+	unmapped := report.UnmappedSavePaths
+	if unmapped == nil {
+		unmapped = []string{}
+	}
+
+	respondWithJSON(w, http.StatusOK, ValidatePathMappingsResponse{
+		Valid:             report.Valid(),
+		Mappings:          pathMappingChecksToDTOList(report.Mappings),
+		UnmappedSavePaths: unmapped,
+		QBittorrentError:  report.QBittorrentError,
+	})
+}
+
+// batchFetchTimeout is how long a batch spends adding downloads, most of it fetching torrent
+// files from providers
+const batchFetchTimeout = 10 * time.Second
+
 // handleBatchCreateDownload godoc
 // @Summary Create multiple downloads in batch
 // @Description Create multiple audiobook downloads in a single request (max 50 items)
@@ -496,6 +1728,11 @@ func (s *Server) handleBatchCreateDownload(w http.ResponseWriter, r *http.Reques
 	var successful []downloadDTO
 	var failed []BatchDownloadError
 
+	// Torrents that can't be fetched in time because of provider rate limits fail with the
+	// wait, rather than the batch outliving the server's write timeout
+	fetchCtx, cancel := context.WithTimeout(searchContext(r), batchFetchTimeout)
+	defer cancel()
+
 	// Process downloads sequentially
 	for i, downloadReq := range req.Downloads {
 		// Validate request
@@ -508,43 +1745,25 @@ func (s *Server) handleBatchCreateDownload(w http.ResponseWriter, r *http.Reques
 			continue
 		}
 
-		// Download torrent file if torrent ID is provided
-		var torrentBytes []byte
-		if downloadReq.TorrentID != "" {
-			torrentID, err := strconv.Atoi(downloadReq.TorrentID)
-			if err != nil {
-				failed = append(failed, BatchDownloadError{
-					Index:   i,
-					Request: downloadReq,
-					Error:   fmt.Sprintf("Invalid torrent ID: %v", err),
-				})
-				continue
-			}
-
-			torrentBytes, err = s.searchService.DownloadTorrent(r.Context(), torrentID)
-			if err != nil {
-				failed = append(failed, BatchDownloadError{
-					Index:   i,
-					Request: downloadReq,
-					Error:   fmt.Sprintf("Failed to download torrent: %v", err),
-				})
-				continue
-			}
-		}
-
 		download := &models.Download{
 			Title:        downloadReq.Title,
 			Author:       downloadReq.Author,
 			Series:       downloadReq.Series,
 			SeriesNumber: downloadReq.SeriesNumber,
+			MediaType:    models.MediaType(downloadReq.MediaType),
 			TorrentURL:   downloadReq.TorrentURL,
 			MagnetLink:   downloadReq.MagnetLink,
-			TorrentBytes: torrentBytes,
+			TorrentID:    downloadReq.TorrentID,
+			Provider:     downloadReq.Provider,
+			SearchQuery:  downloadReq.SearchQuery,
 			Category:     downloadReq.Category,
+			Size:         requestSize(downloadReq),
+			Freeleech:    downloadReq.Freeleech,
+			UseWedge:     downloadReq.UseWedge,
 			CreatedAt:    time.Now(),
 		}
 
-		created, err := s.downloadService.CreateDownload(r.Context(), download)
+		created, err := s.downloadService.CreateDownload(fetchCtx, download)
 		if err != nil {
 			failed = append(failed, BatchDownloadError{
 				Index:   i,
@@ -566,3 +1785,204 @@ func (s *Server) handleBatchCreateDownload(w http.ResponseWriter, r *http.Reques
 		Failed:     failed,
 	})
 }
+
+// handleListImportCandidates godoc
+// @Summary List torrents available for import
+// @Description List qBittorrent torrents in a category and/or tag that no download tracks yet, with a guessed title, author and series for each. Guesses use the torrent name and, unless read_tags is false, the embedded tags of its first audio file.
+// @Tags imports
+// @Produce json
+// @Param category query string false "qBittorrent category to list"
+// @Param tag query string false "qBittorrent tag to list"
+// @Param read_tags query bool false "Read embedded audio tags (default true)"
+// @Success 200 {object} ListImportCandidatesResponse
+// @Failure 400 {object} ErrorResponse "Invalid read_tags value"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /imports/candidates [get]
+func (s *Server) handleListImportCandidates(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	readTags := true
+	if value := query.Get("read_tags"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			respondWithValidationError(w, "read_tags", err)
+			return
+		}
+		readTags = parsed
+	}
+
+	candidates, err := s.downloadService.ListImportCandidates(r.Context(), query.Get("category"), query.Get("tag"), readTags)
+	if err != nil {
+		respondWithInternalError(w, "list import candidates", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ListImportCandidatesResponse{
+		Candidates: importCandidatesToDTOList(candidates),
+		Count:      len(candidates),
+	})
+}
+
+// handleImportTorrents godoc
+// @Summary Import existing torrents
+// @Description Adopt torrents already in qBittorrent as downloads using confirmed metadata (max 500 items). The monitor then tracks and organizes them like any other download.
+// @Tags imports
+// @Accept json
+// @Produce json
+// @Param request body ImportTorrentsRequest true "Torrents to import"
+// @Success 200 {object} ImportTorrentsResponse "Returns imported downloads and failed imports"
+// @Failure 400 {object} ErrorResponse "Invalid request body or batch size exceeded"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /imports [post]
+func (s *Server) handleImportTorrents(w http.ResponseWriter, r *http.Request) {
+	var req ImportTorrentsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithBadRequest(w, "invalid request body", err)
+		return
+	}
+
+	if len(req.Imports) == 0 {
+		respondWithValidationError(w, "imports array", nil)
+		return
+	}
+
+	if len(req.Imports) > 500 {
+		respondWithBadRequest(w, "import size exceeds 500 item limit", nil)
+		return
+	}
+
+	imported := []downloadDTO{}
+	failed := []ImportTorrentError{}
+
+	// Only valid requests reach the service; indexes map its results back to the request
+	var valid []downloads.ImportRequest
+	var validIndexes []int
+	for i, importReq := range req.Imports {
+		if err := validateImportRequest(importReq); err != nil {
+			failed = append(failed, ImportTorrentError{
+				Index: i,
+				Hash:  importReq.Hash,
+				Error: fmt.Sprintf("Validation failed: %v", err),
+			})
+			continue
+		}
+
+		valid = append(valid, downloads.ImportRequest{
+			Hash:         importReq.Hash,
+			Title:        importReq.Title,
+			Author:       importReq.Author,
+			Series:       importReq.Series,
+			SeriesNumber: importReq.SeriesNumber,
+			MediaType:    models.MediaType(importReq.MediaType),
+		})
+		validIndexes = append(validIndexes, i)
+	}
+
+	if len(valid) > 0 {
+		results, err := s.downloadService.ImportTorrents(r.Context(), valid)
+		if err != nil {
+			respondWithInternalError(w, "import torrents", err)
+			return
+		}
+
+		for j, result := range results {
+			i := validIndexes[j]
+			if result.Err != nil {
+				failed = append(failed, ImportTorrentError{
+					Index: i,
+					Hash:  req.Imports[i].Hash,
+					Error: fmt.Sprintf("Failed to import torrent: %v", result.Err),
+				})
+				continue
+			}
+			imported = append(imported, toDTO(result.Download))
+		}
+	}
+
+	sort.Slice(failed, func(a, b int) bool { return failed[a].Index < failed[b].Index })
+
+	log.Printf("Torrent import processed: %d imported, %d failed (total: %d)",
+		len(imported), len(failed), len(req.Imports))
+
+	respondWithJSON(w, http.StatusOK, ImportTorrentsResponse{
+		Imported: imported,
+		Failed:   failed,
+	})
+}
+
+// handleTorrentFinished godoc
+// @Summary Torrent finished webhook
+// @Description Called by qBittorrent's "Run external program on torrent finished" with the torrent's info hash (%I). Checks the download tracking the torrent right away and queues it for organization if it is complete, instead of waiting for the next monitor poll. Authenticated with the webhook.token config value, sent as the X-Webhook-Token header or the token parameter.
+// @Tags webhooks
+// @Produce json
+// @Param hash query string true "Torrent info hash (%I)"
+// @Param token query string false "Webhook token, if not sent as the X-Webhook-Token header"
+// @Success 200 {object} TorrentFinishedResponse "The download after the check"
+// @Failure 400 {object} ErrorResponse "Invalid hash"
+// @Failure 401 {object} ErrorResponse "Missing or wrong token"
+// @Failure 404 {object} ErrorResponse "No download tracks the torrent"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Webhook disabled"
+// @Router /webhooks/torrent-finished [post]
+func (s *Server) handleTorrentFinished(w http.ResponseWriter, r *http.Request) {
+	// Read per request so a changed token applies without a restart
+	expected, _ := s.configService.Get(r.Context(), "webhook.token")
+	if expected == "" {
+		respondWithError(w, http.StatusServiceUnavailable, "webhook is disabled: set webhook.token to enable it", nil)
+		return
+	}
+
+	token := r.Header.Get("X-Webhook-Token")
+	if token == "" {
+		token = r.FormValue("token")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+		respondWithUnauthorized(w, "invalid webhook token")
+		return
+	}
+
+	hash := strings.TrimSpace(r.FormValue("hash"))
+	if err := validateInfoHash(hash); err != nil {
+		respondWithValidationError(w, "hash", err)
+		return
+	}
+
+	download, err := s.monitor.CheckTorrent(r.Context(), hash)
+	if err != nil {
+		if errors.Is(err, persistence.ErrDownloadNotFound) {
+			respondWithNotFound(w, "download", err)
+			return
+		}
+		respondWithInternalError(w, "check torrent", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, TorrentFinishedResponse{Download: toDTO(download)})
+}
+
+// handleMonitorStatus godoc
+// @Summary Monitor status
+// @Description Get what the download monitor is doing: when it last polled qBittorrent and how long that took, how many downloads the last poll checked, changed and failed to check, consecutive polls that couldn't reach qBittorrent, and the organizations currently running.
+// @Tags monitor
+// @Produce json
+// @Success 200 {object} MonitorStatusResponse
+// @Router /monitor/status [get]
+func (s *Server) handleMonitorStatus(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, MonitorStatusResponse{Monitor: monitorStatusToDTO(s.monitor.Status())})
+}
+
+// handleRefreshMonitor godoc
+// @Summary Check downloads now
+// @Description Check all active downloads against qBittorrent right away instead of waiting for the next monitor poll, queueing completed ones for organization. The poll interval restarts from now.
+// @Tags monitor
+// @Success 204 "Downloads checked"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /monitor/refresh [post]
+func (s *Server) handleRefreshMonitor(w http.ResponseWriter, r *http.Request) {
+	if err := s.monitor.Refresh(r.Context()); err != nil {
+		respondWithInternalError(w, "refresh downloads", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
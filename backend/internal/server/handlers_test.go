@@ -7,11 +7,16 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/nathanael/organizr/internal/config"
+	"github.com/nathanael/organizr/internal/downloads"
 	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/persistence"
 )
 
 // Mock download service for testing
@@ -924,3 +929,260 @@ func containsSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+// fakeDownloadRepo serves downloads by ID and torrent hash. Tests calling anything else panic.
+type fakeDownloadRepo struct {
+	persistence.DownloadRepository
+	downloads []*models.Download
+}
+
+func (f *fakeDownloadRepo) GetByID(ctx context.Context, id string) (*models.Download, error) {
+	for _, d := range f.downloads {
+		if d.ID == id {
+			copied := *d
+			return &copied, nil
+		}
+	}
+	return nil, persistence.ErrDownloadNotFound
+}
+
+func (f *fakeDownloadRepo) GetByQBitHash(ctx context.Context, hash string) (*models.Download, error) {
+	for _, d := range f.downloads {
+		if strings.EqualFold(d.QBitHash, hash) {
+			copied := *d
+			return &copied, nil
+		}
+	}
+	return nil, persistence.ErrDownloadNotFound
+}
+
+func (f *fakeDownloadRepo) GetTorrentData(ctx context.Context, id string) ([]byte, error) {
+	return nil, nil
+}
+
+func (f *fakeDownloadRepo) Delete(ctx context.Context, id string) error {
+	for i, d := range f.downloads {
+		if d.ID == id {
+			f.downloads = append(f.downloads[:i], f.downloads[i+1:]...)
+			return nil
+		}
+	}
+	return persistence.ErrDownloadNotFound
+}
+
+func TestHandleDownloadRecovery(t *testing.T) {
+	organizedID := "123e4567-e89b-42d3-a456-426614174000"
+	missingID := "123e4567-e89b-42d3-a456-426614174001"
+	unknownID := "123e4567-e89b-42d3-a456-426614174002"
+
+	tests := []struct {
+		name       string
+		action     string
+		downloadID string
+		wantStatus int
+	}{
+		{
+			name:       "retry returns 400 for invalid UUID",
+			action:     "retry",
+			downloadID: "invalid-uuid",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "retry returns 404 for unknown download",
+			action:     "retry",
+			downloadID: unknownID,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "retry returns 409 for a download that hasn't failed",
+			action:     "retry",
+			downloadID: organizedID,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "re-add returns 400 for invalid UUID",
+			action:     "readd",
+			downloadID: "invalid-uuid",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "re-add returns 404 for unknown download",
+			action:     "readd",
+			downloadID: unknownID,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "re-add returns 409 for a download that isn't missing",
+			action:     "readd",
+			downloadID: organizedID,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "re-add returns 409 for a download with nothing to re-add",
+			action:     "readd",
+			downloadID: missingID,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "discard returns 400 for invalid UUID",
+			action:     "discard",
+			downloadID: "invalid-uuid",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "discard returns 404 for unknown download",
+			action:     "discard",
+			downloadID: unknownID,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "discard returns 409 for a download that isn't missing",
+			action:     "discard",
+			downloadID: organizedID,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "discard deletes a missing download",
+			action:     "discard",
+			downloadID: missingID,
+			wantStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeDownloadRepo{downloads: []*models.Download{
+				{ID: organizedID, Title: "The Final Empire", Status: models.StatusOrganized},
+				{ID: missingID, Title: "The Well of Ascension", Status: models.StatusMissing},
+			}}
+			configSvc := config.NewService(newMockConfigService(map[string]string{}))
+			s := &Server{
+				downloadService: downloads.NewService(nil, nil, repo, nil, nil, downloads.NewDownloadLocks(), configSvc, nil),
+			}
+
+			router := chi.NewRouter()
+			router.Post("/api/downloads/{id}/retry", s.handleRetryDownload)
+			router.Post("/api/downloads/{id}/readd", s.handleReaddDownload)
+			router.Post("/api/downloads/{id}/discard", s.handleDiscardDownload)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/downloads/"+tt.downloadID+"/"+tt.action, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleTorrentFinished(t *testing.T) {
+	knownHash := strings.Repeat("ab", 20)
+	unknownHash := strings.Repeat("cd", 20)
+
+	tests := []struct {
+		name        string
+		configured  string // webhook.token
+		headerToken string
+		formToken   string
+		hash        string
+		wantStatus  int
+	}{
+		{
+			name:       "returns 503 when no token is configured",
+			configured: "",
+			formToken:  "secret",
+			hash:       knownHash,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "returns 401 for a missing token",
+			configured: "secret",
+			hash:       knownHash,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "returns 401 for a wrong token",
+			configured: "secret",
+			formToken:  "guess",
+			hash:       knownHash,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:        "accepts the token as a header",
+			configured:  "secret",
+			headerToken: "secret",
+			hash:        knownHash,
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:       "accepts the token as a parameter",
+			configured: "secret",
+			formToken:  "secret",
+			hash:       knownHash,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "prefers the header to the parameter",
+			configured:  "secret",
+			headerToken: "guess",
+			formToken:   "secret",
+			hash:        knownHash,
+			wantStatus:  http.StatusUnauthorized,
+		},
+		{
+			name:       "returns 400 for an invalid hash",
+			configured: "secret",
+			formToken:  "secret",
+			hash:       "not-a-hash",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "returns 404 for a torrent no download tracks",
+			configured: "secret",
+			formToken:  "secret",
+			hash:       unknownHash,
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configSvc := config.NewService(newMockConfigService(map[string]string{"webhook.token": tt.configured}))
+			// Organized downloads are returned as they are, without asking qBittorrent
+			repo := &fakeDownloadRepo{downloads: []*models.Download{
+				{ID: "dl-1", Title: "The Final Empire", QBitHash: knownHash, Status: models.StatusOrganized},
+			}}
+			s := &Server{
+				configService: configSvc,
+				monitor:       downloads.NewMonitor(nil, nil, repo, nil, nil, downloads.NewDownloadLocks(), configSvc, nil, nil),
+			}
+
+			query := url.Values{"hash": {tt.hash}}
+			if tt.formToken != "" {
+				query.Set("token", tt.formToken)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/webhooks/torrent-finished?"+query.Encode(), nil)
+			if tt.headerToken != "" {
+				req.Header.Set("X-Webhook-Token", tt.headerToken)
+			}
+			w := httptest.NewRecorder()
+
+			s.handleTorrentFinished(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK {
+				var resp TorrentFinishedResponse
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if resp.Download.ID != "dl-1" {
+					t.Errorf("download = %+v, want dl-1", resp.Download)
+				}
+			}
+		})
+	}
+}
@@ -1,5 +1,11 @@
 package server
 
+import (
+	"time"
+
+	"github.com/nathanael/organizr/internal/fileutil"
+)
+
 // API Type Conventions
 //
 // This file defines request and response types following consistent naming patterns:
@@ -29,10 +35,48 @@ type CreateDownloadRequest struct {
 	Author       string `json:"author"`
 	Series       string `json:"series"`
 	SeriesNumber string `json:"series_number,omitempty"`
+	MediaType    string `json:"media_type,omitempty"`
 	TorrentID    string `json:"torrent_id,omitempty"`
+	Provider     string `json:"provider,omitempty"`
 	TorrentURL   string `json:"torrent_url,omitempty"`
 	MagnetLink   string `json:"magnet_link,omitempty"`
 	Category     string `json:"category,omitempty"`
+	SearchQuery  string `json:"search_query,omitempty"`
+	// Size and freeleech of the search result, as the search returned them; used by the MAM
+	// account guard
+	Size      string `json:"size,omitempty"`
+	Freeleech bool   `json:"freeleech,omitempty"`
+	// Spend a MAM freeleech wedge on the torrent; unset follows mam.wedge_policy
+	UseWedge *bool `json:"use_wedge,omitempty"`
+}
+
+type ListImportCandidatesResponse struct {
+	Candidates []importCandidateDTO `json:"candidates"`
+	Count      int                  `json:"count"`
+}
+
+type ImportTorrentRequest struct {
+	Hash         string `json:"hash"`
+	Title        string `json:"title"`
+	Author       string `json:"author"`
+	Series       string `json:"series,omitempty"`
+	SeriesNumber string `json:"series_number,omitempty"`
+	MediaType    string `json:"media_type,omitempty"`
+}
+
+type ImportTorrentsRequest struct {
+	Imports []ImportTorrentRequest `json:"imports"`
+}
+
+type ImportTorrentError struct {
+	Index int    `json:"index"`
+	Hash  string `json:"hash"`
+	Error string `json:"error"`
+}
+
+type ImportTorrentsResponse struct {
+	Imported []downloadDTO        `json:"imported"`
+	Failed   []ImportTorrentError `json:"failed"`
 }
 
 type UpdateConfigRequest struct {
@@ -51,6 +95,31 @@ type GetDownloadResponse struct {
 	Download downloadDTO `json:"download"`
 }
 
+type RetryDownloadResponse struct {
+	Download downloadDTO `json:"download"`
+	Readded  bool        `json:"readded"`
+}
+
+type ListOrganizeAttemptsResponse struct {
+	Attempts []organizeAttemptDTO `json:"attempts"`
+}
+
+type DownloadHistoryResponse struct {
+	Events []downloadEventDTO `json:"events"`
+}
+
+type ListTorrentReplacementsResponse struct {
+	Replacements []torrentReplacementDTO `json:"replacements"`
+}
+
+type TorrentFinishedResponse struct {
+	Download downloadDTO `json:"download"`
+}
+
+type MonitorStatusResponse struct {
+	Monitor monitorStatusDTO `json:"monitor"`
+}
+
 type GetConfigResponse struct {
 	Key   string `json:"key"`
 	Value string `json:"value"`
@@ -70,11 +139,130 @@ type HealthResponse struct {
 type SearchResponse struct {
 	Results []searchResultDTO `json:"results"`
 	Count   int               `json:"count"`
+	// Matches the providers can page through, and all matches; MAM caps how far a
+	// search pages, so total_found can be larger than total
+	Total           int                `json:"total"`
+	TotalFound      int                `json:"total_found"`
+	Offset          int                `json:"offset"`
+	Limit           int                `json:"limit"`
+	FailedProviders []providerErrorDTO `json:"failed_providers"`
+	// When the results were cached; absent when the providers were just searched
+	CachedAt *time.Time `json:"cached_at,omitempty"`
+	// Quality profile that scored and ranked the results, if any
+	QualityProfile *qualityProfileDTO `json:"quality_profile,omitempty"`
+}
+
+// CreateQualityProfileRequest sets every setting of a quality profile; omitted settings
+// are cleared
+type CreateQualityProfileRequest struct {
+	Name               string   `json:"name"`
+	PreferredFormats   []string `json:"preferred_formats"`
+	MinSizeMB          int64    `json:"min_size_mb"`
+	MaxSizeMB          int64    `json:"max_size_mb"`
+	PreferredLanguages []string `json:"preferred_languages"`
+	FreeleechBonus     int      `json:"freeleech_bonus"`
+	MinSeeders         int      `json:"min_seeders"`
+	PreferredUploaders []string `json:"preferred_uploaders"`
+	BlockedUploaders   []string `json:"blocked_uploaders"`
+}
+
+type UpdateQualityProfileRequest CreateQualityProfileRequest
+
+type CreateQualityProfileResponse struct {
+	Profile qualityProfileDTO `json:"profile"`
+}
+
+type GetQualityProfileResponse struct {
+	Profile qualityProfileDTO `json:"profile"`
+}
+
+type UpdateQualityProfileResponse struct {
+	Profile qualityProfileDTO `json:"profile"`
+}
+
+type ListQualityProfilesResponse struct {
+	Profiles []qualityProfileDTO `json:"profiles"`
+}
+
+// CreateWantedBookRequest adds a book to the wanted list; media_type defaults to audiobook
+// and the default quality profile picks its release unless quality_profile_id is set
+type CreateWantedBookRequest struct {
+	Title            string `json:"title"`
+	Author           string `json:"author"`
+	Series           string `json:"series,omitempty"`
+	SeriesNumber     string `json:"series_number,omitempty"`
+	MediaType        string `json:"media_type,omitempty"`
+	Category         string `json:"category,omitempty"`
+	QualityProfileID *int64 `json:"quality_profile_id,omitempty"`
+}
+
+// UpdateWantedBookRequest replaces a wanted book's details; status "wanted" searches for a
+// grabbed book again, and an empty status keeps the current one
+type UpdateWantedBookRequest struct {
+	CreateWantedBookRequest
+	Status string `json:"status,omitempty"`
+}
+
+type WantedBookResponse struct {
+	Book wantedBookDTO `json:"book"`
+}
+
+type ListWantedBooksResponse struct {
+	Books []wantedBookDTO `json:"books"`
+}
+
+// FollowSeriesRequest follows a series by its provider series ID, e.g. the id of a search
+// result's series. provider defaults to MyAnonamouse and media_type to audiobook; entries
+// numbered at or below start_after aren't queued.
+type FollowSeriesRequest struct {
+	Provider         string `json:"provider,omitempty"`
+	SeriesID         string `json:"series_id"`
+	Name             string `json:"name"`
+	MediaType        string `json:"media_type,omitempty"`
+	Category         string `json:"category,omitempty"`
+	QualityProfileID *int64 `json:"quality_profile_id,omitempty"`
+	StartAfter       string `json:"start_after,omitempty"`
+}
+
+type UpdateFollowedSeriesRequest FollowSeriesRequest
+
+type FollowedSeriesResponse struct {
+	Series followedSeriesDTO `json:"series"`
+}
+
+type ListFollowedSeriesResponse struct {
+	Series []followedSeriesDTO `json:"series"`
+}
+
+// CheckFollowedSeriesResponse is a followed series after a check and the books the check added
+// to the wanted list
+type CheckFollowedSeriesResponse struct {
+	Series followedSeriesDTO `json:"series"`
+	Queued []wantedBookDTO   `json:"queued"`
+}
+
+type ListSearchProvidersResponse struct {
+	Providers []searchProviderDTO `json:"providers"`
+}
+
+// RegisterSeedboxIPResponse is MAM's answer to a seedbox IP registration; changed is false
+// when the IP was already registered
+type RegisterSeedboxIPResponse struct {
+	Changed bool   `json:"changed"`
+	Message string `json:"message"`
+	IP      string `json:"ip"`
+	ASN     int    `json:"asn"`
+	AS      string `json:"as"`
+}
+
+type AccountResponse struct {
+	Account accountDTO `json:"account"`
 }
 
 type TestConnectionResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message,omitempty"`
+	Success   bool                    `json:"success"`
+	Message   string                  `json:"message,omitempty"`
+	Providers []providerConnectionDTO `json:"providers,omitempty"`
 }
 
 type PreviewPathRequest struct {
@@ -91,6 +279,17 @@ type PreviewPathResponse struct {
 	Error string `json:"error,omitempty"`
 }
 
+type ValidatePathMappingsRequest struct {
+	Mappings []fileutil.PathMapping `json:"mappings,omitempty"`
+}
+
+type ValidatePathMappingsResponse struct {
+	Valid             bool                  `json:"valid"`
+	Mappings          []pathMappingCheckDTO `json:"mappings"`
+	UnmappedSavePaths []string              `json:"unmapped_save_paths"`
+	QBittorrentError  string                `json:"qbittorrent_error,omitempty"`
+}
+
 type BatchCreateDownloadRequest struct {
 	Downloads []CreateDownloadRequest `json:"downloads"`
 }
@@ -21,6 +21,26 @@ func (s *Server) registerRoutes() {
 			r.Get("/{id}", s.handleGetDownload)
 			r.Delete("/{id}", s.handleCancelDownload)
 			r.Post("/{id}/organize", s.handleOrganize)
+			r.Post("/{id}/retry", s.handleRetryDownload)
+			r.Get("/{id}/attempts", s.handleListOrganizeAttempts)
+			r.Get("/{id}/history", s.handleGetDownloadHistory)
+			r.Get("/{id}/replacements", s.handleListTorrentReplacements)
+			r.Post("/{id}/readd", s.handleReaddDownload)
+			r.Post("/{id}/discard", s.handleDiscardDownload)
+		})
+
+		r.Route("/imports", func(r chi.Router) {
+			r.Get("/candidates", s.handleListImportCandidates)
+			r.Post("/", s.handleImportTorrents)
+		})
+
+		r.Route("/monitor", func(r chi.Router) {
+			r.Get("/status", s.handleMonitorStatus)
+			r.Post("/refresh", s.handleRefreshMonitor)
+		})
+
+		r.Route("/webhooks", func(r chi.Router) {
+			r.Post("/torrent-finished", s.handleTorrentFinished)
 		})
 
 		r.Route("/config", func(r chi.Router) {
@@ -28,11 +48,42 @@ func (s *Server) registerRoutes() {
 			r.Get("/{key}", s.handleGetConfig)
 			r.Put("/{key}", s.handleUpdateConfig)
 			r.Post("/preview-path", s.handlePreviewPath)
+			r.Post("/path-mappings/validate", s.handleValidatePathMappings)
 		})
 
 		r.Route("/search", func(r chi.Router) {
 			r.Get("/", s.handleSearch)
 			r.Post("/test", s.handleTestConnection)
+			r.Get("/providers", s.handleListSearchProviders)
+			r.Delete("/cache", s.handleClearSearchCache)
+			r.Post("/seedbox", s.handleRegisterSeedboxIP)
+			r.Get("/account", s.handleGetAccount)
+		})
+
+		r.Route("/quality-profiles", func(r chi.Router) {
+			r.Get("/", s.handleListQualityProfiles)
+			r.Post("/", s.handleCreateQualityProfile)
+			r.Get("/{id}", s.handleGetQualityProfile)
+			r.Put("/{id}", s.handleUpdateQualityProfile)
+			r.Delete("/{id}", s.handleDeleteQualityProfile)
+		})
+
+		r.Route("/wanted", func(r chi.Router) {
+			r.Get("/", s.handleListWantedBooks)
+			r.Post("/", s.handleCreateWantedBook)
+			r.Get("/{id}", s.handleGetWantedBook)
+			r.Put("/{id}", s.handleUpdateWantedBook)
+			r.Delete("/{id}", s.handleDeleteWantedBook)
+			r.Post("/{id}/search", s.handleSearchWantedBook)
+		})
+
+		r.Route("/series", func(r chi.Router) {
+			r.Get("/", s.handleListFollowedSeries)
+			r.Post("/", s.handleFollowSeries)
+			r.Get("/{id}", s.handleGetFollowedSeries)
+			r.Put("/{id}", s.handleUpdateFollowedSeries)
+			r.Delete("/{id}", s.handleUnfollowSeries)
+			r.Post("/{id}/check", s.handleCheckFollowedSeries)
 		})
 
 		r.Route("/qbittorrent", func(r chi.Router) {
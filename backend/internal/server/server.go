@@ -11,23 +11,31 @@ import (
 	"github.com/go-chi/cors"
 	"github.com/nathanael/organizr/internal/config"
 	"github.com/nathanael/organizr/internal/downloads"
+	"github.com/nathanael/organizr/internal/quality"
 	"github.com/nathanael/organizr/internal/search"
+	"github.com/nathanael/organizr/internal/wanted"
 )
 
 type Config struct {
 	Port            string
 	AllowedOrigins  []string
 	DownloadService *downloads.Service
-	SearchService   *search.MAMService
+	Monitor         *downloads.Monitor
+	SearchService   *search.Service
 	ConfigService   *config.Service
+	QualityService  *quality.Service
+	WantedService   *wanted.Service
 }
 
 type Server struct {
 	router          chi.Router
 	httpServer      *http.Server
 	downloadService *downloads.Service
-	searchService   *search.MAMService
+	monitor         *downloads.Monitor
+	searchService   *search.Service
 	configService   *config.Service
+	qualityService  *quality.Service
+	wantedService   *wanted.Service
 }
 
 func New(cfg Config) *Server {
@@ -43,7 +51,7 @@ func New(cfg Config) *Server {
 	router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   cfg.AllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "Cache-Control", "Pragma"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
 		MaxAge:           300,
@@ -52,8 +60,11 @@ func New(cfg Config) *Server {
 	s := &Server{
 		router:          router,
 		downloadService: cfg.DownloadService,
+		monitor:         cfg.Monitor,
 		searchService:   cfg.SearchService,
 		configService:   cfg.ConfigService,
+		qualityService:  cfg.QualityService,
+		wantedService:   cfg.WantedService,
 	}
 
 	s.registerRoutes()
@@ -2,13 +2,21 @@ package server
 
 import (
 	"fmt"
+	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
+
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/search/providers"
 )
 
 var (
 	// UUID v4 pattern
 	uuidPattern = regexp.MustCompile(`^[a-f0-9]{8}-[a-f0-9]{4}-4[a-f0-9]{3}-[89ab][a-f0-9]{3}-[a-f0-9]{12}$`)
+
+	// BitTorrent v1 (SHA-1) or v2 (SHA-256) info hash
+	infoHashPattern = regexp.MustCompile(`^(?:[a-fA-F0-9]{40}|[a-fA-F0-9]{64})$`)
 )
 
 // validateDownloadRequest validates a download creation request
@@ -40,6 +48,138 @@ func validateDownloadRequest(req CreateDownloadRequest) error {
 		return fmt.Errorf("series must be 200 characters or less")
 	}
 
+	if err := validateMediaType(req.MediaType); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateImportRequest validates the confirmed metadata for adopting a torrent
+func validateImportRequest(req ImportTorrentRequest) error {
+	if strings.TrimSpace(req.Hash) == "" {
+		return fmt.Errorf("hash is required")
+	}
+
+	if strings.TrimSpace(req.Title) == "" {
+		return fmt.Errorf("title is required and cannot be empty")
+	}
+
+	if strings.TrimSpace(req.Author) == "" {
+		return fmt.Errorf("author is required and cannot be empty")
+	}
+
+	if len(req.Title) > 500 {
+		return fmt.Errorf("title must be 500 characters or less")
+	}
+
+	if len(req.Author) > 200 {
+		return fmt.Errorf("author must be 200 characters or less")
+	}
+
+	if len(req.Series) > 200 {
+		return fmt.Errorf("series must be 200 characters or less")
+	}
+
+	return validateMediaType(req.MediaType)
+}
+
+// validateMediaType validates an optional media type; empty means audiobook
+func validateMediaType(mediaType string) error {
+	switch models.MediaType(mediaType) {
+	case "", models.MediaTypeAudiobook, models.MediaTypeEbook:
+		return nil
+	default:
+		return fmt.Errorf("media_type must be 'audiobook' or 'ebook'")
+	}
+}
+
+// parseSearchMediaType converts the media_type search parameter to a MediaType.
+// Searches default to audiobooks; "all" searches every media type.
+func parseSearchMediaType(value string) (models.MediaType, error) {
+	switch value {
+	case "":
+		return models.MediaTypeAudiobook, nil
+	case "all":
+		return "", nil
+	}
+	if err := validateMediaType(value); err != nil {
+		return "", fmt.Errorf("media_type must be 'audiobook', 'ebook' or 'all'")
+	}
+	return models.MediaType(value), nil
+}
+
+// parseSearchFilters reads the filter, sort and paging parameters of a search into q.
+// page is 1-based and pages by limit; it can't be combined with offset.
+func parseSearchFilters(values url.Values, q *providers.Query) error {
+	q.SearchType = providers.SearchType(values.Get("search_type"))
+	for _, field := range splitList(values.Get("search_in")) {
+		q.SearchIn = append(q.SearchIn, providers.SearchIn(field))
+	}
+	q.FileType = strings.TrimSpace(values.Get("file_type"))
+	q.Sort = providers.SortType(values.Get("sort"))
+
+	var err error
+	if q.Languages, err = parseIntList(values.Get("language")); err != nil {
+		return fmt.Errorf("language: %w", err)
+	}
+	if q.Categories, err = parseIntList(values.Get("category")); err != nil {
+		return fmt.Errorf("category: %w", err)
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		if q.Limit, err = strconv.Atoi(limit); err != nil || q.Limit < 1 {
+			return fmt.Errorf("limit must be between 1 and %d", providers.MaxSearchLimit)
+		}
+	}
+	page, offset := values.Get("page"), values.Get("offset")
+	switch {
+	case page != "" && offset != "":
+		return fmt.Errorf("page and offset cannot both be given")
+	case page != "":
+		n, err := strconv.Atoi(page)
+		if err != nil || n < 1 {
+			return fmt.Errorf("page must be a positive integer")
+		}
+		q.Offset = (n - 1) * q.PageSize()
+	case offset != "":
+		if q.Offset, err = strconv.Atoi(offset); err != nil {
+			return fmt.Errorf("offset must be an integer")
+		}
+	}
+
+	return q.Validate()
+}
+
+// splitList splits a comma-separated parameter, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseIntList parses a comma-separated list of IDs
+func parseIntList(value string) ([]int, error) {
+	var ids []int
+	for _, item := range splitList(value) {
+		id, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a numeric ID", item)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// validateInfoHash validates a torrent info hash as qBittorrent reports it
+func validateInfoHash(hash string) error {
+	if !infoHashPattern.MatchString(hash) {
+		return fmt.Errorf("hash must be a 40 or 64 character hex info hash")
+	}
 	return nil
 }
 
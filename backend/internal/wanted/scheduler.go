@@ -0,0 +1,82 @@
+package wanted
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultSearchInterval is how often a wanted book is searched for without a setting
+	defaultSearchInterval = 6 * time.Hour
+	// defaultSeriesCheckInterval is how often a followed series is checked without a setting
+	defaultSeriesCheckInterval = 12 * time.Hour
+	// dueCheckInterval is how often the scheduler looks for books and series that are due
+	dueCheckInterval = 5 * time.Minute
+)
+
+// Run checks followed series every series.check_interval_minutes and searches for wanted
+// books every wanted.search_interval_minutes, and for series and books as soon as they are
+// added, until ctx is cancelled. A zero interval leaves that work to the API.
+func (s *Service) Run(ctx context.Context) error {
+	searchInterval := s.interval(ctx, "wanted.search_interval_minutes", defaultSearchInterval)
+	checkInterval := s.interval(ctx, "series.check_interval_minutes", defaultSeriesCheckInterval)
+
+	// The intervals apply without a restart
+	changed := make(chan struct{}, 1)
+	unsubscribe := s.configService.Subscribe(func(key, value string) {
+		if key == "wanted.search_interval_minutes" || key == "series.check_interval_minutes" {
+			notify(changed)
+		}
+	})
+	defer unsubscribe()
+
+	ticker := time.NewTicker(dueCheckInterval)
+	defer ticker.Stop()
+
+	log.Printf("Wanted list scheduler started, searching every %s and checking followed series every %s", searchInterval, checkInterval)
+
+	for {
+		// Series entries are queued first, so they are searched for in the same pass. On the
+		// first pass this catches up on what became due while the server was down.
+		if checkInterval > 0 {
+			s.checkDueSeries(ctx, checkInterval)
+		}
+		if searchInterval > 0 {
+			s.searchDue(ctx, searchInterval)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		case <-changed:
+			if configured := s.interval(ctx, "wanted.search_interval_minutes", defaultSearchInterval); configured != searchInterval {
+				log.Printf("Wanted list search interval changed: %s → %s", searchInterval, configured)
+				searchInterval = configured
+			}
+			if configured := s.interval(ctx, "series.check_interval_minutes", defaultSeriesCheckInterval); configured != checkInterval {
+				log.Printf("Followed series check interval changed: %s → %s", checkInterval, configured)
+				checkInterval = configured
+			}
+		case <-ctx.Done():
+			log.Println("Wanted list scheduler stopped")
+			return ctx.Err()
+		}
+	}
+}
+
+// interval returns the interval in minutes configured under key; zero disables the work it
+// schedules
+func (s *Service) interval(ctx context.Context, key string, fallback time.Duration) time.Duration {
+	value, err := s.configService.Get(ctx, key)
+	if err != nil {
+		return fallback
+	}
+	minutes, err := strconv.Atoi(value)
+	if err != nil || minutes < 0 {
+		log.Printf("Ignoring invalid %s %q", key, value)
+		return fallback
+	}
+	return time.Duration(minutes) * time.Minute
+}
@@ -0,0 +1,309 @@
+package wanted
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nathanael/organizr/internal/downloads"
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/persistence"
+	"github.com/nathanael/organizr/internal/search"
+	"github.com/nathanael/organizr/internal/search/providers"
+)
+
+// ErrInvalidFollowedSeries is matched by errors of followed series that fail validation
+var ErrInvalidFollowedSeries = errors.New("invalid followed series")
+
+// maxSeriesPages bounds how many pages of results a series check reads
+const maxSeriesPages = 5
+
+func (s *Service) ListSeries(ctx context.Context) ([]*models.FollowedSeries, error) {
+	return s.seriesRepo.List(ctx)
+}
+
+func (s *Service) GetSeries(ctx context.Context, id int64) (*models.FollowedSeries, error) {
+	return s.seriesRepo.GetByID(ctx, id)
+}
+
+// FollowSeries follows a series; the scheduler checks it for missing entries right away
+func (s *Service) FollowSeries(ctx context.Context, fs *models.FollowedSeries) error {
+	if err := s.normalizeSeries(ctx, fs); err != nil {
+		return err
+	}
+	if err := s.seriesRepo.Create(ctx, fs); err != nil {
+		return err
+	}
+	notify(s.wake)
+	return nil
+}
+
+func (s *Service) UpdateSeries(ctx context.Context, fs *models.FollowedSeries) error {
+	if err := s.normalizeSeries(ctx, fs); err != nil {
+		return err
+	}
+	return s.seriesRepo.Update(ctx, fs)
+}
+
+// UnfollowSeries stops following a series. The books already queued for it stay wanted.
+func (s *Service) UnfollowSeries(ctx context.Context, id int64) error {
+	return s.seriesRepo.Delete(ctx, id)
+}
+
+// normalizeSeries validates a followed series and trims its details
+func (s *Service) normalizeSeries(ctx context.Context, fs *models.FollowedSeries) error {
+	fs.Provider = strings.TrimSpace(fs.Provider)
+	fs.SeriesID = strings.TrimSpace(fs.SeriesID)
+	fs.Name = strings.TrimSpace(fs.Name)
+	fs.Category = strings.TrimSpace(fs.Category)
+	fs.StartAfter = strings.TrimSpace(fs.StartAfter)
+
+	if fs.Provider == "" {
+		fs.Provider = providers.MyAnonamouseName
+	}
+	if fs.Provider != providers.MyAnonamouseName {
+		return fmt.Errorf("%w: only %s series can be followed", ErrInvalidFollowedSeries, providers.MyAnonamouseName)
+	}
+	if id, err := strconv.ParseInt(fs.SeriesID, 10, 64); err != nil || id <= 0 {
+		return fmt.Errorf("%w: series ID must be a %s series ID", ErrInvalidFollowedSeries, providers.MyAnonamouseName)
+	}
+	if fs.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidFollowedSeries)
+	}
+	if fs.MediaType == "" {
+		fs.MediaType = models.MediaTypeAudiobook
+	}
+	if fs.MediaType != models.MediaTypeAudiobook && fs.MediaType != models.MediaTypeEbook {
+		return fmt.Errorf("%w: media type must be audiobook or ebook", ErrInvalidFollowedSeries)
+	}
+	if fs.StartAfter != "" {
+		if _, err := strconv.ParseFloat(fs.StartAfter, 64); err != nil {
+			return fmt.Errorf("%w: start after must be a series number", ErrInvalidFollowedSeries)
+		}
+	}
+
+	if fs.QualityProfileID != nil && s.profiles != nil {
+		if _, err := s.profiles.Get(ctx, *fs.QualityProfileID); err != nil {
+			if errors.Is(err, persistence.ErrQualityProfileNotFound) {
+				return fmt.Errorf("%w: quality profile %d doesn't exist", ErrInvalidFollowedSeries, *fs.QualityProfileID)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckSeries checks a followed series for missing entries now, returning the books it added
+// to the wanted list
+func (s *Service) CheckSeries(ctx context.Context, id int64) ([]*models.WantedBook, error) {
+	s.searchMu.Lock()
+	defer s.searchMu.Unlock()
+
+	fs, err := s.seriesRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.checkSeries(ctx, fs)
+}
+
+// checkDueSeries checks every followed series not checked within interval
+func (s *Service) checkDueSeries(ctx context.Context, interval time.Duration) {
+	s.searchMu.Lock()
+	defer s.searchMu.Unlock()
+
+	due, err := s.seriesRepo.ListDue(ctx, time.Now().Add(-interval))
+	if err != nil {
+		log.Printf("Failed to list followed series: %v", err)
+		return
+	}
+
+	for _, fs := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		if _, err := s.checkSeries(ctx, fs); err != nil {
+			log.Printf("Failed to check followed series %d (%s): %v", fs.ID, fs.Name, err)
+		}
+	}
+}
+
+// checkSeries searches for a followed series' entries and adds each one that isn't on the
+// wanted list, downloaded or in the library to the wanted list under the series' quality
+// profile. Entries without a plain series number, e.g. omnibuses numbered "1-3", are skipped.
+// The caller holds searchMu.
+func (s *Service) checkSeries(ctx context.Context, fs *models.FollowedSeries) ([]*models.WantedBook, error) {
+	if s.searcher == nil || s.library == nil {
+		return nil, fmt.Errorf("search is not configured")
+	}
+
+	entries, err := s.seriesEntries(ctx, fs)
+	if err != nil {
+		s.recordCheck(ctx, fs, fmt.Sprintf("search failed: %v", err))
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+
+	wanted, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list wanted books: %w", err)
+	}
+
+	var queued []*models.WantedBook
+	for _, entry := range entries {
+		if fs.StartAfter != "" && !numberAfter(entry.number, fs.StartAfter) {
+			continue
+		}
+		if alreadyWanted(fs, entry.number, wanted) {
+			continue
+		}
+
+		owned, err := s.library.HasSeriesEntry(ctx, &models.Download{
+			Title:        entry.result.Title,
+			Author:       entry.result.Author,
+			Series:       fs.Name,
+			SeriesNumber: entry.number,
+			MediaType:    fs.MediaType,
+		})
+		if err != nil {
+			s.recordCheck(ctx, fs, fmt.Sprintf("could not check the library: %v", err))
+			return queued, fmt.Errorf("failed to check for %s #%s: %w", fs.Name, entry.number, err)
+		}
+		if owned {
+			continue
+		}
+
+		seriesID := fs.ID
+		book := &models.WantedBook{
+			Title:            entry.result.Title,
+			Author:           entry.result.Author,
+			Series:           fs.Name,
+			SeriesNumber:     entry.number,
+			MediaType:        fs.MediaType,
+			Category:         fs.Category,
+			QualityProfileID: fs.QualityProfileID,
+			FollowedSeriesID: &seriesID,
+		}
+		if err := s.Create(ctx, book); err != nil {
+			if errors.Is(err, persistence.ErrWantedBookExists) {
+				// The same title and author is already wanted, numbered differently
+				log.Printf("Not queueing %s #%s (%s) for followed series %d: %v", fs.Name, entry.number, entry.result.Title, fs.ID, err)
+				continue
+			}
+			s.recordCheck(ctx, fs, fmt.Sprintf("could not queue %s: %v", entry.result.Title, err))
+			return queued, fmt.Errorf("failed to queue %s: %w", entry.result.Title, err)
+		}
+		log.Printf("Queued %s #%s (%s) for followed series %d", fs.Name, entry.number, book.Title, fs.ID)
+		queued = append(queued, book)
+		wanted = append(wanted, book)
+	}
+
+	s.recordCheck(ctx, fs, "")
+	return queued, nil
+}
+
+// seriesEntry is the release that stands for one entry of a followed series
+type seriesEntry struct {
+	number string
+	result *models.SearchResult
+}
+
+// seriesEntries searches the provider for releases listed under the followed series' ID and
+// returns one per series number, the best seeded, in series order
+func (s *Service) seriesEntries(ctx context.Context, fs *models.FollowedSeries) ([]seriesEntry, error) {
+	// Cached results would hide entries released since
+	ctx = search.BypassCache(ctx)
+
+	best := map[float64]seriesEntry{}
+	for page := 0; page < maxSeriesPages; page++ {
+		results, err := s.searcher.Search(ctx, providers.Query{
+			Text:      fs.Name,
+			SearchIn:  []providers.SearchIn{providers.SearchInSeries},
+			MediaType: fs.MediaType,
+			Offset:    page * providers.MaxSearchLimit,
+			Limit:     providers.MaxSearchLimit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, failure := range results.Failures {
+			log.Printf("Provider %s failed searching for followed series %d: %v", failure.Provider, fs.ID, failure.Err)
+		}
+
+		for _, r := range results.Results {
+			number, ok := seriesNumber(fs, r)
+			if !ok {
+				continue
+			}
+			value, err := strconv.ParseFloat(number, 64)
+			if err != nil {
+				continue
+			}
+			if current, ok := best[value]; !ok || r.Seeders > current.result.Seeders {
+				best[value] = seriesEntry{number: number, result: r}
+			}
+		}
+
+		if len(results.Results) < providers.MaxSearchLimit || (page+1)*providers.MaxSearchLimit >= results.Total {
+			break
+		}
+	}
+
+	numbers := make([]float64, 0, len(best))
+	for n := range best {
+		numbers = append(numbers, n)
+	}
+	sort.Float64s(numbers)
+
+	entries := make([]seriesEntry, len(numbers))
+	for i, n := range numbers {
+		entries[i] = best[n]
+	}
+	return entries, nil
+}
+
+// seriesNumber returns a result's number in the followed series, if the provider lists it
+// under the series' ID
+func seriesNumber(fs *models.FollowedSeries, r *models.SearchResult) (string, bool) {
+	if r.Provider != fs.Provider || (r.MediaType != "" && r.MediaType != fs.MediaType) {
+		return "", false
+	}
+	for _, series := range r.Series {
+		if series.ID == fs.SeriesID {
+			number := strings.TrimSpace(series.Number)
+			return number, number != ""
+		}
+	}
+	return "", false
+}
+
+// alreadyWanted reports whether an entry of the followed series is already on the wanted list,
+// whether queued for the series or added by hand
+func alreadyWanted(fs *models.FollowedSeries, number string, wanted []*models.WantedBook) bool {
+	for _, b := range wanted {
+		if b.MediaType != fs.MediaType || !downloads.SameSeriesNumber(b.SeriesNumber, number) {
+			continue
+		}
+		if (b.FollowedSeriesID != nil && *b.FollowedSeriesID == fs.ID) || strings.EqualFold(b.Series, fs.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// numberAfter reports whether series number a comes after b
+func numberAfter(a, b string) bool {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	return errA == nil && errB == nil && x > y
+}
+
+// recordCheck notes that a followed series was just checked
+func (s *Service) recordCheck(ctx context.Context, fs *models.FollowedSeries, lastError string) {
+	if err := s.seriesRepo.RecordCheck(ctx, fs.ID, time.Now(), lastError); err != nil {
+		log.Printf("Failed to record check of followed series %d: %v", fs.ID, err)
+	}
+}
@@ -0,0 +1,236 @@
+package wanted
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/nathanael/organizr/internal/downloads"
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/persistence"
+	"github.com/nathanael/organizr/internal/search/providers"
+)
+
+// mockSeriesRepo keeps followed series in memory
+type mockSeriesRepo struct {
+	series map[int64]*models.FollowedSeries
+	nextID int64
+}
+
+func newMockSeriesRepo(series ...*models.FollowedSeries) *mockSeriesRepo {
+	r := &mockSeriesRepo{series: map[int64]*models.FollowedSeries{}}
+	for _, fs := range series {
+		if err := r.Create(context.Background(), fs); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+func (r *mockSeriesRepo) List(ctx context.Context) ([]*models.FollowedSeries, error) {
+	return r.ListDue(ctx, time.Now().Add(time.Hour))
+}
+
+func (r *mockSeriesRepo) ListDue(ctx context.Context, before time.Time) ([]*models.FollowedSeries, error) {
+	var series []*models.FollowedSeries
+	for _, fs := range r.series {
+		if fs.LastCheckedAt == nil || fs.LastCheckedAt.Before(before) {
+			copied := *fs
+			series = append(series, &copied)
+		}
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].ID < series[j].ID })
+	return series, nil
+}
+
+func (r *mockSeriesRepo) GetByID(ctx context.Context, id int64) (*models.FollowedSeries, error) {
+	fs, ok := r.series[id]
+	if !ok {
+		return nil, persistence.ErrFollowedSeriesNotFound
+	}
+	copied := *fs
+	return &copied, nil
+}
+
+func (r *mockSeriesRepo) Create(ctx context.Context, fs *models.FollowedSeries) error {
+	r.nextID++
+	fs.ID = r.nextID
+	copied := *fs
+	r.series[fs.ID] = &copied
+	return nil
+}
+
+func (r *mockSeriesRepo) Update(ctx context.Context, fs *models.FollowedSeries) error {
+	if _, ok := r.series[fs.ID]; !ok {
+		return persistence.ErrFollowedSeriesNotFound
+	}
+	copied := *fs
+	r.series[fs.ID] = &copied
+	return nil
+}
+
+func (r *mockSeriesRepo) RecordCheck(ctx context.Context, id int64, checkedAt time.Time, lastError string) error {
+	fs, ok := r.series[id]
+	if !ok {
+		return persistence.ErrFollowedSeriesNotFound
+	}
+	fs.LastCheckedAt = &checkedAt
+	fs.LastError = lastError
+	return nil
+}
+
+func (r *mockSeriesRepo) Delete(ctx context.Context, id int64) error {
+	if _, ok := r.series[id]; !ok {
+		return persistence.ErrFollowedSeriesNotFound
+	}
+	delete(r.series, id)
+	return nil
+}
+
+// fakeLibrary owns the series numbers it lists
+type fakeLibrary struct {
+	owned []string
+}
+
+func (f *fakeLibrary) HasSeriesEntry(ctx context.Context, book *models.Download) (bool, error) {
+	for _, number := range f.owned {
+		if downloads.SameSeriesNumber(number, book.SeriesNumber) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// duneRelease is a MAM audiobook listed under the Dune series, MAM series 42
+func duneRelease(id, title, number string, seeders int) *models.SearchResult {
+	return &models.SearchResult{
+		ID:        id,
+		Title:     title,
+		Author:    "Frank Herbert",
+		Seeders:   seeders,
+		Provider:  providers.MyAnonamouseName,
+		MediaType: models.MediaTypeAudiobook,
+		Series:    []models.SeriesInfo{{ID: "42", Name: "Dune", Number: number}},
+	}
+}
+
+func newSeriesTestService(wanted *mockWantedRepo, series *mockSeriesRepo, searcher *fakeSearcher, library *fakeLibrary) *Service {
+	s := newTestService(wanted, searcher, &fakeDownloads{})
+	s.seriesRepo = series
+	s.library = library
+	return s
+}
+
+func TestService_CheckSeries(t *testing.T) {
+	profileID := int64(5)
+	releases := []*models.SearchResult{
+		duneRelease("1", "Dune", "1", 80),
+		duneRelease("2", "Dune Messiah", "2", 3),
+		duneRelease("3", "Dune Messiah (Unabridged)", "02", 30),
+		duneRelease("4", "Children of Dune", "3", 20),
+		duneRelease("5", "God Emperor of Dune", "4", 0),
+		duneRelease("6", "Dune Chronicles 1-3", "1-3", 90),
+		{ID: "7", Title: "Dune: House Atreides", Author: "Brian Herbert", Seeders: 50, Provider: providers.MyAnonamouseName, MediaType: models.MediaTypeAudiobook, Series: []models.SeriesInfo{{ID: "77", Name: "Prelude to Dune", Number: "1"}}},
+		{ID: "8", Title: "Heretics of Dune", Author: "Frank Herbert", Seeders: 10, Provider: providers.MyAnonamouseName, MediaType: models.MediaTypeEbook, Series: []models.SeriesInfo{{ID: "42", Name: "Dune", Number: "5"}}},
+	}
+
+	tests := []struct {
+		name       string
+		startAfter string
+		want       []string // Titles queued
+	}{
+		{"Missing entries", "", []string{"Dune Messiah (Unabridged)", "God Emperor of Dune"}},
+		{"Only new entries", "3", []string{"God Emperor of Dune"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Children of Dune was added by hand; Dune is in the library
+			wantedRepo := newMockWantedRepo(&models.WantedBook{Title: "Children of Dune", Author: "Frank Herbert", Series: "Dune", SeriesNumber: "3", MediaType: models.MediaTypeAudiobook})
+			seriesRepo := newMockSeriesRepo(&models.FollowedSeries{Provider: providers.MyAnonamouseName, SeriesID: "42", Name: "Dune", MediaType: models.MediaTypeAudiobook, Category: "Audiobooks", QualityProfileID: &profileID, StartAfter: tt.startAfter})
+			searcher := &fakeSearcher{results: releases}
+			s := newSeriesTestService(wantedRepo, seriesRepo, searcher, &fakeLibrary{owned: []string{"1"}})
+			s.profiles = &fakeProfiles{profiles: map[int64]*models.QualityProfile{profileID: {ID: profileID}}}
+
+			queued, err := s.CheckSeries(context.Background(), 1)
+			if err != nil {
+				t.Fatalf("CheckSeries() error = %v", err)
+			}
+
+			var titles []string
+			for _, b := range queued {
+				titles = append(titles, b.Title)
+				if b.Series != "Dune" || b.FollowedSeriesID == nil || *b.FollowedSeriesID != 1 || b.QualityProfileID == nil || *b.QualityProfileID != profileID || b.Category != "Audiobooks" || b.Status != models.WantedStatusWanted {
+					t.Errorf("queued %+v, want it wanted under the series' profile and category", b)
+				}
+			}
+			if len(titles) != len(tt.want) || (len(titles) > 0 && (titles[0] != tt.want[0] || titles[len(titles)-1] != tt.want[len(tt.want)-1])) {
+				t.Errorf("queued %v, want %v", titles, tt.want)
+			}
+
+			q := searcher.queries[0]
+			if q.Text != "Dune" || len(q.SearchIn) != 1 || q.SearchIn[0] != providers.SearchInSeries || q.MediaType != models.MediaTypeAudiobook {
+				t.Errorf("query = %+v, want a series search for audiobooks", q)
+			}
+			if fs, _ := seriesRepo.GetByID(context.Background(), 1); fs.LastCheckedAt == nil || fs.LastError != "" {
+				t.Errorf("series = %+v, want the check recorded", fs)
+			}
+
+			// Queued entries aren't queued again
+			again, err := s.CheckSeries(context.Background(), 1)
+			if err != nil || len(again) != 0 {
+				t.Errorf("CheckSeries() again = %v, %v, want nothing queued", again, err)
+			}
+		})
+	}
+}
+
+func TestService_CheckSeriesRecordsFailures(t *testing.T) {
+	seriesRepo := newMockSeriesRepo(&models.FollowedSeries{Provider: providers.MyAnonamouseName, SeriesID: "42", Name: "Dune", MediaType: models.MediaTypeAudiobook})
+	s := newSeriesTestService(newMockWantedRepo(), seriesRepo, &fakeSearcher{err: errors.New("timeout")}, &fakeLibrary{})
+
+	if _, err := s.CheckSeries(context.Background(), 1); err == nil {
+		t.Fatal("CheckSeries() error = nil, want the search failure")
+	}
+	if fs, _ := seriesRepo.GetByID(context.Background(), 1); fs.LastCheckedAt == nil || fs.LastError != "search failed: timeout" {
+		t.Errorf("series = %+v, want the failure recorded", fs)
+	}
+}
+
+func TestService_FollowSeriesValidates(t *testing.T) {
+	tests := []struct {
+		name   string
+		series *models.FollowedSeries
+	}{
+		{"Another provider", &models.FollowedSeries{Provider: "Prowlarr", SeriesID: "42", Name: "Dune"}},
+		{"No series ID", &models.FollowedSeries{Name: "Dune"}},
+		{"Non-numeric series ID", &models.FollowedSeries{SeriesID: "dune", Name: "Dune"}},
+		{"No name", &models.FollowedSeries{SeriesID: "42"}},
+		{"Unknown media type", &models.FollowedSeries{SeriesID: "42", Name: "Dune", MediaType: "podcast"}},
+		{"Start after isn't a number", &models.FollowedSeries{SeriesID: "42", Name: "Dune", StartAfter: "first"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSeriesTestService(newMockWantedRepo(), newMockSeriesRepo(), &fakeSearcher{}, &fakeLibrary{})
+			if err := s.FollowSeries(context.Background(), tt.series); !errors.Is(err, ErrInvalidFollowedSeries) {
+				t.Errorf("FollowSeries() error = %v, want ErrInvalidFollowedSeries", err)
+			}
+		})
+	}
+
+	// A valid series defaults to MAM audiobooks and wakes the scheduler
+	s := newSeriesTestService(newMockWantedRepo(), newMockSeriesRepo(), &fakeSearcher{}, &fakeLibrary{})
+	fs := &models.FollowedSeries{SeriesID: " 42 ", Name: "Dune"}
+	if err := s.FollowSeries(context.Background(), fs); err != nil {
+		t.Fatalf("FollowSeries() error = %v", err)
+	}
+	if fs.Provider != providers.MyAnonamouseName || fs.SeriesID != "42" || fs.MediaType != models.MediaTypeAudiobook {
+		t.Errorf("FollowSeries() stored %+v", fs)
+	}
+	select {
+	case <-s.wake:
+	default:
+		t.Error("FollowSeries() didn't wake the scheduler")
+	}
+}
@@ -0,0 +1,336 @@
+package wanted
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nathanael/organizr/internal/config"
+	"github.com/nathanael/organizr/internal/downloads"
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/persistence"
+	"github.com/nathanael/organizr/internal/quality"
+	"github.com/nathanael/organizr/internal/search"
+	"github.com/nathanael/organizr/internal/search/providers"
+)
+
+// ErrInvalidWantedBook is matched by errors of wanted books that fail validation
+var ErrInvalidWantedBook = errors.New("invalid wanted book")
+
+// ErrAlreadyGrabbed is returned when searching for a wanted book whose release was already grabbed
+var ErrAlreadyGrabbed = errors.New("a release of this book was already grabbed")
+
+// noReleaseFound is recorded as the last error of searches that found nothing to grab
+const noReleaseFound = "no matching release found"
+
+// bookSearcher interface defines the methods we need to find releases
+type bookSearcher interface {
+	Search(ctx context.Context, q providers.Query) (*search.Results, error)
+}
+
+// qualityProfiles interface defines the methods we need to pick a release
+type qualityProfiles interface {
+	Get(ctx context.Context, id int64) (*models.QualityProfile, error)
+	Default(ctx context.Context) (*models.QualityProfile, error)
+}
+
+// downloadCreator interface defines the methods we need to grab a release
+type downloadCreator interface {
+	CreateDownload(ctx context.Context, d *models.Download) (*models.Download, error)
+}
+
+// seriesLibrary interface defines the methods we need to tell which series entries are owned
+type seriesLibrary interface {
+	HasSeriesEntry(ctx context.Context, book *models.Download) (bool, error)
+}
+
+// Service keeps the wanted list and grabs releases of the books on it, and adds the missing
+// entries of followed series to it
+type Service struct {
+	repo          persistence.WantedBookRepository
+	seriesRepo    persistence.FollowedSeriesRepository
+	searcher      bookSearcher
+	profiles      qualityProfiles
+	downloads     downloadCreator
+	library       seriesLibrary
+	configService *config.Service
+
+	// Held while searching or checking series, so a book is never grabbed or queued twice
+	searchMu sync.Mutex
+	// Signals the scheduler to search for newly added books
+	wake chan struct{}
+}
+
+func NewService(repo persistence.WantedBookRepository, seriesRepo persistence.FollowedSeriesRepository, searchService *search.Service, qualityService *quality.Service, downloadService *downloads.Service, configService *config.Service) *Service {
+	s := &Service{
+		repo:          repo,
+		seriesRepo:    seriesRepo,
+		configService: configService,
+		wake:          make(chan struct{}, 1),
+	}
+	if searchService != nil {
+		s.searcher = searchService
+	}
+	if qualityService != nil {
+		s.profiles = qualityService
+	}
+	if downloadService != nil {
+		s.downloads = downloadService
+		s.library = downloadService
+	}
+	return s
+}
+
+func (s *Service) List(ctx context.Context) ([]*models.WantedBook, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *Service) Get(ctx context.Context, id int64) (*models.WantedBook, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// Create adds a book to the wanted list; the scheduler searches for it right away
+func (s *Service) Create(ctx context.Context, b *models.WantedBook) error {
+	b.Status = models.WantedStatusWanted
+	b.DownloadID = ""
+	if err := s.normalize(ctx, b); err != nil {
+		return err
+	}
+	if err := s.repo.Create(ctx, b); err != nil {
+		return err
+	}
+	notify(s.wake)
+	return nil
+}
+
+// Update saves a wanted book's details. Setting a grabbed book back to wanted searches for it
+// again.
+func (s *Service) Update(ctx context.Context, b *models.WantedBook) error {
+	if err := s.normalize(ctx, b); err != nil {
+		return err
+	}
+	if b.Status == models.WantedStatusWanted {
+		b.DownloadID = ""
+	}
+	if err := s.repo.Update(ctx, b); err != nil {
+		return err
+	}
+	if b.Status == models.WantedStatusWanted {
+		notify(s.wake)
+	}
+	return nil
+}
+
+func (s *Service) Delete(ctx context.Context, id int64) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// normalize validates a wanted book and trims its details
+func (s *Service) normalize(ctx context.Context, b *models.WantedBook) error {
+	b.Title = strings.TrimSpace(b.Title)
+	b.Author = strings.TrimSpace(b.Author)
+	b.Series = strings.TrimSpace(b.Series)
+	b.SeriesNumber = strings.TrimSpace(b.SeriesNumber)
+	b.Category = strings.TrimSpace(b.Category)
+
+	if b.Title == "" || b.Author == "" {
+		return fmt.Errorf("%w: title and author are required", ErrInvalidWantedBook)
+	}
+	if b.MediaType == "" {
+		b.MediaType = models.MediaTypeAudiobook
+	}
+	if b.MediaType != models.MediaTypeAudiobook && b.MediaType != models.MediaTypeEbook {
+		return fmt.Errorf("%w: media type must be audiobook or ebook", ErrInvalidWantedBook)
+	}
+	if b.Status != models.WantedStatusWanted && b.Status != models.WantedStatusGrabbed {
+		return fmt.Errorf("%w: status must be wanted or grabbed", ErrInvalidWantedBook)
+	}
+
+	if b.QualityProfileID != nil && s.profiles != nil {
+		if _, err := s.profiles.Get(ctx, *b.QualityProfileID); err != nil {
+			if errors.Is(err, persistence.ErrQualityProfileNotFound) {
+				return fmt.Errorf("%w: quality profile %d doesn't exist", ErrInvalidWantedBook, *b.QualityProfileID)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Search searches for a wanted book now and grabs the best release, returning the book with
+// the outcome recorded
+func (s *Service) Search(ctx context.Context, id int64) (*models.WantedBook, error) {
+	s.searchMu.Lock()
+	defer s.searchMu.Unlock()
+
+	b, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if b.Status != models.WantedStatusWanted {
+		return nil, ErrAlreadyGrabbed
+	}
+
+	if err := s.searchBook(ctx, b); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+// searchDue searches for every wanted book not searched for within interval
+func (s *Service) searchDue(ctx context.Context, interval time.Duration) {
+	s.searchMu.Lock()
+	defer s.searchMu.Unlock()
+
+	books, err := s.repo.ListDue(ctx, time.Now().Add(-interval))
+	if err != nil {
+		log.Printf("Failed to list wanted books: %v", err)
+		return
+	}
+
+	for _, b := range books {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.searchBook(ctx, b); err != nil {
+			log.Printf("Failed to search for wanted book %d (%s): %v", b.ID, b.Title, err)
+		}
+	}
+}
+
+// searchBook searches every provider for a wanted book and grabs the release its quality
+// profile ranks best. Searches that find nothing only record that; failing to search or to
+// add the release is returned as well. The caller holds searchMu.
+func (s *Service) searchBook(ctx context.Context, b *models.WantedBook) error {
+	if s.searcher == nil || s.downloads == nil {
+		return fmt.Errorf("search is not configured")
+	}
+
+	// Cached results would hide releases uploaded since
+	results, err := s.searcher.Search(search.BypassCache(ctx), providers.Query{Title: b.Title, Author: b.Author, MediaType: b.MediaType})
+	if err != nil {
+		s.recordSearch(ctx, b, fmt.Sprintf("search failed: %v", err))
+		return fmt.Errorf("failed to search: %w", err)
+	}
+	for _, failure := range results.Failures {
+		log.Printf("Provider %s failed searching for wanted book %d: %v", failure.Provider, b.ID, failure.Err)
+	}
+
+	best := pickRelease(b, results.Results, s.profile(ctx, b))
+	if best == nil {
+		s.recordSearch(ctx, b, noReleaseFound)
+		return nil
+	}
+
+	size, _ := quality.ParseSize(best.Size)
+	download, err := s.downloads.CreateDownload(ctx, &models.Download{
+		Title:        b.Title,
+		Author:       b.Author,
+		Series:       b.Series,
+		SeriesNumber: b.SeriesNumber,
+		MediaType:    b.MediaType,
+		TorrentURL:   best.TorrentURL,
+		MagnetLink:   best.MagnetLink,
+		TorrentID:    best.ID,
+		Provider:     best.Provider,
+		Category:     b.Category,
+		Size:         size,
+		Freeleech:    best.Freeleech || best.FreeleechVIP,
+		AddedBy:      models.ActorWanted,
+		CreatedAt:    time.Now(),
+	})
+	if err != nil {
+		s.recordSearch(ctx, b, fmt.Sprintf("could not add %s: %v", best.Title, err))
+		return fmt.Errorf("failed to add release %s: %w", best.Title, err)
+	}
+
+	b.Status = models.WantedStatusGrabbed
+	b.DownloadID = download.ID
+	if err := s.repo.Update(ctx, b); err != nil {
+		return fmt.Errorf("failed to link download %s: %w", download.ID, err)
+	}
+	s.recordSearch(ctx, b, "")
+	log.Printf("Grabbed %s from %s for wanted book %d (%s)", best.Title, best.Provider, b.ID, b.Title)
+	return nil
+}
+
+// recordSearch notes that a wanted book was just searched for
+func (s *Service) recordSearch(ctx context.Context, b *models.WantedBook, lastError string) {
+	if err := s.repo.RecordSearch(ctx, b.ID, time.Now(), lastError); err != nil {
+		log.Printf("Failed to record search for wanted book %d: %v", b.ID, err)
+	}
+}
+
+// profile returns the quality profile that picks a wanted book's release: its own, else the
+// default. Nil picks by seeders.
+func (s *Service) profile(ctx context.Context, b *models.WantedBook) *models.QualityProfile {
+	if s.profiles == nil {
+		return nil
+	}
+	if b.QualityProfileID != nil {
+		p, err := s.profiles.Get(ctx, *b.QualityProfileID)
+		if err == nil {
+			return p
+		}
+		log.Printf("Failed to load quality profile %d of wanted book %d, using the default: %v", *b.QualityProfileID, b.ID, err)
+	}
+	p, err := s.profiles.Default(ctx)
+	if err != nil {
+		log.Printf("Failed to load default quality profile for wanted book %d, picking by seeders: %v", b.ID, err)
+		return nil
+	}
+	return p
+}
+
+// pickRelease returns the seeded release of a wanted book the profile ranks best, or the
+// best-seeded one without a profile. Releases of another book in the same series are skipped.
+func pickRelease(b *models.WantedBook, results []*models.SearchResult, profile *models.QualityProfile) *models.SearchResult {
+	var candidates []*models.SearchResult
+	for _, r := range results {
+		if r.Seeders < 1 || !downloads.MatchesBook(b.Title, b.Author, r) || otherSeriesEntry(b, r) {
+			continue
+		}
+		if r.MediaType != "" && r.MediaType != b.MediaType {
+			continue
+		}
+		candidates = append(candidates, r)
+	}
+
+	if profile != nil {
+		return quality.Best(profile, candidates)
+	}
+
+	var best *models.SearchResult
+	for _, r := range candidates {
+		if best == nil || r.Seeders > best.Seeders {
+			best = r
+		}
+	}
+	return best
+}
+
+// otherSeriesEntry reports whether a result is numbered as another entry of the wanted book's
+// series, e.g. "Dune Messiah" for "Dune"
+func otherSeriesEntry(b *models.WantedBook, r *models.SearchResult) bool {
+	if b.SeriesNumber == "" {
+		return false
+	}
+	for _, series := range r.Series {
+		if series.Number != "" && (b.Series == "" || strings.EqualFold(series.Name, b.Series)) {
+			return !downloads.SameSeriesNumber(series.Number, b.SeriesNumber)
+		}
+	}
+	return false
+}
+
+// notify signals ch without blocking; a signal already pending covers this one
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,316 @@
+package wanted
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/nathanael/organizr/internal/models"
+	"github.com/nathanael/organizr/internal/persistence"
+	"github.com/nathanael/organizr/internal/search"
+	"github.com/nathanael/organizr/internal/search/providers"
+)
+
+// mockWantedRepo keeps wanted books in memory
+type mockWantedRepo struct {
+	books  map[int64]*models.WantedBook
+	nextID int64
+}
+
+func newMockWantedRepo(books ...*models.WantedBook) *mockWantedRepo {
+	r := &mockWantedRepo{books: map[int64]*models.WantedBook{}}
+	for _, b := range books {
+		if err := r.Create(context.Background(), b); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+func (r *mockWantedRepo) List(ctx context.Context) ([]*models.WantedBook, error) {
+	return r.sorted(func(*models.WantedBook) bool { return true }), nil
+}
+
+func (r *mockWantedRepo) ListDue(ctx context.Context, before time.Time) ([]*models.WantedBook, error) {
+	return r.sorted(func(b *models.WantedBook) bool {
+		return b.Status == models.WantedStatusWanted && (b.LastSearchedAt == nil || b.LastSearchedAt.Before(before))
+	}), nil
+}
+
+func (r *mockWantedRepo) sorted(keep func(*models.WantedBook) bool) []*models.WantedBook {
+	var books []*models.WantedBook
+	for _, b := range r.books {
+		if keep(b) {
+			copied := *b
+			books = append(books, &copied)
+		}
+	}
+	sort.Slice(books, func(i, j int) bool { return books[i].ID < books[j].ID })
+	return books
+}
+
+func (r *mockWantedRepo) GetByID(ctx context.Context, id int64) (*models.WantedBook, error) {
+	b, ok := r.books[id]
+	if !ok {
+		return nil, persistence.ErrWantedBookNotFound
+	}
+	copied := *b
+	return &copied, nil
+}
+
+func (r *mockWantedRepo) Create(ctx context.Context, b *models.WantedBook) error {
+	r.nextID++
+	b.ID = r.nextID
+	if b.Status == "" {
+		b.Status = models.WantedStatusWanted
+	}
+	copied := *b
+	r.books[b.ID] = &copied
+	return nil
+}
+
+func (r *mockWantedRepo) Update(ctx context.Context, b *models.WantedBook) error {
+	existing, ok := r.books[b.ID]
+	if !ok {
+		return persistence.ErrWantedBookNotFound
+	}
+	copied := *b
+	copied.LastSearchedAt, copied.LastError = existing.LastSearchedAt, existing.LastError
+	r.books[b.ID] = &copied
+	return nil
+}
+
+func (r *mockWantedRepo) RecordSearch(ctx context.Context, id int64, searchedAt time.Time, lastError string) error {
+	b, ok := r.books[id]
+	if !ok {
+		return persistence.ErrWantedBookNotFound
+	}
+	b.LastSearchedAt = &searchedAt
+	b.LastError = lastError
+	return nil
+}
+
+func (r *mockWantedRepo) Delete(ctx context.Context, id int64) error {
+	if _, ok := r.books[id]; !ok {
+		return persistence.ErrWantedBookNotFound
+	}
+	delete(r.books, id)
+	return nil
+}
+
+// fakeSearcher returns fixed results and records the queries it got
+type fakeSearcher struct {
+	results []*models.SearchResult
+	err     error
+	queries []providers.Query
+}
+
+func (f *fakeSearcher) Search(ctx context.Context, q providers.Query) (*search.Results, error) {
+	f.queries = append(f.queries, q)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &search.Results{Results: f.results}, nil
+}
+
+// fakeProfiles serves quality profiles by ID
+type fakeProfiles struct {
+	profiles map[int64]*models.QualityProfile
+	def      *models.QualityProfile
+}
+
+func (f *fakeProfiles) Get(ctx context.Context, id int64) (*models.QualityProfile, error) {
+	p, ok := f.profiles[id]
+	if !ok {
+		return nil, persistence.ErrQualityProfileNotFound
+	}
+	return p, nil
+}
+
+func (f *fakeProfiles) Default(ctx context.Context) (*models.QualityProfile, error) {
+	return f.def, nil
+}
+
+// fakeDownloads records the downloads it is asked to create
+type fakeDownloads struct {
+	created []*models.Download
+	err     error
+}
+
+func (f *fakeDownloads) CreateDownload(ctx context.Context, d *models.Download) (*models.Download, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	d.ID = "download-1"
+	f.created = append(f.created, d)
+	return d, nil
+}
+
+func newTestService(repo *mockWantedRepo, searcher *fakeSearcher, downloads *fakeDownloads) *Service {
+	return &Service{
+		repo:      repo,
+		searcher:  searcher,
+		profiles:  &fakeProfiles{profiles: map[int64]*models.QualityProfile{}},
+		downloads: downloads,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+func TestPickRelease(t *testing.T) {
+	book := &models.WantedBook{Title: "Dune", Author: "Frank Herbert", Series: "Dune", SeriesNumber: "1", MediaType: models.MediaTypeAudiobook}
+	m4b := &models.QualityProfile{PreferredFormats: []string{"m4b"}}
+
+	results := []*models.SearchResult{
+		{ID: "1", Title: "Dune", Author: "Frank Herbert", FileType: "mp3", Seeders: 40, MediaType: models.MediaTypeAudiobook, Series: []models.SeriesInfo{{Name: "Dune", Number: "1"}}},
+		{ID: "2", Title: "Dune (Unabridged)", Author: "Frank Herbert", FileType: "m4b", Seeders: 5, MediaType: models.MediaTypeAudiobook, Series: []models.SeriesInfo{{Name: "Dune", Number: "01"}}},
+		{ID: "3", Title: "Dune Messiah", Author: "Frank Herbert", FileType: "m4b", Seeders: 90, MediaType: models.MediaTypeAudiobook, Series: []models.SeriesInfo{{Name: "Dune", Number: "2"}}},
+		{ID: "4", Title: "Dune", Author: "Frank Herbert", FileType: "epub", Seeders: 100, MediaType: models.MediaTypeEbook},
+		{ID: "5", Title: "Dune", Author: "Frank Herbert", FileType: "m4b", Seeders: 0, MediaType: models.MediaTypeAudiobook},
+		{ID: "6", Title: "Dune", Author: "Brian Herbert", FileType: "m4b", Seeders: 70, MediaType: models.MediaTypeAudiobook},
+	}
+
+	tests := []struct {
+		name    string
+		profile *models.QualityProfile
+		want    string
+	}{
+		{"Most seeders without a profile", nil, "1"},
+		{"Profile's preferred format", m4b, "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pickRelease(book, results, tt.profile)
+			if got == nil || got.ID != tt.want {
+				t.Errorf("pickRelease() = %+v, want result %s", got, tt.want)
+			}
+		})
+	}
+
+	if got := pickRelease(book, results[2:4], nil); got != nil {
+		t.Errorf("pickRelease() = %+v, want nothing for another entry and another media type", got)
+	}
+}
+
+func TestService_SearchGrabsBestRelease(t *testing.T) {
+	profileID := int64(7)
+	repo := newMockWantedRepo(&models.WantedBook{Title: "Dune", Author: "Frank Herbert", MediaType: models.MediaTypeAudiobook, Category: "Audiobooks", QualityProfileID: &profileID})
+	searcher := &fakeSearcher{results: []*models.SearchResult{
+		{ID: "11", Title: "Dune", Author: "Frank Herbert", FileType: "mp3", Seeders: 50, Provider: providers.MyAnonamouseName, TorrentURL: "https://www.myanonamouse.net/tor/download.php?tid=11"},
+		{ID: "12", Title: "Dune", Author: "Frank Herbert", FileType: "m4b", Seeders: 5, Size: "1.5 GiB", Freeleech: true, Provider: providers.MyAnonamouseName, TorrentURL: "https://www.myanonamouse.net/tor/download.php?tid=12"},
+	}}
+	downloads := &fakeDownloads{}
+	s := newTestService(repo, searcher, downloads)
+	s.profiles = &fakeProfiles{profiles: map[int64]*models.QualityProfile{profileID: {ID: profileID, PreferredFormats: []string{"m4b"}}}}
+
+	book, err := s.Search(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+
+	if len(searcher.queries) != 1 || searcher.queries[0].Title != "Dune" || searcher.queries[0].Author != "Frank Herbert" {
+		t.Errorf("queries = %+v, want a title and author search", searcher.queries)
+	}
+	if len(downloads.created) != 1 {
+		t.Fatalf("created %d downloads, want 1", len(downloads.created))
+	}
+	d := downloads.created[0]
+	if d.TorrentID != "12" || d.Category != "Audiobooks" || !d.Freeleech || d.Size != 1536*1024*1024 || d.AddedBy != models.ActorWanted {
+		t.Errorf("created download = %+v, want the m4b release added by the wanted list", d)
+	}
+	if book.Status != models.WantedStatusGrabbed || book.DownloadID != "download-1" || book.LastSearchedAt == nil || book.LastError != "" {
+		t.Errorf("book = %+v, want it grabbed and linked to the download", book)
+	}
+
+	// A grabbed book isn't searched for again
+	if _, err := s.Search(context.Background(), 1); !errors.Is(err, ErrAlreadyGrabbed) {
+		t.Errorf("Search() again error = %v, want ErrAlreadyGrabbed", err)
+	}
+}
+
+func TestService_SearchRecordsFailures(t *testing.T) {
+	tests := []struct {
+		name      string
+		searcher  *fakeSearcher
+		downloads *fakeDownloads
+		wantErr   bool
+		wantError string
+	}{
+		{"No release", &fakeSearcher{}, &fakeDownloads{}, false, noReleaseFound},
+		{"Search failed", &fakeSearcher{err: errors.New("timeout")}, &fakeDownloads{}, true, "search failed: timeout"},
+		{"Add failed", &fakeSearcher{results: []*models.SearchResult{{ID: "1", Title: "Dune", Seeders: 3}}}, &fakeDownloads{err: errors.New("qBittorrent not reachable")}, true, "could not add Dune: qBittorrent not reachable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockWantedRepo(&models.WantedBook{Title: "Dune", Author: "Frank Herbert", MediaType: models.MediaTypeAudiobook})
+			s := newTestService(repo, tt.searcher, tt.downloads)
+
+			_, err := s.Search(context.Background(), 1)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Search() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			book, _ := repo.GetByID(context.Background(), 1)
+			if book.Status != models.WantedStatusWanted || book.LastError != tt.wantError || book.LastSearchedAt == nil {
+				t.Errorf("book = %+v, want it still wanted with last error %q", book, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestService_SearchDue(t *testing.T) {
+	recently := time.Now().Add(-time.Minute)
+	repo := newMockWantedRepo(
+		&models.WantedBook{Title: "Dune", Author: "Frank Herbert", MediaType: models.MediaTypeAudiobook},
+		&models.WantedBook{Title: "Hyperion", Author: "Dan Simmons", MediaType: models.MediaTypeAudiobook},
+		&models.WantedBook{Title: "Foundation", Author: "Isaac Asimov", MediaType: models.MediaTypeAudiobook, Status: models.WantedStatusGrabbed},
+	)
+	if err := repo.RecordSearch(context.Background(), 2, recently, noReleaseFound); err != nil {
+		t.Fatal(err)
+	}
+	searcher := &fakeSearcher{}
+	s := newTestService(repo, searcher, &fakeDownloads{})
+
+	s.searchDue(context.Background(), time.Hour)
+
+	// Only the book never searched for is due; grabbed books aren't searched for
+	if len(searcher.queries) != 1 || searcher.queries[0].Title != "Dune" {
+		t.Errorf("queries = %+v, want only Dune", searcher.queries)
+	}
+}
+
+func TestService_CreateValidates(t *testing.T) {
+	missing := int64(99)
+	tests := []struct {
+		name string
+		book *models.WantedBook
+	}{
+		{"No title", &models.WantedBook{Author: "Frank Herbert"}},
+		{"No author", &models.WantedBook{Title: "Dune"}},
+		{"Unknown media type", &models.WantedBook{Title: "Dune", Author: "Frank Herbert", MediaType: "podcast"}},
+		{"Missing profile", &models.WantedBook{Title: "Dune", Author: "Frank Herbert", QualityProfileID: &missing}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(newMockWantedRepo(), &fakeSearcher{}, &fakeDownloads{})
+			if err := s.Create(context.Background(), tt.book); !errors.Is(err, ErrInvalidWantedBook) {
+				t.Errorf("Create() error = %v, want ErrInvalidWantedBook", err)
+			}
+		})
+	}
+
+	// A valid book is trimmed, defaults to audiobook and wakes the scheduler
+	s := newTestService(newMockWantedRepo(), &fakeSearcher{}, &fakeDownloads{})
+	book := &models.WantedBook{Title: "  Dune ", Author: "Frank Herbert"}
+	if err := s.Create(context.Background(), book); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if book.Title != "Dune" || book.MediaType != models.MediaTypeAudiobook || book.Status != models.WantedStatusWanted {
+		t.Errorf("Create() stored %+v", book)
+	}
+	select {
+	case <-s.wake:
+	default:
+		t.Error("Create() didn't wake the scheduler")
+	}
+}
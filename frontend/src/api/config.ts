@@ -15,6 +15,28 @@ export interface PreviewPathResponse {
   error?: string
 }
 
+export interface PathMapping {
+  remote: string
+  local: string
+}
+
+export interface PathMappingCheck {
+  remote: string
+  local: string
+  local_exists: boolean
+  local_is_dir: boolean
+  local_readable: boolean
+  matched_save_paths: string[]
+  errors: string[]
+}
+
+export interface ValidatePathMappingsResponse {
+  valid: boolean
+  mappings: PathMappingCheck[]
+  unmapped_save_paths: string[]
+  qbittorrent_error?: string
+}
+
 export const configApi = {
   getAll: () => api.get<AppConfigResponse>('/api/config'),
 
@@ -24,4 +46,7 @@ export const configApi = {
 
   previewPath: (data: PreviewPathRequest) =>
     api.post<PreviewPathResponse>('/api/config/preview-path', data),
+
+  validatePathMappings: (mappings?: PathMapping[]) =>
+    api.post<ValidatePathMappingsResponse>('/api/config/path-mappings/validate', { mappings }),
 }
@@ -4,6 +4,10 @@ import type {
   CreateDownloadRequest,
   BatchCreateDownloadRequest,
   BatchCreateDownloadResponse,
+  DownloadEvent,
+  OrganizeAttempt,
+  RetryDownloadResponse,
+  TorrentReplacement,
 } from '../types/download'
 
 interface ListDownloadsResponse {
@@ -18,6 +22,18 @@ interface CreateDownloadResponse {
   download: Download
 }
 
+interface ListOrganizeAttemptsResponse {
+  attempts: OrganizeAttempt[]
+}
+
+interface DownloadHistoryResponse {
+  events: DownloadEvent[]
+}
+
+interface ListTorrentReplacementsResponse {
+  replacements: TorrentReplacement[]
+}
+
 export const downloadsApi = {
   list: async () => {
     const response = await api.get<ListDownloadsResponse>('/api/downloads')
@@ -43,4 +59,28 @@ export const downloadsApi = {
   cancel: (id: string) => api.delete<void>(`/api/downloads/${id}`),
 
   organize: (id: string) => api.post<void>(`/api/downloads/${id}/organize`),
+
+  readd: async (id: string) => {
+    const response = await api.post<GetDownloadResponse>(`/api/downloads/${id}/readd`)
+    return response.download
+  },
+
+  discard: (id: string) => api.post<void>(`/api/downloads/${id}/discard`),
+
+  retry: (id: string) => api.post<RetryDownloadResponse>(`/api/downloads/${id}/retry`),
+
+  attempts: async (id: string) => {
+    const response = await api.get<ListOrganizeAttemptsResponse>(`/api/downloads/${id}/attempts`)
+    return response.attempts
+  },
+
+  history: async (id: string) => {
+    const response = await api.get<DownloadHistoryResponse>(`/api/downloads/${id}/history`)
+    return response.events
+  },
+
+  replacements: async (id: string) => {
+    const response = await api.get<ListTorrentReplacementsResponse>(`/api/downloads/${id}/replacements`)
+    return response.replacements
+  },
 }
@@ -0,0 +1,16 @@
+import { api } from './client'
+import type {
+  ImportTorrentRequest,
+  ImportTorrentsResponse,
+  ListImportCandidatesResponse,
+} from '../types/import'
+
+export const importsApi = {
+  candidates: async (params: { category?: string; tag?: string; read_tags?: boolean } = {}) => {
+    const response = await api.get<ListImportCandidatesResponse>('/api/imports/candidates', params)
+    return response.candidates
+  },
+
+  import: (imports: ImportTorrentRequest[]) =>
+    api.post<ImportTorrentsResponse>('/api/imports', { imports }),
+}
@@ -0,0 +1,8 @@
+import { api } from './client'
+import type { MonitorStatusResponse } from '../types/monitor'
+
+export const monitorApi = {
+  getStatus: () => api.get<MonitorStatusResponse>('/api/monitor/status'),
+
+  refresh: () => api.post<void>('/api/monitor/refresh'),
+}
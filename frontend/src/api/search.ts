@@ -1,8 +1,24 @@
 import { api } from './client'
-import type { SearchResponse } from '../types/search'
+import type { MAMAccount, ProviderConnection, SearchParams, SearchProvider, SearchResponse } from '../types/search'
 
 export const searchApi = {
-  search: (params: { q: string }) => api.get<SearchResponse>('/api/search', params),
+  search: (params: SearchParams) => api.get<SearchResponse>('/api/search', params),
 
-  testConnection: () => api.post<{ success: boolean; message?: string }>('/api/search/test', {}),
+  providers: async () => {
+    const response = await api.get<{ providers: SearchProvider[] }>('/api/search/providers')
+    return response.providers
+  },
+
+  registerSeedbox: () =>
+    api.post<{ changed: boolean; message: string; ip: string; asn: number; as: string }>('/api/search/seedbox', {}),
+
+  account: async () => {
+    const response = await api.get<{ account: MAMAccount }>('/api/search/account')
+    return response.account
+  },
+
+  clearCache: () => api.delete<void>('/api/search/cache'),
+
+  testConnection: () =>
+    api.post<{ success: boolean; message?: string; providers?: ProviderConnection[] }>('/api/search/test', {}),
 }
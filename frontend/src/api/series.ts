@@ -0,0 +1,28 @@
+import { api } from './client'
+import type { FollowSeriesRequest, FollowedSeries, WantedBook } from '../types/wanted'
+
+export const seriesApi = {
+  list: async () => {
+    const response = await api.get<{ series: FollowedSeries[] }>('/api/series')
+    return response.series
+  },
+
+  get: async (id: number) => {
+    const response = await api.get<{ series: FollowedSeries }>(`/api/series/${id}`)
+    return response.series
+  },
+
+  follow: async (series: FollowSeriesRequest) => {
+    const response = await api.post<{ series: FollowedSeries }>('/api/series', series)
+    return response.series
+  },
+
+  update: async (id: number, series: FollowSeriesRequest) => {
+    const response = await api.put<{ series: FollowedSeries }>(`/api/series/${id}`, series)
+    return response.series
+  },
+
+  unfollow: (id: number) => api.delete<void>(`/api/series/${id}`),
+
+  check: (id: number) => api.post<{ series: FollowedSeries; queued: WantedBook[] }>(`/api/series/${id}/check`, {}),
+}
@@ -0,0 +1,31 @@
+import { api } from './client'
+import type { UpdateWantedBookRequest, WantedBook, WantedBookRequest } from '../types/wanted'
+
+export const wantedApi = {
+  list: async () => {
+    const response = await api.get<{ books: WantedBook[] }>('/api/wanted')
+    return response.books
+  },
+
+  get: async (id: number) => {
+    const response = await api.get<{ book: WantedBook }>(`/api/wanted/${id}`)
+    return response.book
+  },
+
+  create: async (book: WantedBookRequest) => {
+    const response = await api.post<{ book: WantedBook }>('/api/wanted', book)
+    return response.book
+  },
+
+  update: async (id: number, book: UpdateWantedBookRequest) => {
+    const response = await api.put<{ book: WantedBook }>(`/api/wanted/${id}`, book)
+    return response.book
+  },
+
+  delete: (id: number) => api.delete<void>(`/api/wanted/${id}`),
+
+  search: async (id: number) => {
+    const response = await api.post<{ book: WantedBook }>(`/api/wanted/${id}/search`, {})
+    return response.book
+  },
+}
@@ -18,6 +18,7 @@ const statusColors: Record<DownloadStatus, string> = {
   organizing: 'bg-yellow-600',
   organized: 'bg-emerald-600',
   failed: 'bg-red-600',
+  missing: 'bg-orange-600',
 }
 
 const sizeStyles: Record<ProgressBarSize, string> = {
@@ -17,6 +17,7 @@ const filters: { value: FilterStatus; label: string }[] = [
   { value: 'organizing', label: 'Organizing' },
   { value: 'organized', label: 'Organized' },
   { value: 'failed', label: 'Failed' },
+  { value: 'missing', label: 'Missing' },
 ]
 
 export const DownloadFilters: React.FC<DownloadFiltersProps> = ({
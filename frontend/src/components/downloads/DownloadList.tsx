@@ -54,6 +54,7 @@ export const DownloadList: React.FC<DownloadListProps> = ({ downloads, groupBySt
     'completed',
     'organized',
     'failed',
+    'missing',
   ]
 
   const groupedDownloads = statusOrder.reduce(
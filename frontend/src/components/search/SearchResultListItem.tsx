@@ -4,6 +4,7 @@ import { Button } from '../common/Button'
 import { Badge } from '../common/Badge'
 import type { SearchResult } from '../../types/search'
 import { useDownloadStore } from '../../stores/useDownloadStore'
+import { useSearchStore } from '../../stores/useSearchStore'
 import { formatFileSize } from '../../utils/formatters'
 
 interface SearchResultListItemProps {
@@ -23,6 +24,7 @@ export const SearchResultListItem: React.FC<SearchResultListItemProps> = ({
 }) => {
   const navigate = useNavigate()
   const createDownload = useDownloadStore((state) => state.createDownload)
+  const query = useSearchStore((state) => state.filters.query)
   const [expanded, setExpanded] = useState(false)
   const [downloading, setDownloading] = useState(false)
   const [showDescription, setShowDescription] = useState(false)
@@ -40,8 +42,13 @@ export const SearchResultListItem: React.FC<SearchResultListItemProps> = ({
         series: series,
         seriesNumber: seriesNumber,
         category: 'Audiobooks',
+        torrent_id: result.id,
+        provider: result.provider,
         torrent_url: result.torrent_url,
         magnet_link: result.magnet_link,
+        search_query: query,
+        size: result.size,
+        freeleech: result.freeleech,
       })
       // Navigate to downloads page after successful creation
       navigate('/downloads')
@@ -6,6 +6,7 @@ import { Spinner } from '../common/Spinner'
 import { Button } from '../common/Button'
 import type { SearchResult } from '../../types/search'
 import { useDownloadStore } from '../../stores/useDownloadStore'
+import { useSearchStore } from '../../stores/useSearchStore'
 import type { CreateDownloadRequest } from '../../types/download'
 
 interface SearchResultsProps {
@@ -22,6 +23,7 @@ export const SearchResults: React.FC<SearchResultsProps> = ({ results, loading,
   const [batchMode, setBatchMode] = useState(false)
   const [selectedIds, setSelectedIds] = useState<Set<string>>(new Set())
   const createBatchDownload = useDownloadStore((state) => state.createBatchDownload)
+  const query = useSearchStore((state) => state.filters.query)
   const [downloadingBatch, setDownloadingBatch] = useState(false)
 
   // Toggle batch mode
@@ -74,8 +76,13 @@ export const SearchResults: React.FC<SearchResultsProps> = ({ results, loading,
           series: series,
           seriesNumber: seriesNumber,
           category: 'Audiobooks',
+          torrent_id: result.id,
+          provider: result.provider,
           torrent_url: result.torrent_url,
           magnet_link: result.magnet_link,
+          search_query: query,
+          size: result.size,
+          freeleech: result.freeleech,
         }
       })
 
@@ -31,6 +31,7 @@ export const DownloadsPage: React.FC = () => {
     organizing: downloads.filter((d) => d.status === 'organizing').length,
     organized: downloads.filter((d) => d.status === 'organized').length,
     failed: downloads.filter((d) => d.status === 'failed').length,
+    missing: downloads.filter((d) => d.status === 'missing').length,
   }
 
   return (
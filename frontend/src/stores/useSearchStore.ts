@@ -49,6 +49,13 @@ export const useSearchStore = create<SearchStore>((set, get) => ({
         loading: false,
         filters: { ...get().filters, query },
       })
+
+      // Results of the providers that answered are still shown
+      for (const failure of results.failed_providers ?? []) {
+        useNotificationStore
+          .getState()
+          .addNotification('warning', `${failure.provider} search failed: ${failure.error}`)
+      }
     } catch (error) {
       const message = error instanceof APIClientError ? error.message : 'Search failed'
       set({ error: message, loading: false, results: [] })
@@ -10,7 +10,7 @@ export interface APIResponse<T = any> {
 }
 
 export interface HealthStatus {
-  status: string
+  status: 'healthy' | 'degraded' | 'unhealthy'
   database: string
   qbittorrent: string
   monitor: string
@@ -1,10 +1,14 @@
 export type DownloadStatus =
   | 'queued'
   | 'downloading'
+  | 'stalled'
   | 'completed'
   | 'organizing'
   | 'organized'
   | 'failed'
+  | 'missing'
+
+export type MediaType = 'audiobook' | 'ebook'
 
 export interface Download {
   id: string
@@ -12,6 +16,8 @@ export interface Download {
   author: string
   series?: string
   seriesNumber?: string
+  media_type?: MediaType
+  torrent_id?: string
   status: DownloadStatus
   progress: number // 0-100
   organized_path?: string
@@ -19,6 +25,9 @@ export interface Download {
   created_at: string
   completed_at?: string
   organized_at?: string
+  missing_since?: string
+  wedge_used?: boolean // A MAM freeleech wedge was spent on the torrent
+  warnings?: string[] // Raised while adding the download, e.g. by the MAM account guard
 }
 
 export interface CreateDownloadRequest {
@@ -26,9 +35,16 @@ export interface CreateDownloadRequest {
   author: string
   series?: string
   seriesNumber?: string
+  media_type?: MediaType
   category: string
+  torrent_id?: string
+  provider?: string
   torrent_url?: string
   magnet_link?: string
+  search_query?: string
+  size?: string
+  freeleech?: boolean
+  use_wedge?: boolean // Unset follows mam.wedge_policy
 }
 
 export interface BatchCreateDownloadRequest {
@@ -45,3 +61,42 @@ export interface BatchCreateDownloadResponse {
   successful: Download[]
   failed: BatchDownloadError[]
 }
+
+export type OrganizeAttemptStatus = 'pending' | 'running' | 'done' | 'failed'
+
+export interface OrganizeAttempt {
+  attempt: number
+  status: OrganizeAttemptStatus
+  source: string
+  error?: string
+  transient: boolean
+  created_at: string
+  run_after?: string
+  started_at?: string
+  finished_at?: string
+}
+
+export type DownloadEventActor = 'monitor' | 'user' | 'api' | 'wanted'
+
+export interface DownloadEvent {
+  id: number
+  from_status?: DownloadStatus
+  to_status: DownloadStatus
+  actor: DownloadEventActor
+  reason?: string
+  created_at: string
+}
+
+export interface TorrentReplacement {
+  old_hash: string
+  old_torrent_id?: string
+  new_hash: string
+  new_torrent_id?: string
+  reason?: string
+  created_at: string
+}
+
+export interface RetryDownloadResponse {
+  download: Download
+  readded: boolean
+}
@@ -0,0 +1,49 @@
+import type { Download, MediaType } from './download'
+
+export interface BookGuess {
+  title: string
+  author: string
+  series?: string
+  series_number?: string
+  media_type: MediaType
+  from_tags: boolean
+}
+
+export interface ImportCandidate {
+  hash: string
+  name: string
+  category?: string
+  tags?: string
+  state: string
+  progress: number
+  size: number
+  save_path: string
+  added_on: string
+  guess: BookGuess
+  tag_error?: string
+}
+
+export interface ListImportCandidatesResponse {
+  candidates: ImportCandidate[]
+  count: number
+}
+
+export interface ImportTorrentRequest {
+  hash: string
+  title: string
+  author: string
+  series?: string
+  series_number?: string
+  media_type?: MediaType
+}
+
+export interface ImportTorrentError {
+  index: number
+  hash: string
+  error: string
+}
+
+export interface ImportTorrentsResponse {
+  imported: Download[]
+  failed: ImportTorrentError[]
+}
@@ -0,0 +1,26 @@
+export interface InFlightOrganization {
+  download_id: string
+  title: string
+  job_id: number
+  started_at: string
+}
+
+export interface MonitorStatus {
+  running: boolean
+  recovering: boolean
+  degraded: boolean
+  interval_seconds: number
+  last_tick_at?: string
+  last_tick_duration_ms: number
+  ticks: number
+  checked: number
+  changed: number
+  errored: number
+  consecutive_qbittorrent_failures: number
+  last_qbittorrent_error?: string
+  in_flight_organizations: InFlightOrganization[]
+}
+
+export interface MonitorStatusResponse {
+  monitor: MonitorStatus
+}
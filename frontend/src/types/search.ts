@@ -4,8 +4,63 @@ export interface SeriesInfo {
   number: string
 }
 
+export interface ProviderError {
+  provider: string
+  error: string
+}
+
 export interface SearchResponse {
   results: SearchResult[]
+  count: number
+  total: number
+  total_found: number
+  offset: number
+  limit: number
+  failed_providers: ProviderError[]
+  cached_at?: string
+  quality_profile?: QualityProfile
+}
+
+export interface SearchParams {
+  q: string
+  media_type?: 'audiobook' | 'ebook' | 'all'
+  search_type?: 'all' | 'active' | 'inactive' | 'fl' | 'fl-VIP' | 'VIP' | 'nVIP'
+  search_in?: string
+  language?: string
+  category?: string
+  file_type?: string
+  sort?: string
+  profile?: string
+  page?: number
+  offset?: number
+  limit?: number
+}
+
+export interface SearchProvider {
+  name: string
+  media_types: ('audiobook' | 'ebook')[]
+  download_by_id: boolean
+}
+
+export interface MAMAccount {
+  username: string
+  user_id: number
+  class: string
+  ratio: number | null // null before anything was downloaded
+  uploaded: string
+  downloaded: string
+  uploaded_bytes: number
+  downloaded_bytes: number
+  bonus_points: number
+  unsatisfied: number
+  unsatisfied_limit: number
+  wedges: number
+}
+
+export interface ProviderConnection {
+  provider: string
+  success: boolean
+  message?: string
 }
 export interface SearchResult {
   id?: string
@@ -15,7 +70,9 @@ export interface SearchResult {
   narrator?: string
   torrent_url?: string
   magnet_link?: string
+  info_hash?: string
   provider: string
+  media_type?: 'audiobook' | 'ebook'
   size: string
   seeders: number
   leechers: number
@@ -30,6 +87,34 @@ export interface SearchResult {
   num_files?: number
   times_completed?: number
   added?: string
+  uploader?: string
+  score?: Score
+}
+
+export interface ScoreReason {
+  points: number
+  message: string
+}
+
+export interface Score {
+  total: number
+  rejected: boolean
+  reasons: ScoreReason[]
+}
+
+export interface QualityProfile {
+  id: number
+  name: string
+  preferred_formats: string[]
+  min_size_mb: number
+  max_size_mb: number
+  preferred_languages: string[]
+  freeleech_bonus: number
+  min_seeders: number
+  preferred_uploaders: string[]
+  blocked_uploaders: string[]
+  created_at: string
+  updated_at: string
 }
 
 export interface SearchFilters {
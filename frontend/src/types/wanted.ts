@@ -0,0 +1,60 @@
+import type { MediaType } from './download'
+
+export type WantedStatus = 'wanted' | 'grabbed'
+
+export interface WantedBook {
+  id: number
+  title: string
+  author: string
+  series?: string
+  series_number?: string
+  media_type: MediaType
+  category?: string
+  quality_profile_id?: number
+  status: WantedStatus
+  download_id?: string
+  last_searched_at?: string
+  last_error?: string
+  followed_series_id?: number
+  created_at: string
+  updated_at: string
+}
+
+export interface WantedBookRequest {
+  title: string
+  author: string
+  series?: string
+  series_number?: string
+  media_type?: MediaType
+  category?: string
+  quality_profile_id?: number
+}
+
+export interface UpdateWantedBookRequest extends WantedBookRequest {
+  status?: WantedStatus
+}
+
+export interface FollowedSeries {
+  id: number
+  provider: string
+  series_id: string
+  name: string
+  media_type: MediaType
+  category?: string
+  quality_profile_id?: number
+  start_after?: string
+  last_checked_at?: string
+  last_error?: string
+  created_at: string
+  updated_at: string
+}
+
+export interface FollowSeriesRequest {
+  provider?: string
+  series_id: string
+  name: string
+  media_type?: MediaType
+  category?: string
+  quality_profile_id?: number
+  start_after?: string
+}
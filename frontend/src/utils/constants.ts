@@ -10,6 +10,7 @@ export const STATUS_COLORS: Record<DownloadStatus, string> = {
   organizing: 'bg-yellow-100 text-yellow-700 border-yellow-300',
   organized: 'bg-emerald-100 text-emerald-700 border-emerald-300',
   failed: 'bg-red-100 text-red-700 border-red-300',
+  missing: 'bg-orange-100 text-orange-700 border-orange-300',
 }
 
 /**
@@ -22,6 +23,7 @@ export const STATUS_LABELS: Record<DownloadStatus, string> = {
   organizing: 'Organizing',
   organized: 'Organized',
   failed: 'Failed',
+  missing: 'Missing',
 }
 
 /**